@@ -1,12 +1,15 @@
 package blr
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"net/http"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
+	"github.com/dip-software/go-dip-api/connect/wait"
 	"github.com/dip-software/go-dip-api/internal"
 )
 
@@ -193,6 +196,11 @@ func (b *BlobsService) Find(opt *GetBlobOptions, options ...OptionFunc) (*[]Blob
 	return &resources, resp, err
 }
 
+// FindAll looks up blobs based on GetBlobOptions, retrieving all result pages
+func (b *BlobsService) FindAll(opt *GetBlobOptions, options ...OptionFunc) (*[]Blob, *Response, error) {
+	return findAll[Blob](b.Client, "/Blob", blobAPIVersion, opt, options...)
+}
+
 func (b *BlobsService) Delete(blob Blob) (bool, *Response, error) {
 	req, err := b.NewRequest(http.MethodDelete, "/Blob/"+blob.ID, nil, nil)
 	if err != nil {
@@ -335,3 +343,17 @@ func (b *BlobsService) ListParts(blob Blob) (*BlobPartUpload, *Response, error)
 	}
 	return &resource, resp, nil
 }
+
+// WaitUntilDeleted polls the blob until it no longer exists. Blob deletes are processed
+// asynchronously by BLR. A nil bo uses wait.DefaultBackOff
+func (b *BlobsService) WaitUntilDeleted(ctx context.Context, id string, bo backoff.BackOff) error {
+	get, gone := wait.UntilGone(func(ctx context.Context) (*Blob, error) {
+		blob, resp, err := b.GetByID(id)
+		if err != nil && resp != nil && resp.StatusCode() == http.StatusNotFound {
+			return nil, wait.ErrNotFound
+		}
+		return blob, err
+	})
+	_, err := wait.WaitFor(ctx, get, gone, bo)
+	return err
+}
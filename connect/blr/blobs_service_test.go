@@ -1,12 +1,15 @@
 package blr_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/dip-software/go-dip-api/connect/blr"
 	"io"
 	"net/http"
 	"testing"
+	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -90,3 +93,26 @@ func TestBlobCRUD(t *testing.T) {
 	assert.True(t, res)
 	assert.Equal(t, http.StatusNoContent, resp.StatusCode())
 }
+
+func TestBlobWaitUntilDeleted(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	blobID := "8a6c7b8e-2f3d-4c5e-9a1b-0c2d3e4f5a6b"
+	calls := 0
+	muxBLR.HandleFunc("/connect/blobrepository/Blob/"+blobID, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		calls++
+		if calls > 2 {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"resourceType": "OperationOutcome", "issue": []}`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, blobBody(blobID, "TestType", "Deleting"))
+	})
+
+	err := blrClient.Blobs.WaitUntilDeleted(context.Background(), blobID, backoff.NewConstantBackOff(time.Millisecond))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, calls)
+}
@@ -49,6 +49,10 @@ type Client struct {
 
 	Blobs          *BlobsService
 	Configurations *ConfigurationsService
+	StoreAccess    *StoreAccessService
+	Topics         *TopicsService
+	Contracts      *ContractsService
+	Subscriptions  *SubscriptionsService
 }
 
 // NewClient returns a new BLR client
@@ -69,6 +73,10 @@ func NewClient(iamClient *iam.Client, config *Config) (*Client, error) {
 
 	c.Blobs = &BlobsService{Client: c, validate: validator.New()}
 	c.Configurations = &ConfigurationsService{Client: c, validate: validator.New()}
+	c.StoreAccess = &StoreAccessService{Client: c, validate: validator.New()}
+	c.Topics = &TopicsService{Client: c, validate: validator.New()}
+	c.Contracts = &ContractsService{Client: c, validate: validator.New()}
+	c.Subscriptions = &SubscriptionsService{Client: c, validate: validator.New()}
 
 	return c, nil
 }
@@ -0,0 +1,111 @@
+package blr
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ContractsService manages the blob data contracts of the Blob Repository
+type ContractsService struct {
+	*Client
+	validate *validator.Validate
+}
+
+var (
+	contractAPIVersion = "1"
+)
+
+// BlobDataContract determines where and how blobs of a data type are stored
+type BlobDataContract struct {
+	ResourceType                  string    `json:"resourceType" validate:"required"`
+	ID                            string    `json:"id,omitempty"`
+	Name                          string    `json:"name" validate:"required,min=1,max=20"`
+	DataType                      string    `json:"dataType" validate:"required"`
+	BucketID                      Reference `json:"bucketId" validate:"required"`
+	StorageClass                  string    `json:"storageClass,omitempty"`
+	RootPathInBucket              string    `json:"rootPathInBucket" validate:"required,max=256"`
+	LoggingEnabled                bool      `json:"loggingEnabled"`
+	CrossRegionReplicationEnabled bool      `json:"crossRegionReplicationEnabled"`
+	Meta                          *Meta     `json:"meta,omitempty"`
+}
+
+// GetContractOptions struct describes search criteria for looking up BlobDataContract
+type GetContractOptions struct {
+	ID       *string `url:"_id,omitempty"`
+	Name     *string `url:"name,omitempty"`
+	DataType *string `url:"dataType,omitempty"`
+	BucketID *string `url:"bucketId,omitempty"`
+	Count    *int    `url:"_count,omitempty"`
+	Page     *int    `url:"page,omitempty"`
+}
+
+// Create creates a BlobDataContract
+func (c *ContractsService) Create(contract BlobDataContract) (*BlobDataContract, *Response, error) {
+	contract.ResourceType = "BlobDataContract"
+	if err := c.validate.Struct(contract); err != nil {
+		return nil, nil, err
+	}
+
+	req, _ := c.NewRequest(http.MethodPost, "/configuration/BlobDataContract", contract, nil)
+	req.Header.Set("api-version", contractAPIVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	var created BlobDataContract
+
+	resp, err := c.Do(req, &created)
+	if err != nil {
+		return nil, resp, err
+	}
+	if created.ID == "" {
+		return nil, resp, fmt.Errorf("the 'ID' field is missing")
+	}
+	return &created, resp, nil
+}
+
+// Update updates a BlobDataContract
+func (c *ContractsService) Update(contract BlobDataContract) (*BlobDataContract, *Response, error) {
+	contract.ResourceType = "BlobDataContract"
+	id := contract.ID
+	contract.ID = "" // Server does not like a value here
+	if err := c.validate.Struct(contract); err != nil {
+		return nil, nil, err
+	}
+	req, _ := c.NewRequest(http.MethodPut, "/configuration/BlobDataContract/"+id, contract, nil)
+	req.Header.Set("api-version", contractAPIVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	var updated BlobDataContract
+
+	resp, err := c.Do(req, &updated)
+	if err != nil {
+		return nil, resp, err
+	}
+	return &updated, resp, nil
+}
+
+// Delete deletes the given BlobDataContract
+func (c *ContractsService) Delete(contract BlobDataContract) (bool, *Response, error) {
+	return deleteResource(c.Client, "/configuration/BlobDataContract/"+contract.ID, contractAPIVersion)
+}
+
+// GetByID retrieves a BlobDataContract by its ID
+func (c *ContractsService) GetByID(id string) (*BlobDataContract, *Response, error) {
+	if len(id) == 0 {
+		return nil, nil, fmt.Errorf("GetByID: missing id")
+	}
+	contracts, resp, err := c.Find(&GetContractOptions{ID: &id})
+	if err != nil {
+		return nil, resp, err
+	}
+	if len(*contracts) == 0 {
+		return nil, resp, ErrEmptyResult
+	}
+	return &(*contracts)[0], resp, nil
+}
+
+// Find looks up contracts based on GetContractOptions, following all bundle pages
+func (c *ContractsService) Find(opt *GetContractOptions, options ...OptionFunc) (*[]BlobDataContract, *Response, error) {
+	return findAll[BlobDataContract](c.Client, "/configuration/BlobDataContract", contractAPIVersion, opt, options...)
+}
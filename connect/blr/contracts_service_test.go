@@ -0,0 +1,94 @@
+package blr_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/connect/blr"
+	"github.com/stretchr/testify/assert"
+)
+
+func contractBody(id, name string) string {
+	return fmt.Sprintf(`{
+  "resourceType": "BlobDataContract",
+  "id": "%s",
+  "name": "%s",
+  "dataType": "tf-exact-moose",
+  "bucketId": {
+    "reference": "Bucket/8b26ddb7-910b-4faf-b122-e1fd27356b14"
+  },
+  "storageClass": "STANDARD",
+  "rootPathInBucket": "foo",
+  "loggingEnabled": true,
+  "crossRegionReplicationEnabled": false
+}`, id, name)
+}
+
+func TestContractsCRUD(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	contractID := "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	name := "TestContract"
+	muxBLR.HandleFunc("/connect/blobrepository/configuration/BlobDataContract", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, contractBody(contractID, name))
+		case http.MethodGet:
+			assert.Equal(t, contractID, r.URL.Query().Get("_id"))
+			w.WriteHeader(http.StatusOK)
+			_, _ = io.WriteString(w, `{"resourceType": "Bundle", "type": "searchset", "entry": [{"resource": `+contractBody(contractID, name)+`}]}`)
+		}
+	})
+	muxBLR.HandleFunc("/connect/blobrepository/configuration/BlobDataContract/"+contractID, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPut:
+			w.WriteHeader(http.StatusOK)
+			_, _ = io.WriteString(w, contractBody(contractID, name))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	_, _, err := blrClient.Contracts.Create(blr.BlobDataContract{Name: name})
+	assert.NotNil(t, err)
+
+	created, resp, err := blrClient.Contracts.Create(blr.BlobDataContract{
+		Name:             name,
+		DataType:         "tf-exact-moose",
+		BucketID:         blr.Reference{Reference: "Bucket/8b26ddb7-910b-4faf-b122-e1fd27356b14"},
+		RootPathInBucket: "foo",
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.NotNil(t, resp) {
+		return
+	}
+	if !assert.NotNil(t, created) {
+		return
+	}
+	assert.Equal(t, contractID, created.ID)
+	assert.True(t, created.LoggingEnabled)
+
+	found, _, err := blrClient.Contracts.GetByID(contractID)
+	if !assert.Nil(t, err) || !assert.NotNil(t, found) {
+		return
+	}
+	assert.Equal(t, "STANDARD", found.StorageClass)
+
+	updated, _, err := blrClient.Contracts.Update(*found)
+	if !assert.Nil(t, err) || !assert.NotNil(t, updated) {
+		return
+	}
+	assert.Equal(t, contractID, updated.ID)
+
+	ok, _, err := blrClient.Contracts.Delete(*created)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
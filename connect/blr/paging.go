@@ -0,0 +1,64 @@
+package blr
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/dip-software/go-dip-api/internal"
+)
+
+func withQuery(rawQuery string) OptionFunc {
+	return func(req *http.Request) error {
+		req.URL.RawQuery = rawQuery
+		return nil
+	}
+}
+
+// findAll searches requestPath and collects the resources of all bundle pages
+func findAll[T any](c *Client, requestPath, apiVersion string, opt interface{}, options ...OptionFunc) (*[]T, *Response, error) {
+	var resources []T
+	var resp *Response
+
+	err := internal.FollowNext(func(next *url.URL) (internal.BundleLinks, error) {
+		pageOptions := options
+		if next != nil {
+			pageOptions = append(append([]OptionFunc{}, options...), withQuery(next.RawQuery))
+		}
+		req, err := c.NewRequest(http.MethodGet, requestPath, opt, pageOptions...)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("api-version", apiVersion)
+		req.Header.Set("Content-Type", "application/json")
+
+		var bundleResponse internal.Bundle
+
+		resp, err = c.Do(req, &bundleResponse)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, internal.Resources[T](&bundleResponse)...)
+		return bundleResponse.Link, nil
+	})
+	if err != nil {
+		return nil, resp, err
+	}
+	return &resources, resp, nil
+}
+
+// deleteResource deletes the resource at requestPath, returning true on 204 No Content
+func deleteResource(c *Client, requestPath, apiVersion string) (bool, *Response, error) {
+	req, err := c.NewRequest(http.MethodDelete, requestPath, nil, nil)
+	if err != nil {
+		return false, nil, err
+	}
+	req.Header.Set("api-version", apiVersion)
+
+	var deleteResponse interface{}
+
+	resp, err := c.Do(req, &deleteResponse)
+	if resp == nil || resp.StatusCode() != http.StatusNoContent {
+		return false, resp, err
+	}
+	return true, resp, nil
+}
@@ -0,0 +1,15 @@
+package s3
+
+import (
+	"errors"
+)
+
+// Exported Errors
+var (
+	ErrMissingBucket      = errors.New("missing bucket")
+	ErrMissingRegion      = errors.New("missing region")
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrMissingKey         = errors.New("missing object key")
+	ErrInvalidEndpoint    = errors.New("invalid endpoint")
+	ErrNoSuchKey          = errors.New("no such key")
+)
@@ -0,0 +1,281 @@
+// Package s3 is a minimal S3 compatible object store client used for direct bucket access
+// with temporary credentials obtained from the HSDP Blob Repository
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CredentialsProvider supplies the credentials for signing each request
+type CredentialsProvider interface {
+	Retrieve(ctx context.Context) (Credentials, error)
+}
+
+// StaticCredentials is a CredentialsProvider which always returns itself
+type StaticCredentials Credentials
+
+// Retrieve implements CredentialsProvider
+func (s StaticCredentials) Retrieve(_ context.Context) (Credentials, error) {
+	return Credentials(s), nil
+}
+
+// Config configures a Client
+type Config struct {
+	// Endpoint is the base URL of the object store, e.g. https://s3.eu-west-1.amazonaws.com
+	Endpoint string
+	Region   string
+	Bucket   string
+	// PathStyle addresses the bucket as the first path segment instead of as a sub domain
+	PathStyle   bool
+	Credentials CredentialsProvider
+	HTTPClient  *http.Client
+}
+
+// Client performs signed requests against a single bucket
+type Client struct {
+	endpoint    *url.URL
+	region      string
+	bucket      string
+	pathStyle   bool
+	credentials CredentialsProvider
+	httpClient  *http.Client
+	now         func() time.Time
+}
+
+// Object describes an object in the bucket
+type Object struct {
+	Key          string    `xml:"Key"`
+	LastModified time.Time `xml:"LastModified"`
+	ETag         string    `xml:"ETag"`
+	Size         int64     `xml:"Size"`
+}
+
+// ListObjectsOutput is a single page of a ListObjectsV2 call
+type ListObjectsOutput struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Name                  string   `xml:"Name"`
+	Prefix                string   `xml:"Prefix"`
+	KeyCount              int      `xml:"KeyCount"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+	Contents              []Object `xml:"Contents"`
+	CommonPrefixes        []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+}
+
+// ListObjectsInput describes a ListObjectsV2 call
+type ListObjectsInput struct {
+	Prefix            string
+	Delimiter         string
+	ContinuationToken string
+	MaxKeys           int
+}
+
+// ObjectInfo contains the metadata returned with an object
+type ObjectInfo struct {
+	ContentType   string
+	ContentLength int64
+	ETag          string
+	LastModified  time.Time
+}
+
+// Error is returned when the object store responds with an error
+type Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3: status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("s3: status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Is reports ErrNoSuchKey for missing objects
+func (e *Error) Is(target error) bool {
+	return target == ErrNoSuchKey && (e.Code == "NoSuchKey" || e.StatusCode == http.StatusNotFound)
+}
+
+// New returns a Client for the bucket in config
+func New(config Config) (*Client, error) {
+	if config.Bucket == "" {
+		return nil, ErrMissingBucket
+	}
+	if config.Region == "" {
+		return nil, ErrMissingRegion
+	}
+	if config.Credentials == nil {
+		return nil, ErrMissingCredentials
+	}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEndpoint, endpoint)
+	}
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		endpoint:    u,
+		region:      config.Region,
+		bucket:      config.Bucket,
+		pathStyle:   config.PathStyle,
+		credentials: config.Credentials,
+		httpClient:  httpClient,
+		now:         time.Now,
+	}, nil
+}
+
+// Bucket returns the name of the bucket the client operates on
+func (c *Client) Bucket() string {
+	return c.bucket
+}
+
+func (c *Client) objectURL(key string, query url.Values) *url.URL {
+	u := *c.endpoint
+	basePath := strings.TrimSuffix(u.Path, "/")
+	if c.pathStyle {
+		basePath += "/" + c.bucket
+	} else {
+		u.Host = c.bucket + "." + u.Host
+	}
+	switch {
+	case key != "":
+		u.Path = basePath + "/" + key
+	case basePath != "":
+		u.Path = basePath
+	default:
+		u.Path = "/"
+	}
+	u.RawPath = ""
+	if query != nil {
+		u.RawQuery = strings.ReplaceAll(query.Encode(), "+", "%20")
+	}
+	return &u
+}
+
+func (c *Client) do(ctx context.Context, method string, u *url.URL, body []byte, header http.Header) (*http.Response, error) {
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s3: credentials: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.ContentLength = int64(len(body))
+	SignV4(req, creds, c.region, PayloadHash(body), c.now())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		s3Err := &Error{StatusCode: resp.StatusCode}
+		data, _ := io.ReadAll(resp.Body)
+		_ = xml.Unmarshal(data, s3Err)
+		return nil, s3Err
+	}
+	return resp, nil
+}
+
+// ListObjects returns a single page of objects using ListObjectsV2
+func (c *Client) ListObjects(ctx context.Context, input ListObjectsInput) (*ListObjectsOutput, error) {
+	query := url.Values{"list-type": {"2"}}
+	if input.Prefix != "" {
+		query.Set("prefix", input.Prefix)
+	}
+	if input.Delimiter != "" {
+		query.Set("delimiter", input.Delimiter)
+	}
+	if input.ContinuationToken != "" {
+		query.Set("continuation-token", input.ContinuationToken)
+	}
+	if input.MaxKeys > 0 {
+		query.Set("max-keys", fmt.Sprintf("%d", input.MaxKeys))
+	}
+	resp, err := c.do(ctx, http.MethodGet, c.objectURL("", query), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var output ListObjectsOutput
+	if err := xml.NewDecoder(resp.Body).Decode(&output); err != nil {
+		return nil, fmt.Errorf("s3: decoding list response: %w", err)
+	}
+	return &output, nil
+}
+
+// List returns all objects under prefix, following continuation tokens
+func (c *Client) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	input := ListObjectsInput{Prefix: prefix}
+	for {
+		page, err := c.ListObjects(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, page.Contents...)
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		input.ContinuationToken = page.NextContinuationToken
+	}
+}
+
+// GetObject returns the contents of the object. The caller must close the returned reader
+func (c *Client) GetObject(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	if key == "" {
+		return nil, nil, ErrMissingKey
+	}
+	resp, err := c.do(ctx, http.MethodGet, c.objectURL(key, nil), nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	info := &ObjectInfo{
+		ContentType:   resp.Header.Get("Content-Type"),
+		ContentLength: resp.ContentLength,
+		ETag:          resp.Header.Get("ETag"),
+	}
+	if lm, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = lm
+	}
+	return resp.Body, info, nil
+}
+
+// PutObject uploads data as the object key. The ETag of the stored object is returned
+func (c *Client) PutObject(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	if key == "" {
+		return "", ErrMissingKey
+	}
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := c.do(ctx, http.MethodPut, c.objectURL(key, nil), data, header)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Header.Get("ETag"), nil
+}
@@ -0,0 +1,162 @@
+package blr
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dip-software/go-dip-api/connect/blr/s3"
+	"github.com/go-playground/validator/v10"
+)
+
+// StoreAccessService provides temporary credentials for direct access to the underlying object store
+type StoreAccessService struct {
+	*Client
+	validate *validator.Validate
+}
+
+var (
+	storeAccessAPIVersion    = "1"
+	defaultStoreAccessMargin = 5 * time.Minute
+)
+
+// StoreAccessRequest describes the bucket, prefix and actions temporary credentials are requested for
+type StoreAccessRequest struct {
+	ResourceType string   `json:"resourceType" validate:"required"`
+	Bucket       string   `json:"bucket" validate:"required"`
+	Prefix       string   `json:"prefix,omitempty"`
+	Actions      []string `json:"actions,omitempty" validate:"omitempty,dive,oneof=read write list"`
+}
+
+// StoreCredentials are temporary object store credentials
+type StoreCredentials struct {
+	AccessKey    string    `json:"accessKey"`
+	SecretKey    string    `json:"secretKey"`
+	SessionToken string    `json:"sessionToken"`
+	Expires      time.Time `json:"expires"`
+}
+
+// ExpiresWithin returns true if the credentials expire within d of now
+func (c StoreCredentials) ExpiresWithin(now time.Time, d time.Duration) bool {
+	return !now.Add(d).Before(c.Expires)
+}
+
+// StoreAccess describes how to access a bucket prefix directly
+type StoreAccess struct {
+	ResourceType string           `json:"resourceType"`
+	Bucket       string           `json:"bucket"`
+	Prefix       string           `json:"prefix,omitempty"`
+	Region       string           `json:"region"`
+	Endpoint     string           `json:"endpoint,omitempty"`
+	Credentials  StoreCredentials `json:"credentials"`
+}
+
+// GetStoreAccess requests temporary credentials scoped to the bucket and prefix in the request
+func (s *StoreAccessService) GetStoreAccess(request StoreAccessRequest, options ...OptionFunc) (*StoreAccess, *Response, error) {
+	request.ResourceType = "StoreAccessRequest"
+	if err := s.validate.Struct(request); err != nil {
+		return nil, nil, err
+	}
+	req, err := s.NewRequest(http.MethodPost, "/StoreAccess", request, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("api-version", storeAccessAPIVersion)
+
+	var access StoreAccess
+
+	resp, err := s.Do(req, &access)
+	if err != nil {
+		return nil, resp, err
+	}
+	return &access, resp, nil
+}
+
+// StoreAccessProvider caches store access and refreshes it before the
+// credentials expire. It is safe for concurrent use
+type StoreAccessProvider struct {
+	service *StoreAccessService
+	request StoreAccessRequest
+	margin  time.Duration
+
+	mu      sync.Mutex
+	current *StoreAccess
+	now     func() time.Time
+}
+
+// NewProvider returns a StoreAccessProvider for request. Credentials are refreshed
+// when they expire within margin. A zero margin defaults to 5 minutes
+func (s *StoreAccessService) NewProvider(request StoreAccessRequest, margin time.Duration) *StoreAccessProvider {
+	if margin <= 0 {
+		margin = defaultStoreAccessMargin
+	}
+	return &StoreAccessProvider{
+		service: s,
+		request: request,
+		margin:  margin,
+		now:     time.Now,
+	}
+}
+
+// StoreAccess returns cached store access, fetching a new one when the credentials are about to expire
+func (p *StoreAccessProvider) StoreAccess(ctx context.Context) (*StoreAccess, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current != nil && !p.current.Credentials.ExpiresWithin(p.now(), p.margin) {
+		return p.current, nil
+	}
+	access, _, err := p.service.GetStoreAccess(p.request, func(req *http.Request) error {
+		*req = *req.WithContext(ctx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.current = access
+	return access, nil
+}
+
+// Retrieve implements s3.CredentialsProvider
+func (p *StoreAccessProvider) Retrieve(ctx context.Context) (s3.Credentials, error) {
+	access, err := p.StoreAccess(ctx)
+	if err != nil {
+		return s3.Credentials{}, err
+	}
+	return s3.Credentials{
+		AccessKey:    access.Credentials.AccessKey,
+		SecretKey:    access.Credentials.SecretKey,
+		SessionToken: access.Credentials.SessionToken,
+	}, nil
+}
+
+// ObjectStore returns an S3 client for the bucket which signs every request with
+// fresh credentials from the provider. Custom endpoints are addressed path style
+func (p *StoreAccessProvider) ObjectStore(ctx context.Context, httpClient *http.Client) (*s3.Client, error) {
+	access, err := p.StoreAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s3.New(s3.Config{
+		Endpoint:    access.Endpoint,
+		Region:      access.Region,
+		Bucket:      access.Bucket,
+		PathStyle:   pathStyle(access.Endpoint),
+		Credentials: p,
+		HTTPClient:  httpClient,
+	})
+}
+
+// pathStyle returns true for custom, non AWS, endpoints
+func pathStyle(endpoint string) bool {
+	if endpoint == "" {
+		return false
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return true
+	}
+	return !strings.HasSuffix(u.Hostname(), ".amazonaws.com")
+}
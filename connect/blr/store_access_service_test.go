@@ -0,0 +1,121 @@
+package blr_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dip-software/go-dip-api/connect/blr"
+	"github.com/stretchr/testify/assert"
+)
+
+func storeAccessBody(bucket, prefix, endpoint string, expires time.Time) string {
+	return fmt.Sprintf(`{
+  "resourceType": "StoreAccess",
+  "bucket": "%s",
+  "prefix": "%s",
+  "region": "us-east-1",
+  "endpoint": "%s",
+  "credentials": {
+    "accessKey": "AKIAEXAMPLE",
+    "secretKey": "secret",
+    "sessionToken": "token",
+    "expires": "%s"
+  }
+}`, bucket, prefix, endpoint, expires.UTC().Format(time.RFC3339))
+}
+
+func TestStoreAccess(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	objectStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIAEXAMPLE/") ||
+			r.Header.Get("X-Amz-Security-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			assert.Equal(t, "/bucket-exact-moose/data/hello.txt", r.URL.Path)
+			w.Header().Set("ETag", `"1"`)
+		case http.MethodGet:
+			_, _ = io.WriteString(w, "hello")
+		}
+	}))
+	defer objectStore.Close()
+
+	bucket := "bucket-exact-moose"
+	expires := time.Now().Add(time.Hour)
+	calls := 0
+	muxBLR.HandleFunc("/connect/blobrepository/StoreAccess", func(w http.ResponseWriter, r *http.Request) {
+		if !assert.Equal(t, http.MethodPost, r.Method) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var request blr.StoreAccessRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		assert.Equal(t, "StoreAccessRequest", request.ResourceType)
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, storeAccessBody(request.Bucket, request.Prefix, objectStore.URL, expires))
+	})
+
+	_, _, err := blrClient.StoreAccess.GetStoreAccess(blr.StoreAccessRequest{})
+	assert.NotNil(t, err)
+
+	request := blr.StoreAccessRequest{Bucket: bucket, Prefix: "data/", Actions: []string{"read", "write"}}
+	access, resp, err := blrClient.StoreAccess.GetStoreAccess(request)
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.NotNil(t, resp) {
+		return
+	}
+	if !assert.NotNil(t, access) {
+		return
+	}
+	assert.Equal(t, bucket, access.Bucket)
+	assert.Equal(t, "data/", access.Prefix)
+	assert.False(t, access.Credentials.ExpiresWithin(time.Now(), time.Minute))
+
+	ctx := context.Background()
+	provider := blrClient.StoreAccess.NewProvider(request, 0)
+	for i := 0; i < 3; i++ {
+		credentials, err := provider.Retrieve(ctx)
+		if !assert.Nil(t, err) {
+			return
+		}
+		assert.Equal(t, "token", credentials.SessionToken)
+	}
+	assert.Equal(t, 2, calls)
+
+	store, err := provider.ObjectStore(ctx, nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+	etag, err := store.PutObject(ctx, "data/hello.txt", []byte("hello"), "text/plain")
+	assert.Nil(t, err)
+	assert.Equal(t, `"1"`, etag)
+	body, _, err := store.GetObject(ctx, "data/hello.txt")
+	if assert.Nil(t, err) {
+		data, _ := io.ReadAll(body)
+		_ = body.Close()
+		assert.Equal(t, "hello", string(data))
+	}
+	assert.Equal(t, 2, calls)
+
+	// Credentials expiring within the margin are refreshed on every call
+	expires = time.Now().Add(time.Minute)
+	provider = blrClient.StoreAccess.NewProvider(request, 0)
+	_, _ = provider.Retrieve(ctx)
+	_, _ = provider.Retrieve(ctx)
+	assert.Equal(t, 4, calls)
+}
@@ -0,0 +1,107 @@
+package blr
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// SubscriptionsService manages the blob subscriptions of the Blob Repository
+type SubscriptionsService struct {
+	*Client
+	validate *validator.Validate
+}
+
+var (
+	subscriptionAPIVersion = "1"
+)
+
+// BlobSubscription publishes blob events of a data type to a notification topic
+type BlobSubscription struct {
+	ResourceType        string    `json:"resourceType" validate:"required"`
+	ID                  string    `json:"id,omitempty"`
+	Name                string    `json:"name" validate:"required,max=64"`
+	Description         string    `json:"description,omitempty" validate:"omitempty,max=250"`
+	DataType            string    `json:"dataType" validate:"required"`
+	NotificationTopicID Reference `json:"notificationTopicId" validate:"required"`
+	Meta                *Meta     `json:"meta,omitempty"`
+}
+
+// GetSubscriptionOptions struct describes search criteria for looking up BlobSubscription
+type GetSubscriptionOptions struct {
+	ID       *string `url:"_id,omitempty"`
+	Name     *string `url:"name,omitempty"`
+	DataType *string `url:"dataType,omitempty"`
+	Count    *int    `url:"_count,omitempty"`
+	Page     *int    `url:"page,omitempty"`
+}
+
+// Create creates a BlobSubscription
+func (s *SubscriptionsService) Create(subscription BlobSubscription) (*BlobSubscription, *Response, error) {
+	subscription.ResourceType = "BlobSubscription"
+	if err := s.validate.Struct(subscription); err != nil {
+		return nil, nil, err
+	}
+
+	req, _ := s.NewRequest(http.MethodPost, "/configuration/BlobSubscription", subscription, nil)
+	req.Header.Set("api-version", subscriptionAPIVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	var created BlobSubscription
+
+	resp, err := s.Do(req, &created)
+	if err != nil {
+		return nil, resp, err
+	}
+	if created.ID == "" {
+		return nil, resp, fmt.Errorf("the 'ID' field is missing")
+	}
+	return &created, resp, nil
+}
+
+// Update updates a BlobSubscription
+func (s *SubscriptionsService) Update(subscription BlobSubscription) (*BlobSubscription, *Response, error) {
+	subscription.ResourceType = "BlobSubscription"
+	id := subscription.ID
+	subscription.ID = "" // Server does not like a value here
+	if err := s.validate.Struct(subscription); err != nil {
+		return nil, nil, err
+	}
+	req, _ := s.NewRequest(http.MethodPut, "/configuration/BlobSubscription/"+id, subscription, nil)
+	req.Header.Set("api-version", subscriptionAPIVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	var updated BlobSubscription
+
+	resp, err := s.Do(req, &updated)
+	if err != nil {
+		return nil, resp, err
+	}
+	return &updated, resp, nil
+}
+
+// Delete deletes the given BlobSubscription
+func (s *SubscriptionsService) Delete(subscription BlobSubscription) (bool, *Response, error) {
+	return deleteResource(s.Client, "/configuration/BlobSubscription/"+subscription.ID, subscriptionAPIVersion)
+}
+
+// GetByID retrieves a BlobSubscription by its ID
+func (s *SubscriptionsService) GetByID(id string) (*BlobSubscription, *Response, error) {
+	if len(id) == 0 {
+		return nil, nil, fmt.Errorf("GetByID: missing id")
+	}
+	subscriptions, resp, err := s.Find(&GetSubscriptionOptions{ID: &id})
+	if err != nil {
+		return nil, resp, err
+	}
+	if len(*subscriptions) == 0 {
+		return nil, resp, ErrEmptyResult
+	}
+	return &(*subscriptions)[0], resp, nil
+}
+
+// Find looks up subscriptions based on GetSubscriptionOptions, following all bundle pages
+func (s *SubscriptionsService) Find(opt *GetSubscriptionOptions, options ...OptionFunc) (*[]BlobSubscription, *Response, error) {
+	return findAll[BlobSubscription](s.Client, "/configuration/BlobSubscription", subscriptionAPIVersion, opt, options...)
+}
@@ -0,0 +1,92 @@
+package blr_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/connect/blr"
+	"github.com/stretchr/testify/assert"
+)
+
+func subscriptionBody(id, name string) string {
+	return fmt.Sprintf(`{
+  "resourceType": "BlobSubscription",
+  "id": "%s",
+  "name": "%s",
+  "description": "Notify on new blobs",
+  "dataType": "tf-exact-moose",
+  "notificationTopicId": {
+    "reference": "Topic/5e9f1f39-3a1e-4c9c-9a3b-0e9a4f3b5d2a"
+  }
+}`, id, name)
+}
+
+func TestSubscriptionsCRUD(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	subscriptionID := "7c1e5a0b-2a6e-4f0c-8d0e-3b8a5c1f9e21"
+	name := "tf-subscription"
+	muxBLR.HandleFunc("/connect/blobrepository/configuration/BlobSubscription", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, subscriptionBody(subscriptionID, name))
+		case http.MethodGet:
+			w.WriteHeader(http.StatusOK)
+			if r.URL.Query().Get("_id") == "unknown" {
+				_, _ = io.WriteString(w, `{"resourceType": "Bundle", "type": "searchset"}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"resourceType": "Bundle", "type": "searchset", "entry": [{"resource": `+subscriptionBody(subscriptionID, name)+`}]}`)
+		}
+	})
+	muxBLR.HandleFunc("/connect/blobrepository/configuration/BlobSubscription/"+subscriptionID, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPut:
+			w.WriteHeader(http.StatusOK)
+			_, _ = io.WriteString(w, subscriptionBody(subscriptionID, name))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	created, resp, err := blrClient.Subscriptions.Create(blr.BlobSubscription{
+		Name:                name,
+		DataType:            "tf-exact-moose",
+		NotificationTopicID: blr.Reference{Reference: "Topic/5e9f1f39-3a1e-4c9c-9a3b-0e9a4f3b5d2a"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.NotNil(t, resp) {
+		return
+	}
+	if !assert.NotNil(t, created) {
+		return
+	}
+	assert.Equal(t, subscriptionID, created.ID)
+
+	found, _, err := blrClient.Subscriptions.GetByID(subscriptionID)
+	if !assert.Nil(t, err) || !assert.NotNil(t, found) {
+		return
+	}
+	assert.Equal(t, "Topic/5e9f1f39-3a1e-4c9c-9a3b-0e9a4f3b5d2a", found.NotificationTopicID.Reference)
+
+	_, _, err = blrClient.Subscriptions.GetByID("unknown")
+	assert.Equal(t, blr.ErrEmptyResult, err)
+
+	updated, _, err := blrClient.Subscriptions.Update(*found)
+	if !assert.Nil(t, err) || !assert.NotNil(t, updated) {
+		return
+	}
+	assert.Equal(t, name, updated.Name)
+
+	ok, _, err := blrClient.Subscriptions.Delete(*created)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,107 @@
+package blr
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// TopicsService manages the notification topics of the Blob Repository
+type TopicsService struct {
+	*Client
+	validate *validator.Validate
+}
+
+var (
+	topicAPIVersion = "1"
+)
+
+// Topic is a notification topic blob subscriptions publish to
+type Topic struct {
+	ResourceType  string    `json:"resourceType" validate:"required"`
+	ID            string    `json:"id,omitempty"`
+	Name          string    `json:"name" validate:"required,max=64"`
+	Description   string    `json:"description,omitempty" validate:"omitempty,max=250"`
+	PropositionID Reference `json:"propositionId" validate:"required"`
+	IsAuditable   bool      `json:"isAuditable"`
+	Meta          *Meta     `json:"meta,omitempty"`
+}
+
+// GetTopicOptions struct describes search criteria for looking up Topic
+type GetTopicOptions struct {
+	ID            *string `url:"_id,omitempty"`
+	Name          *string `url:"name,omitempty"`
+	PropositionID *string `url:"propositionId,omitempty"`
+	Count         *int    `url:"_count,omitempty"`
+	Page          *int    `url:"page,omitempty"`
+}
+
+// Create creates a Topic
+func (t *TopicsService) Create(topic Topic) (*Topic, *Response, error) {
+	topic.ResourceType = "Topic"
+	if err := t.validate.Struct(topic); err != nil {
+		return nil, nil, err
+	}
+
+	req, _ := t.NewRequest(http.MethodPost, "/configuration/Topic", topic, nil)
+	req.Header.Set("api-version", topicAPIVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	var created Topic
+
+	resp, err := t.Do(req, &created)
+	if err != nil {
+		return nil, resp, err
+	}
+	if created.ID == "" {
+		return nil, resp, fmt.Errorf("the 'ID' field is missing")
+	}
+	return &created, resp, nil
+}
+
+// Update updates a Topic
+func (t *TopicsService) Update(topic Topic) (*Topic, *Response, error) {
+	topic.ResourceType = "Topic"
+	id := topic.ID
+	topic.ID = "" // Server does not like a value here
+	if err := t.validate.Struct(topic); err != nil {
+		return nil, nil, err
+	}
+	req, _ := t.NewRequest(http.MethodPut, "/configuration/Topic/"+id, topic, nil)
+	req.Header.Set("api-version", topicAPIVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	var updated Topic
+
+	resp, err := t.Do(req, &updated)
+	if err != nil {
+		return nil, resp, err
+	}
+	return &updated, resp, nil
+}
+
+// Delete deletes the given Topic
+func (t *TopicsService) Delete(topic Topic) (bool, *Response, error) {
+	return deleteResource(t.Client, "/configuration/Topic/"+topic.ID, topicAPIVersion)
+}
+
+// GetByID retrieves a Topic by its ID
+func (t *TopicsService) GetByID(id string) (*Topic, *Response, error) {
+	if len(id) == 0 {
+		return nil, nil, fmt.Errorf("GetByID: missing id")
+	}
+	topics, resp, err := t.Find(&GetTopicOptions{ID: &id})
+	if err != nil {
+		return nil, resp, err
+	}
+	if len(*topics) == 0 {
+		return nil, resp, ErrEmptyResult
+	}
+	return &(*topics)[0], resp, nil
+}
+
+// Find looks up topics based on GetTopicOptions, following all bundle pages
+func (t *TopicsService) Find(opt *GetTopicOptions, options ...OptionFunc) (*[]Topic, *Response, error) {
+	return findAll[Topic](t.Client, "/configuration/Topic", topicAPIVersion, opt, options...)
+}
@@ -0,0 +1,122 @@
+package blr_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/connect/blr"
+	"github.com/stretchr/testify/assert"
+)
+
+func topicBody(id, name string) string {
+	return fmt.Sprintf(`{
+  "resourceType": "Topic",
+  "id": "%s",
+  "name": "%s",
+  "description": "Blob notifications",
+  "propositionId": {
+    "reference": "Proposition/64e403e6-d215-457a-bf12-2a4f49038208"
+  },
+  "isAuditable": true,
+  "meta": {
+    "lastUpdated": "2022-05-25T19:36:10Z",
+    "versionId": "1"
+  }
+}`, id, name)
+}
+
+func TestTopicsCRUD(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	topicID := "5e9f1f39-3a1e-4c9c-9a3b-0e9a4f3b5d2a"
+	otherID := "6e9f1f39-3a1e-4c9c-9a3b-0e9a4f3b5d2a"
+	name := "tf-topic"
+	muxBLR.HandleFunc("/connect/blobrepository/configuration/Topic", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPost:
+			var topic blr.Topic
+			_ = json.NewDecoder(r.Body).Decode(&topic)
+			assert.Equal(t, "Topic", topic.ResourceType)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, topicBody(topicID, topic.Name))
+		case http.MethodGet:
+			w.WriteHeader(http.StatusOK)
+			if r.URL.Query().Get("page") == "2" {
+				_, _ = io.WriteString(w, `{"resourceType": "Bundle", "type": "searchset", "entry": [{"resource": `+topicBody(otherID, "other")+`}]}`)
+				return
+			}
+			if id := r.URL.Query().Get("_id"); id != "" {
+				_, _ = io.WriteString(w, `{"resourceType": "Bundle", "type": "searchset", "entry": [{"resource": `+topicBody(id, name)+`}]}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{
+  "resourceType": "Bundle",
+  "type": "searchset",
+  "link": [{"relation": "next", "url": "https://blr/connect/blobrepository/configuration/Topic?page=2"}],
+  "entry": [{"resource": `+topicBody(topicID, name)+`}]
+}`)
+		}
+	})
+	muxBLR.HandleFunc("/connect/blobrepository/configuration/Topic/"+topicID, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPut:
+			var topic blr.Topic
+			_ = json.NewDecoder(r.Body).Decode(&topic)
+			assert.Empty(t, topic.ID)
+			w.WriteHeader(http.StatusOK)
+			_, _ = io.WriteString(w, topicBody(topicID, topic.Name))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	_, _, err := blrClient.Topics.Create(blr.Topic{})
+	assert.NotNil(t, err)
+
+	created, resp, err := blrClient.Topics.Create(blr.Topic{
+		Name:          name,
+		PropositionID: blr.Reference{Reference: "Proposition/64e403e6-d215-457a-bf12-2a4f49038208"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.NotNil(t, resp) {
+		return
+	}
+	if !assert.NotNil(t, created) {
+		return
+	}
+	assert.Equal(t, topicID, created.ID)
+	assert.True(t, created.IsAuditable)
+
+	found, _, err := blrClient.Topics.Find(&blr.GetTopicOptions{Name: &name})
+	if !assert.Nil(t, err) || !assert.NotNil(t, found) {
+		return
+	}
+	if assert.Len(t, *found, 2) {
+		assert.Equal(t, otherID, (*found)[1].ID)
+	}
+
+	topic, _, err := blrClient.Topics.GetByID(topicID)
+	if !assert.Nil(t, err) || !assert.NotNil(t, topic) {
+		return
+	}
+	assert.Equal(t, name, topic.Name)
+
+	topic.Name = "renamed"
+	updated, _, err := blrClient.Topics.Update(*topic)
+	if !assert.Nil(t, err) || !assert.NotNil(t, updated) {
+		return
+	}
+	assert.Equal(t, "renamed", updated.Name)
+
+	ok, _, err := blrClient.Topics.Delete(*created)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
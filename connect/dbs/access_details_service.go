@@ -0,0 +1,125 @@
+package dbs
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AccessDetailsService provides access details of subscribers such as queue URLs and temporary credentials
+type AccessDetailsService struct {
+	*Client
+}
+
+var (
+	accessDetailsAPIVersion   = "1"
+	defaultCredentialsRefresh = 5 * time.Minute
+)
+
+// Credentials are temporary AWS credentials for consuming from a subscriber
+type Credentials struct {
+	AccessKey    string    `json:"accessKey"`
+	SecretKey    string    `json:"secretKey"`
+	SessionToken string    `json:"sessionToken"`
+	Expires      time.Time `json:"expires"`
+}
+
+// ExpiresWithin returns true if the credentials expire within d of now
+func (c Credentials) ExpiresWithin(now time.Time, d time.Duration) bool {
+	return !now.Add(d).Before(c.Expires)
+}
+
+// AccessDetails describes how to consume from a SQS or Kinesis subscriber
+type AccessDetails struct {
+	ResourceType string      `json:"resourceType"`
+	SubscriberID string      `json:"subscriberId"`
+	Region       string      `json:"region"`
+	QueueURL     string      `json:"queueUrl,omitempty"`
+	QueueName    string      `json:"queueName,omitempty"`
+	StreamName   string      `json:"streamName,omitempty"`
+	StreamARN    string      `json:"streamArn,omitempty"`
+	Credentials  Credentials `json:"credentials"`
+}
+
+func subscriberPath(subscriber TopicSubscriber) (string, error) {
+	switch subscriber.SubscriberType() {
+	case "SQSSubscriber":
+		return "/Subscriber/SQS/" + subscriber.SubscriberID(), nil
+	case "KinesisSubscriber":
+		return "/Subscriber/Kinesis/" + subscriber.SubscriberID(), nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedSubscriber, subscriber.SubscriberType())
+}
+
+// GetAccessDetails returns the access details of the subscriber including fresh temporary credentials
+func (a *AccessDetailsService) GetAccessDetails(subscriber TopicSubscriber, options ...OptionFunc) (*AccessDetails, *Response, error) {
+	subscriberPath, err := subscriberPath(subscriber)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := a.NewRequest(http.MethodGet, subscriberPath+"/$access-details", nil, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("api-version", accessDetailsAPIVersion)
+
+	var details AccessDetails
+
+	resp, err := a.Do(req, &details)
+	if err != nil {
+		return nil, resp, err
+	}
+	return &details, resp, nil
+}
+
+// AccessDetailsProvider caches the access details of a subscriber and
+// refreshes them before the credentials expire. It is safe for concurrent use
+type AccessDetailsProvider struct {
+	service    *AccessDetailsService
+	subscriber TopicSubscriber
+	margin     time.Duration
+
+	mu      sync.Mutex
+	current *AccessDetails
+	now     func() time.Time
+}
+
+// NewProvider returns an AccessDetailsProvider for the subscriber. Credentials are refreshed
+// when they expire within margin. A zero margin defaults to 5 minutes
+func (a *AccessDetailsService) NewProvider(subscriber TopicSubscriber, margin time.Duration) *AccessDetailsProvider {
+	if margin <= 0 {
+		margin = defaultCredentialsRefresh
+	}
+	return &AccessDetailsProvider{
+		service:    a,
+		subscriber: subscriber,
+		margin:     margin,
+		now:        time.Now,
+	}
+}
+
+// AccessDetails returns cached access details, fetching new ones when the credentials are about to expire
+func (p *AccessDetailsProvider) AccessDetails(options ...OptionFunc) (*AccessDetails, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current != nil && !p.current.Credentials.ExpiresWithin(p.now(), p.margin) {
+		return p.current, nil
+	}
+	details, _, err := p.service.GetAccessDetails(p.subscriber, options...)
+	if err != nil {
+		return nil, err
+	}
+	p.current = details
+	return details, nil
+}
+
+// Credentials returns valid credentials, refreshing them when needed
+func (p *AccessDetailsProvider) Credentials(options ...OptionFunc) (*Credentials, error) {
+	details, err := p.AccessDetails(options...)
+	if err != nil {
+		return nil, err
+	}
+	credentials := details.Credentials
+	return &credentials, nil
+}
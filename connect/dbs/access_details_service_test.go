@@ -0,0 +1,89 @@
+package dbs_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dip-software/go-dip-api/connect/dbs"
+	"github.com/stretchr/testify/assert"
+)
+
+func accessDetailsBody(id string, expires time.Time) string {
+	return fmt.Sprintf(`{
+  "resourceType": "AccessDetails",
+  "subscriberId": "%s",
+  "region": "eu-west-1",
+  "queueUrl": "https://sqs.eu-west-1.amazonaws.com/123456789012/dbs-my_infix-%s",
+  "queueName": "dbs-my_infix-%s",
+  "credentials": {
+    "accessKey": "AKIAEXAMPLE",
+    "secretKey": "secret",
+    "sessionToken": "token",
+    "expires": "%s"
+  }
+}`, id, id, id, expires.UTC().Format(time.RFC3339))
+}
+
+func TestAccessDetails(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	sqsID := "9f80f9e0-5cb2-4ebd-8980-03f550cb453f"
+	expires := time.Now().Add(time.Hour)
+	calls := 0
+	muxDBS.HandleFunc("/client-test/connect/databroker/Subscriber/SQS/"+sqsID+"/$access-details", func(w http.ResponseWriter, r *http.Request) {
+		if !assert.Equal(t, http.MethodGet, r.Method) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, accessDetailsBody(sqsID, expires))
+	})
+
+	subscriber := dbs.SQSSubscriber{ID: sqsID}
+	details, resp, err := dbsClient.AccessDetails.GetAccessDetails(subscriber)
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.NotNil(t, resp) {
+		return
+	}
+	if !assert.NotNil(t, details) {
+		return
+	}
+	assert.Equal(t, "eu-west-1", details.Region)
+	assert.Contains(t, details.QueueURL, sqsID)
+	assert.Equal(t, "AKIAEXAMPLE", details.Credentials.AccessKey)
+	assert.False(t, details.Credentials.ExpiresWithin(time.Now(), time.Minute))
+	assert.True(t, details.Credentials.ExpiresWithin(time.Now(), 2*time.Hour))
+
+	provider := dbsClient.AccessDetails.NewProvider(subscriber, 0)
+	for i := 0; i < 3; i++ {
+		credentials, err := provider.Credentials()
+		if !assert.Nil(t, err) {
+			return
+		}
+		assert.Equal(t, "token", credentials.SessionToken)
+	}
+	assert.Equal(t, 2, calls)
+
+	// Credentials expiring within the margin are refreshed on every call
+	expires = time.Now().Add(time.Minute)
+	provider = dbsClient.AccessDetails.NewProvider(subscriber, 0)
+	_, _ = provider.Credentials()
+	_, _ = provider.Credentials()
+	assert.Equal(t, 4, calls)
+
+	_, _, err = dbsClient.AccessDetails.GetAccessDetails(unknownSubscriber{})
+	assert.ErrorIs(t, err, dbs.ErrUnsupportedSubscriber)
+}
+
+type unknownSubscriber struct{}
+
+func (unknownSubscriber) SubscriberID() string   { return "id" }
+func (unknownSubscriber) SubscriberType() string { return "HTTPSubscriber" }
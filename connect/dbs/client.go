@@ -49,6 +49,8 @@ type Client struct {
 
 	Subscribers   *SubscribersService
 	Subscriptions *SubscriptionService
+	AccessDetails *AccessDetailsService
+	DataItems     *DataItemsService
 }
 
 // NewClient returns a new DBS client
@@ -69,6 +71,8 @@ func NewClient(iamClient *iam.Client, config *Config) (*Client, error) {
 
 	c.Subscribers = &SubscribersService{Client: c, validate: validator.New()}
 	c.Subscriptions = &SubscriptionService{Client: c, validate: validator.New()}
+	c.AccessDetails = &AccessDetailsService{Client: c}
+	c.DataItems = &DataItemsService{Client: c}
 
 	return c, nil
 }
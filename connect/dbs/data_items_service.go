@@ -0,0 +1,95 @@
+package dbs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dip-software/go-dip-api/internal"
+)
+
+// DataItemsService provides read access to the data items flowing through Data Broker topics
+type DataItemsService struct {
+	*Client
+}
+
+var (
+	dataItemAPIVersion = "1"
+)
+
+// DataItem is a single message received by Data Broker
+type DataItem struct {
+	ResourceType string          `json:"resourceType"`
+	ID           string          `json:"id"`
+	Meta         *Meta           `json:"meta,omitempty"`
+	DataType     string          `json:"dataType"`
+	DeviceID     string          `json:"deviceId,omitempty"`
+	Timestamp    time.Time       `json:"timestamp"`
+	Data         json.RawMessage `json:"data,omitempty"`
+}
+
+// GetDataItemsOptions describes the criteria for looking up data items
+type GetDataItemsOptions struct {
+	ID        *string  `url:"_id,omitempty"`
+	DataType  *string  `url:"dataType,omitempty"`
+	DeviceID  *string  `url:"deviceId,omitempty"`
+	Timestamp []string `url:"timestamp,omitempty"`
+	Count     *int     `url:"_count,omitempty"`
+}
+
+// TimeWindow returns the timestamp search values for items received at or after from and
+// before to. A zero time leaves that side of the window open
+func TimeWindow(from, to time.Time) []string {
+	var window []string
+	if !from.IsZero() {
+		window = append(window, "ge"+from.UTC().Format(time.RFC3339))
+	}
+	if !to.IsZero() {
+		window = append(window, "lt"+to.UTC().Format(time.RFC3339))
+	}
+	return window
+}
+
+// GetDataItems returns the data items matching the options, following all bundle pages
+func (d *DataItemsService) GetDataItems(opt *GetDataItemsOptions, options ...OptionFunc) (*[]DataItem, *Response, error) {
+	var items []DataItem
+	var resp *Response
+
+	err := internal.FollowNext(func(next *url.URL) (internal.BundleLinks, error) {
+		pageOptions := options
+		if next != nil {
+			pageOptions = append(append([]OptionFunc{}, options...), withQuery(next.RawQuery))
+		}
+		req, err := d.NewRequest(http.MethodGet, "/DataItem", opt, pageOptions...)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("api-version", dataItemAPIVersion)
+
+		var bundleResponse internal.Bundle
+
+		resp, err = d.Do(req, &bundleResponse)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, internal.Resources[DataItem](&bundleResponse)...)
+		return bundleResponse.Link, nil
+	})
+	if err != nil {
+		return nil, resp, err
+	}
+	return &items, resp, nil
+}
+
+// GetDataItemByID retrieves a single data item
+func (d *DataItemsService) GetDataItemByID(id string) (*DataItem, *Response, error) {
+	items, resp, err := d.GetDataItems(&GetDataItemsOptions{ID: &id})
+	if err != nil {
+		return nil, resp, err
+	}
+	if len(*items) == 0 {
+		return nil, resp, ErrEmptyResult
+	}
+	return &(*items)[0], resp, nil
+}
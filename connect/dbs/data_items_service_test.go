@@ -0,0 +1,75 @@
+package dbs_test
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dip-software/go-dip-api/connect/dbs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDataItems(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	dataType := "my-datatype"
+	deviceID := "device-1"
+	muxDBS.HandleFunc("/client-test/connect/databroker/DataItem", func(w http.ResponseWriter, r *http.Request) {
+		if !assert.Equal(t, http.MethodGet, r.Method) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if q.Get("_id") != "" {
+			_, _ = io.WriteString(w, `{"type": "searchset", "entry": []}`)
+			return
+		}
+		assert.Equal(t, dataType, q.Get("dataType"))
+		assert.Equal(t, deviceID, q.Get("deviceId"))
+		assert.Equal(t, []string{"ge2024-01-01T00:00:00Z", "lt2024-01-02T00:00:00Z"}, q["timestamp"])
+		if q.Get("_page") == "2" {
+			_, _ = io.WriteString(w, `{
+  "type": "searchset",
+  "entry": [
+    {"resource": {"resourceType": "DataItem", "id": "item-2", "dataType": "my-datatype", "deviceId": "device-1", "timestamp": "2024-01-01T12:00:00Z", "data": {"temp": 22}}}
+  ]
+}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{
+  "type": "searchset",
+  "entry": [
+    {"resource": {"resourceType": "DataItem", "id": "item-1", "dataType": "my-datatype", "deviceId": "device-1", "timestamp": "2024-01-01T11:00:00Z", "data": {"temp": 21}}}
+  ],
+  "link": [
+    {"relation": "next", "url": "https://databroker.example.com/client-test/connect/databroker/DataItem?`+r.URL.RawQuery+`&_page=2"}
+  ]
+}`)
+	})
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	items, resp, err := dbsClient.DataItems.GetDataItems(&dbs.GetDataItemsOptions{
+		DataType:  &dataType,
+		DeviceID:  &deviceID,
+		Timestamp: dbs.TimeWindow(from, from.Add(24*time.Hour)),
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.NotNil(t, resp) {
+		return
+	}
+	if !assert.Len(t, *items, 2) {
+		return
+	}
+	assert.Equal(t, "item-1", (*items)[0].ID)
+	assert.Equal(t, "item-2", (*items)[1].ID)
+	assert.JSONEq(t, `{"temp": 22}`, string((*items)[1].Data))
+
+	_, _, err = dbsClient.DataItems.GetDataItemByID("missing")
+	assert.ErrorIs(t, err, dbs.ErrEmptyResult)
+}
@@ -19,4 +19,8 @@ var (
 	ErrEmptyResults                   = errors.New("empty results")
 	ErrOperationFailed                = errors.New("operation failed")
 	ErrCouldNoReadResourceAfterCreate = errors.New("could not read resource after create")
+	ErrSubscriberInError              = errors.New("subscriber is in error")
+	ErrSubscriptionInError            = errors.New("subscription is in error")
+	ErrMissingPartitionKey            = errors.New("missing Kinesis stream partition key")
+	ErrUnsupportedSubscriber          = errors.New("unsupported subscriber type")
 )
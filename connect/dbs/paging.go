@@ -0,0 +1,50 @@
+package dbs
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/dip-software/go-dip-api/internal"
+)
+
+func withQuery(rawQuery string) OptionFunc {
+	return func(req *http.Request) error {
+		req.URL.RawQuery = rawQuery
+		return nil
+	}
+}
+
+// findAll searches requestPath and collects the entries of all bundle pages
+func findAll[T any](c *Client, requestPath, apiVersion string, opt interface{}, options ...OptionFunc) (*[]T, *Response, error) {
+	var resources []T
+	var resp *Response
+
+	err := internal.FollowNext(func(next *url.URL) (internal.BundleLinks, error) {
+		pageOptions := options
+		if next != nil {
+			pageOptions = append(append([]OptionFunc{}, options...), withQuery(next.RawQuery))
+		}
+		req, err := c.NewRequest(http.MethodGet, requestPath, opt, pageOptions...)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("api-version", apiVersion)
+		req.Header.Set("Content-Type", "application/json")
+
+		var bundleResponse struct {
+			Entry []T                  `json:"entry,omitempty"`
+			Link  internal.BundleLinks `json:"link,omitempty"`
+		}
+
+		resp, err = c.Do(req, &bundleResponse)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, bundleResponse.Entry...)
+		return bundleResponse.Link, nil
+	})
+	if err != nil {
+		return nil, resp, err
+	}
+	return &resources, resp, nil
+}
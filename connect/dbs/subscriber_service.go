@@ -1,10 +1,12 @@
 package dbs
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/dip-software/go-dip-api/internal"
 	"github.com/go-playground/validator/v10"
 )
@@ -16,6 +18,7 @@ type SubscribersService struct {
 
 var (
 	subscriberAPIVersion = "1"
+	defaultPollInterval  = 5 * time.Second
 )
 
 type SQSSubscriberConfig struct {
@@ -125,6 +128,11 @@ func (b *SubscribersService) FindSQS(opt *GetSQSSubscriberOptions, options ...Op
 	return &bundleResponse.Entry, resp, err
 }
 
+// FindAllSQS looks up SQS subscribers based on GetSQSSubscriberOptions, retrieving all result pages
+func (b *SubscribersService) FindAllSQS(opt *GetSQSSubscriberOptions, options ...OptionFunc) (*[]SQSSubscriber, *Response, error) {
+	return findAll[SQSSubscriber](b.Client, "/Subscriber/SQS", subscriberAPIVersion, opt, options...)
+}
+
 func (b *SubscribersService) DeleteSQS(subscriber SQSSubscriber) (bool, *Response, error) {
 	req, err := b.NewRequest(http.MethodDelete, "/Subscriber/SQS/"+subscriber.ID, nil, nil)
 	if err != nil {
@@ -140,3 +148,183 @@ func (b *SubscribersService) DeleteSQS(subscriber SQSSubscriber) (bool, *Respons
 	}
 	return true, resp, nil
 }
+
+// TopicSubscriber is implemented by subscribers which can receive topic subscriptions
+type TopicSubscriber interface {
+	SubscriberID() string
+	SubscriberType() string
+}
+
+// SubscriberID returns the ID of the SQS subscriber
+func (s SQSSubscriber) SubscriberID() string {
+	return s.ID
+}
+
+// SubscriberType returns the resource type of the SQS subscriber
+func (s SQSSubscriber) SubscriberType() string {
+	return "SQSSubscriber"
+}
+
+type KinesisSubscriberConfig struct {
+	ResourceType         string `json:"resourceType" validate:"required" enum:"KinesisSubscriberConfig"`
+	NameInfix            string `json:"nameInfix" validate:"required"`
+	Description          string `json:"description" validate:"required"`
+	NumberOfShards       int    `json:"numberOfShards" validate:"required,min=1"`
+	RetentionPeriod      int    `json:"retentionPeriod,omitempty"`
+	ServerSideEncryption bool   `json:"serverSideEncryption,omitempty"`
+}
+
+type KinesisSubscriber struct {
+	ID                   string `json:"id"`
+	Meta                 *Meta  `json:"meta"`
+	Name                 string `json:"name" validate:"required"`
+	Description          string `json:"description" validate:"required"`
+	Status               string `json:"status" validate:"required" enum:"Creating|Deleting|Active|Updating|InError"`
+	ErrorMessage         string `json:"errorMessage,omitempty"`
+	ResourceType         string `json:"resourceType" validate:"required" enum:"KinesisSubscriber"`
+	StreamName           string `json:"streamName"`
+	NumberOfShards       int    `json:"numberOfShards" validate:"required,min=1"`
+	RetentionPeriod      int    `json:"retentionPeriod"`
+	ServerSideEncryption bool   `json:"serverSideEncryption"`
+}
+
+// SubscriberID returns the ID of the Kinesis subscriber
+func (s KinesisSubscriber) SubscriberID() string {
+	return s.ID
+}
+
+// SubscriberType returns the resource type of the Kinesis subscriber
+func (s KinesisSubscriber) SubscriberType() string {
+	return "KinesisSubscriber"
+}
+
+type GetKinesisSubscriberOptions struct {
+	ID          *string `url:"_id,omitempty"`
+	Name        *string `url:"name,omitempty"`
+	LastUpdated *string `url:"_lastUpdated,omitempty"`
+}
+
+type KinesisBundle struct {
+	Type  string              `json:"type,omitempty"`
+	Entry []KinesisSubscriber `json:"entry,omitempty"`
+}
+
+func (b *SubscribersService) CreateKinesis(kinesisConfig KinesisSubscriberConfig) (*KinesisSubscriber, *Response, error) {
+	kinesisConfig.ResourceType = "KinesisSubscriberConfig"
+	if err := b.validate.Struct(kinesisConfig); err != nil {
+		return nil, nil, err
+	}
+
+	req, _ := b.NewRequest(http.MethodPost, "/Subscriber/Kinesis", kinesisConfig, nil)
+	req.Header.Set("api-version", subscriberAPIVersion)
+
+	var created KinesisSubscriber
+
+	resp, err := b.Do(req, &created)
+
+	if err != nil {
+		return nil, resp, err
+	}
+	if created.ID == "" {
+		return nil, resp, fmt.Errorf("the 'ID' field is missing")
+	}
+	return &created, resp, nil
+}
+
+func (b *SubscribersService) GetKinesisByID(id string) (*KinesisSubscriber, *Response, error) {
+	req, err := b.NewRequest(http.MethodGet, "/Subscriber/Kinesis/"+id, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("api-version", subscriberAPIVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	var resource KinesisSubscriber
+
+	resp, err := b.Do(req, &resource)
+	if err != nil {
+		return nil, resp, err
+	}
+	err = internal.CheckResponse(resp.Response)
+	if err != nil {
+		return nil, resp, fmt.Errorf("GetKinesisByID: %w", err)
+	}
+	if resource.ID != id {
+		return nil, nil, fmt.Errorf("returned resource does not match")
+	}
+	return &resource, resp, nil
+}
+
+func (b *SubscribersService) FindKinesis(opt *GetKinesisSubscriberOptions, options ...OptionFunc) (*[]KinesisSubscriber, *Response, error) {
+	req, err := b.NewRequest(http.MethodGet, "/Subscriber/Kinesis", opt, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("api-version", subscriberAPIVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	var bundleResponse KinesisBundle
+
+	resp, err := b.Do(req, &bundleResponse)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return &bundleResponse.Entry, resp, err
+}
+
+// FindAllKinesis looks up Kinesis subscribers based on GetKinesisSubscriberOptions, retrieving all result pages
+func (b *SubscribersService) FindAllKinesis(opt *GetKinesisSubscriberOptions, options ...OptionFunc) (*[]KinesisSubscriber, *Response, error) {
+	return findAll[KinesisSubscriber](b.Client, "/Subscriber/Kinesis", subscriberAPIVersion, opt, options...)
+}
+
+// UpdateKinesis updates the description, number of shards and retention period of the Kinesis subscriber
+func (b *SubscribersService) UpdateKinesis(subscriber KinesisSubscriber) (*KinesisSubscriber, *Response, error) {
+	subscriber.ResourceType = "KinesisSubscriber"
+	req, err := b.NewRequest(http.MethodPut, "/Subscriber/Kinesis/"+subscriber.ID, subscriber, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("api-version", subscriberAPIVersion)
+	if subscriber.Meta != nil && subscriber.Meta.VersionID != "" {
+		req.Header.Set("If-Match", subscriber.Meta.VersionID)
+	}
+
+	var updated KinesisSubscriber
+
+	resp, err := b.Do(req, &updated)
+	if err != nil {
+		return nil, resp, err
+	}
+	return &updated, resp, nil
+}
+
+func (b *SubscribersService) DeleteKinesis(subscriber KinesisSubscriber) (bool, *Response, error) {
+	req, err := b.NewRequest(http.MethodDelete, "/Subscriber/Kinesis/"+subscriber.ID, nil, nil)
+	if err != nil {
+		return false, nil, err
+	}
+	req.Header.Set("api-version", subscriberAPIVersion)
+
+	var deleteResponse interface{}
+
+	resp, err := b.Do(req, &deleteResponse)
+	if resp == nil || resp.StatusCode() != http.StatusNoContent {
+		return false, resp, err
+	}
+	return true, resp, nil
+}
+
+// WaitForKinesis polls the Kinesis subscriber at a fixed interval until its status is Active or InError.
+// An InError status is returned as ErrSubscriberInError together with the subscriber
+func (b *SubscribersService) WaitForKinesis(ctx context.Context, id string, interval time.Duration) (*KinesisSubscriber, error) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	found, err := b.WaitUntilActive(ctx, KinesisSubscriber{ID: id}, backoff.NewConstantBackOff(interval))
+	subscriber, ok := found.(KinesisSubscriber)
+	if !ok {
+		return nil, err
+	}
+	return &subscriber, err
+}
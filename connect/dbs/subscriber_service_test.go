@@ -1,11 +1,13 @@
 package dbs_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/dip-software/go-dip-api/connect/dbs"
 	"io"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -35,6 +37,7 @@ func TestSQSCRUD(t *testing.T) {
 	defer teardown()
 
 	sqsID := "9f80f9e0-5cb2-4ebd-8980-03f550cb453f"
+	otherID := "2c1a4a56-0f6b-4a53-9d0b-7f4e6f3f8a21"
 	queueType := "FIFO"
 	infix := "my_infix"
 	muxDBS.HandleFunc("/client-test/connect/databroker/Subscriber/SQS", func(w http.ResponseWriter, r *http.Request) {
@@ -44,6 +47,18 @@ func TestSQSCRUD(t *testing.T) {
 			w.Header().Set("Etag", "1")
 			w.WriteHeader(http.StatusCreated)
 			_, _ = io.WriteString(w, sqsBody(sqsID, queueType, infix, "Creating"))
+		case "GET":
+			w.WriteHeader(http.StatusOK)
+			if r.URL.Query().Get("_page") == "2" {
+				_, _ = io.WriteString(w, `{"resourceType": "Bundle", "type": "searchset", "entry": [`+sqsBody(otherID, queueType, infix, "Active")+`]}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{
+  "resourceType": "Bundle",
+  "type": "searchset",
+  "link": [{"relation": "next", "url": "https://dbs/client-test/connect/databroker/Subscriber/SQS?_page=2"}],
+  "entry": [`+sqsBody(sqsID, queueType, infix, "Active")+`]
+}`)
 		}
 	})
 	muxDBS.HandleFunc("/client-test/connect/databroker/Subscriber/SQS/"+sqsID, func(w http.ResponseWriter, r *http.Request) {
@@ -79,6 +94,20 @@ func TestSQSCRUD(t *testing.T) {
 	assert.Equal(t, sqsID, created.ID)
 	assert.NotNil(t, created.Status)
 
+	found, _, err := dbsClient.Subscribers.FindSQS(&dbs.GetSQSSubscriberOptions{})
+	if !assert.Nil(t, err) || !assert.NotNil(t, found) {
+		return
+	}
+	assert.Len(t, *found, 1)
+	found, _, err = dbsClient.Subscribers.FindAllSQS(&dbs.GetSQSSubscriberOptions{})
+	if !assert.Nil(t, err) || !assert.NotNil(t, found) {
+		return
+	}
+	if assert.Len(t, *found, 2) {
+		assert.Equal(t, sqsID, (*found)[0].ID)
+		assert.Equal(t, otherID, (*found)[1].ID)
+	}
+
 	res, resp, err := dbsClient.Subscribers.DeleteSQS(*created)
 	if !assert.Nil(t, err) {
 		return
@@ -89,3 +118,144 @@ func TestSQSCRUD(t *testing.T) {
 	assert.True(t, res)
 	assert.Equal(t, http.StatusNoContent, resp.StatusCode())
 }
+
+func kinesisBody(id string, infix string, status string, shards int) string {
+	return fmt.Sprintf(`{
+            "id": "%s",
+            "meta": {
+                "lastUpdated": "2022-12-06T10:18:11.947Z",
+                "versionId": "1"
+            },
+            "name": "dbs-%s-%s",
+            "description": "MyKinesisStream",
+            "status": "%s",
+            "resourceType": "KinesisSubscriber",
+            "streamName": "dbs-%s-%s",
+            "numberOfShards": %d,
+            "retentionPeriod": 24,
+            "serverSideEncryption": true
+}`, id, infix, id, status, infix, id, shards)
+}
+
+func TestKinesisCRUD(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	kinesisID := "5d0b3c4e-8f3a-4d55-a1ef-0f3a2b6e7c11"
+	otherID := "8e2f6a1d-3b7c-4c9e-9a10-6d4b2c8f5e37"
+	infix := "my_infix"
+	gets := 0
+	muxDBS.HandleFunc("/client-test/connect/databroker/Subscriber/Kinesis", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case "POST":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, kinesisBody(kinesisID, infix, "Creating", 1))
+		case "GET":
+			assert.Equal(t, "dbs-my_infix", r.URL.Query().Get("name"))
+			w.WriteHeader(http.StatusOK)
+			if r.URL.Query().Get("_page") == "2" {
+				_, _ = io.WriteString(w, `{"type": "searchset", "entry": [`+kinesisBody(otherID, infix, "Active", 1)+`]}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{
+  "type": "searchset",
+  "link": [{"relation": "next", "url": "https://dbs/client-test/connect/databroker/Subscriber/Kinesis?name=dbs-my_infix&_page=2"}],
+  "entry": [`+kinesisBody(kinesisID, infix, "Active", 1)+`]
+}`)
+		}
+	})
+	muxDBS.HandleFunc("/client-test/connect/databroker/Subscriber/Kinesis/"+kinesisID, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case "GET":
+			gets++
+			status := "Creating"
+			if gets > 1 {
+				status = "Active"
+			}
+			w.WriteHeader(http.StatusOK)
+			_, _ = io.WriteString(w, kinesisBody(kinesisID, infix, status, 1))
+		case "PUT":
+			assert.Equal(t, "1", r.Header.Get("If-Match"))
+			w.WriteHeader(http.StatusOK)
+			_, _ = io.WriteString(w, kinesisBody(kinesisID, infix, "Updating", 2))
+		case "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	created, resp, err := dbsClient.Subscribers.CreateKinesis(dbs.KinesisSubscriberConfig{
+		NameInfix:            infix,
+		Description:          "MyKinesisStream",
+		NumberOfShards:       1,
+		ServerSideEncryption: true,
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.NotNil(t, resp) {
+		return
+	}
+	if !assert.NotNil(t, created) {
+		return
+	}
+	assert.Equal(t, kinesisID, created.ID)
+	assert.Equal(t, "Creating", created.Status)
+	assert.Equal(t, kinesisID, created.SubscriberID())
+	assert.Equal(t, "KinesisSubscriber", created.SubscriberType())
+
+	active, err := dbsClient.Subscribers.WaitForKinesis(context.Background(), kinesisID, time.Millisecond)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "Active", active.Status)
+	assert.Equal(t, 2, gets)
+
+	name := "dbs-my_infix"
+	found, _, err := dbsClient.Subscribers.FindKinesis(&dbs.GetKinesisSubscriberOptions{Name: &name})
+	if assert.Nil(t, err) && assert.NotNil(t, found) {
+		assert.Len(t, *found, 1)
+	}
+	found, _, err = dbsClient.Subscribers.FindAllKinesis(&dbs.GetKinesisSubscriberOptions{Name: &name})
+	if assert.Nil(t, err) && assert.NotNil(t, found) && assert.Len(t, *found, 2) {
+		assert.Equal(t, otherID, (*found)[1].ID)
+	}
+
+	active.NumberOfShards = 2
+	updated, _, err := dbsClient.Subscribers.UpdateKinesis(*active)
+	if assert.Nil(t, err) && assert.NotNil(t, updated) {
+		assert.Equal(t, 2, updated.NumberOfShards)
+	}
+
+	res, resp, err := dbsClient.Subscribers.DeleteKinesis(*created)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, res)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode())
+
+	_, _, err = dbsClient.Subscribers.CreateKinesis(dbs.KinesisSubscriberConfig{
+		NameInfix:   infix,
+		Description: "MyKinesisStream",
+	})
+	assert.NotNil(t, err)
+}
+
+func TestWaitForKinesisInError(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	kinesisID := "5d0b3c4e-8f3a-4d55-a1ef-0f3a2b6e7c11"
+	muxDBS.HandleFunc("/client-test/connect/databroker/Subscriber/Kinesis/"+kinesisID, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, kinesisBody(kinesisID, "infix", "InError", 1))
+	})
+
+	subscriber, err := dbsClient.Subscribers.WaitForKinesis(context.Background(), kinesisID, time.Millisecond)
+	assert.ErrorIs(t, err, dbs.ErrSubscriberInError)
+	if assert.NotNil(t, subscriber) {
+		assert.Equal(t, "InError", subscriber.Status)
+	}
+}
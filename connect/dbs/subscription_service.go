@@ -24,6 +24,8 @@ type TopicSubscriptionConfig struct {
 	DeliverDataOnly           bool   `json:"deliverDataOnly,omitempty"`
 	KinesisStreamPartitionKey string `json:"kinesisStreamPartitionKey,omitempty"`
 	DataType                  string `json:"dataType" validate:"required"`
+	// Subscriber, when set, determines SubscriberId. Kinesis subscribers require a KinesisStreamPartitionKey
+	Subscriber TopicSubscriber `json:"-" validate:"-"`
 }
 
 type Subscriber struct {
@@ -60,6 +62,12 @@ type TopicSubscriptionBundle struct {
 
 func (b *SubscriptionService) CreateTopicSubscription(subscriptionConfig TopicSubscriptionConfig) (*TopicSubscription, *Response, error) {
 	subscriptionConfig.ResourceType = "TopicSubscriptionConfig"
+	if subscriber := subscriptionConfig.Subscriber; subscriber != nil {
+		subscriptionConfig.SubscriberId = subscriber.SubscriberID()
+		if subscriber.SubscriberType() == "KinesisSubscriber" && subscriptionConfig.KinesisStreamPartitionKey == "" {
+			return nil, nil, ErrMissingPartitionKey
+		}
+	}
 	if err := b.validate.Struct(subscriptionConfig); err != nil {
 		return nil, nil, err
 	}
@@ -122,6 +130,12 @@ func (b *SubscriptionService) FindTopicSubscription(opt *GetTopicSubscriptionOpt
 	return &bundleResponse.Entry, resp, err
 }
 
+// FindAllTopicSubscriptions looks up topic subscriptions based on GetTopicSubscriptionOptions,
+// retrieving all result pages
+func (b *SubscriptionService) FindAllTopicSubscriptions(opt *GetTopicSubscriptionOptions, options ...OptionFunc) (*[]TopicSubscription, *Response, error) {
+	return findAll[TopicSubscription](b.Client, "/Subscription/Topic", subscriptionAPIVersion, opt, options...)
+}
+
 func (b *SubscriptionService) DeleteTopicSubscription(subscription TopicSubscription) (bool, *Response, error) {
 	req, err := b.NewRequest(http.MethodDelete, "/Subscription/Topic/"+subscription.ID, nil, nil)
 	if err != nil {
@@ -1,6 +1,7 @@
 package dbs_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/dip-software/go-dip-api/connect/dbs"
 	"io"
@@ -93,3 +94,37 @@ func TestSubscriptionCRUD(t *testing.T) {
 	assert.True(t, res)
 	assert.Equal(t, http.StatusNoContent, resp.StatusCode())
 }
+
+func TestSubscriptionWithKinesisSubscriber(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	kinesisID := "5d0b3c4e-8f3a-4d55-a1ef-0f3a2b6e7c11"
+	subscriptionID := "1ca7251b-42a1-4560-99a5-2b359c6f3914"
+	muxDBS.HandleFunc("/client-test/connect/databroker/Subscription/Topic", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, kinesisID, body["subscriberId"])
+		assert.Equal(t, "$.deviceId", body["kinesisStreamPartitionKey"])
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, subscriptionBody(subscriptionID, "my-datatype", "my_infix", "Creating", kinesisID))
+	})
+
+	subscriber := dbs.KinesisSubscriber{ID: kinesisID}
+	config := dbs.TopicSubscriptionConfig{
+		NameInfix:   "my_infix",
+		Description: "MyTopicSubscription",
+		DataType:    "my-datatype",
+		Subscriber:  subscriber,
+	}
+	_, _, err := dbsClient.Subscriptions.CreateTopicSubscription(config)
+	assert.ErrorIs(t, err, dbs.ErrMissingPartitionKey)
+
+	config.KinesisStreamPartitionKey = "$.deviceId"
+	created, _, err := dbsClient.Subscriptions.CreateTopicSubscription(config)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, subscriptionID, created.ID)
+}
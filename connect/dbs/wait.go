@@ -0,0 +1,102 @@
+package dbs
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/dip-software/go-dip-api/connect/wait"
+)
+
+const (
+	statusActive  = "Active"
+	statusInError = "InError"
+)
+
+func notFound(resp *Response, err error) error {
+	if resp != nil && resp.StatusCode() == http.StatusNotFound {
+		return wait.ErrNotFound
+	}
+	return err
+}
+
+func activePredicate(status, errorMessage string, kind error) (bool, error) {
+	switch status {
+	case statusActive:
+		return true, nil
+	case statusInError:
+		return false, &wait.StatusError{Status: status, Message: errorMessage, Err: kind}
+	}
+	return false, nil
+}
+
+func (b *SubscribersService) getSubscriber(subscriber TopicSubscriber) (TopicSubscriber, error) {
+	switch subscriber.SubscriberType() {
+	case "SQSSubscriber":
+		found, resp, err := b.GetSQSByID(subscriber.SubscriberID())
+		if err != nil {
+			return nil, notFound(resp, err)
+		}
+		return *found, nil
+	case "KinesisSubscriber":
+		found, resp, err := b.GetKinesisByID(subscriber.SubscriberID())
+		if err != nil {
+			return nil, notFound(resp, err)
+		}
+		return *found, nil
+	}
+	return nil, ErrUnsupportedSubscriber
+}
+
+// WaitUntilActive polls the SQS or Kinesis subscriber until its status is Active and returns
+// the latest version of it. An InError status is returned as a *wait.StatusError which
+// wraps ErrSubscriberInError. A nil bo uses wait.DefaultBackOff
+func (b *SubscribersService) WaitUntilActive(ctx context.Context, subscriber TopicSubscriber, bo backoff.BackOff) (TopicSubscriber, error) {
+	return wait.WaitFor(ctx, func(ctx context.Context) (TopicSubscriber, error) {
+		return b.getSubscriber(subscriber)
+	}, func(s TopicSubscriber) (bool, error) {
+		switch s := s.(type) {
+		case SQSSubscriber:
+			return activePredicate(s.Status, s.ErrorMessage, ErrSubscriberInError)
+		case KinesisSubscriber:
+			return activePredicate(s.Status, s.ErrorMessage, ErrSubscriberInError)
+		}
+		return false, ErrUnsupportedSubscriber
+	}, bo)
+}
+
+// WaitUntilDeleted polls the SQS or Kinesis subscriber until it no longer exists
+func (b *SubscribersService) WaitUntilDeleted(ctx context.Context, subscriber TopicSubscriber, bo backoff.BackOff) error {
+	get, gone := wait.UntilGone(func(ctx context.Context) (TopicSubscriber, error) {
+		return b.getSubscriber(subscriber)
+	})
+	_, err := wait.WaitFor(ctx, get, gone, bo)
+	return err
+}
+
+func (b *SubscriptionService) getTopicSubscription(id string) (*TopicSubscription, error) {
+	found, resp, err := b.GetTopicSubscriptionByID(id)
+	if err != nil {
+		return nil, notFound(resp, err)
+	}
+	return found, nil
+}
+
+// WaitUntilActive polls the topic subscription until its status is Active. An InError status
+// is returned as a *wait.StatusError which wraps ErrSubscriptionInError
+func (b *SubscriptionService) WaitUntilActive(ctx context.Context, id string, bo backoff.BackOff) (*TopicSubscription, error) {
+	return wait.WaitFor(ctx, func(ctx context.Context) (*TopicSubscription, error) {
+		return b.getTopicSubscription(id)
+	}, func(s *TopicSubscription) (bool, error) {
+		return activePredicate(s.Status, s.ErrorMessage, ErrSubscriptionInError)
+	}, bo)
+}
+
+// WaitUntilDeleted polls the topic subscription until it no longer exists
+func (b *SubscriptionService) WaitUntilDeleted(ctx context.Context, id string, bo backoff.BackOff) error {
+	get, gone := wait.UntilGone(func(ctx context.Context) (*TopicSubscription, error) {
+		return b.getTopicSubscription(id)
+	})
+	_, err := wait.WaitFor(ctx, get, gone, bo)
+	return err
+}
@@ -0,0 +1,77 @@
+package dbs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/dip-software/go-dip-api/connect/dbs"
+	"github.com/dip-software/go-dip-api/connect/wait"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriberWaitUntil(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	sqsID := "9f80f9e0-5cb2-4ebd-8980-03f550cb453f"
+	statuses := []string{"Creating", "Active", "Deleting"}
+	calls := 0
+	muxDBS.HandleFunc("/client-test/connect/databroker/Subscriber/SQS/"+sqsID, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls >= len(statuses) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"resourceType": "OperationOutcome", "issue": []}`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, sqsBody(sqsID, "Standard", "my_infix", statuses[calls]))
+		calls++
+	})
+	bo := backoff.NewConstantBackOff(time.Millisecond)
+
+	subscriber, err := dbsClient.Subscribers.WaitUntilActive(context.Background(), dbs.SQSSubscriber{ID: sqsID}, bo)
+	if !assert.Nil(t, err) {
+		return
+	}
+	sqs, ok := subscriber.(dbs.SQSSubscriber)
+	if assert.True(t, ok) {
+		assert.Equal(t, "Active", sqs.Status)
+	}
+
+	err = dbsClient.Subscribers.WaitUntilDeleted(context.Background(), sqs, bo)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestSubscriptionWaitUntilActiveInError(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	subscriptionID := "1ca7251b-42a1-4560-99a5-2b359c6f3914"
+	muxDBS.HandleFunc("/client-test/connect/databroker/Subscription/Topic/"+subscriptionID, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, `{
+  "resourceType": "TopicSubscription",
+  "id": "`+subscriptionID+`",
+  "status": "InError",
+  "errorMessage": "rule creation failed"
+}`)
+	})
+
+	subscription, err := dbsClient.Subscriptions.WaitUntilActive(context.Background(), subscriptionID, backoff.NewConstantBackOff(time.Millisecond))
+	assert.ErrorIs(t, err, dbs.ErrSubscriptionInError)
+	assert.ErrorIs(t, err, wait.ErrInError)
+	var statusErr *wait.StatusError
+	if assert.True(t, errors.As(err, &statusErr)) {
+		assert.Equal(t, "rule creation failed", statusErr.Message)
+	}
+	if assert.NotNil(t, subscription) {
+		assert.Equal(t, "InError", subscription.Status)
+	}
+}
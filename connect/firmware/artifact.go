@@ -0,0 +1,123 @@
+// Package firmware releases firmware through the HSDP Connect MDM firmware services. It prepares
+// artifacts, uploads them, registers FirmwareComponentVersions and rolls them out in waves
+package firmware
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/dip-software/go-dip-api/connect/mdm"
+)
+
+const (
+	// FingerprintAlgorithm is the hash algorithm of artifact fingerprints
+	FingerprintAlgorithm = "SHA-256"
+	// EncryptionAlgorithm is the cipher used by Artifact.Encrypt. The ciphertext is prefixed with the nonce
+	EncryptionAlgorithm = "AES-256-GCM"
+)
+
+// Artifact is a firmware image ready for upload. Size and Fingerprint describe Data as
+// uploaded, so devices can verify the download before decrypting it
+type Artifact struct {
+	Name        string
+	Data        []byte
+	Size        int
+	Fingerprint mdm.Fingerprint
+	// Encryption is set when Data is encrypted
+	Encryption *mdm.EncryptionInfo
+}
+
+// NewArtifact returns an artifact for data, computing its size and fingerprint
+func NewArtifact(name string, data []byte) *Artifact {
+	return &Artifact{
+		Name:        name,
+		Data:        data,
+		Size:        len(data),
+		Fingerprint: fingerprint(data),
+	}
+}
+
+// ReadArtifact reads the local file at path as an artifact
+func ReadArtifact(path string) (*Artifact, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewArtifact(filepath.Base(path), data), nil
+}
+
+func fingerprint(data []byte) mdm.Fingerprint {
+	sum := sha256.Sum256(data)
+	return mdm.Fingerprint{Algorithm: FingerprintAlgorithm, Hash: hex.EncodeToString(sum[:])}
+}
+
+// Verify checks data against the fingerprint of the artifact
+func (a *Artifact) Verify(data []byte) error {
+	if fingerprint(data) != a.Fingerprint {
+		return ErrFingerprintMismatch
+	}
+	return nil
+}
+
+// Encrypt returns a copy of the artifact encrypted with a new random key. The
+// base64 encoded key is part of the EncryptionInfo registered with the version
+func (a *Artifact) Encrypt() (*Artifact, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	encrypted := NewArtifact(a.Name, gcm.Seal(nonce, nonce, a.Data, nil))
+	encrypted.Encryption = &mdm.EncryptionInfo{
+		Encrypted:     true,
+		Algorithm:     EncryptionAlgorithm,
+		DecryptionKey: base64.StdEncoding.EncodeToString(key),
+	}
+	return encrypted, nil
+}
+
+// Decrypt returns the plain firmware image of an encrypted artifact download
+func Decrypt(data []byte, info mdm.EncryptionInfo) ([]byte, error) {
+	if !info.Encrypted {
+		return nil, ErrNotEncrypted
+	}
+	if info.Algorithm != EncryptionAlgorithm {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCipher, info.Algorithm)
+	}
+	key, err := base64.StdEncoding.DecodeString(info.DecryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("decryption key: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,56 @@
+package firmware_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/connect/firmware"
+	"github.com/dip-software/go-dip-api/connect/mdm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtifact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.bin")
+	require.NoError(t, os.WriteFile(path, []byte("hello firmware"), 0600))
+
+	artifact, err := firmware.ReadArtifact(path)
+	require.NoError(t, err)
+	assert.Equal(t, "image.bin", artifact.Name)
+	assert.Equal(t, 14, artifact.Size)
+	assert.Equal(t, firmware.FingerprintAlgorithm, artifact.Fingerprint.Algorithm)
+	assert.Equal(t, "836bdaaef7134e769cf2c51b1494bb1602a3975412f7893e8b153ce20bbf3acd", artifact.Fingerprint.Hash)
+	assert.NoError(t, artifact.Verify([]byte("hello firmware")))
+	assert.ErrorIs(t, artifact.Verify([]byte("tampered")), firmware.ErrFingerprintMismatch)
+
+	_, err = firmware.ReadArtifact(filepath.Join(t.TempDir(), "missing.bin"))
+	assert.Error(t, err)
+}
+
+func TestEncrypt(t *testing.T) {
+	artifact := firmware.NewArtifact("image.bin", []byte("hello firmware"))
+
+	encrypted, err := artifact.Encrypt()
+	require.NoError(t, err)
+	require.NotNil(t, encrypted.Encryption)
+	assert.True(t, encrypted.Encryption.Encrypted)
+	assert.Equal(t, firmware.EncryptionAlgorithm, encrypted.Encryption.Algorithm)
+	assert.NotEqual(t, artifact.Data, encrypted.Data)
+	assert.Equal(t, len(encrypted.Data), encrypted.Size)
+	assert.NotEqual(t, artifact.Fingerprint, encrypted.Fingerprint)
+	assert.NoError(t, encrypted.Verify(encrypted.Data))
+
+	plain, err := firmware.Decrypt(encrypted.Data, *encrypted.Encryption)
+	require.NoError(t, err)
+	assert.Equal(t, artifact.Data, plain)
+
+	_, err = firmware.Decrypt(encrypted.Data, mdm.EncryptionInfo{})
+	assert.ErrorIs(t, err, firmware.ErrNotEncrypted)
+	_, err = firmware.Decrypt(encrypted.Data, mdm.EncryptionInfo{Encrypted: true, Algorithm: "ROT13"})
+	assert.ErrorIs(t, err, firmware.ErrUnsupportedCipher)
+	info := *encrypted.Encryption
+	info.DecryptionKey = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+	_, err = firmware.Decrypt(encrypted.Data, info)
+	assert.Error(t, err)
+}
@@ -0,0 +1,24 @@
+package firmware
+
+import (
+	"errors"
+)
+
+// Exported Errors
+var (
+	ErrMissingMDMClient    = errors.New("missing MDM client")
+	ErrMissingUploader     = errors.New("missing uploader")
+	ErrMissingComponent    = errors.New("missing firmware component")
+	ErrMissingVersion      = errors.New("missing firmware version")
+	ErrMissingWaves        = errors.New("missing rollout waves")
+	ErrMissingTargets      = errors.New("wave has no distribution targets")
+	ErrNotEncrypted        = errors.New("artifact is not encrypted")
+	ErrUnsupportedCipher   = errors.New("unsupported encryption algorithm")
+	ErrFingerprintMismatch = errors.New("fingerprint mismatch")
+	ErrUploadFailed        = errors.New("upload failed")
+	ErrAborted             = errors.New("rollout aborted")
+	ErrAlreadyStarted      = errors.New("rollout already started")
+	ErrNotRunning          = errors.New("rollout is not running")
+	ErrNotPaused           = errors.New("rollout is not paused")
+	ErrRequestCancelled    = errors.New("distribution request was cancelled")
+)
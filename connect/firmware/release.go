@@ -0,0 +1,141 @@
+package firmware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dip-software/go-dip-api/connect/blr"
+	"github.com/dip-software/go-dip-api/connect/mdm"
+)
+
+// Uploader stores firmware artifacts and returns the URL devices download them from
+type Uploader interface {
+	Upload(ctx context.Context, name string, data []byte) (string, error)
+}
+
+// BLRUploader uploads artifacts as blobs to the HSDP Blob Repository
+type BLRUploader struct {
+	Client *blr.Client
+	// DataType is the BLR data type of the firmware blobs
+	DataType string
+	// HTTPClient is used for the upload to the pre-signed URL. Defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// Upload creates a blob, puts data to its access URL and returns the blob URL
+func (u *BLRUploader) Upload(ctx context.Context, name string, data []byte) (string, error) {
+	blob, _, err := u.Client.Blobs.Create(blr.Blob{
+		DataType:    u.DataType,
+		VirtualName: name,
+	})
+	if err != nil {
+		return "", fmt.Errorf("create blob: %w", err)
+	}
+	if blob == nil {
+		return "", fmt.Errorf("create blob: %w", ErrUploadFailed)
+	}
+	access, _, err := u.Client.Blobs.GetAccessURL(*blob)
+	if err != nil {
+		return "", fmt.Errorf("access URL: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, access.URL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	httpClient := u.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("%w: %s", ErrUploadFailed, resp.Status)
+	}
+	return u.Client.GetBaseURL() + "Blob/" + blob.ID, nil
+}
+
+// Releaser registers firmware component versions and starts rollouts
+type Releaser struct {
+	client   *mdm.Client
+	uploader Uploader
+}
+
+// NewReleaser returns a Releaser which uploads artifacts with uploader
+func NewReleaser(client *mdm.Client, uploader Uploader) (*Releaser, error) {
+	if client == nil {
+		return nil, ErrMissingMDMClient
+	}
+	if uploader == nil {
+		return nil, ErrMissingUploader
+	}
+	return &Releaser{client: client, uploader: uploader}, nil
+}
+
+// VersionOptions describes the FirmwareComponentVersion to register
+type VersionOptions struct {
+	// ComponentID is the ID of the FirmwareComponent
+	ComponentID string
+	Version     string
+	Description string
+	// Encrypt encrypts the artifact before upload
+	Encrypt           bool
+	ComponentRequired bool
+	// PreviousVersionID is the ID of the FirmwareComponentVersion this version supersedes
+	PreviousVersionID string
+	// EffectiveDate defaults to today
+	EffectiveDate string
+}
+
+// RegisterVersion uploads the artifact and registers it as a new FirmwareComponentVersion
+func (r *Releaser) RegisterVersion(ctx context.Context, artifact *Artifact, opts VersionOptions) (*mdm.FirmwareComponentVersion, error) {
+	if opts.ComponentID == "" {
+		return nil, ErrMissingComponent
+	}
+	if opts.Version == "" {
+		return nil, ErrMissingVersion
+	}
+	if opts.Encrypt && artifact.Encryption == nil {
+		encrypted, err := artifact.Encrypt()
+		if err != nil {
+			return nil, fmt.Errorf("encrypt: %w", err)
+		}
+		artifact = encrypted
+	}
+	blobURL, err := r.uploader.Upload(ctx, artifact.Name, artifact.Data)
+	if err != nil {
+		return nil, fmt.Errorf("upload: %w", err)
+	}
+	fp := artifact.Fingerprint
+	version := mdm.FirmwareComponentVersion{
+		Version:             opts.Version,
+		Description:         opts.Description,
+		Size:                artifact.Size,
+		BlobURL:             blobURL,
+		ComponentRequired:   opts.ComponentRequired,
+		FingerPrint:         &fp,
+		EncryptionInfo:      artifact.Encryption,
+		FirmwareComponentId: mdm.Reference{Reference: "FirmwareComponent/" + opts.ComponentID},
+		EffectiveDate:       opts.EffectiveDate,
+	}
+	if version.EffectiveDate == "" {
+		version.EffectiveDate = time.Now().UTC().Format("2006-01-02")
+	}
+	if opts.PreviousVersionID != "" {
+		version.PreviousComponentVersionId = &mdm.Reference{Reference: "FirmwareComponentVersion/" + opts.PreviousVersionID}
+	}
+	created, _, err := r.client.FirmwareComponentVersions.Create(version)
+	if err != nil {
+		return nil, fmt.Errorf("register version: %w", err)
+	}
+	if created == nil {
+		return nil, fmt.Errorf("register version: %w", mdm.ErrCouldNoReadResourceAfterCreate)
+	}
+	return created, nil
+}
@@ -0,0 +1,72 @@
+package firmware_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/connect/blr"
+	"github.com/dip-software/go-dip-api/connect/firmware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBLRUploader(t *testing.T) {
+	iamClient, closeIAM := newIAMClient(t)
+	defer closeIAM()
+
+	var uploaded []byte
+	storageStatus := http.StatusOK
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/upload/blob-1", r.URL.Path)
+		assert.Equal(t, "application/octet-stream", r.Header.Get("Content-Type"))
+		uploaded, _ = io.ReadAll(r.Body)
+		w.WriteHeader(storageStatus)
+	}))
+	defer storage.Close()
+
+	muxBLR := http.NewServeMux()
+	serverBLR := httptest.NewServer(muxBLR)
+	defer serverBLR.Close()
+	muxBLR.HandleFunc("/connect/blobrepository/Blob", func(w http.ResponseWriter, r *http.Request) {
+		if !assert.Equal(t, http.MethodPost, r.Method) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var blob blr.Blob
+		_ = json.NewDecoder(r.Body).Decode(&blob)
+		assert.Equal(t, "firmware", blob.DataType)
+		assert.Equal(t, "image.bin", blob.VirtualName)
+		blob.ID = "blob-1"
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(blob)
+	})
+	muxBLR.HandleFunc("/connect/blobrepository/Blob/blob-1/$getAccessUrl", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(blr.AccessURL{
+			ResourceType: "BlobAccessUrl",
+			Actions:      []string{"PUT"},
+			URL:          storage.URL + "/upload/blob-1",
+		})
+	})
+
+	blrClient, err := blr.NewClient(iamClient, &blr.Config{
+		BaseURL: serverBLR.URL + "/connect/blobrepository",
+	})
+	require.NoError(t, err)
+	uploader := &firmware.BLRUploader{Client: blrClient, DataType: "firmware", HTTPClient: storage.Client()}
+
+	blobURL, err := uploader.Upload(context.Background(), "image.bin", []byte("hello firmware"))
+	require.NoError(t, err)
+	assert.Equal(t, serverBLR.URL+"/connect/blobrepository/Blob/blob-1", blobURL)
+	assert.Equal(t, []byte("hello firmware"), uploaded)
+
+	storageStatus = http.StatusForbidden
+	_, err = uploader.Upload(context.Background(), "image.bin", []byte("hello firmware"))
+	assert.ErrorIs(t, err, firmware.ErrUploadFailed)
+}
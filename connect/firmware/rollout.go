@@ -0,0 +1,413 @@
+package firmware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/dip-software/go-dip-api/connect/mdm"
+)
+
+// FirmwareDistributionRequest statuses used by rollouts
+const (
+	StatusActive    = "Active"
+	StatusInactive  = "Inactive"
+	StatusCancelled = "Cancelled"
+)
+
+// State is the state of a rollout
+type State string
+
+// Rollout states
+const (
+	StatePending   State = "Pending"
+	StateRunning   State = "Running"
+	StatePaused    State = "Paused"
+	StateCompleted State = "Completed"
+	StateAborted   State = "Aborted"
+	StateFailed    State = "Failed"
+)
+
+// Wave is one stage of a rollout, e.g. a canary device group
+type Wave struct {
+	Name string
+	// Targets are DeviceGroup or DeviceType references
+	Targets             []mdm.Reference
+	UserConsentRequired bool
+	// OrchestrationMode defaults to none
+	OrchestrationMode string
+	// Soak is the time to wait after the wave is active before the next wave starts
+	Soak time.Duration
+}
+
+// RolloutOptions describes a staged rollout
+type RolloutOptions struct {
+	FirmwareVersion string
+	// ComponentVersionIDs are the FirmwareComponentVersion IDs to distribute
+	ComponentVersionIDs []string
+	Description         string
+	Waves               []Wave
+	// BackOff is used while waiting for requests to become active. Defaults to wait.DefaultBackOff
+	BackOff backoff.BackOff
+	// Gate is called after a wave soaked. An error stops the rollout before the next wave
+	Gate func(ctx context.Context, wave Wave, request *mdm.FirmwareDistributionRequest) error
+	// OnProgress is called on every state change. It must not call Pause, Resume or Abort
+	OnProgress func(Progress)
+}
+
+// WaveProgress is the progress of a single wave
+type WaveProgress struct {
+	Name      string
+	RequestID string
+	Status    string
+	Started   time.Time
+	Finished  time.Time
+}
+
+// Progress is a snapshot of the rollout
+type Progress struct {
+	State State
+	// Current is the index of the wave in progress
+	Current int
+	Waves   []WaveProgress
+	Err     error
+}
+
+// String renders the progress as one line per wave
+func (p Progress) String() string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "rollout %s", p.State)
+	if p.Err != nil {
+		_, _ = fmt.Fprintf(&b, ": %v", p.Err)
+	}
+	b.WriteString("\n")
+	for i, w := range p.Waves {
+		marker := " "
+		if i == p.Current && (p.State == StateRunning || p.State == StatePaused) {
+			marker = ">"
+		}
+		status := w.Status
+		if status == "" {
+			status = "-"
+		}
+		_, _ = fmt.Fprintf(&b, "%s %d %-20s %-10s %s\n", marker, i+1, w.Name, status, w.RequestID)
+	}
+	return b.String()
+}
+
+// Rollout distributes firmware in waves. Each wave creates a FirmwareDistributionRequest
+// and the next wave starts once it is active, soaked and passed the gate
+type Rollout struct {
+	client *mdm.Client
+	opts   RolloutOptions
+
+	// control serializes Pause, Resume, Abort and the creation of requests
+	control  sync.Mutex
+	mu       sync.Mutex
+	progress Progress
+	requests []*mdm.FirmwareDistributionRequest
+	changed  chan struct{}
+}
+
+// NewRollout returns a rollout for opts. Nothing happens until Run is called
+func (r *Releaser) NewRollout(opts RolloutOptions) (*Rollout, error) {
+	if opts.FirmwareVersion == "" {
+		return nil, ErrMissingVersion
+	}
+	if len(opts.ComponentVersionIDs) == 0 {
+		return nil, ErrMissingComponent
+	}
+	if len(opts.Waves) == 0 {
+		return nil, ErrMissingWaves
+	}
+	waves := make([]WaveProgress, len(opts.Waves))
+	for i, w := range opts.Waves {
+		if len(w.Targets) == 0 {
+			return nil, fmt.Errorf("wave %d: %w", i+1, ErrMissingTargets)
+		}
+		waves[i].Name = w.Name
+	}
+	return &Rollout{
+		client:   r.client,
+		opts:     opts,
+		progress: Progress{State: StatePending, Waves: waves},
+		changed:  make(chan struct{}),
+	}, nil
+}
+
+// Progress returns a snapshot of the rollout
+func (ro *Rollout) Progress() Progress {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+	return ro.snapshot()
+}
+
+func (ro *Rollout) state() State {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+	return ro.progress.State
+}
+
+func (ro *Rollout) snapshot() Progress {
+	p := ro.progress
+	p.Waves = append([]WaveProgress(nil), ro.progress.Waves...)
+	return p
+}
+
+// update changes the progress under the lock, wakes up Run and reports the new progress
+func (ro *Rollout) update(fn func(p *Progress)) {
+	ro.mu.Lock()
+	fn(&ro.progress)
+	close(ro.changed)
+	ro.changed = make(chan struct{})
+	p := ro.snapshot()
+	ro.mu.Unlock()
+	if ro.opts.OnProgress != nil {
+		ro.opts.OnProgress(p)
+	}
+}
+
+// Run executes the waves in order and blocks until the rollout completed, failed or
+// was aborted. While paused it waits for Resume
+func (ro *Rollout) Run(ctx context.Context) error {
+	ro.mu.Lock()
+	state := ro.progress.State
+	ro.mu.Unlock()
+	switch state {
+	case StatePending:
+	case StateAborted:
+		return ErrAborted
+	default:
+		return ErrAlreadyStarted
+	}
+	ro.update(func(p *Progress) { p.State = StateRunning })
+
+	for i, wave := range ro.opts.Waves {
+		ro.update(func(p *Progress) { p.Current = i })
+		err := ro.runWave(ctx, i, wave)
+		if err != nil {
+			if errors.Is(err, ErrAborted) {
+				return err
+			}
+			ro.update(func(p *Progress) {
+				p.State = StateFailed
+				p.Err = err
+			})
+			return err
+		}
+	}
+	ro.update(func(p *Progress) { p.State = StateCompleted })
+	return nil
+}
+
+func (ro *Rollout) runWave(ctx context.Context, i int, wave Wave) error {
+	if err := ro.waitRunning(ctx); err != nil {
+		return err
+	}
+	mode := wave.OrchestrationMode
+	if mode == "" {
+		mode = "none"
+	}
+	refs := make([]mdm.Reference, len(ro.opts.ComponentVersionIDs))
+	for j, id := range ro.opts.ComponentVersionIDs {
+		refs[j] = mdm.Reference{Reference: "FirmwareComponentVersion/" + id}
+	}
+	created, _, err := ro.client.FirmwareDistributionRequests.Create(mdm.FirmwareDistributionRequest{
+		Status:                    StatusActive,
+		UserConsentRequired:       wave.UserConsentRequired,
+		DistributionTargets:       wave.Targets,
+		FirmwareVersion:           ro.opts.FirmwareVersion,
+		OrchestrationMode:         mode,
+		FirmwareComponentVersions: refs,
+		Description:               ro.opts.Description,
+	})
+	if err != nil {
+		return fmt.Errorf("wave %s: %w", wave.Name, err)
+	}
+	if created == nil {
+		return fmt.Errorf("wave %s: %w", wave.Name, mdm.ErrCouldNoReadResourceAfterCreate)
+	}
+	ro.update(func(p *Progress) {
+		p.Waves[i].RequestID = created.ID
+		p.Waves[i].Status = created.Status
+		p.Waves[i].Started = time.Now()
+	})
+	ro.control.Lock()
+	ro.mu.Lock()
+	ro.requests = append(ro.requests, created)
+	state := ro.progress.State
+	ro.mu.Unlock()
+	// Pause or Abort may have happened while the request was created
+	switch state {
+	case StateAborted:
+		_ = ro.setStatus(StatusCancelled)
+		ro.control.Unlock()
+		return ErrAborted
+	case StatePaused:
+		if err := ro.setStatus(StatusInactive); err != nil {
+			ro.control.Unlock()
+			return err
+		}
+	}
+	ro.control.Unlock()
+
+	active, err := ro.waitActive(ctx, created.ID)
+	if err != nil {
+		return fmt.Errorf("wave %s: %w", wave.Name, err)
+	}
+	ro.update(func(p *Progress) { p.Waves[i].Status = active.Status })
+
+	if err := ro.soak(ctx, wave.Soak); err != nil {
+		return err
+	}
+	if err := ro.waitRunning(ctx); err != nil {
+		return err
+	}
+	if ro.opts.Gate != nil {
+		ro.mu.Lock()
+		request := ro.requests[i]
+		ro.mu.Unlock()
+		if err := ro.opts.Gate(ctx, wave, request); err != nil {
+			return fmt.Errorf("wave %s gate: %w", wave.Name, err)
+		}
+	}
+	ro.update(func(p *Progress) { p.Waves[i].Finished = time.Now() })
+	return nil
+}
+
+// waitActive waits until the request is active, or inactive because the rollout was paused.
+// A cancelled request fails the wave unless the rollout was aborted
+func (ro *Rollout) waitActive(ctx context.Context, id string) (*mdm.FirmwareDistributionRequest, error) {
+	fdr, err := ro.client.FirmwareDistributionRequests.WaitUntilStatus(ctx, id, ro.opts.BackOff, StatusActive, StatusInactive, StatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+	if fdr.Status == StatusCancelled {
+		if ro.state() == StateAborted {
+			return nil, ErrAborted
+		}
+		return nil, fmt.Errorf("%w: %s", ErrRequestCancelled, fdr.ID)
+	}
+	return fdr, nil
+}
+
+// waitRunning blocks while the rollout is paused
+func (ro *Rollout) waitRunning(ctx context.Context) error {
+	for {
+		ro.mu.Lock()
+		state, changed := ro.progress.State, ro.changed
+		ro.mu.Unlock()
+		switch state {
+		case StateAborted:
+			return ErrAborted
+		case StatePaused:
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-changed:
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// soak waits for d while the rollout is running. Time spent paused does not count
+func (ro *Rollout) soak(ctx context.Context, d time.Duration) error {
+	for d > 0 {
+		if err := ro.waitRunning(ctx); err != nil {
+			return err
+		}
+		ro.mu.Lock()
+		changed := ro.changed
+		ro.mu.Unlock()
+		start := time.Now()
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		case <-changed:
+			timer.Stop()
+			d -= time.Since(start)
+		}
+	}
+	return nil
+}
+
+// setStatus updates the status of all requests created so far
+func (ro *Rollout) setStatus(status string) error {
+	ro.mu.Lock()
+	requests := append([]*mdm.FirmwareDistributionRequest(nil), ro.requests...)
+	ro.mu.Unlock()
+	var errs []error
+	for i, fdr := range requests {
+		update := *fdr
+		update.Status = status
+		updated, _, err := ro.client.FirmwareDistributionRequests.Update(update)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", fdr.ID, err))
+			continue
+		}
+		ro.mu.Lock()
+		ro.requests[i] = updated
+		ro.mu.Unlock()
+		ro.update(func(p *Progress) {
+			for w := range p.Waves {
+				if p.Waves[w].RequestID == fdr.ID {
+					p.Waves[w].Status = updated.Status
+				}
+			}
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// Pause deactivates the distribution requests and holds the rollout before the next wave.
+// It returns ErrNotRunning unless the rollout is running
+func (ro *Rollout) Pause() error {
+	ro.control.Lock()
+	defer ro.control.Unlock()
+	if ro.state() != StateRunning {
+		return ErrNotRunning
+	}
+	ro.update(func(p *Progress) { p.State = StatePaused })
+	return ro.setStatus(StatusInactive)
+}
+
+// Resume reactivates the distribution requests and continues the rollout.
+// It returns ErrNotPaused unless the rollout is paused
+func (ro *Rollout) Resume() error {
+	ro.control.Lock()
+	defer ro.control.Unlock()
+	if ro.state() != StatePaused {
+		return ErrNotPaused
+	}
+	if err := ro.setStatus(StatusActive); err != nil {
+		return err
+	}
+	ro.update(func(p *Progress) { p.State = StateRunning })
+	return nil
+}
+
+// Abort cancels the distribution requests. A running Run returns ErrAborted.
+// Aborting a completed, failed or already aborted rollout does nothing
+func (ro *Rollout) Abort() error {
+	ro.control.Lock()
+	defer ro.control.Unlock()
+	switch ro.state() {
+	case StateCompleted, StateFailed, StateAborted:
+		return nil
+	}
+	ro.update(func(p *Progress) {
+		p.State = StateAborted
+		p.Err = ErrAborted
+	})
+	return ro.setStatus(StatusCancelled)
+}
@@ -0,0 +1,350 @@
+package firmware_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/dip-software/go-dip-api/connect/firmware"
+	"github.com/dip-software/go-dip-api/connect/mdm"
+	"github.com/dip-software/go-dip-api/iam"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMDM stores firmware resources in memory
+type fakeMDM struct {
+	sync.Mutex
+	resources map[string]map[string]map[string]interface{}
+	nextID    int
+	// status overrides the status of created resources, e.g. to simulate a cancellation
+	status string
+}
+
+func (f *fakeMDM) all(kind string) []map[string]interface{} {
+	f.Lock()
+	defer f.Unlock()
+	var list []map[string]interface{}
+	for i := 1; i <= f.nextID; i++ {
+		if r, ok := f.resources[kind][fmt.Sprintf("%d", i)]; ok {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+func (f *fakeMDM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/connect/mdm/"), "/")
+	kind := parts[0]
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		var resource map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&resource)
+		f.nextID++
+		resource["id"] = fmt.Sprintf("%d", f.nextID)
+		if f.status != "" {
+			resource["status"] = f.status
+		}
+		if f.resources[kind] == nil {
+			f.resources[kind] = make(map[string]map[string]interface{})
+		}
+		f.resources[kind][resource["id"].(string)] = resource
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(resource)
+	case len(parts) == 2:
+		resource, ok := f.resources[kind][parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(resource)
+		case http.MethodPut:
+			var updated map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&updated)
+			updated["id"] = parts[1]
+			f.resources[kind][parts[1]] = updated
+			_ = json.NewEncoder(w).Encode(updated)
+		}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+type fakeUploader struct {
+	uploads map[string][]byte
+}
+
+func (u *fakeUploader) Upload(_ context.Context, name string, data []byte) (string, error) {
+	u.uploads[name] = data
+	return "https://blr.example.com/connect/blobrepository/Blob/" + name, nil
+}
+
+// newIAMClient returns an IAM client which is logged in to a fake IAM server
+func newIAMClient(t *testing.T) (*iam.Client, func()) {
+	muxIAM := http.NewServeMux()
+	serverIAM := httptest.NewServer(muxIAM)
+
+	muxIAM.HandleFunc("/authorize/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+    "scope": "mail",
+    "access_token": "44d20214-7879-4e35-923d-f9d4e01c9746",
+    "refresh_token": "31f1a449-ef8e-4bfc-a227-4f2353fde547",
+    "expires_in": 1799,
+    "token_type": "Bearer"
+}`)
+	})
+	muxIAM.HandleFunc("/authorize/oauth2/introspect", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+  "active": true,
+  "username": "ronswanson",
+  "sub": "33d20214-7879-4e35-923d-f9d4e01c9746",
+  "organizations": {
+    "managingOrganization": "22d20214-7879-4e35-923d-f9d4e01c9746",
+    "organizationList": []
+  },
+  "client_id": "testclientid",
+  "token_type": "Bearer",
+  "identity_type": "user"
+}`)
+	})
+
+	iamClient, err := iam.NewClient(nil, &iam.Config{
+		OAuth2ClientID: "TestClient",
+		OAuth2Secret:   "Secret",
+		IAMURL:         serverIAM.URL,
+		IDMURL:         serverIAM.URL,
+	})
+	require.NoError(t, err)
+	require.NoError(t, iamClient.Login("username", "password"))
+	return iamClient, serverIAM.Close
+}
+
+func setup(t *testing.T) (*firmware.Releaser, *fakeMDM, *fakeUploader, func()) {
+	iamClient, closeIAM := newIAMClient(t)
+	fake := &fakeMDM{resources: make(map[string]map[string]map[string]interface{})}
+	serverMDM := httptest.NewServer(fake)
+
+	mdmClient, err := mdm.NewClient(iamClient, &mdm.Config{
+		BaseURL: serverMDM.URL + "/connect/mdm",
+	})
+	require.NoError(t, err)
+	uploader := &fakeUploader{uploads: make(map[string][]byte)}
+	releaser, err := firmware.NewReleaser(mdmClient, uploader)
+	require.NoError(t, err)
+	return releaser, fake, uploader, func() {
+		closeIAM()
+		serverMDM.Close()
+	}
+}
+
+func waves() []firmware.Wave {
+	return []firmware.Wave{
+		{Name: "canary", Targets: []mdm.Reference{{Reference: "DeviceGroup/canary"}}, Soak: time.Millisecond},
+		{Name: "fleet", Targets: []mdm.Reference{{Reference: "DeviceType/a"}, {Reference: "DeviceType/b"}}},
+	}
+}
+
+func TestRegisterVersion(t *testing.T) {
+	releaser, fake, uploader, teardown := setup(t)
+	defer teardown()
+
+	artifact := firmware.NewArtifact("image.bin", []byte("hello firmware"))
+	_, err := releaser.RegisterVersion(context.Background(), artifact, firmware.VersionOptions{Version: "1.0.0"})
+	assert.ErrorIs(t, err, firmware.ErrMissingComponent)
+
+	version, err := releaser.RegisterVersion(context.Background(), artifact, firmware.VersionOptions{
+		ComponentID:       "component-1",
+		Version:           "1.0.0",
+		Encrypt:           true,
+		PreviousVersionID: "0",
+		EffectiveDate:     "2026-10-19",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://blr.example.com/connect/blobrepository/Blob/image.bin", version.BlobURL)
+	assert.Equal(t, "FirmwareComponent/component-1", version.FirmwareComponentId.Reference)
+	if assert.NotNil(t, version.PreviousComponentVersionId) {
+		assert.Equal(t, "FirmwareComponentVersion/0", version.PreviousComponentVersionId.Reference)
+	}
+	require.NotNil(t, version.EncryptionInfo)
+	require.NotNil(t, version.FingerPrint)
+
+	uploaded := uploader.uploads["image.bin"]
+	assert.Equal(t, len(uploaded), version.Size)
+	assert.Equal(t, firmware.NewArtifact("image.bin", uploaded).Fingerprint, *version.FingerPrint)
+	plain, err := firmware.Decrypt(uploaded, *version.EncryptionInfo)
+	require.NoError(t, err)
+	assert.Equal(t, artifact.Data, plain)
+	assert.Len(t, fake.all("FirmwareComponentVersion"), 1)
+}
+
+func TestRollout(t *testing.T) {
+	releaser, fake, _, teardown := setup(t)
+	defer teardown()
+
+	_, err := releaser.NewRollout(firmware.RolloutOptions{FirmwareVersion: "1.0.0", ComponentVersionIDs: []string{"v1"}})
+	assert.ErrorIs(t, err, firmware.ErrMissingWaves)
+	_, err = releaser.NewRollout(firmware.RolloutOptions{FirmwareVersion: "1.0.0", ComponentVersionIDs: []string{"v1"},
+		Waves: []firmware.Wave{{Name: "empty"}}})
+	assert.ErrorIs(t, err, firmware.ErrMissingTargets)
+
+	var gated []string
+	var states []firmware.State
+	rollout, err := releaser.NewRollout(firmware.RolloutOptions{
+		FirmwareVersion:     "1.0.0",
+		ComponentVersionIDs: []string{"v1"},
+		Waves:               waves(),
+		BackOff:             backoff.NewConstantBackOff(time.Millisecond),
+		Gate: func(ctx context.Context, wave firmware.Wave, request *mdm.FirmwareDistributionRequest) error {
+			gated = append(gated, wave.Name+":"+request.Status)
+			return nil
+		},
+		OnProgress: func(p firmware.Progress) {
+			if len(states) == 0 || states[len(states)-1] != p.State {
+				states = append(states, p.State)
+			}
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, rollout.Run(context.Background()))
+	assert.ErrorIs(t, rollout.Run(context.Background()), firmware.ErrAlreadyStarted)
+
+	assert.Equal(t, []string{"canary:Active", "fleet:Active"}, gated)
+	assert.Equal(t, []firmware.State{firmware.StateRunning, firmware.StateCompleted}, states)
+	requests := fake.all("FirmwareDistributionRequest")
+	require.Len(t, requests, 2)
+	assert.Equal(t, "1.0.0", requests[0]["firmwareVersion"])
+	assert.Equal(t, "none", requests[0]["orchestrationMode"])
+	assert.Len(t, requests[1]["distributionTarget"], 2)
+
+	progress := rollout.Progress()
+	assert.Equal(t, firmware.StateCompleted, progress.State)
+	for _, w := range progress.Waves {
+		assert.Equal(t, firmware.StatusActive, w.Status)
+		assert.NotEmpty(t, w.RequestID)
+		assert.False(t, w.Finished.IsZero())
+	}
+	assert.Contains(t, progress.String(), "rollout Completed")
+	assert.Contains(t, progress.String(), "canary")
+
+	// Control operations do not touch the requests of a completed rollout
+	assert.ErrorIs(t, rollout.Pause(), firmware.ErrNotRunning)
+	assert.ErrorIs(t, rollout.Resume(), firmware.ErrNotPaused)
+	assert.NoError(t, rollout.Abort())
+	assert.Equal(t, firmware.StateCompleted, rollout.Progress().State)
+	for _, r := range fake.all("FirmwareDistributionRequest") {
+		assert.Equal(t, firmware.StatusActive, r["status"])
+	}
+}
+
+func TestRolloutCancelledRequest(t *testing.T) {
+	releaser, fake, _, teardown := setup(t)
+	defer teardown()
+	fake.status = firmware.StatusCancelled
+
+	rollout, err := releaser.NewRollout(firmware.RolloutOptions{
+		FirmwareVersion:     "1.0.0",
+		ComponentVersionIDs: []string{"v1"},
+		Waves:               waves(),
+		BackOff:             backoff.NewConstantBackOff(time.Millisecond),
+	})
+	require.NoError(t, err)
+	assert.ErrorIs(t, rollout.Run(context.Background()), firmware.ErrRequestCancelled)
+	assert.Equal(t, firmware.StateFailed, rollout.Progress().State)
+	assert.Len(t, fake.all("FirmwareDistributionRequest"), 1)
+}
+
+func TestRolloutPauseResume(t *testing.T) {
+	releaser, fake, _, teardown := setup(t)
+	defer teardown()
+
+	var rollout *firmware.Rollout
+	resume := make(chan struct{})
+	resumed := make(chan error, 1)
+	rollout, err := releaser.NewRollout(firmware.RolloutOptions{
+		FirmwareVersion:     "1.0.0",
+		ComponentVersionIDs: []string{"v1"},
+		Waves:               waves(),
+		BackOff:             backoff.NewConstantBackOff(time.Millisecond),
+		Gate: func(ctx context.Context, wave firmware.Wave, request *mdm.FirmwareDistributionRequest) error {
+			if wave.Name != "canary" {
+				return nil
+			}
+			if err := rollout.Pause(); err != nil {
+				return err
+			}
+			go func() {
+				<-resume
+				resumed <- rollout.Resume()
+			}()
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- rollout.Run(context.Background()) }()
+
+	require.Eventually(t, func() bool {
+		return rollout.Progress().Waves[0].Status == firmware.StatusInactive
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, firmware.StatePaused, rollout.Progress().State)
+	requests := fake.all("FirmwareDistributionRequest")
+	require.Len(t, requests, 1)
+	assert.Equal(t, firmware.StatusInactive, requests[0]["status"])
+	close(resume)
+
+	require.NoError(t, <-resumed)
+	require.NoError(t, <-done)
+	requests = fake.all("FirmwareDistributionRequest")
+	require.Len(t, requests, 2)
+	for _, r := range requests {
+		assert.Equal(t, firmware.StatusActive, r["status"])
+	}
+	assert.Equal(t, firmware.StateCompleted, rollout.Progress().State)
+}
+
+func TestRolloutAbort(t *testing.T) {
+	releaser, fake, _, teardown := setup(t)
+	defer teardown()
+
+	var rollout *firmware.Rollout
+	rollout, err := releaser.NewRollout(firmware.RolloutOptions{
+		FirmwareVersion:     "1.0.0",
+		ComponentVersionIDs: []string{"v1"},
+		Waves:               waves(),
+		BackOff:             backoff.NewConstantBackOff(time.Millisecond),
+		Gate: func(ctx context.Context, wave firmware.Wave, request *mdm.FirmwareDistributionRequest) error {
+			return rollout.Abort()
+		},
+	})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, rollout.Run(context.Background()), firmware.ErrAborted)
+	requests := fake.all("FirmwareDistributionRequest")
+	require.Len(t, requests, 1)
+	assert.Equal(t, firmware.StatusCancelled, requests[0]["status"])
+	progress := rollout.Progress()
+	assert.Equal(t, firmware.StateAborted, progress.State)
+	assert.ErrorIs(t, progress.Err, firmware.ErrAborted)
+	assert.Equal(t, firmware.StatusCancelled, progress.Waves[0].Status)
+	assert.Empty(t, progress.Waves[1].RequestID)
+
+	// Cancelled requests stay cancelled
+	assert.ErrorIs(t, rollout.Resume(), firmware.ErrNotPaused)
+	assert.Equal(t, firmware.StatusCancelled, fake.all("FirmwareDistributionRequest")[0]["status"])
+}
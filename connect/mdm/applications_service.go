@@ -74,6 +74,11 @@ func (a *ApplicationsService) GetApplications(opt *GetApplicationsOptions, optio
 	return &apps, resp, nil
 }
 
+// GetAllApplications looks up applications based on GetApplicationsOptions, retrieving all result pages
+func (a *ApplicationsService) GetAllApplications(opt *GetApplicationsOptions, options ...OptionFunc) (*[]Application, *Response, error) {
+	return findAll[Application](a.Client, "/Application", applicationAPIVersion, opt, options...)
+}
+
 // CreateApplication creates a Application
 func (a *ApplicationsService) CreateApplication(app Application) (*Application, *Response, error) {
 	app.ResourceType = "Application"
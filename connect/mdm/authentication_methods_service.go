@@ -128,6 +128,11 @@ func (c *AuthenticationMethodsService) Find(opt *GetAuthenticationMethodOptions,
 	return &resources, resp, err
 }
 
+// FindAll looks up authentication methods based on GetAuthenticationMethodOptions, retrieving all result pages
+func (c *AuthenticationMethodsService) FindAll(opt *GetAuthenticationMethodOptions, options ...OptionFunc) (*[]AuthenticationMethod, *Response, error) {
+	return findAll[AuthenticationMethod](c.Client, "/AuthenticationMethod", authenticationMethodAPIVersion, opt, options...)
+}
+
 // Update updates a standard service
 func (c *AuthenticationMethodsService) Update(ac AuthenticationMethod) (*AuthenticationMethod, *Response, error) {
 	ac.ResourceType = "AuthenticationMethod"
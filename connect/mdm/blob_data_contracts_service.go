@@ -127,6 +127,11 @@ func (c *BlobDataContractsService) Find(opt *GetBlobDataContractOptions, options
 	return &resources, resp, err
 }
 
+// FindAll looks up BlobDataContracts based on GetBlobDataContractOptions, retrieving all result pages
+func (c *BlobDataContractsService) FindAll(opt *GetBlobDataContractOptions, options ...OptionFunc) (*[]BlobDataContract, *Response, error) {
+	return findAll[BlobDataContract](c.Client, "/BlobDataContract", blobDataContractPIVersion, opt, options...)
+}
+
 // Update updates a standard service
 func (c *BlobDataContractsService) Update(ac BlobDataContract) (*BlobDataContract, *Response, error) {
 	ac.ResourceType = "BlobDataContract"
@@ -124,6 +124,11 @@ func (c *BlobSubscriptionsService) Find(opt *GetBlobSubscriptionOptions, options
 	return &resources, resp, err
 }
 
+// FindAll looks up BlobSubscriptions based on GetBlobSubscriptionOptions, retrieving all result pages
+func (c *BlobSubscriptionsService) FindAll(opt *GetBlobSubscriptionOptions, options ...OptionFunc) (*[]BlobSubscription, *Response, error) {
+	return findAll[BlobSubscription](c.Client, "/BlobSubscription", blobSubscriptionPIVersion, opt, options...)
+}
+
 // Update updates a standard service
 func (c *BlobSubscriptionsService) Update(ac BlobSubscription) (*BlobSubscription, *Response, error) {
 	ac.ResourceType = "BlobSubscription"
@@ -0,0 +1,381 @@
+package bootstrap_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/connect/mdm"
+	"github.com/dip-software/go-dip-api/connect/mdm/bootstrap"
+	"github.com/dip-software/go-dip-api/iam"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const specYAML = `
+proposition:
+  name: tf-prop
+  organizationId: 22d20214-7879-4e35-923d-f9d4e01c9746
+  globalReferenceId: tf-prop-ref
+applications:
+  - name: tf-app
+    globalReferenceId: tf-app-ref
+authenticationMethods:
+  - name: tf-auth
+    loginName: login
+    password: ${AUTH_PASSWORD}
+    clientId: client
+    clientSecret: secret
+standardServices:
+  - name: tf-service
+    tags: [tag]
+    urls: [https://service.example.com]
+    authenticationMethod: tf-auth
+serviceActions:
+  - name: tf-action
+    standardService: tf-service
+serviceReferences:
+  - name: tf-reference
+    application: tf-app
+    standardService: tf-service
+    serviceActions: [tf-action]
+oauthClients:
+  - name: tf-client
+    application: tf-app
+    globalReferenceId: tf-client-ref
+    scopes: [mdm.read]
+deviceGroups:
+  - name: tf-group
+    application: tf-app
+deviceTypes:
+  - name: tf-type
+    deviceGroup: tf-group
+    ctn: CTN-1
+dataTypes:
+  - name: tf-data
+buckets:
+  - name: tf-bucket
+    defaultRegion: eu-west-1
+blobDataContracts:
+  - name: tf-contract
+    dataType: tf-data
+    bucket: tf-bucket
+    storageClass: Standard
+    rootPathInBucket: /
+blobSubscriptions:
+  - name: tf-blob-subscription
+    dataType: tf-data
+    notificationTopicId: 5e9f1f39-3a1e-4c9c-9a3b-0e9a4f3b5d2a
+dataBrokerSubscriptions:
+  - name: tf-dbs
+    dataType: tf-data
+    authenticationMethod: tf-auth
+    dataSubscriber: sqs
+    dataAdapter: adapter
+    serviceAgent: agent
+    configuration:
+      deliverDelaySeconds: 10
+`
+
+// fakeMDM is an in-memory MDM which supports create, search by name or _id, update and delete
+type fakeMDM struct {
+	sync.Mutex
+	resources map[string]map[string]map[string]interface{}
+	nextID    int
+	calls     []string
+}
+
+func newFakeMDM() *fakeMDM {
+	f := &fakeMDM{resources: make(map[string]map[string]map[string]interface{})}
+	for kind, name := range map[string]string{
+		"Region":         "eu-west-1",
+		"StorageClass":   "Standard",
+		"DataSubscriber": "sqs",
+		"DataAdapter":    "adapter",
+		"ServiceAgent":   "agent",
+	} {
+		f.store(kind, map[string]interface{}{"resourceType": kind, "name": name})
+	}
+	return f
+}
+
+func (f *fakeMDM) store(kind string, resource map[string]interface{}) map[string]interface{} {
+	f.nextID++
+	id := fmt.Sprintf("%s-%d", strings.ToLower(kind), f.nextID)
+	resource["id"] = id
+	if kind == "OAuthClient" {
+		resource["clientGuid"] = map[string]interface{}{"value": "guid-" + id}
+	}
+	if f.resources[kind] == nil {
+		f.resources[kind] = make(map[string]map[string]interface{})
+	}
+	f.resources[kind][id] = resource
+	return resource
+}
+
+func (f *fakeMDM) count(kind string) int {
+	f.Lock()
+	defer f.Unlock()
+	return len(f.resources[kind])
+}
+
+func (f *fakeMDM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/connect/mdm/"), "/")
+	kind := parts[0]
+	if r.Method != http.MethodGet {
+		f.calls = append(f.calls, r.Method+" "+kind)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case len(parts) > 2 && strings.HasSuffix(r.URL.Path, "/$scopes"):
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		var resource map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&resource)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(f.store(kind, resource))
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		name := r.URL.Query().Get("name")
+		id := r.URL.Query().Get("_id")
+		var entries []map[string]interface{}
+		for _, resource := range f.resources[kind] {
+			if (name == "" || resource["name"] == name) && (id == "" || resource["id"] == id) {
+				entries = append(entries, map[string]interface{}{"resource": resource})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"resourceType": "Bundle", "type": "searchset", "entry": entries})
+	case len(parts) == 2:
+		resource, ok := f.resources[kind][parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(resource)
+		case http.MethodPut:
+			var updated map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&updated)
+			updated["id"] = parts[1]
+			f.resources[kind][parts[1]] = updated
+			_ = json.NewEncoder(w).Encode(updated)
+		case http.MethodDelete:
+			delete(f.resources[kind], parts[1])
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func setup(t *testing.T) (*mdm.Client, *fakeMDM, func()) {
+	muxIAM := http.NewServeMux()
+	serverIAM := httptest.NewServer(muxIAM)
+	fake := newFakeMDM()
+	serverMDM := httptest.NewServer(fake)
+
+	muxIAM.HandleFunc("/authorize/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+    "scope": "mail",
+    "access_token": "44d20214-7879-4e35-923d-f9d4e01c9746",
+    "refresh_token": "31f1a449-ef8e-4bfc-a227-4f2353fde547",
+    "expires_in": 1799,
+    "token_type": "Bearer"
+}`)
+	})
+	muxIAM.HandleFunc("/authorize/oauth2/introspect", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+  "active": true,
+  "username": "ronswanson",
+  "sub": "33d20214-7879-4e35-923d-f9d4e01c9746",
+  "organizations": {
+    "managingOrganization": "22d20214-7879-4e35-923d-f9d4e01c9746",
+    "organizationList": []
+  },
+  "client_id": "testclientid",
+  "token_type": "Bearer",
+  "identity_type": "user"
+}`)
+	})
+
+	iamClient, err := iam.NewClient(nil, &iam.Config{
+		OAuth2ClientID: "TestClient",
+		OAuth2Secret:   "Secret",
+		IAMURL:         serverIAM.URL,
+		IDMURL:         serverIAM.URL,
+	})
+	require.NoError(t, err)
+	require.NoError(t, iamClient.Login("username", "password"))
+	mdmClient, err := mdm.NewClient(iamClient, &mdm.Config{
+		BaseURL: serverMDM.URL + "/connect/mdm",
+	})
+	require.NoError(t, err)
+	return mdmClient, fake, func() {
+		serverIAM.Close()
+		serverMDM.Close()
+	}
+}
+
+func readSpec(t *testing.T) *bootstrap.Spec {
+	spec, err := bootstrap.Read(strings.NewReader(specYAML), bootstrap.FormatYAML)
+	require.NoError(t, err)
+	return spec
+}
+
+func secrets(name string) (string, bool) {
+	if name == "AUTH_PASSWORD" {
+		return "Password1!", true
+	}
+	return "", false
+}
+
+func TestSpecOrder(t *testing.T) {
+	spec := readSpec(t)
+	order, err := spec.Order()
+	require.NoError(t, err)
+	position := make(map[string]int)
+	for i, ref := range order {
+		position[ref.String()] = i
+	}
+	assert.Len(t, order, 14)
+	assert.Equal(t, 0, position["Proposition/tf-prop"])
+	assert.Less(t, position["DeviceGroup/tf-group"], position["DeviceType/tf-type"])
+	assert.Less(t, position["ServiceAction/tf-action"], position["ServiceReference/tf-reference"])
+	assert.Less(t, position["Bucket/tf-bucket"], position["BlobDataContract/tf-contract"])
+	assert.Less(t, position["AuthenticationMethod/tf-auth"], position["DataBrokerSubscription/tf-dbs"])
+
+	spec.DeviceTypes[0].DeviceGroup = "unknown"
+	assert.ErrorIs(t, spec.Validate(), bootstrap.ErrUnresolvedReference)
+
+	spec = readSpec(t)
+	spec.DataTypes = append(spec.DataTypes, spec.DataTypes[0])
+	assert.ErrorIs(t, spec.Validate(), bootstrap.ErrDuplicateName)
+
+	_, err = bootstrap.Read(strings.NewReader(specYAML), "toml")
+	assert.ErrorIs(t, err, bootstrap.ErrUnsupportedFormat)
+
+	var buf strings.Builder
+	require.NoError(t, readSpec(t).Write(&buf, bootstrap.FormatJSON))
+	parsed, err := bootstrap.Read(strings.NewReader(buf.String()), bootstrap.FormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, readSpec(t).Buckets, parsed.Buckets)
+	assert.Equal(t, readSpec(t).ServiceReferences, parsed.ServiceReferences)
+}
+
+func TestApplyAndDestroy(t *testing.T) {
+	client, fake, teardown := setup(t)
+	defer teardown()
+	ctx := context.Background()
+
+	_, err := bootstrap.NewEngine(nil, nil)
+	assert.ErrorIs(t, err, bootstrap.ErrMissingMDMClient)
+	engine, err := bootstrap.NewEngine(client, &bootstrap.Options{Secrets: secrets})
+	require.NoError(t, err)
+	spec := readSpec(t)
+
+	// Dry-run against an empty MDM creates everything without touching it
+	plan, err := engine.Plan(ctx, spec)
+	require.NoError(t, err)
+	assert.True(t, plan.HasChanges())
+	assert.Len(t, plan.Changes, 14)
+	for _, c := range plan.Changes {
+		assert.Equal(t, bootstrap.ActionCreate, c.Action, c.String())
+	}
+	assert.Contains(t, plan.String(), `+ create DeviceType "tf-type" (after DeviceGroup/tf-group)`)
+	assert.Empty(t, fake.calls)
+
+	report, err := plan.Apply(ctx)
+	require.NoError(t, err)
+	assert.Len(t, report.Applied, 14)
+	assert.Equal(t, "devicegroup-", report.IDs["DeviceGroup/tf-group"][:12])
+	assert.Equal(t, 1, fake.count("DeviceType"))
+	assert.Contains(t, fake.calls, "PUT OAuthClient") // scopes
+
+	// Applying again is a no-op
+	plan, err = engine.Plan(ctx, spec)
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges(), plan.String())
+
+	// Drift is detected and corrected
+	spec.Buckets[0].Description = "changed"
+	spec.Buckets[0].VersioningEnabled = true
+	plan, err = engine.Plan(ctx, spec)
+	require.NoError(t, err)
+	assert.True(t, plan.HasChanges())
+	var updates []bootstrap.Change
+	for _, c := range plan.Changes {
+		if c.Action == bootstrap.ActionUpdate {
+			updates = append(updates, c)
+		}
+	}
+	if assert.Len(t, updates, 1) {
+		assert.Equal(t, bootstrap.KindBucket, updates[0].Kind)
+		assert.Equal(t, []string{"description", "versioningEnabled"}, updates[0].Details)
+	}
+	_, err = engine.Apply(ctx, spec)
+	require.NoError(t, err)
+	plan, err = engine.Plan(ctx, spec)
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges(), plan.String())
+
+	// Tear down in reverse order, propositions and applications cannot be deleted
+	plan, err = engine.PlanDestroy(ctx, spec)
+	require.NoError(t, err)
+	assert.Equal(t, bootstrap.KindProposition, plan.Changes[len(plan.Changes)-1].Kind)
+	assert.Equal(t, bootstrap.ActionSkip, plan.Changes[len(plan.Changes)-1].Action)
+	report, err = plan.Apply(ctx)
+	require.NoError(t, err)
+	assert.Len(t, report.Applied, 12)
+	assert.Equal(t, 0, fake.count("DeviceType"))
+	assert.Equal(t, 0, fake.count("Bucket"))
+	assert.Equal(t, 1, fake.count("Application"))
+
+	plan, err = engine.PlanDestroy(ctx, spec)
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges(), plan.String())
+}
+
+func TestPlanErrors(t *testing.T) {
+	client, _, teardown := setup(t)
+	defer teardown()
+	ctx := context.Background()
+
+	engine, err := bootstrap.NewEngine(client, &bootstrap.Options{Secrets: func(string) (string, bool) { return "", false }})
+	require.NoError(t, err)
+	_, err = engine.Plan(ctx, readSpec(t))
+	assert.ErrorIs(t, err, bootstrap.ErrUnresolvedSecret)
+
+	engine, err = bootstrap.NewEngine(client, &bootstrap.Options{Secrets: secrets})
+	require.NoError(t, err)
+	spec := readSpec(t)
+	spec.Buckets[0].DefaultRegion = "mars-north-1"
+	_, err = engine.Plan(ctx, spec)
+	assert.ErrorIs(t, err, bootstrap.ErrPlatformResource)
+}
+
+func TestPlanIgnoresOtherOrganizations(t *testing.T) {
+	client, fake, teardown := setup(t)
+	defer teardown()
+
+	other := map[string]interface{}{"value": "other-org"}
+	fake.store("AuthenticationMethod", map[string]interface{}{"resourceType": "AuthenticationMethod", "name": "tf-auth", "organizationGuid": other})
+	fake.store("StandardService", map[string]interface{}{"resourceType": "StandardService", "name": "tf-service", "organizationGuid": other})
+
+	engine, err := bootstrap.NewEngine(client, &bootstrap.Options{Secrets: secrets})
+	require.NoError(t, err)
+	plan, err := engine.Plan(context.Background(), readSpec(t))
+	require.NoError(t, err)
+	for _, c := range plan.Changes {
+		assert.Equal(t, bootstrap.ActionCreate, c.Action, c.String())
+	}
+}
@@ -0,0 +1,442 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/dip-software/go-dip-api/connect/mdm"
+)
+
+// Action describes what applying a plan does with a resource
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	// ActionSkip is used for resources which MDM does not allow to be deleted
+	ActionSkip Action = "skip"
+	ActionNoop Action = "noop"
+)
+
+// Change is a single planned change to an MDM resource
+type Change struct {
+	Action  Action   `json:"action"`
+	Kind    Kind     `json:"kind"`
+	Name    string   `json:"name"`
+	ID      string   `json:"id,omitempty"`
+	Details []string `json:"details,omitempty"`
+
+	apply func(st *state) error
+}
+
+func (c Change) String() string {
+	symbol := map[Action]string{
+		ActionCreate: "+",
+		ActionUpdate: "~",
+		ActionDelete: "-",
+		ActionSkip:   "!",
+		ActionNoop:   "=",
+	}[c.Action]
+	line := fmt.Sprintf("%s %s %s %q", symbol, c.Action, c.Kind, c.Name)
+	if len(c.Details) > 0 {
+		line += " (" + strings.Join(c.Details, ", ") + ")"
+	}
+	return line
+}
+
+// Plan holds the ordered changes needed to make MDM match a Spec, or to tear it down
+type Plan struct {
+	Changes []Change `json:"changes"`
+
+	ids map[Ref]string
+}
+
+// HasChanges returns true if applying the plan would modify MDM
+func (p *Plan) HasChanges() bool {
+	for _, c := range p.Changes {
+		if c.Action != ActionNoop && c.Action != ActionSkip {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the dry-run output of the plan
+func (p *Plan) String() string {
+	var b strings.Builder
+	for _, c := range p.Changes {
+		b.WriteString(c.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Report describes the outcome of applying a Plan
+type Report struct {
+	Applied []Change `json:"applied"`
+	// IDs holds the MDM ID of every resource in the spec, keyed by Ref.String()
+	IDs map[string]string `json:"ids"`
+}
+
+type state struct {
+	ids    map[Ref]string
+	report *Report
+}
+
+// Apply executes the planned changes in order. On error the report
+// contains the changes which were applied before the failure
+func (p *Plan) Apply(ctx context.Context) (*Report, error) {
+	st := &state{
+		ids:    make(map[Ref]string, len(p.ids)),
+		report: &Report{Applied: []Change{}, IDs: make(map[string]string)},
+	}
+	for ref, id := range p.ids {
+		st.ids[ref] = id
+	}
+	for _, c := range p.Changes {
+		if err := ctx.Err(); err != nil {
+			return st.report, err
+		}
+		if c.apply != nil {
+			if err := c.apply(st); err != nil {
+				return st.report, fmt.Errorf("%s %s %q: %w", c.Action, c.Kind, c.Name, err)
+			}
+		}
+		ref := Ref{Kind: c.Kind, Name: c.Name}
+		c.ID = st.ids[ref]
+		if c.Action != ActionNoop && c.Action != ActionSkip {
+			st.report.Applied = append(st.report.Applied, c)
+		}
+		if c.Action == ActionDelete {
+			delete(st.ids, ref)
+		}
+	}
+	for ref, id := range st.ids {
+		st.report.IDs[ref.String()] = id
+	}
+	return st.report, nil
+}
+
+// Options controls the behaviour of the Engine
+type Options struct {
+	// Secrets resolves ${NAME} placeholders in the spec. Defaults to os.LookupEnv
+	Secrets func(name string) (string, bool)
+}
+
+// Engine plans and applies specs idempotently, matching existing resources by name
+type Engine struct {
+	client   *mdm.Client
+	secrets  func(name string) (string, bool)
+	platform map[string]string
+}
+
+// NewEngine returns an Engine which uses the given MDM client
+func NewEngine(client *mdm.Client, opts *Options) (*Engine, error) {
+	if client == nil {
+		return nil, ErrMissingMDMClient
+	}
+	e := &Engine{client: client, secrets: os.LookupEnv, platform: make(map[string]string)}
+	if opts != nil && opts.Secrets != nil {
+		e.secrets = opts.Secrets
+	}
+	return e, nil
+}
+
+// planner plans a single spec entry
+type planner interface {
+	planApply(ids map[Ref]string, deps []Ref) (Change, error)
+	planDestroy(ids map[Ref]string, deps []Ref) (Change, error)
+}
+
+// Plan compares the spec with MDM and returns the changes needed, in creation order
+func (e *Engine) Plan(ctx context.Context, s *Spec) (*Plan, error) {
+	nodes, err := s.graph()
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{ids: make(map[Ref]string)}
+	for _, n := range nodes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		p, err := e.planner(s, n)
+		if err != nil {
+			return nil, err
+		}
+		change, err := p.planApply(plan.ids, n.deps)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", n.ref, err)
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+	return plan, nil
+}
+
+// Apply plans and applies the spec in one go
+func (e *Engine) Apply(ctx context.Context, s *Spec) (*Report, error) {
+	plan, err := e.Plan(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Apply(ctx)
+}
+
+// PlanDestroy returns the changes needed to remove the resources of the spec, in reverse creation order.
+// Propositions and applications cannot be deleted through MDM and are reported as skipped
+func (e *Engine) PlanDestroy(ctx context.Context, s *Spec) (*Plan, error) {
+	nodes, err := s.graph()
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{ids: make(map[Ref]string)}
+	changes := make([]Change, 0, len(nodes))
+	for _, n := range nodes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		p, err := e.planner(s, n)
+		if err != nil {
+			return nil, err
+		}
+		change, err := p.planDestroy(plan.ids, n.deps)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", n.ref, err)
+		}
+		changes = append(changes, change)
+	}
+	for i := len(changes) - 1; i >= 0; i-- {
+		plan.Changes = append(plan.Changes, changes[i])
+	}
+	return plan, nil
+}
+
+// Destroy plans and applies the removal of the spec in one go
+func (e *Engine) Destroy(ctx context.Context, s *Spec) (*Report, error) {
+	plan, err := e.PlanDestroy(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Apply(ctx)
+}
+
+// resource binds a spec entry to the MDM calls for its type
+type resource[T any] struct {
+	ref Ref
+	// find returns the existing resource or nil
+	find func(ids map[Ref]string) (*T, error)
+	// build applies the spec entry onto base
+	build  func(ids map[Ref]string, base T) (T, error)
+	create func(T) (string, error)
+	update func(T) error
+	// remove is nil for resources which cannot be deleted
+	remove func(T) error
+	id     func(T) string
+	// secrets lists fields which are never compared as MDM does not return them
+	secrets []string
+}
+
+func pending(ids map[Ref]string, deps []Ref) []string {
+	var missing []string
+	for _, d := range deps {
+		if ids[d] == "" {
+			missing = append(missing, "after "+d.String())
+		}
+	}
+	return missing
+}
+
+func (r *resource[T]) planApply(ids map[Ref]string, deps []Ref) (Change, error) {
+	change := Change{Kind: r.ref.Kind, Name: r.ref.Name}
+	var zero T
+	// Resolve platform resources and secrets early so the plan fails before anything is changed
+	if _, err := r.build(ids, zero); err != nil {
+		return change, err
+	}
+	create := func(st *state) error {
+		desired, err := r.build(st.ids, zero)
+		if err != nil {
+			return err
+		}
+		id, err := r.create(desired)
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			found, err := r.find(st.ids)
+			if err != nil {
+				return err
+			}
+			if found == nil {
+				return mdm.ErrCouldNoReadResourceAfterCreate
+			}
+			id = r.id(*found)
+		}
+		st.ids[r.ref] = id
+		return nil
+	}
+
+	if missing := pending(ids, deps); len(missing) > 0 {
+		change.Action = ActionCreate
+		change.Details = missing
+		change.apply = create
+		return change, nil
+	}
+	actual, err := r.find(ids)
+	if err != nil {
+		return change, err
+	}
+	if actual == nil {
+		change.Action = ActionCreate
+		change.apply = create
+		return change, nil
+	}
+	current := *actual
+	change.ID = r.id(current)
+	ids[r.ref] = change.ID
+	desired, err := r.build(ids, current)
+	if err != nil {
+		return change, err
+	}
+	change.Details = drift(current, desired, r.secrets)
+	if len(change.Details) == 0 {
+		change.Action = ActionNoop
+		return change, nil
+	}
+	change.Action = ActionUpdate
+	change.apply = func(st *state) error {
+		desired, err := r.build(st.ids, current)
+		if err != nil {
+			return err
+		}
+		return r.update(desired)
+	}
+	return change, nil
+}
+
+func (r *resource[T]) planDestroy(ids map[Ref]string, deps []Ref) (Change, error) {
+	change := Change{Kind: r.ref.Kind, Name: r.ref.Name, Action: ActionNoop}
+	if len(pending(ids, deps)) > 0 { // Parent does not exist so neither does this resource
+		change.Details = []string{"not found"}
+		return change, nil
+	}
+	actual, err := r.find(ids)
+	if err != nil {
+		return change, err
+	}
+	if actual == nil {
+		change.Details = []string{"not found"}
+		return change, nil
+	}
+	current := *actual
+	change.ID = r.id(current)
+	ids[r.ref] = change.ID
+	if r.remove == nil {
+		change.Action = ActionSkip
+		change.Details = []string{"cannot be deleted through MDM"}
+		return change, nil
+	}
+	change.Action = ActionDelete
+	change.apply = func(_ *state) error {
+		return r.remove(current)
+	}
+	return change, nil
+}
+
+var ignoredFields = map[string]bool{"ResourceType": true, "ID": true, "Meta": true}
+
+// drift returns the JSON names of the fields which differ between actual and desired
+func drift[T any](actual, desired T, secrets []string) []string {
+	var fields []string
+	a := reflect.ValueOf(actual)
+	d := reflect.ValueOf(desired)
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if ignoredFields[field.Name] || contains(secrets, field.Name) {
+			continue
+		}
+		if !equalJSON(a.Field(i).Interface(), d.Field(i).Interface()) {
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" {
+				name = field.Name
+			}
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// equalJSON compares values by their JSON representation, treating null and empty values alike
+func equalJSON(a, b interface{}) bool {
+	return reflect.DeepEqual(normalizeJSON(a), normalizeJSON(b))
+}
+
+func normalizeJSON(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return v
+	}
+	return dropEmpty(generic)
+}
+
+func dropEmpty(v interface{}) interface{} {
+	switch value := v.(type) {
+	case []interface{}:
+		if len(value) == 0 {
+			return nil
+		}
+		for i := range value {
+			value[i] = dropEmpty(value[i])
+		}
+	case map[string]interface{}:
+		for k, e := range value {
+			if e = dropEmpty(e); e == nil || e == "" || e == false {
+				delete(value, k)
+				continue
+			}
+			value[k] = e
+		}
+		if len(value) == 0 {
+			return nil
+		}
+	case string:
+		if value == "" {
+			return nil
+		}
+	}
+	return v
+}
+
+func contains(list []string, value string) bool {
+	for _, e := range list {
+		if e == value {
+			return true
+		}
+	}
+	return false
+}
+
+var placeholderRegex = regexp.MustCompile(`^\$\{([A-Za-z0-9_]+)\}$`)
+
+// secret resolves a ${NAME} placeholder, other values are returned as is
+func (e *Engine) secret(value string) (string, error) {
+	m := placeholderRegex.FindStringSubmatch(value)
+	if m == nil {
+		return value, nil
+	}
+	resolved, ok := e.secrets(m[1])
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnresolvedSecret, m[1])
+	}
+	return resolved, nil
+}
@@ -0,0 +1,18 @@
+package bootstrap
+
+import (
+	"errors"
+)
+
+// Exported Errors
+var (
+	ErrMissingMDMClient    = errors.New("missing MDM client")
+	ErrUnsupportedFormat   = errors.New("unsupported spec format")
+	ErrMissingName         = errors.New("missing name")
+	ErrDuplicateName       = errors.New("duplicate name")
+	ErrUnresolvedReference = errors.New("unresolved reference")
+	ErrDependencyCycle     = errors.New("dependency cycle")
+	ErrPlatformResource    = errors.New("platform resource not found")
+	ErrUnresolvedSecret    = errors.New("secret could not be resolved")
+	ErrUnsupportedEntry    = errors.New("unsupported spec entry")
+)
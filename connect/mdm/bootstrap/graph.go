@@ -0,0 +1,207 @@
+package bootstrap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is the MDM resource type of a spec entry
+type Kind string
+
+const (
+	KindProposition            Kind = "Proposition"
+	KindApplication            Kind = "Application"
+	KindAuthenticationMethod   Kind = "AuthenticationMethod"
+	KindStandardService        Kind = "StandardService"
+	KindServiceAction          Kind = "ServiceAction"
+	KindServiceReference       Kind = "ServiceReference"
+	KindOAuthClient            Kind = "OAuthClient"
+	KindDeviceGroup            Kind = "DeviceGroup"
+	KindDeviceType             Kind = "DeviceType"
+	KindDataType               Kind = "DataType"
+	KindBucket                 Kind = "Bucket"
+	KindBlobDataContract       Kind = "BlobDataContract"
+	KindBlobSubscription       Kind = "BlobSubscription"
+	KindDataBrokerSubscription Kind = "DataBrokerSubscription"
+)
+
+// Ref identifies a spec entry by kind and name
+type Ref struct {
+	Kind Kind
+	Name string
+}
+
+func (r Ref) String() string {
+	return string(r.Kind) + "/" + r.Name
+}
+
+type node struct {
+	ref  Ref
+	deps []Ref
+	// entry is the spec entry, e.g. a DeviceType
+	entry interface{}
+}
+
+type graphBuilder struct {
+	nodes []*node
+	index map[Ref]*node
+	err   error
+}
+
+func (g *graphBuilder) add(kind Kind, name string, entry interface{}, deps ...Ref) {
+	if g.err != nil {
+		return
+	}
+	ref := Ref{Kind: kind, Name: name}
+	if name == "" {
+		g.err = fmt.Errorf("%w: %s #%d", ErrMissingName, kind, g.count(kind)+1)
+		return
+	}
+	if _, ok := g.index[ref]; ok {
+		g.err = fmt.Errorf("%w: %s", ErrDuplicateName, ref)
+		return
+	}
+	var required []Ref
+	for _, d := range deps {
+		if d.Name != "" {
+			required = append(required, d)
+		}
+	}
+	n := &node{ref: ref, deps: required, entry: entry}
+	g.nodes = append(g.nodes, n)
+	g.index[ref] = n
+}
+
+func (g *graphBuilder) count(kind Kind) int {
+	count := 0
+	for _, n := range g.nodes {
+		if n.ref.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+func refs(kind Kind, names ...string) []Ref {
+	list := make([]Ref, 0, len(names))
+	for _, name := range names {
+		list = append(list, Ref{Kind: kind, Name: name})
+	}
+	return list
+}
+
+// graph returns the spec entries in creation order, i.e. every entry comes after the entries it references
+func (s *Spec) graph() ([]*node, error) {
+	g := &graphBuilder{index: make(map[Ref]*node)}
+	prop := Ref{Kind: KindProposition, Name: s.Proposition.Name}
+
+	g.add(KindProposition, s.Proposition.Name, s.Proposition)
+	for _, e := range s.Applications {
+		g.add(KindApplication, e.Name, e, prop)
+	}
+	for _, e := range s.AuthenticationMethods {
+		g.add(KindAuthenticationMethod, e.Name, e)
+	}
+	for _, e := range s.StandardServices {
+		g.add(KindStandardService, e.Name, e, Ref{Kind: KindAuthenticationMethod, Name: e.AuthenticationMethod})
+	}
+	for _, e := range s.ServiceActions {
+		g.add(KindServiceAction, e.Name, e, Ref{Kind: KindStandardService, Name: e.StandardService})
+	}
+	for _, e := range s.ServiceReferences {
+		deps := append([]Ref{
+			{Kind: KindApplication, Name: e.Application},
+			{Kind: KindStandardService, Name: e.StandardService},
+		}, refs(KindServiceAction, e.ServiceActions...)...)
+		g.add(KindServiceReference, e.Name, e, deps...)
+	}
+	for _, e := range s.OAuthClients {
+		g.add(KindOAuthClient, e.Name, e, Ref{Kind: KindApplication, Name: e.Application})
+	}
+	for _, e := range s.DeviceGroups {
+		g.add(KindDeviceGroup, e.Name, e, Ref{Kind: KindApplication, Name: e.Application})
+	}
+	for _, e := range s.DeviceTypes {
+		g.add(KindDeviceType, e.Name, e, Ref{Kind: KindDeviceGroup, Name: e.DeviceGroup})
+	}
+	for _, e := range s.DataTypes {
+		g.add(KindDataType, e.Name, e, prop)
+	}
+	for _, e := range s.Buckets {
+		g.add(KindBucket, e.Name, e, prop)
+	}
+	for _, e := range s.BlobDataContracts {
+		g.add(KindBlobDataContract, e.Name, e,
+			Ref{Kind: KindDataType, Name: e.DataType},
+			Ref{Kind: KindBucket, Name: e.Bucket})
+	}
+	for _, e := range s.BlobSubscriptions {
+		g.add(KindBlobSubscription, e.Name, e, Ref{Kind: KindDataType, Name: e.DataType})
+	}
+	for _, e := range s.DataBrokerSubscriptions {
+		g.add(KindDataBrokerSubscription, e.Name, e,
+			Ref{Kind: KindDataType, Name: e.DataType},
+			Ref{Kind: KindAuthenticationMethod, Name: e.AuthenticationMethod})
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+	for _, n := range g.nodes {
+		for _, d := range n.deps {
+			if _, ok := g.index[d]; !ok {
+				return nil, fmt.Errorf("%w: %s references %s", ErrUnresolvedReference, n.ref, d)
+			}
+		}
+	}
+	return sortNodes(g.nodes)
+}
+
+// sortNodes orders nodes topologically. Ties keep their spec order so plans are deterministic
+func sortNodes(nodes []*node) ([]*node, error) {
+	done := make(map[Ref]bool, len(nodes))
+	sorted := make([]*node, 0, len(nodes))
+	for len(sorted) < len(nodes) {
+		progress := false
+		for _, n := range nodes {
+			if done[n.ref] || !depsDone(n, done) {
+				continue
+			}
+			done[n.ref] = true
+			sorted = append(sorted, n)
+			progress = true
+			break
+		}
+		if !progress {
+			var cycle []string
+			for _, n := range nodes {
+				if !done[n.ref] {
+					cycle = append(cycle, n.ref.String())
+				}
+			}
+			return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, ", "))
+		}
+	}
+	return sorted, nil
+}
+
+func depsDone(n *node, done map[Ref]bool) bool {
+	for _, d := range n.deps {
+		if !done[d] {
+			return false
+		}
+	}
+	return true
+}
+
+// Order returns the references of all spec entries in creation order
+func (s *Spec) Order() ([]Ref, error) {
+	nodes, err := s.graph()
+	if err != nil {
+		return nil, err
+	}
+	order := make([]Ref, 0, len(nodes))
+	for _, n := range nodes {
+		order = append(order, n.ref)
+	}
+	return order, nil
+}
@@ -0,0 +1,747 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dip-software/go-dip-api/connect/mdm"
+)
+
+// reference returns the MDM reference to the resource, e.g. DataType/<id>
+func reference(ids map[Ref]string, kind Kind, name string) mdm.Reference {
+	return mdm.Reference{Reference: string(kind) + "/" + ids[Ref{Kind: kind, Name: name}]}
+}
+
+func identifier(value string) *mdm.Identifier {
+	if value == "" {
+		return nil
+	}
+	return &mdm.Identifier{Value: value}
+}
+
+// first returns the first element of list which matches, or nil
+func first[T any](list *[]T, match func(T) bool) *T {
+	if list == nil {
+		return nil
+	}
+	for i := range *list {
+		if match((*list)[i]) {
+			return &(*list)[i]
+		}
+	}
+	return nil
+}
+
+// removed turns the result of a MDM Delete call into an error
+func removed(ok bool, _ *mdm.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return mdm.ErrOperationFailed
+	}
+	return nil
+}
+
+// platformID looks up the ID of a platform resource, such as a region, by name
+func (e *Engine) platformID(kind, name string, lookup func(string) ([]string, error)) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	key := kind + "/" + name
+	if id, ok := e.platform[key]; ok {
+		return id, nil
+	}
+	ids, err := lookup(name)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", key, err)
+	}
+	if len(ids) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrPlatformResource, key)
+	}
+	e.platform[key] = ids[0]
+	return ids[0], nil
+}
+
+func (e *Engine) regionID(name string) (string, error) {
+	return e.platformID("Region", name, func(name string) ([]string, error) {
+		found, _, err := e.client.Regions.GetRegions(&mdm.GetRegionOptions{Name: &name})
+		if err != nil || found == nil {
+			return nil, err
+		}
+		var ids []string
+		for _, r := range *found {
+			if r.Name == name {
+				ids = append(ids, r.ID)
+			}
+		}
+		return ids, nil
+	})
+}
+
+func (e *Engine) storageClassID(name string) (string, error) {
+	return e.platformID("StorageClass", name, func(name string) ([]string, error) {
+		found, _, err := e.client.StorageClasses.GetStorageClasses(&mdm.GetStorageClassOptions{Name: &name})
+		if err != nil || found == nil {
+			return nil, err
+		}
+		var ids []string
+		for _, s := range *found {
+			if s.Name == name {
+				ids = append(ids, s.ID)
+			}
+		}
+		return ids, nil
+	})
+}
+
+func (e *Engine) dataSubscriberID(name string) (string, error) {
+	return e.platformID("DataSubscriber", name, func(name string) ([]string, error) {
+		found, _, err := e.client.DataSubscribers.Get(&mdm.GetDataSubscriberOptions{Name: &name})
+		if err != nil || found == nil {
+			return nil, err
+		}
+		var ids []string
+		for _, s := range *found {
+			if s.Name == name {
+				ids = append(ids, s.ID)
+			}
+		}
+		return ids, nil
+	})
+}
+
+func (e *Engine) dataAdapterID(name string) (string, error) {
+	return e.platformID("DataAdapter", name, func(name string) ([]string, error) {
+		found, _, err := e.client.DataAdapters.Get(&mdm.GetDataAdapterOptions{Name: &name})
+		if err != nil || found == nil {
+			return nil, err
+		}
+		var ids []string
+		for _, a := range *found {
+			if a.Name == name {
+				ids = append(ids, a.ID)
+			}
+		}
+		return ids, nil
+	})
+}
+
+func (e *Engine) serviceAgentID(name string) (string, error) {
+	return e.platformID("ServiceAgent", name, func(name string) ([]string, error) {
+		found, _, err := e.client.ServiceAgents.Get(&mdm.GetServiceAgentOptions{Name: &name})
+		if err != nil || found == nil {
+			return nil, err
+		}
+		var ids []string
+		for _, a := range *found {
+			if a.Name == name {
+				ids = append(ids, a.ID)
+			}
+		}
+		return ids, nil
+	})
+}
+
+// planner returns the planner for a spec entry
+func (e *Engine) planner(s *Spec, n *node) (planner, error) {
+	c := e.client
+	orgID := s.Proposition.OrganizationID
+	prop := s.Proposition.Name
+
+	switch entry := n.entry.(type) {
+	case Proposition:
+		return &resource[mdm.Proposition]{
+			ref: n.ref,
+			find: func(ids map[Ref]string) (*mdm.Proposition, error) {
+				found, _, err := c.Propositions.GetPropositions(&mdm.GetPropositionsOptions{Name: &entry.Name, OrganizationID: &orgID})
+				if err != nil {
+					return nil, err
+				}
+				return first(found, func(p mdm.Proposition) bool {
+					return p.Name == entry.Name && p.OrganizationGuid.Value == orgID
+				}), nil
+			},
+			build: func(ids map[Ref]string, p mdm.Proposition) (mdm.Proposition, error) {
+				p.Name = entry.Name
+				p.Description = entry.Description
+				p.OrganizationGuid = mdm.Identifier{Value: orgID}
+				p.GlobalReferenceID = entry.GlobalReferenceID
+				return p, nil
+			},
+			create: func(p mdm.Proposition) (string, error) {
+				created, _, err := c.Propositions.CreateProposition(p)
+				if err != nil || created == nil {
+					return "", err
+				}
+				return created.ID, nil
+			},
+			update: func(p mdm.Proposition) error {
+				_, _, err := c.Propositions.UpdateProposition(p)
+				return err
+			},
+			id: func(p mdm.Proposition) string { return p.ID },
+		}, nil
+	case Application:
+		return &resource[mdm.Application]{
+			ref: n.ref,
+			find: func(ids map[Ref]string) (*mdm.Application, error) {
+				propID := ids[Ref{Kind: KindProposition, Name: prop}]
+				found, _, err := c.Applications.GetApplications(&mdm.GetApplicationsOptions{Name: &entry.Name, PropositionID: &propID})
+				if err != nil {
+					return nil, err
+				}
+				return first(found, func(a mdm.Application) bool { return a.Name == entry.Name }), nil
+			},
+			build: func(ids map[Ref]string, a mdm.Application) (mdm.Application, error) {
+				a.Name = entry.Name
+				a.Description = entry.Description
+				a.PropositionID = reference(ids, KindProposition, prop)
+				a.GlobalReferenceID = entry.GlobalReferenceID
+				if entry.DefaultGroupID != "" {
+					a.DefaultGroupGuid = identifier(entry.DefaultGroupID)
+				}
+				return a, nil
+			},
+			create: func(a mdm.Application) (string, error) {
+				created, _, err := c.Applications.CreateApplication(a)
+				if err != nil || created == nil {
+					return "", err
+				}
+				return created.ID, nil
+			},
+			update: func(a mdm.Application) error {
+				_, _, err := c.Applications.UpdateApplication(a)
+				return err
+			},
+			id: func(a mdm.Application) string { return a.ID },
+		}, nil
+	case AuthenticationMethod:
+		return &resource[mdm.AuthenticationMethod]{
+			ref: n.ref,
+			find: func(ids map[Ref]string) (*mdm.AuthenticationMethod, error) {
+				found, _, err := c.AuthenticationMethods.Find(&mdm.GetAuthenticationMethodOptions{Name: &entry.Name})
+				if err != nil {
+					return nil, err
+				}
+				return first(found, func(a mdm.AuthenticationMethod) bool {
+					return a.Name == entry.Name && a.OrganizationGuid != nil && a.OrganizationGuid.Value == orgID
+				}), nil
+			},
+			build: func(ids map[Ref]string, a mdm.AuthenticationMethod) (mdm.AuthenticationMethod, error) {
+				password, err := e.secret(entry.Password)
+				if err != nil {
+					return a, err
+				}
+				clientSecret, err := e.secret(entry.ClientSecret)
+				if err != nil {
+					return a, err
+				}
+				a.Name = entry.Name
+				a.Description = entry.Description
+				a.LoginName = entry.LoginName
+				a.Password = password
+				a.ClientID = entry.ClientID
+				a.ClientSecret = clientSecret
+				a.AuthURL = entry.AuthURL
+				a.AuthMethod = entry.AuthMethod
+				a.APIVersion = entry.APIVersion
+				a.OrganizationGuid = identifier(orgID)
+				return a, nil
+			},
+			create: func(a mdm.AuthenticationMethod) (string, error) {
+				created, _, err := c.AuthenticationMethods.Create(a)
+				if err != nil || created == nil {
+					return "", err
+				}
+				return created.ID, nil
+			},
+			update: func(a mdm.AuthenticationMethod) error {
+				_, _, err := c.AuthenticationMethods.Update(a)
+				return err
+			},
+			remove: func(a mdm.AuthenticationMethod) error {
+				return removed(c.AuthenticationMethods.Delete(a))
+			},
+			id:      func(a mdm.AuthenticationMethod) string { return a.ID },
+			secrets: []string{"Password", "ClientSecret"},
+		}, nil
+	case StandardService:
+		return &resource[mdm.StandardService]{
+			ref: n.ref,
+			find: func(ids map[Ref]string) (*mdm.StandardService, error) {
+				found, _, err := c.StandardServices.GetStandardServices(&mdm.GetStandardServiceOptions{Name: &entry.Name})
+				if err != nil {
+					return nil, err
+				}
+				return first(found, func(s mdm.StandardService) bool {
+					return s.Name == entry.Name && s.OrganizationGuid != nil && s.OrganizationGuid.Value == orgID
+				}), nil
+			},
+			build: func(ids map[Ref]string, s mdm.StandardService) (mdm.StandardService, error) {
+				s.Name = entry.Name
+				s.Description = entry.Description
+				s.Trusted = entry.Trusted
+				s.Tags = entry.Tags
+				s.ServiceUrls = nil
+				for i, u := range entry.URLs {
+					serviceURL := mdm.ServiceURL{URL: u, SortOrder: i + 1}
+					if entry.AuthenticationMethod != "" {
+						authRef := reference(ids, KindAuthenticationMethod, entry.AuthenticationMethod)
+						serviceURL.AuthenticationMethodID = &authRef
+					}
+					s.ServiceUrls = append(s.ServiceUrls, serviceURL)
+				}
+				s.OrganizationGuid = identifier(orgID)
+				return s, nil
+			},
+			create: func(s mdm.StandardService) (string, error) {
+				created, _, err := c.StandardServices.CreateStandardService(s)
+				if err != nil || created == nil {
+					return "", err
+				}
+				return created.ID, nil
+			},
+			update: func(s mdm.StandardService) error {
+				_, _, err := c.StandardServices.Update(s)
+				return err
+			},
+			remove: func(s mdm.StandardService) error {
+				return removed(c.StandardServices.DeleteStandardService(s))
+			},
+			id: func(s mdm.StandardService) string { return s.ID },
+		}, nil
+	case ServiceAction:
+		return &resource[mdm.ServiceAction]{
+			ref: n.ref,
+			find: func(ids map[Ref]string) (*mdm.ServiceAction, error) {
+				serviceID := ids[Ref{Kind: KindStandardService, Name: entry.StandardService}]
+				found, _, err := c.ServiceActions.Find(&mdm.GetServiceActionOptions{Name: &entry.Name, StandardServiceID: &serviceID})
+				if err != nil {
+					return nil, err
+				}
+				service := reference(ids, KindStandardService, entry.StandardService)
+				return first(found, func(a mdm.ServiceAction) bool {
+					return a.Name == entry.Name && a.StandardServiceId == service
+				}), nil
+			},
+			build: func(ids map[Ref]string, a mdm.ServiceAction) (mdm.ServiceAction, error) {
+				a.Name = entry.Name
+				a.Description = entry.Description
+				a.StandardServiceId = reference(ids, KindStandardService, entry.StandardService)
+				a.OrganizationGuid = identifier(orgID)
+				return a, nil
+			},
+			create: func(a mdm.ServiceAction) (string, error) {
+				created, _, err := c.ServiceActions.Create(a)
+				if err != nil || created == nil {
+					return "", err
+				}
+				return created.ID, nil
+			},
+			update: func(a mdm.ServiceAction) error {
+				_, _, err := c.ServiceActions.Update(a)
+				return err
+			},
+			remove: func(a mdm.ServiceAction) error {
+				return removed(c.ServiceActions.Delete(a))
+			},
+			id: func(a mdm.ServiceAction) string { return a.ID },
+		}, nil
+	case ServiceReference:
+		return &resource[mdm.ServiceReference]{
+			ref: n.ref,
+			find: func(ids map[Ref]string) (*mdm.ServiceReference, error) {
+				appID := ids[Ref{Kind: KindApplication, Name: entry.Application}]
+				found, _, err := c.ServiceReferences.Find(&mdm.GetServiceReferenceOptions{Name: &entry.Name, ApplicationID: &appID})
+				if err != nil {
+					return nil, err
+				}
+				app := reference(ids, KindApplication, entry.Application)
+				return first(found, func(r mdm.ServiceReference) bool {
+					return r.Name == entry.Name && r.ApplicationID == app
+				}), nil
+			},
+			build: func(ids map[Ref]string, r mdm.ServiceReference) (mdm.ServiceReference, error) {
+				r.Name = entry.Name
+				r.Description = entry.Description
+				r.ApplicationID = reference(ids, KindApplication, entry.Application)
+				r.StandardServiceID = reference(ids, KindStandardService, entry.StandardService)
+				r.MatchingRule = entry.MatchingRule
+				r.BootstrapEnabled = entry.BootstrapEnabled
+				r.ServiceActionIDs = nil
+				for _, action := range entry.ServiceActions {
+					r.ServiceActionIDs = append(r.ServiceActionIDs, reference(ids, KindServiceAction, action))
+				}
+				return r, nil
+			},
+			create: func(r mdm.ServiceReference) (string, error) {
+				created, _, err := c.ServiceReferences.Create(r)
+				if err != nil || created == nil {
+					return "", err
+				}
+				return created.ID, nil
+			},
+			update: func(r mdm.ServiceReference) error {
+				_, _, err := c.ServiceReferences.Update(r)
+				return err
+			},
+			remove: func(r mdm.ServiceReference) error {
+				return removed(c.ServiceReferences.Delete(r))
+			},
+			id: func(r mdm.ServiceReference) string { return r.ID },
+		}, nil
+	case OAuthClient:
+		return &resource[mdm.OAuthClient]{
+			ref: n.ref,
+			find: func(ids map[Ref]string) (*mdm.OAuthClient, error) {
+				appID := ids[Ref{Kind: KindApplication, Name: entry.Application}]
+				found, _, err := c.OAuthClients.GetOAuthClients(&mdm.GetOAuthClientsOptions{Name: &entry.Name, ApplicationID: &appID})
+				if err != nil {
+					return nil, err
+				}
+				app := reference(ids, KindApplication, entry.Application)
+				return first(found, func(o mdm.OAuthClient) bool {
+					return o.Name == entry.Name && o.ApplicationId == app
+				}), nil
+			},
+			build: func(ids map[Ref]string, o mdm.OAuthClient) (mdm.OAuthClient, error) {
+				o.Name = entry.Name
+				o.Description = entry.Description
+				o.ApplicationId = reference(ids, KindApplication, entry.Application)
+				o.GlobalReferenceID = entry.GlobalReferenceID
+				o.RedirectionURIs = entry.RedirectionURIs
+				o.ResponseTypes = entry.ResponseTypes
+				o.UserClient = entry.UserClient
+				return o, nil
+			},
+			create: func(o mdm.OAuthClient) (string, error) {
+				created, _, err := c.OAuthClients.CreateOAuthClient(o)
+				if err != nil {
+					return "", err
+				}
+				if created == nil {
+					return "", mdm.ErrCouldNoReadResourceAfterCreate
+				}
+				if len(entry.Scopes) > 0 || len(entry.DefaultScopes) > 0 {
+					if err := removed(c.OAuthClients.UpdateScopes(*created, entry.Scopes, entry.DefaultScopes)); err != nil {
+						return "", fmt.Errorf("scopes: %w", err)
+					}
+				}
+				return created.ID, nil
+			},
+			update: func(o mdm.OAuthClient) error {
+				_, _, err := c.OAuthClients.Update(o)
+				return err
+			},
+			remove: func(o mdm.OAuthClient) error {
+				return removed(c.OAuthClients.DeleteOAuthClient(o))
+			},
+			id: func(o mdm.OAuthClient) string { return o.ID },
+		}, nil
+	case DeviceGroup:
+		return &resource[mdm.DeviceGroup]{
+			ref: n.ref,
+			find: func(ids map[Ref]string) (*mdm.DeviceGroup, error) {
+				appID := ids[Ref{Kind: KindApplication, Name: entry.Application}]
+				found, _, err := c.DeviceGroups.Find(&mdm.GetDeviceGroupOptions{Name: &entry.Name, ApplicationID: &appID})
+				if err != nil {
+					return nil, err
+				}
+				app := reference(ids, KindApplication, entry.Application)
+				return first(found, func(g mdm.DeviceGroup) bool {
+					return g.Name == entry.Name && g.ApplicationId == app
+				}), nil
+			},
+			build: func(ids map[Ref]string, g mdm.DeviceGroup) (mdm.DeviceGroup, error) {
+				g.Name = entry.Name
+				g.Description = entry.Description
+				g.ApplicationId = reference(ids, KindApplication, entry.Application)
+				if entry.DefaultGroupID != "" {
+					g.DefaultGroupGuid = identifier(entry.DefaultGroupID)
+				}
+				return g, nil
+			},
+			create: func(g mdm.DeviceGroup) (string, error) {
+				created, _, err := c.DeviceGroups.Create(g)
+				if err != nil || created == nil {
+					return "", err
+				}
+				return created.ID, nil
+			},
+			update: func(g mdm.DeviceGroup) error {
+				_, _, err := c.DeviceGroups.Update(g)
+				return err
+			},
+			remove: func(g mdm.DeviceGroup) error {
+				return removed(c.DeviceGroups.Delete(g))
+			},
+			id: func(g mdm.DeviceGroup) string { return g.ID },
+		}, nil
+	case DeviceType:
+		return &resource[mdm.DeviceType]{
+			ref: n.ref,
+			find: func(ids map[Ref]string) (*mdm.DeviceType, error) {
+				appID := ids[Ref{Kind: KindApplication, Name: s.deviceGroupApplication(entry.DeviceGroup)}]
+				found, _, err := c.DeviceTypes.Find(&mdm.GetDeviceTypeOptions{Name: &entry.Name, ApplicationID: &appID})
+				if err != nil {
+					return nil, err
+				}
+				group := reference(ids, KindDeviceGroup, entry.DeviceGroup)
+				return first(found, func(t mdm.DeviceType) bool {
+					return t.Name == entry.Name && t.DeviceGroupId == group
+				}), nil
+			},
+			build: func(ids map[Ref]string, t mdm.DeviceType) (mdm.DeviceType, error) {
+				t.Name = entry.Name
+				t.Description = entry.Description
+				t.CTN = entry.CTN
+				t.DeviceGroupId = reference(ids, KindDeviceGroup, entry.DeviceGroup)
+				if entry.DefaultGroupID != "" {
+					t.DefaultGroupGuid = identifier(entry.DefaultGroupID)
+				}
+				return t, nil
+			},
+			create: func(t mdm.DeviceType) (string, error) {
+				created, _, err := c.DeviceTypes.Create(t)
+				if err != nil || created == nil {
+					return "", err
+				}
+				return created.ID, nil
+			},
+			update: func(t mdm.DeviceType) error {
+				_, _, err := c.DeviceTypes.Update(t)
+				return err
+			},
+			remove: func(t mdm.DeviceType) error {
+				return removed(c.DeviceTypes.Delete(t))
+			},
+			id: func(t mdm.DeviceType) string { return t.ID },
+		}, nil
+	case DataType:
+		return &resource[mdm.DataType]{
+			ref: n.ref,
+			find: func(ids map[Ref]string) (*mdm.DataType, error) {
+				propID := ids[Ref{Kind: KindProposition, Name: prop}]
+				found, _, err := c.DataTypes.Find(&mdm.GetDataTypeOptions{Name: &entry.Name, PropositionID: &propID})
+				if err != nil {
+					return nil, err
+				}
+				return first(found, func(d mdm.DataType) bool { return d.Name == entry.Name }), nil
+			},
+			build: func(ids map[Ref]string, d mdm.DataType) (mdm.DataType, error) {
+				d.Name = entry.Name
+				d.Description = entry.Description
+				d.Tags = entry.Tags
+				d.PropositionId = reference(ids, KindProposition, prop)
+				return d, nil
+			},
+			create: func(d mdm.DataType) (string, error) {
+				created, _, err := c.DataTypes.Create(d)
+				if err != nil || created == nil {
+					return "", err
+				}
+				return created.ID, nil
+			},
+			update: func(d mdm.DataType) error {
+				_, _, err := c.DataTypes.Update(d)
+				return err
+			},
+			remove: func(d mdm.DataType) error {
+				return removed(c.DataTypes.Delete(d))
+			},
+			id: func(d mdm.DataType) string { return d.ID },
+		}, nil
+	case Bucket:
+		return &resource[mdm.Bucket]{
+			ref: n.ref,
+			find: func(ids map[Ref]string) (*mdm.Bucket, error) {
+				propID := ids[Ref{Kind: KindProposition, Name: prop}]
+				found, _, err := c.Buckets.Find(&mdm.GetBucketOptions{Name: &entry.Name, PropositionID: &propID})
+				if err != nil {
+					return nil, err
+				}
+				return first(found, func(b mdm.Bucket) bool { return b.Name == entry.Name }), nil
+			},
+			build: func(ids map[Ref]string, b mdm.Bucket) (mdm.Bucket, error) {
+				defaultRegion, err := e.regionID(entry.DefaultRegion)
+				if err != nil {
+					return b, err
+				}
+				replicationRegion, err := e.regionID(entry.ReplicationRegion)
+				if err != nil {
+					return b, err
+				}
+				b.Name = entry.Name
+				b.Description = entry.Description
+				b.PropositionID = reference(ids, KindProposition, prop)
+				b.DefaultRegionID = mdm.Reference{Reference: "Region/" + defaultRegion}
+				b.ReplicationRegionID = nil
+				if replicationRegion != "" {
+					b.ReplicationRegionID = &mdm.Reference{Reference: "Region/" + replicationRegion}
+				}
+				b.VersioningEnabled = entry.VersioningEnabled
+				b.LoggingEnabled = entry.LoggingEnabled
+				b.CrossRegionReplicationEnabled = entry.CrossRegionReplicationEnabled
+				b.AuditingEnabled = entry.AuditingEnabled
+				b.EnableCDN = entry.EnableCDN
+				b.CacheControlAge = entry.CacheControlAge
+				return b, nil
+			},
+			create: func(b mdm.Bucket) (string, error) {
+				created, _, err := c.Buckets.Create(b)
+				if err != nil || created == nil {
+					return "", err
+				}
+				return created.ID, nil
+			},
+			update: func(b mdm.Bucket) error {
+				_, _, err := c.Buckets.Update(b)
+				return err
+			},
+			remove: func(b mdm.Bucket) error {
+				return removed(c.Buckets.Delete(b))
+			},
+			id: func(b mdm.Bucket) string { return b.ID },
+		}, nil
+	case BlobDataContract:
+		return &resource[mdm.BlobDataContract]{
+			ref: n.ref,
+			find: func(ids map[Ref]string) (*mdm.BlobDataContract, error) {
+				found, _, err := c.BlobDataContracts.Find(&mdm.GetBlobDataContractOptions{Name: &entry.Name})
+				if err != nil {
+					return nil, err
+				}
+				dataType := reference(ids, KindDataType, entry.DataType)
+				return first(found, func(b mdm.BlobDataContract) bool {
+					return b.Name == entry.Name && b.DataTypeID == dataType
+				}), nil
+			},
+			build: func(ids map[Ref]string, b mdm.BlobDataContract) (mdm.BlobDataContract, error) {
+				storageClass, err := e.storageClassID(entry.StorageClass)
+				if err != nil {
+					return b, err
+				}
+				b.Name = entry.Name
+				b.DataTypeID = reference(ids, KindDataType, entry.DataType)
+				b.BucketID = reference(ids, KindBucket, entry.Bucket)
+				b.StorageClassID = mdm.Reference{Reference: "StorageClass/" + storageClass}
+				b.RootPathInBucket = entry.RootPathInBucket
+				b.LoggingEnabled = entry.LoggingEnabled
+				b.CrossRegionReplicationEnabled = entry.CrossRegionReplicationEnabled
+				return b, nil
+			},
+			create: func(b mdm.BlobDataContract) (string, error) {
+				created, _, err := c.BlobDataContracts.Create(b)
+				if err != nil || created == nil {
+					return "", err
+				}
+				return created.ID, nil
+			},
+			update: func(b mdm.BlobDataContract) error {
+				_, _, err := c.BlobDataContracts.Update(b)
+				return err
+			},
+			remove: func(b mdm.BlobDataContract) error {
+				return removed(c.BlobDataContracts.Delete(b))
+			},
+			id: func(b mdm.BlobDataContract) string { return b.ID },
+		}, nil
+	case BlobSubscription:
+		return &resource[mdm.BlobSubscription]{
+			ref: n.ref,
+			find: func(ids map[Ref]string) (*mdm.BlobSubscription, error) {
+				found, _, err := c.BlobSubscriptions.Find(&mdm.GetBlobSubscriptionOptions{Name: &entry.Name})
+				if err != nil {
+					return nil, err
+				}
+				dataType := reference(ids, KindDataType, entry.DataType)
+				return first(found, func(b mdm.BlobSubscription) bool {
+					return b.Name == entry.Name && b.DataTypeId == dataType
+				}), nil
+			},
+			build: func(ids map[Ref]string, b mdm.BlobSubscription) (mdm.BlobSubscription, error) {
+				b.Name = entry.Name
+				b.Description = entry.Description
+				b.DataTypeId = reference(ids, KindDataType, entry.DataType)
+				b.NotificationTopicGuid = mdm.Identifier{Value: entry.NotificationTopicID}
+				return b, nil
+			},
+			create: func(b mdm.BlobSubscription) (string, error) {
+				created, _, err := c.BlobSubscriptions.Create(b)
+				if err != nil || created == nil {
+					return "", err
+				}
+				return created.ID, nil
+			},
+			update: func(b mdm.BlobSubscription) error {
+				_, _, err := c.BlobSubscriptions.Update(b)
+				return err
+			},
+			remove: func(b mdm.BlobSubscription) error {
+				return removed(c.BlobSubscriptions.Delete(b))
+			},
+			id: func(b mdm.BlobSubscription) string { return b.ID },
+		}, nil
+	case DataBrokerSubscription:
+		return &resource[mdm.DataBrokerSubscription]{
+			ref: n.ref,
+			find: func(ids map[Ref]string) (*mdm.DataBrokerSubscription, error) {
+				found, _, err := c.DataBrokerSubscriptions.Find(&mdm.GetDataBrokerSubscriptionOptions{Name: &entry.Name})
+				if err != nil {
+					return nil, err
+				}
+				dataType := reference(ids, KindDataType, entry.DataType)
+				return first(found, func(d mdm.DataBrokerSubscription) bool {
+					return d.Name == entry.Name && d.DataTypeID == dataType
+				}), nil
+			},
+			build: func(ids map[Ref]string, d mdm.DataBrokerSubscription) (mdm.DataBrokerSubscription, error) {
+				subscriber, err := e.dataSubscriberID(entry.DataSubscriber)
+				if err != nil {
+					return d, err
+				}
+				adapter, err := e.dataAdapterID(entry.DataAdapter)
+				if err != nil {
+					return d, err
+				}
+				agent, err := e.serviceAgentID(entry.ServiceAgent)
+				if err != nil {
+					return d, err
+				}
+				d.Name = entry.Name
+				d.Description = entry.Description
+				d.ServiceAgentId = agent
+				d.DataSubscriberId = mdm.Reference{Reference: "DataSubscriber/" + subscriber}
+				d.DataAdapterId = mdm.Reference{Reference: "DataAdapter/" + adapter}
+				d.AuthenticationMethodId = reference(ids, KindAuthenticationMethod, entry.AuthenticationMethod)
+				d.DataTypeID = reference(ids, KindDataType, entry.DataType)
+				d.Configuration = nil
+				if len(entry.Configuration) > 0 {
+					configuration, err := json.Marshal(entry.Configuration)
+					if err != nil {
+						return d, err
+					}
+					d.Configuration = configuration
+				}
+				return d, nil
+			},
+			create: func(d mdm.DataBrokerSubscription) (string, error) {
+				created, _, err := c.DataBrokerSubscriptions.Create(d)
+				if err != nil || created == nil {
+					return "", err
+				}
+				return created.ID, nil
+			},
+			update: func(d mdm.DataBrokerSubscription) error {
+				_, _, err := c.DataBrokerSubscriptions.Update(d)
+				return err
+			},
+			remove: func(d mdm.DataBrokerSubscription) error {
+				return removed(c.DataBrokerSubscriptions.Delete(d))
+			},
+			id: func(d mdm.DataBrokerSubscription) string { return d.ID },
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %T", ErrUnsupportedEntry, n.entry)
+}
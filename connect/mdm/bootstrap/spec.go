@@ -0,0 +1,235 @@
+// Package bootstrap declaratively provisions a Connect IoT proposition. A Spec describes the
+// MDM resources of a proposition by name; the Engine resolves the references between them,
+// creates them in dependency order and detects drift of existing resources
+package bootstrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the serialization format of a Spec
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// Spec describes a proposition and all MDM resources belonging to it. Resources
+// refer to each other by name. Secrets may be given as ${NAME} placeholders
+type Spec struct {
+	Proposition             Proposition              `json:"proposition" yaml:"proposition"`
+	Applications            []Application            `json:"applications,omitempty" yaml:"applications,omitempty"`
+	AuthenticationMethods   []AuthenticationMethod   `json:"authenticationMethods,omitempty" yaml:"authenticationMethods,omitempty"`
+	StandardServices        []StandardService        `json:"standardServices,omitempty" yaml:"standardServices,omitempty"`
+	ServiceActions          []ServiceAction          `json:"serviceActions,omitempty" yaml:"serviceActions,omitempty"`
+	ServiceReferences       []ServiceReference       `json:"serviceReferences,omitempty" yaml:"serviceReferences,omitempty"`
+	OAuthClients            []OAuthClient            `json:"oauthClients,omitempty" yaml:"oauthClients,omitempty"`
+	DeviceGroups            []DeviceGroup            `json:"deviceGroups,omitempty" yaml:"deviceGroups,omitempty"`
+	DeviceTypes             []DeviceType             `json:"deviceTypes,omitempty" yaml:"deviceTypes,omitempty"`
+	DataTypes               []DataType               `json:"dataTypes,omitempty" yaml:"dataTypes,omitempty"`
+	Buckets                 []Bucket                 `json:"buckets,omitempty" yaml:"buckets,omitempty"`
+	BlobDataContracts       []BlobDataContract       `json:"blobDataContracts,omitempty" yaml:"blobDataContracts,omitempty"`
+	BlobSubscriptions       []BlobSubscription       `json:"blobSubscriptions,omitempty" yaml:"blobSubscriptions,omitempty"`
+	DataBrokerSubscriptions []DataBrokerSubscription `json:"dataBrokerSubscriptions,omitempty" yaml:"dataBrokerSubscriptions,omitempty"`
+}
+
+// Proposition describes the MDM proposition all other resources belong to
+type Proposition struct {
+	Name              string `json:"name" yaml:"name"`
+	Description       string `json:"description,omitempty" yaml:"description,omitempty"`
+	OrganizationID    string `json:"organizationId" yaml:"organizationId"`
+	GlobalReferenceID string `json:"globalReferenceId" yaml:"globalReferenceId"`
+}
+
+// Application describes an MDM application of the proposition
+type Application struct {
+	Name              string `json:"name" yaml:"name"`
+	Description       string `json:"description,omitempty" yaml:"description,omitempty"`
+	GlobalReferenceID string `json:"globalReferenceId" yaml:"globalReferenceId"`
+	DefaultGroupID    string `json:"defaultGroupId,omitempty" yaml:"defaultGroupId,omitempty"`
+}
+
+// AuthenticationMethod describes the credentials services use to authenticate
+type AuthenticationMethod struct {
+	Name         string `json:"name" yaml:"name"`
+	Description  string `json:"description,omitempty" yaml:"description,omitempty"`
+	LoginName    string `json:"loginName" yaml:"loginName"`
+	Password     string `json:"password" yaml:"password"`
+	ClientID     string `json:"clientId" yaml:"clientId"`
+	ClientSecret string `json:"clientSecret" yaml:"clientSecret"`
+	AuthURL      string `json:"authUrl,omitempty" yaml:"authUrl,omitempty"`
+	AuthMethod   string `json:"authMethod,omitempty" yaml:"authMethod,omitempty"`
+	APIVersion   string `json:"apiVersion,omitempty" yaml:"apiVersion,omitempty"`
+}
+
+// StandardService describes a service devices can discover
+type StandardService struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Trusted     bool     `json:"trusted,omitempty" yaml:"trusted,omitempty"`
+	Tags        []string `json:"tags" yaml:"tags"`
+	URLs        []string `json:"urls" yaml:"urls"`
+	// AuthenticationMethod optionally names the AuthenticationMethod used for the URLs
+	AuthenticationMethod string `json:"authenticationMethod,omitempty" yaml:"authenticationMethod,omitempty"`
+}
+
+// ServiceAction describes an action of a StandardService
+type ServiceAction struct {
+	Name            string `json:"name" yaml:"name"`
+	Description     string `json:"description,omitempty" yaml:"description,omitempty"`
+	StandardService string `json:"standardService" yaml:"standardService"`
+}
+
+// ServiceReference grants an Application access to actions of a StandardService
+type ServiceReference struct {
+	Name             string   `json:"name" yaml:"name"`
+	Description      string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Application      string   `json:"application" yaml:"application"`
+	StandardService  string   `json:"standardService" yaml:"standardService"`
+	ServiceActions   []string `json:"serviceActions" yaml:"serviceActions"`
+	MatchingRule     string   `json:"matchingRule,omitempty" yaml:"matchingRule,omitempty"`
+	BootstrapEnabled bool     `json:"bootstrapEnabled,omitempty" yaml:"bootstrapEnabled,omitempty"`
+}
+
+// OAuthClient describes an MDM OAuth client of an Application. Scopes are only set on creation
+type OAuthClient struct {
+	Name              string   `json:"name" yaml:"name"`
+	Description       string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Application       string   `json:"application" yaml:"application"`
+	GlobalReferenceID string   `json:"globalReferenceId" yaml:"globalReferenceId"`
+	RedirectionURIs   []string `json:"redirectionURIs,omitempty" yaml:"redirectionURIs,omitempty"`
+	ResponseTypes     []string `json:"responseTypes,omitempty" yaml:"responseTypes,omitempty"`
+	UserClient        bool     `json:"userClient,omitempty" yaml:"userClient,omitempty"`
+	Scopes            []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	DefaultScopes     []string `json:"defaultScopes,omitempty" yaml:"defaultScopes,omitempty"`
+}
+
+// DeviceGroup describes a group of devices of an Application
+type DeviceGroup struct {
+	Name           string `json:"name" yaml:"name"`
+	Description    string `json:"description,omitempty" yaml:"description,omitempty"`
+	Application    string `json:"application" yaml:"application"`
+	DefaultGroupID string `json:"defaultGroupId,omitempty" yaml:"defaultGroupId,omitempty"`
+}
+
+// DeviceType describes a type of device in a DeviceGroup
+type DeviceType struct {
+	Name           string `json:"name" yaml:"name"`
+	Description    string `json:"description,omitempty" yaml:"description,omitempty"`
+	DeviceGroup    string `json:"deviceGroup" yaml:"deviceGroup"`
+	CTN            string `json:"ctn" yaml:"ctn"`
+	DefaultGroupID string `json:"defaultGroupId,omitempty" yaml:"defaultGroupId,omitempty"`
+}
+
+// DataType describes a type of data devices send
+type DataType struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// Bucket describes a blob bucket. Regions are referenced by name
+type Bucket struct {
+	Name                          string `json:"name" yaml:"name"`
+	Description                   string `json:"description,omitempty" yaml:"description,omitempty"`
+	DefaultRegion                 string `json:"defaultRegion" yaml:"defaultRegion"`
+	ReplicationRegion             string `json:"replicationRegion,omitempty" yaml:"replicationRegion,omitempty"`
+	VersioningEnabled             bool   `json:"versioningEnabled,omitempty" yaml:"versioningEnabled,omitempty"`
+	LoggingEnabled                bool   `json:"loggingEnabled,omitempty" yaml:"loggingEnabled,omitempty"`
+	CrossRegionReplicationEnabled bool   `json:"crossRegionReplicationEnabled,omitempty" yaml:"crossRegionReplicationEnabled,omitempty"`
+	AuditingEnabled               bool   `json:"auditingEnabled,omitempty" yaml:"auditingEnabled,omitempty"`
+	EnableCDN                     bool   `json:"enableCDN,omitempty" yaml:"enableCDN,omitempty"`
+	CacheControlAge               int    `json:"cacheControlAge,omitempty" yaml:"cacheControlAge,omitempty"`
+}
+
+// BlobDataContract stores blobs of a DataType in a Bucket. The storage class is referenced by name
+type BlobDataContract struct {
+	Name                          string `json:"name" yaml:"name"`
+	DataType                      string `json:"dataType" yaml:"dataType"`
+	Bucket                        string `json:"bucket" yaml:"bucket"`
+	StorageClass                  string `json:"storageClass" yaml:"storageClass"`
+	RootPathInBucket              string `json:"rootPathInBucket" yaml:"rootPathInBucket"`
+	LoggingEnabled                bool   `json:"loggingEnabled,omitempty" yaml:"loggingEnabled,omitempty"`
+	CrossRegionReplicationEnabled bool   `json:"crossRegionReplicationEnabled,omitempty" yaml:"crossRegionReplicationEnabled,omitempty"`
+}
+
+// BlobSubscription notifies a topic about blobs of a DataType
+type BlobSubscription struct {
+	Name                string `json:"name" yaml:"name"`
+	Description         string `json:"description,omitempty" yaml:"description,omitempty"`
+	DataType            string `json:"dataType" yaml:"dataType"`
+	NotificationTopicID string `json:"notificationTopicId" yaml:"notificationTopicId"`
+}
+
+// DataBrokerSubscription forwards data of a DataType to a data subscriber. The data
+// subscriber, data adapter and service agent are platform resources referenced by name
+type DataBrokerSubscription struct {
+	Name                 string                 `json:"name" yaml:"name"`
+	Description          string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	DataType             string                 `json:"dataType" yaml:"dataType"`
+	AuthenticationMethod string                 `json:"authenticationMethod" yaml:"authenticationMethod"`
+	DataSubscriber       string                 `json:"dataSubscriber" yaml:"dataSubscriber"`
+	DataAdapter          string                 `json:"dataAdapter" yaml:"dataAdapter"`
+	ServiceAgent         string                 `json:"serviceAgent" yaml:"serviceAgent"`
+	Configuration        map[string]interface{} `json:"configuration,omitempty" yaml:"configuration,omitempty"`
+}
+
+// Read parses a spec in the given format and validates its references
+func Read(r io.Reader, format Format) (*Spec, error) {
+	var s Spec
+	switch format {
+	case FormatJSON:
+		if err := json.NewDecoder(r).Decode(&s); err != nil {
+			return nil, err
+		}
+	case FormatYAML:
+		if err := yaml.NewDecoder(r).Decode(&s); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Write serializes the spec in the given format
+func (s *Spec) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(s); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// deviceGroupApplication returns the name of the application of a device group
+func (s *Spec) deviceGroupApplication(name string) string {
+	for _, g := range s.DeviceGroups {
+		if g.Name == name {
+			return g.Application
+		}
+	}
+	return ""
+}
+
+// Validate checks that names are unique per kind and that all references resolve
+func (s *Spec) Validate() error {
+	_, err := s.graph()
+	return err
+}
@@ -146,6 +146,11 @@ func (c *BucketsService) Find(opt *GetBucketOptions, options ...OptionFunc) (*[]
 	return &resources, resp, err
 }
 
+// FindAll looks up buckets based on GetBucketOptions, retrieving all result pages
+func (c *BucketsService) FindAll(opt *GetBucketOptions, options ...OptionFunc) (*[]Bucket, *Response, error) {
+	return findAll[Bucket](c.Client, "/Bucket", bucketAPIVersion, opt, options...)
+}
+
 // Update updates a standard service
 func (c *BucketsService) Update(ac Bucket) (*Bucket, *Response, error) {
 	ac.ResourceType = "Bucket"
@@ -131,6 +131,11 @@ func (c *DataBrokerSubscriptionsService) Find(opt *GetDataBrokerSubscriptionOpti
 	return &resources, resp, err
 }
 
+// FindAll looks up data broker subscriptions based on GetDataBrokerSubscriptionOptions, retrieving all result pages
+func (c *DataBrokerSubscriptionsService) FindAll(opt *GetDataBrokerSubscriptionOptions, options ...OptionFunc) (*[]DataBrokerSubscription, *Response, error) {
+	return findAll[DataBrokerSubscription](c.Client, "/DataBrokerSubscription", dataBrokerSubscriptionAPIVersion, opt, options...)
+}
+
 // Update updates a standard service
 func (c *DataBrokerSubscriptionsService) Update(ac DataBrokerSubscription) (*DataBrokerSubscription, *Response, error) {
 	ac.ResourceType = "DataBrokerSubscription"
@@ -125,6 +125,11 @@ func (c *DataTypesService) Find(opt *GetDataTypeOptions, options ...OptionFunc)
 	return &resources, resp, err
 }
 
+// FindAll looks up data types based on GetDataTypeOptions, retrieving all result pages
+func (c *DataTypesService) FindAll(opt *GetDataTypeOptions, options ...OptionFunc) (*[]DataType, *Response, error) {
+	return findAll[DataType](c.Client, "/DataType", dataTypesAPIVersion, opt, options...)
+}
+
 // Update updates a standard service
 func (c *DataTypesService) Update(ac DataType) (*DataType, *Response, error) {
 	ac.ResourceType = "DataType"
@@ -124,6 +124,11 @@ func (c *DeviceGroupsService) Find(opt *GetDeviceGroupOptions, options ...Option
 	return &resources, resp, err
 }
 
+// FindAll looks up device groups based on GetDeviceGroupOptions, retrieving all result pages
+func (c *DeviceGroupsService) FindAll(opt *GetDeviceGroupOptions, options ...OptionFunc) (*[]DeviceGroup, *Response, error) {
+	return findAll[DeviceGroup](c.Client, "/DeviceGroup", deviceGroupAPIVersion, opt, options...)
+}
+
 // Update updates a standard service
 func (c *DeviceGroupsService) Update(ac DeviceGroup) (*DeviceGroup, *Response, error) {
 	ac.ResourceType = "DeviceGroup"
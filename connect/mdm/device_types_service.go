@@ -126,6 +126,11 @@ func (c *DeviceTypesService) Find(opt *GetDeviceTypeOptions, options ...OptionFu
 	return &resources, resp, err
 }
 
+// FindAll looks up device types based on GetDeviceTypeOptions, retrieving all result pages
+func (c *DeviceTypesService) FindAll(opt *GetDeviceTypeOptions, options ...OptionFunc) (*[]DeviceType, *Response, error) {
+	return findAll[DeviceType](c.Client, "/DeviceType", deviceTypeAPIVersion, opt, options...)
+}
+
 // Update updates a standard service
 func (c *DeviceTypesService) Update(ac DeviceType) (*DeviceType, *Response, error) {
 	ac.ResourceType = "DeviceType"
@@ -10,4 +10,6 @@ var (
 	ErrEmptyResult                    = errors.New("empty result")
 	ErrOperationFailed                = errors.New("operation failed")
 	ErrCouldNoReadResourceAfterCreate = errors.New("could not read resource after create")
+
+	ErrFirmwareDistributionRequestInError = errors.New("firmware distribution request is in error")
 )
@@ -146,6 +146,11 @@ func (c *FirmwareComponentVersionsService) Find(opt *GetFirmwareComponentVersion
 	return &resources, resp, err
 }
 
+// FindAll looks up firmware component versions based on GetFirmwareComponentVersionOptions, retrieving all result pages
+func (c *FirmwareComponentVersionsService) FindAll(opt *GetFirmwareComponentVersionOptions, options ...OptionFunc) (*[]FirmwareComponentVersion, *Response, error) {
+	return findAll[FirmwareComponentVersion](c.Client, "/FirmwareComponentVersion", firmwareComponentVersionAPIVersion, opt, options...)
+}
+
 // Update updates a standard service
 func (c *FirmwareComponentVersionsService) Update(ac FirmwareComponentVersion) (*FirmwareComponentVersion, *Response, error) {
 	ac.ResourceType = "FirmwareComponentVersion"
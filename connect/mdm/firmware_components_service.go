@@ -127,6 +127,11 @@ func (c *FirmwareComponentsService) Find(opt *GetFirmwareComponentOptions, optio
 	return &resources, resp, err
 }
 
+// FindAll looks up firmware components based on GetFirmwareComponentOptions, retrieving all result pages
+func (c *FirmwareComponentsService) FindAll(opt *GetFirmwareComponentOptions, options ...OptionFunc) (*[]FirmwareComponent, *Response, error) {
+	return findAll[FirmwareComponent](c.Client, "/FirmwareComponent", firmwareComponentAPIVersion, opt, options...)
+}
+
 // Update updates a standard service
 func (c *FirmwareComponentsService) Update(ac FirmwareComponent) (*FirmwareComponent, *Response, error) {
 	ac.ResourceType = "FirmwareComponent"
@@ -1,10 +1,14 @@
 package mdm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/cenkalti/backoff/v4"
+	"github.com/dip-software/go-dip-api/connect/wait"
 	"github.com/dip-software/go-dip-api/internal"
 	"github.com/go-playground/validator/v10"
 )
@@ -35,6 +39,7 @@ type FirmwareDistributionRequest struct {
 	OrchestrationMode         string      `json:"orchestrationMode" validate:"required,oneof=none continuous snapshot"`
 	FirmwareComponentVersions []Reference `json:"firmwareComponentVersions" validate:"required,min=1,max=5"`
 	Description               string      `json:"description" validate:"omitempty,max=250"`
+	ErrorMessage              string      `json:"errorMessage,omitempty"`
 }
 
 // Create creates a FirmwareDistributionRequest
@@ -129,6 +134,11 @@ func (c *FirmwareDistributionRequestsService) Find(opt *GetFirmwareDistributionR
 	return &resources, resp, err
 }
 
+// FindAll looks up firmware distribution requests based on GetFirmwareDistributionRequestOptions, retrieving all result pages
+func (c *FirmwareDistributionRequestsService) FindAll(opt *GetFirmwareDistributionRequestOptions, options ...OptionFunc) (*[]FirmwareDistributionRequest, *Response, error) {
+	return findAll[FirmwareDistributionRequest](c.Client, "/FirmwareDistributionRequest", firmwareDistributionRequestAPIVersion, opt, options...)
+}
+
 // Update updates a standard service
 func (c *FirmwareDistributionRequestsService) Update(ac FirmwareDistributionRequest) (*FirmwareDistributionRequest, *Response, error) {
 	ac.ResourceType = "FirmwareDistributionRequest"
@@ -149,3 +159,51 @@ func (c *FirmwareDistributionRequestsService) Update(ac FirmwareDistributionRequ
 	}
 	return &updated, resp, nil
 }
+
+func (c *FirmwareDistributionRequestsService) get(id string) (*FirmwareDistributionRequest, error) {
+	found, resp, err := c.GetByID(id)
+	if err != nil {
+		if resp != nil && resp.StatusCode() == http.StatusNotFound {
+			return nil, wait.ErrNotFound
+		}
+		return nil, err
+	}
+	return found, nil
+}
+
+// WaitUntilStatus polls the FirmwareDistributionRequest until its status is one of statuses.
+// An InError status is returned as a *wait.StatusError carrying the request ID and error
+// message, which matches ErrFirmwareDistributionRequestInError. A nil bo uses wait.DefaultBackOff
+func (c *FirmwareDistributionRequestsService) WaitUntilStatus(ctx context.Context, id string, bo backoff.BackOff, statuses ...string) (*FirmwareDistributionRequest, error) {
+	return wait.WaitFor(ctx, func(ctx context.Context) (*FirmwareDistributionRequest, error) {
+		return c.get(id)
+	}, func(fdr *FirmwareDistributionRequest) (bool, error) {
+		if fdr.Status == "InError" {
+			message := "request " + fdr.ID
+			if fdr.ErrorMessage != "" {
+				message += ": " + fdr.ErrorMessage
+			}
+			return false, &wait.StatusError{Status: fdr.Status, Message: message, Err: ErrFirmwareDistributionRequestInError}
+		}
+		for _, s := range statuses {
+			if strings.EqualFold(fdr.Status, s) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}, bo)
+}
+
+// WaitUntilActive polls the FirmwareDistributionRequest until its status is Active
+func (c *FirmwareDistributionRequestsService) WaitUntilActive(ctx context.Context, id string, bo backoff.BackOff) (*FirmwareDistributionRequest, error) {
+	return c.WaitUntilStatus(ctx, id, bo, "Active")
+}
+
+// WaitUntilDeleted polls the FirmwareDistributionRequest until it no longer exists
+func (c *FirmwareDistributionRequestsService) WaitUntilDeleted(ctx context.Context, id string, bo backoff.BackOff) error {
+	get, gone := wait.UntilGone(func(ctx context.Context) (*FirmwareDistributionRequest, error) {
+		return c.get(id)
+	})
+	_, err := wait.WaitFor(ctx, get, gone, bo)
+	return err
+}
@@ -0,0 +1,61 @@
+package mdm_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/dip-software/go-dip-api/connect/mdm"
+	"github.com/dip-software/go-dip-api/connect/wait"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirmwareDistributionRequestWaitUntil(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	id := "0b1e3c8d-7a2f-4d4e-9f1a-5c6b7d8e9f00"
+	statuses := []string{"Pending", "Active", "InError"}
+	calls := 0
+	muxMDM.HandleFunc("/connect/mdm/FirmwareDistributionRequest/"+id, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls >= len(statuses) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"resourceType": "OperationOutcome", "issue": []}`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, `{
+  "resourceType": "FirmwareDistributionRequest",
+  "id": "`+id+`",
+  "status": "`+statuses[calls]+`",
+  "firmwareVersion": "1.0.0",
+  "orchestrationMode": "none",
+  "errorMessage": "`+map[string]string{"InError": "device unreachable"}[statuses[calls]]+`"
+}`)
+		calls++
+	})
+	bo := backoff.NewConstantBackOff(time.Millisecond)
+
+	fdr, err := mdmClient.FirmwareDistributionRequests.WaitUntilActive(context.Background(), id, bo)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "Active", fdr.Status)
+
+	fdr, err = mdmClient.FirmwareDistributionRequests.WaitUntilStatus(context.Background(), id, bo, "Cancelled")
+	assert.ErrorIs(t, err, wait.ErrInError)
+	assert.ErrorIs(t, err, mdm.ErrFirmwareDistributionRequestInError)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "request "+id+": device unreachable")
+	}
+	if assert.NotNil(t, fdr) {
+		assert.Equal(t, "InError", fdr.Status)
+	}
+
+	err = mdmClient.FirmwareDistributionRequests.WaitUntilDeleted(context.Background(), id, bo)
+	assert.Nil(t, err)
+}
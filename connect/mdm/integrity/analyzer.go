@@ -0,0 +1,392 @@
+package integrity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/dip-software/go-dip-api/connect/mdm"
+)
+
+const defaultHeadroom = 1
+
+// unusedKinds are the kinds which only exist to be referenced by other resources
+var unusedKinds = map[string]bool{
+	"AuthenticationMethod": true,
+	"StandardService":      true,
+	"ServiceAction":        true,
+	"DeviceGroup":          true,
+	"DataType":             true,
+	"Bucket":               true,
+	"FirmwareComponent":    true,
+}
+
+// Options control the checks of an Analyzer
+type Options struct {
+	// Headroom is the number of resources per kind which must still fit in the resource
+	// limits, counting the resources of the analyzed proposition only. Defaults to 1,
+	// i.e. it must be possible to create one more
+	Headroom int
+}
+
+// Limit is the usage of a MDM resource limit by the proposition. MDM applies the limits to
+// the whole organization, so Count only covers the analyzed proposition and Remaining is an
+// upper bound when the organization has other propositions
+type Limit struct {
+	Kind string `json:"kind"`
+	// Count is the number of resources of this kind in the proposition
+	Count     int  `json:"count"`
+	Limit     int  `json:"limit"`
+	Remaining int  `json:"remaining"`
+	Exceeded  bool `json:"exceeded"`
+}
+
+// Report is the outcome of an integrity check
+type Report struct {
+	// Dangling are references to resources which do not exist
+	Dangling []Edge `json:"dangling,omitempty"`
+	// Unused are resources of the proposition which nothing references
+	Unused []Node `json:"unused,omitempty"`
+	// DeleteOrder lists the proposition resources, dependents first
+	DeleteOrder []string `json:"deleteOrder,omitempty"`
+	Limits      []Limit  `json:"limits,omitempty"`
+}
+
+// Err returns an error when there are dangling references or exceeded limits
+func (r *Report) Err() error {
+	if len(r.Dangling) > 0 {
+		return fmt.Errorf("%w: %d found", ErrDanglingReference, len(r.Dangling))
+	}
+	for _, l := range r.Limits {
+		if l.Exceeded {
+			return fmt.Errorf("%w: %s %d/%d", ErrLimitExceeded, l.Kind, l.Count, l.Limit)
+		}
+	}
+	return nil
+}
+
+// String renders the report for humans
+func (r *Report) String() string {
+	var b strings.Builder
+	for _, e := range r.Dangling {
+		_, _ = fmt.Fprintf(&b, "dangling: %s.%s -> %s\n", e.From, e.Field, e.To)
+	}
+	for _, n := range r.Unused {
+		_, _ = fmt.Fprintf(&b, "unused:   %s (%s)\n", n.Key(), n.Name)
+	}
+	for _, l := range r.Limits {
+		if l.Exceeded {
+			_, _ = fmt.Fprintf(&b, "limit:    %s %d/%d\n", l.Kind, l.Count, l.Limit)
+		}
+	}
+	if len(r.DeleteOrder) > 0 {
+		b.WriteString("delete order:\n")
+		for i, key := range r.DeleteOrder {
+			_, _ = fmt.Fprintf(&b, "  %d. %s\n", i+1, key)
+		}
+	}
+	return b.String()
+}
+
+// Analyzer loads and checks the MDM resources of propositions
+type Analyzer struct {
+	client  *mdm.Client
+	opts    Options
+	lookups map[string]func(id string) (string, bool, error)
+}
+
+// NewAnalyzer returns an analyzer which uses client to read MDM resources
+func NewAnalyzer(client *mdm.Client, opts Options) (*Analyzer, error) {
+	if client == nil {
+		return nil, ErrMissingMDMClient
+	}
+	if opts.Headroom <= 0 {
+		opts.Headroom = defaultHeadroom
+	}
+	a := &Analyzer{client: client, opts: opts}
+	a.lookups = map[string]func(string) (string, bool, error){
+		"Proposition":                 lookup(client.Propositions.GetPropositionByID),
+		"Application":                 lookup(client.Applications.GetApplicationByID),
+		"AuthenticationMethod":        lookup(client.AuthenticationMethods.GetByID),
+		"StandardService":             lookup(client.StandardServices.GetStandardServiceByID),
+		"ServiceAction":               lookup(client.ServiceActions.GetByID),
+		"ServiceReference":            lookup(client.ServiceReferences.GetByID),
+		"OAuthClient":                 lookup(client.OAuthClients.GetOAuthClientByID),
+		"DeviceGroup":                 lookup(client.DeviceGroups.GetByID),
+		"DeviceType":                  lookup(client.DeviceTypes.GetByID),
+		"DataType":                    lookup(client.DataTypes.GetByID),
+		"Bucket":                      lookup(client.Buckets.GetByID),
+		"BlobDataContract":            lookup(client.BlobDataContracts.GetByID),
+		"BlobSubscription":            lookup(client.BlobSubscriptions.GetByID),
+		"DataBrokerSubscription":      lookup(client.DataBrokerSubscriptions.GetByID),
+		"FirmwareComponent":           lookup(client.FirmwareComponents.GetByID),
+		"FirmwareComponentVersion":    lookup(client.FirmwareComponentVersions.GetByID),
+		"FirmwareDistributionRequest": lookup(client.FirmwareDistributionRequests.GetByID),
+		"Region":                      lookup(client.Regions.GetRegionByID),
+		"StorageClass":                lookup(client.StorageClasses.GetStorageClassByID),
+		"DataSubscriber":              lookup(client.DataSubscribers.GetByID),
+		"DataAdapter":                 lookup(client.DataAdapters.GetByID),
+		"ServiceAgent":                lookup(client.ServiceAgents.GetByID),
+		"SubscriberType":              lookup(client.SubscriberTypes.GetByID),
+	}
+	return a, nil
+}
+
+// lookup turns a GetByID call into an existence check which also returns the name
+func lookup[T any](get func(string) (*T, *mdm.Response, error)) func(string) (string, bool, error) {
+	return func(id string) (string, bool, error) {
+		resource, resp, err := get(id)
+		if err != nil {
+			// Lookups by ID are either direct reads or _id searches
+			if (resp != nil && resp.StatusCode() == http.StatusNotFound) ||
+				errors.Is(err, mdm.ErrEmptyResult) || errors.Is(err, mdm.ErrNotFound) {
+				return "", false, nil
+			}
+			return "", false, err
+		}
+		if resource == nil {
+			return "", false, nil
+		}
+		data, err := json.Marshal(resource)
+		if err != nil {
+			return "", true, nil
+		}
+		var named struct {
+			Name string `json:"name"`
+		}
+		_ = json.Unmarshal(data, &named)
+		return named.Name, true, nil
+	}
+}
+
+// add adds the result of a Find call to the graph and returns the IDs of the resources
+func add[T any](g *Graph, kind string, list *[]T, err error) ([]string, error) {
+	if err != nil {
+		return nil, fmt.Errorf("find %s: %w", kind, err)
+	}
+	if list == nil {
+		return nil, nil
+	}
+	ids := make([]string, 0, len(*list))
+	for _, resource := range *list {
+		node, err := g.Add(kind, resource)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, node.ID)
+	}
+	return ids, nil
+}
+
+// Load reads the proposition and the resources which belong to it into a graph, following all result pages
+func (a *Analyzer) Load(propositionID string) (*Graph, error) {
+	if propositionID == "" {
+		return nil, ErrMissingProposition
+	}
+	c := a.client
+	g := NewGraph()
+	proposition, _, err := c.Propositions.GetPropositionByID(propositionID)
+	if err != nil {
+		return nil, fmt.Errorf("proposition %s: %w", propositionID, err)
+	}
+	if proposition == nil {
+		return nil, fmt.Errorf("%w: %s", ErrMissingProposition, propositionID)
+	}
+	if _, err := g.Add("Proposition", proposition); err != nil {
+		return nil, err
+	}
+
+	applications, _, err := c.Applications.GetAllApplications(&mdm.GetApplicationsOptions{PropositionID: &propositionID})
+	applicationIDs, err := add(g, "Application", applications, err)
+	if err != nil {
+		return nil, err
+	}
+	buckets, _, err := c.Buckets.FindAll(&mdm.GetBucketOptions{PropositionID: &propositionID})
+	if _, err := add(g, "Bucket", buckets, err); err != nil {
+		return nil, err
+	}
+	dataTypes, _, err := c.DataTypes.FindAll(&mdm.GetDataTypeOptions{PropositionID: &propositionID})
+	if _, err := add(g, "DataType", dataTypes, err); err != nil {
+		return nil, err
+	}
+	subscriptions, _, err := c.DataBrokerSubscriptions.FindAll(&mdm.GetDataBrokerSubscriptionOptions{PropositionID: &propositionID})
+	if _, err := add(g, "DataBrokerSubscription", subscriptions, err); err != nil {
+		return nil, err
+	}
+
+	var standardServiceIDs []string
+	for _, id := range applicationIDs {
+		authenticationMethods, _, err := c.AuthenticationMethods.FindAll(&mdm.GetAuthenticationMethodOptions{ApplicationID: &id})
+		if _, err := add(g, "AuthenticationMethod", authenticationMethods, err); err != nil {
+			return nil, err
+		}
+		standardServices, _, err := c.StandardServices.GetAllStandardServices(&mdm.GetStandardServiceOptions{ApplicationID: &id})
+		ids, err := add(g, "StandardService", standardServices, err)
+		if err != nil {
+			return nil, err
+		}
+		standardServiceIDs = append(standardServiceIDs, ids...)
+		serviceReferences, _, err := c.ServiceReferences.FindAll(&mdm.GetServiceReferenceOptions{ApplicationID: &id})
+		if _, err := add(g, "ServiceReference", serviceReferences, err); err != nil {
+			return nil, err
+		}
+		oauthClients, _, err := c.OAuthClients.GetAllOAuthClients(&mdm.GetOAuthClientsOptions{ApplicationID: &id})
+		if _, err := add(g, "OAuthClient", oauthClients, err); err != nil {
+			return nil, err
+		}
+		deviceGroups, _, err := c.DeviceGroups.FindAll(&mdm.GetDeviceGroupOptions{ApplicationID: &id})
+		if _, err := add(g, "DeviceGroup", deviceGroups, err); err != nil {
+			return nil, err
+		}
+		deviceTypes, _, err := c.DeviceTypes.FindAll(&mdm.GetDeviceTypeOptions{ApplicationID: &id})
+		if _, err := add(g, "DeviceType", deviceTypes, err); err != nil {
+			return nil, err
+		}
+		components, _, err := c.FirmwareComponents.FindAll(&mdm.GetFirmwareComponentOptions{ApplicationID: &id})
+		if _, err := add(g, "FirmwareComponent", components, err); err != nil {
+			return nil, err
+		}
+		versions, _, err := c.FirmwareComponentVersions.FindAll(&mdm.GetFirmwareComponentVersionOptions{ApplicationID: &id})
+		if _, err := add(g, "FirmwareComponentVersion", versions, err); err != nil {
+			return nil, err
+		}
+		distributions, _, err := c.FirmwareDistributionRequests.FindAll(&mdm.GetFirmwareDistributionRequestOptions{ApplicationID: &id})
+		if _, err := add(g, "FirmwareDistributionRequest", distributions, err); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range standardServiceIDs {
+		actions, _, err := c.ServiceActions.FindAll(&mdm.GetServiceActionOptions{StandardServiceID: &id})
+		if _, err := add(g, "ServiceAction", actions, err); err != nil {
+			return nil, err
+		}
+	}
+
+	// Contracts and blob subscriptions can not be searched by proposition, so keep the
+	// ones which use a data type of the proposition
+	contracts, _, err := c.BlobDataContracts.FindAll(nil)
+	if err != nil {
+		return nil, fmt.Errorf("find BlobDataContract: %w", err)
+	}
+	if contracts != nil {
+		for _, contract := range *contracts {
+			if _, ok := g.nodes[contract.DataTypeID.Reference]; ok {
+				if _, err := g.Add("BlobDataContract", contract); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	blobSubscriptions, _, err := c.BlobSubscriptions.FindAll(nil)
+	if err != nil {
+		return nil, fmt.Errorf("find BlobSubscription: %w", err)
+	}
+	if blobSubscriptions != nil {
+		for _, subscription := range *blobSubscriptions {
+			if _, ok := g.nodes[subscription.DataTypeId.Reference]; ok {
+				if _, err := g.Add("BlobSubscription", subscription); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return g, nil
+}
+
+// Check resolves the references of the graph which point outside of it and reports the findings.
+// Resolved references are added to the graph as external or missing nodes
+func (a *Analyzer) Check(g *Graph) (*Report, error) {
+	report := &Report{}
+	for _, key := range g.unresolved() {
+		kind, id, _ := strings.Cut(key, "/")
+		get, ok := a.lookups[kind]
+		if !ok {
+			// Unknown kinds can not be verified
+			g.addReferenced(key, "", false)
+			continue
+		}
+		name, found, err := get(id)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", key, err)
+		}
+		g.addReferenced(key, name, !found)
+	}
+	for _, e := range g.edges {
+		if n := g.nodes[e.To]; n != nil && n.Missing {
+			report.Dangling = append(report.Dangling, e)
+		}
+	}
+	for _, n := range g.Nodes() {
+		if unusedKinds[n.Kind] && !n.External && !n.Missing && len(g.Dependents(n.Key())) == 0 {
+			report.Unused = append(report.Unused, n)
+		}
+	}
+	order, err := g.DeleteOrder()
+	if err != nil {
+		return nil, err
+	}
+	report.DeleteOrder = order
+
+	limits, err := a.limits()
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, n := range g.nodes {
+		if !n.External && !n.Missing {
+			counts[n.Kind]++
+		}
+	}
+	kinds := make([]string, 0, len(limits))
+	for kind := range limits {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		l := Limit{Kind: kind, Count: counts[kind], Limit: limits[kind]}
+		l.Remaining = l.Limit - l.Count
+		l.Exceeded = l.Remaining < a.opts.Headroom
+		report.Limits = append(report.Limits, l)
+	}
+	return report, nil
+}
+
+// limits returns the default resource limits with the overrides applied
+func (a *Analyzer) limits() (mdm.ResourcesLimits, error) {
+	limits := make(mdm.ResourcesLimits)
+	defaults, _, err := a.client.ResourcesLimits.GetDefault()
+	if err != nil {
+		return nil, fmt.Errorf("default resource limits: %w", err)
+	}
+	if defaults != nil {
+		for kind, limit := range *defaults {
+			limits[kind] = limit
+		}
+	}
+	overrides, resp, err := a.client.ResourcesLimits.GetOverride()
+	if err != nil && (resp == nil || resp.StatusCode() != http.StatusNotFound) {
+		return nil, fmt.Errorf("resource limit overrides: %w", err)
+	}
+	if overrides != nil {
+		for kind, limit := range *overrides {
+			limits[kind] = limit
+		}
+	}
+	return limits, nil
+}
+
+// Analyze loads the proposition and checks it
+func (a *Analyzer) Analyze(propositionID string) (*Graph, *Report, error) {
+	g, err := a.Load(propositionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	report, err := a.Check(g)
+	if err != nil {
+		return g, nil, err
+	}
+	return g, report, nil
+}
@@ -0,0 +1,217 @@
+package integrity_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/connect/mdm"
+	"github.com/dip-software/go-dip-api/connect/mdm/integrity"
+	"github.com/dip-software/go-dip-api/iam"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const resourcesJSON = `[
+  {"resourceType": "Proposition", "id": "p1", "name": "prop"},
+  {"resourceType": "Application", "id": "a1", "name": "app", "propositionId": {"reference": "Proposition/p1"}},
+  {"resourceType": "AuthenticationMethod", "id": "am1", "name": "used-auth"},
+  {"resourceType": "AuthenticationMethod", "id": "am2", "name": "unused-auth"},
+  {"resourceType": "StandardService", "id": "ss1", "name": "service",
+   "serviceUrls": [{"url": "https://service.example.com", "sortOrder": 1, "AuthenticationMethodId": {"reference": "AuthenticationMethod/am1"}}]},
+  {"resourceType": "ServiceAction", "id": "sa1", "name": "action", "standardServiceId": {"reference": "StandardService/ss1"}},
+  {"resourceType": "DeviceGroup", "id": "dg1", "name": "group", "applicationId": {"reference": "Application/a1"}},
+  {"resourceType": "DeviceType", "id": "dt1", "name": "type", "deviceGroupId": {"reference": "DeviceGroup/dg1"}},
+  {"resourceType": "DeviceType", "id": "dt2", "name": "orphan", "deviceGroupId": {"reference": "DeviceGroup/gone"}},
+  {"resourceType": "DataType", "id": "dat1", "name": "data", "propositionId": {"reference": "Proposition/p1"}},
+  {"resourceType": "Bucket", "id": "b1", "name": "bucket", "propositionId": {"reference": "Proposition/p1"}, "defaultRegionId": {"reference": "Region/r1"}},
+  {"resourceType": "Region", "id": "r1", "name": "eu-west-1"},
+  {"resourceType": "StorageClass", "id": "sc1", "name": "Standard"},
+  {"resourceType": "BlobDataContract", "id": "c2", "name": "other", "dataTypeId": {"reference": "DataType/other"},
+   "bucketId": {"reference": "Bucket/other"}, "storageClassId": {"reference": "StorageClass/sc1"}},
+  {"resourceType": "BlobDataContract", "id": "c1", "name": "contract", "dataTypeId": {"reference": "DataType/dat1"},
+   "bucketId": {"reference": "Bucket/b1"}, "storageClassId": {"reference": "StorageClass/sc1"}}
+]`
+
+// fakeMDM serves resources by ID and searches on _id or any field. A reference field matches the ID it points to.
+// Searches return one resource per page
+type fakeMDM struct {
+	resources []map[string]interface{}
+}
+
+func matches(resource map[string]interface{}, key, value string) bool {
+	if key == "_id" {
+		return resource["id"] == value
+	}
+	switch field := resource[key].(type) {
+	case nil:
+		return true
+	case string:
+		return field == value
+	case map[string]interface{}:
+		ref, _ := field["reference"].(string)
+		return strings.HasSuffix(ref, "/"+value)
+	}
+	return false
+}
+
+func (f *fakeMDM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/connect/mdm/"), "/")
+	switch {
+	case parts[0] == "ResourcesLimit":
+		if parts[1] == "$default" {
+			_, _ = io.WriteString(w, `{"DeviceType": 10, "Application": 5}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"DeviceType": 2}`)
+	case len(parts) == 2:
+		for _, resource := range f.resources {
+			if resource["resourceType"] == parts[0] && resource["id"] == parts[1] {
+				_ = json.NewEncoder(w).Encode(resource)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"resourceType": "OperationOutcome", "issue": []}`)
+	default:
+		var entries []map[string]interface{}
+		for _, resource := range f.resources {
+			if resource["resourceType"] != parts[0] {
+				continue
+			}
+			ok := true
+			for key, values := range r.URL.Query() {
+				ok = ok && (key == "_page" || matches(resource, key, values[0]))
+			}
+			if ok {
+				entries = append(entries, map[string]interface{}{"resource": resource})
+			}
+		}
+		bundle := map[string]interface{}{"resourceType": "Bundle", "type": "searchset", "entry": entries}
+		if len(entries) > 0 {
+			query := r.URL.Query()
+			page, _ := strconv.Atoi(query.Get("_page"))
+			page = max(page, 1)
+			bundle["entry"] = entries[page-1 : page]
+			if page < len(entries) {
+				query.Set("_page", strconv.Itoa(page+1))
+				bundle["link"] = []map[string]string{{"relation": "next", "url": "/connect/mdm/" + parts[0] + "?" + query.Encode()}}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(bundle)
+	}
+}
+
+func setup(t *testing.T) (*mdm.Client, func()) {
+	muxIAM := http.NewServeMux()
+	serverIAM := httptest.NewServer(muxIAM)
+	fake := &fakeMDM{}
+	require.NoError(t, json.Unmarshal([]byte(resourcesJSON), &fake.resources))
+	serverMDM := httptest.NewServer(fake)
+
+	muxIAM.HandleFunc("/authorize/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+    "scope": "mail",
+    "access_token": "44d20214-7879-4e35-923d-f9d4e01c9746",
+    "refresh_token": "31f1a449-ef8e-4bfc-a227-4f2353fde547",
+    "expires_in": 1799,
+    "token_type": "Bearer"
+}`)
+	})
+	muxIAM.HandleFunc("/authorize/oauth2/introspect", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+  "active": true,
+  "username": "ronswanson",
+  "sub": "33d20214-7879-4e35-923d-f9d4e01c9746",
+  "organizations": {
+    "managingOrganization": "22d20214-7879-4e35-923d-f9d4e01c9746",
+    "organizationList": []
+  },
+  "client_id": "testclientid",
+  "token_type": "Bearer",
+  "identity_type": "user"
+}`)
+	})
+
+	iamClient, err := iam.NewClient(nil, &iam.Config{
+		OAuth2ClientID: "TestClient",
+		OAuth2Secret:   "Secret",
+		IAMURL:         serverIAM.URL,
+		IDMURL:         serverIAM.URL,
+	})
+	require.NoError(t, err)
+	require.NoError(t, iamClient.Login("username", "password"))
+	mdmClient, err := mdm.NewClient(iamClient, &mdm.Config{
+		BaseURL: serverMDM.URL + "/connect/mdm",
+	})
+	require.NoError(t, err)
+	return mdmClient, func() {
+		serverIAM.Close()
+		serverMDM.Close()
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	client, teardown := setup(t)
+	defer teardown()
+
+	_, err := integrity.NewAnalyzer(nil, integrity.Options{})
+	assert.ErrorIs(t, err, integrity.ErrMissingMDMClient)
+	analyzer, err := integrity.NewAnalyzer(client, integrity.Options{})
+	require.NoError(t, err)
+
+	g, report, err := analyzer.Analyze("p1")
+	require.NoError(t, err)
+
+	_, ok := g.Node("BlobDataContract/c1")
+	assert.True(t, ok)
+	_, ok = g.Node("BlobDataContract/c2")
+	assert.False(t, ok, "contract of another proposition")
+	region, ok := g.Node("Region/r1")
+	if assert.True(t, ok) {
+		assert.True(t, region.External)
+		assert.Equal(t, "eu-west-1", region.Name)
+	}
+
+	if assert.Len(t, report.Dangling, 1) {
+		assert.Equal(t, integrity.Edge{From: "DeviceType/dt2", To: "DeviceGroup/gone", Field: "deviceGroupId"}, report.Dangling[0])
+	}
+	var unused []string
+	for _, n := range report.Unused {
+		unused = append(unused, n.Key())
+	}
+	assert.Equal(t, []string{"AuthenticationMethod/am2", "ServiceAction/sa1"}, unused)
+
+	position := make(map[string]int)
+	for i, key := range report.DeleteOrder {
+		position[key] = i
+	}
+	assert.NotContains(t, position, "Region/r1")
+	assert.Less(t, position["DeviceType/dt1"], position["DeviceGroup/dg1"])
+	assert.Less(t, position["DeviceGroup/dg1"], position["Application/a1"])
+	assert.Less(t, position["BlobDataContract/c1"], position["Bucket/b1"])
+	assert.Less(t, position["ServiceAction/sa1"], position["StandardService/ss1"])
+	assert.Less(t, position["StandardService/ss1"], position["AuthenticationMethod/am1"])
+	assert.Equal(t, "Proposition/p1", report.DeleteOrder[len(report.DeleteOrder)-1])
+
+	assert.Equal(t, []integrity.Limit{
+		{Kind: "Application", Count: 1, Limit: 5, Remaining: 4},
+		{Kind: "DeviceType", Count: 2, Limit: 2, Remaining: 0, Exceeded: true},
+	}, report.Limits)
+	assert.ErrorIs(t, report.Err(), integrity.ErrDanglingReference)
+	assert.Contains(t, report.String(), "dangling: DeviceType/dt2.deviceGroupId -> DeviceGroup/gone")
+	assert.Contains(t, report.String(), "limit:    DeviceType 2/2")
+
+	report.Dangling = nil
+	assert.ErrorIs(t, report.Err(), integrity.ErrLimitExceeded)
+
+	_, err = analyzer.Load("")
+	assert.ErrorIs(t, err, integrity.ErrMissingProposition)
+}
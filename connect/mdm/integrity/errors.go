@@ -0,0 +1,15 @@
+package integrity
+
+import (
+	"errors"
+)
+
+// Exported Errors
+var (
+	ErrMissingMDMClient   = errors.New("missing MDM client")
+	ErrMissingProposition = errors.New("missing proposition")
+	ErrMissingID          = errors.New("resource has no id")
+	ErrDependencyCycle    = errors.New("dependency cycle")
+	ErrDanglingReference  = errors.New("dangling reference")
+	ErrLimitExceeded      = errors.New("resource limit headroom exceeded")
+)
@@ -0,0 +1,263 @@
+// Package integrity analyzes the references between the MDM resources of a proposition. It
+// reports dangling references, unused resources, a safe delete order and the resource limit
+// headroom left by the proposition
+package integrity
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Node is a MDM resource in the dependency graph. Its key is Kind/ID, which is also
+// the MDM reference format
+type Node struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// External nodes are referenced by the proposition but live outside of it, e.g. a Region
+	External bool `json:"external,omitempty"`
+	// Missing nodes are referenced but do not exist
+	Missing bool `json:"missing,omitempty"`
+}
+
+// Key returns the reference of the node, e.g. DeviceGroup/<id>
+func (n Node) Key() string {
+	return n.Kind + "/" + n.ID
+}
+
+// Edge is a reference from one resource to another
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Field is the JSON field which holds the reference
+	Field string `json:"field"`
+}
+
+// Graph is the dependency graph of MDM resources
+type Graph struct {
+	nodes map[string]*Node
+	edges []Edge
+}
+
+// NewGraph returns an empty graph
+func NewGraph() *Graph {
+	return &Graph{nodes: make(map[string]*Node)}
+}
+
+// Add adds a MDM resource of the given kind and an edge for every Reference it contains.
+// Adding a resource which is already in the graph returns the existing node
+func (g *Graph) Add(kind string, resource interface{}) (*Node, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	id, _ := fields["id"].(string)
+	if id == "" {
+		return nil, fmt.Errorf("%w: %s", ErrMissingID, kind)
+	}
+	node := &Node{Kind: kind, ID: id}
+	if existing, ok := g.nodes[node.Key()]; ok {
+		return existing, nil
+	}
+	node.Name, _ = fields["name"].(string)
+	g.nodes[node.Key()] = node
+
+	var refs []Edge
+	collectReferences(fields, "", func(field, reference string) {
+		refs = append(refs, Edge{From: node.Key(), To: reference, Field: field})
+	})
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Field != refs[j].Field {
+			return refs[i].Field < refs[j].Field
+		}
+		return refs[i].To < refs[j].To
+	})
+	g.edges = append(g.edges, refs...)
+	return node, nil
+}
+
+// collectReferences walks the decoded JSON of a resource and reports every {"reference": "Kind/id"} value
+func collectReferences(value interface{}, field string, found func(field, reference string)) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["reference"].(string); ok && len(v) == 1 {
+			if strings.Contains(ref, "/") {
+				found(field, ref)
+			}
+			return
+		}
+		for key, child := range v {
+			if key == "meta" {
+				continue
+			}
+			collectReferences(child, key, found)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectReferences(child, field, found)
+		}
+	}
+}
+
+// Node returns the node with the given key
+func (g *Graph) Node(key string) (*Node, bool) {
+	n, ok := g.nodes[key]
+	return n, ok
+}
+
+// Nodes returns all nodes ordered by key
+func (g *Graph) Nodes() []Node {
+	nodes := make([]Node, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		nodes = append(nodes, *n)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Key() < nodes[j].Key()
+	})
+	return nodes
+}
+
+// Edges returns all references
+func (g *Graph) Edges() []Edge {
+	return append([]Edge(nil), g.edges...)
+}
+
+// Dependents returns the keys of the resources which reference key
+func (g *Graph) Dependents(key string) []string {
+	var dependents []string
+	seen := make(map[string]bool)
+	for _, e := range g.edges {
+		if e.To == key && !seen[e.From] {
+			seen[e.From] = true
+			dependents = append(dependents, e.From)
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
+// Dependencies returns the keys of the resources key references
+func (g *Graph) Dependencies(key string) []string {
+	var dependencies []string
+	seen := make(map[string]bool)
+	for _, e := range g.edges {
+		if e.From == key && !seen[e.To] {
+			seen[e.To] = true
+			dependencies = append(dependencies, e.To)
+		}
+	}
+	sort.Strings(dependencies)
+	return dependencies
+}
+
+// unresolved returns the referenced keys which are not part of the graph
+func (g *Graph) unresolved() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, e := range g.edges {
+		if _, ok := g.nodes[e.To]; !ok && !seen[e.To] {
+			seen[e.To] = true
+			keys = append(keys, e.To)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (g *Graph) addReferenced(key string, name string, missing bool) {
+	kind, id, _ := strings.Cut(key, "/")
+	g.nodes[key] = &Node{Kind: kind, ID: id, Name: name, External: !missing, Missing: missing}
+}
+
+// DeleteOrder returns the keys of the proposition resources in an order in which they
+// can be deleted: every resource comes before the resources it references. External and
+// missing nodes are left out
+func (g *Graph) DeleteOrder() ([]string, error) {
+	pending := make(map[string]int)
+	for key, n := range g.nodes {
+		if !n.External && !n.Missing {
+			pending[key] = 0
+		}
+	}
+	for _, e := range g.edges {
+		if _, ok := pending[e.To]; ok && e.From != e.To {
+			if _, ok := pending[e.From]; ok {
+				pending[e.To]++
+			}
+		}
+	}
+	var order []string
+	for len(pending) > 0 {
+		var ready []string
+		for key, dependents := range pending {
+			if dependents == 0 {
+				ready = append(ready, key)
+			}
+		}
+		if len(ready) == 0 {
+			var cycle []string
+			for key := range pending {
+				cycle = append(cycle, key)
+			}
+			sort.Strings(cycle)
+			return order, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, ", "))
+		}
+		sort.Strings(ready)
+		for _, key := range ready {
+			delete(pending, key)
+			for _, e := range g.edges {
+				if e.From == key && e.To != key {
+					if _, ok := pending[e.To]; ok {
+						pending[e.To]--
+					}
+				}
+			}
+		}
+		order = append(order, ready...)
+	}
+	return order, nil
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. External nodes are dashed and
+// missing nodes are red
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph mdm {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, n := range g.Nodes() {
+		label := n.Kind + "\n" + n.Name
+		if n.Name == "" {
+			label = n.Kind + "\n" + n.ID
+		}
+		attrs := fmt.Sprintf("label=%q", label)
+		switch {
+		case n.Missing:
+			attrs += `, color=red, fontcolor=red`
+		case n.External:
+			attrs += `, style=dashed`
+		}
+		_, _ = fmt.Fprintf(&b, "  %q [%s];\n", n.Key(), attrs)
+	}
+	for _, e := range g.edges {
+		_, _ = fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", e.From, e.To, e.Field)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the nodes and edges of the graph as JSON
+func (g *Graph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Nodes []Node `json:"nodes"`
+		Edges []Edge `json:"edges"`
+	}{g.Nodes(), g.Edges()})
+}
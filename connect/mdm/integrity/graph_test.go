@@ -0,0 +1,58 @@
+package integrity_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/connect/mdm"
+	"github.com/dip-software/go-dip-api/connect/mdm/integrity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph(t *testing.T) {
+	g := integrity.NewGraph()
+	_, err := g.Add("DeviceGroup", mdm.DeviceGroup{ID: "dg1", Name: "group", ApplicationId: mdm.Reference{Reference: "Application/a1"}})
+	require.NoError(t, err)
+	_, err = g.Add("Application", mdm.Application{ID: "a1", Name: "app", PropositionID: mdm.Reference{Reference: "Proposition/p1"}})
+	require.NoError(t, err)
+	_, err = g.Add("DeviceType", mdm.DeviceType{ID: "dt1", Name: "type", DeviceGroupId: mdm.Reference{Reference: "DeviceGroup/dg1"}})
+	require.NoError(t, err)
+	_, err = g.Add("Proposition", mdm.Proposition{ID: "p1", Name: "prop"})
+	require.NoError(t, err)
+	_, err = g.Add("DeviceType", mdm.DeviceType{Name: "no id"})
+	assert.ErrorIs(t, err, integrity.ErrMissingID)
+
+	assert.Equal(t, []string{"DeviceType/dt1"}, g.Dependents("DeviceGroup/dg1"))
+	assert.Equal(t, []string{"Application/a1"}, g.Dependencies("DeviceGroup/dg1"))
+
+	order, err := g.DeleteOrder()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DeviceType/dt1", "DeviceGroup/dg1", "Application/a1", "Proposition/p1"}, order)
+
+	var dot bytes.Buffer
+	require.NoError(t, g.WriteDOT(&dot))
+	assert.Contains(t, dot.String(), `"DeviceType/dt1" -> "DeviceGroup/dg1" [label="deviceGroupId"];`)
+	assert.Contains(t, dot.String(), `"Application/a1" [label="Application\napp"];`)
+
+	var out bytes.Buffer
+	require.NoError(t, g.WriteJSON(&out))
+	var decoded struct {
+		Nodes []integrity.Node `json:"nodes"`
+		Edges []integrity.Edge `json:"edges"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Len(t, decoded.Nodes, 4)
+	assert.Len(t, decoded.Edges, 3)
+}
+
+func TestDeleteOrderCycle(t *testing.T) {
+	g := integrity.NewGraph()
+	_, err := g.Add("DeviceGroup", mdm.DeviceGroup{ID: "dg1", ApplicationId: mdm.Reference{Reference: "DeviceType/dt1"}})
+	require.NoError(t, err)
+	_, err = g.Add("DeviceType", mdm.DeviceType{ID: "dt1", DeviceGroupId: mdm.Reference{Reference: "DeviceGroup/dg1"}})
+	require.NoError(t, err)
+	_, err = g.DeleteOrder()
+	assert.ErrorIs(t, err, integrity.ErrDependencyCycle)
+}
@@ -142,6 +142,11 @@ func (c *OAuthClientsService) GetOAuthClients(opt *GetOAuthClientsOptions, optio
 	return &clients, resp, err
 }
 
+// GetAllOAuthClients looks up OAuth clients based on GetOAuthClientsOptions, retrieving all result pages
+func (c *OAuthClientsService) GetAllOAuthClients(opt *GetOAuthClientsOptions, options ...OptionFunc) (*[]OAuthClient, *Response, error) {
+	return findAll[OAuthClient](c.Client, "/OAuthClient", clientAPIVersion, opt, options...)
+}
+
 // UpdateScopes updates a clients scope
 func (c *OAuthClientsService) UpdateScopes(ac OAuthClient, scopes []string, defaultScopes []string) (bool, *Response, error) {
 	return c.UpdateScopesByFlag(ac, scopes, defaultScopes, false)
@@ -124,6 +124,11 @@ func (c *ServiceActionsService) Find(opt *GetServiceActionOptions, options ...Op
 	return &services, resp, err
 }
 
+// FindAll looks up service actions based on GetServiceActionOptions, retrieving all result pages
+func (c *ServiceActionsService) FindAll(opt *GetServiceActionOptions, options ...OptionFunc) (*[]ServiceAction, *Response, error) {
+	return findAll[ServiceAction](c.Client, "/ServiceAction", serviceActionAPIVersion, opt, options...)
+}
+
 // Update updates a standard service
 func (c *ServiceActionsService) Update(ac ServiceAction) (*ServiceAction, *Response, error) {
 	ac.ResourceType = "ServiceAction"
@@ -127,6 +127,11 @@ func (c *ServiceReferencesService) Find(opt *GetServiceReferenceOptions, options
 	return &resources, resp, err
 }
 
+// FindAll looks up service references based on GetServiceReferenceOptions, retrieving all result pages
+func (c *ServiceReferencesService) FindAll(opt *GetServiceReferenceOptions, options ...OptionFunc) (*[]ServiceReference, *Response, error) {
+	return findAll[ServiceReference](c.Client, "/ServiceReference", serviceReferenceAPIVersion, opt, options...)
+}
+
 // Update updates a standard service
 func (c *ServiceReferencesService) Update(ac ServiceReference) (*ServiceReference, *Response, error) {
 	ac.ResourceType = "ServiceReference"
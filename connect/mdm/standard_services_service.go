@@ -133,6 +133,11 @@ func (c *StandardServicesService) GetStandardServices(opt *GetStandardServiceOpt
 	return &services, resp, err
 }
 
+// GetAllStandardServices looks up standard services based on GetStandardServiceOptions, retrieving all result pages
+func (c *StandardServicesService) GetAllStandardServices(opt *GetStandardServiceOptions, options ...OptionFunc) (*[]StandardService, *Response, error) {
+	return findAll[StandardService](c.Client, "/StandardService", standardServiceAPIVersion, opt, options...)
+}
+
 // Update updates a standard service
 func (c *StandardServicesService) Update(ac StandardService) (*StandardService, *Response, error) {
 	ac.ResourceType = "StandardService"
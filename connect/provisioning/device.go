@@ -0,0 +1,179 @@
+package provisioning
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dip-software/go-dip-api/iam"
+	"github.com/dip-software/go-dip-api/internal"
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	identityRequestAPIVersion = "1"
+)
+
+// BootstrapPayload is the statement a device signs with its bootstrap key to prove it belongs to the organization
+type BootstrapPayload struct {
+	OrganizationGuid string `json:"organizationGuid" validate:"required"`
+	DeviceIdentifier string `json:"deviceIdentifier" validate:"required"`
+	DeviceType       string `json:"deviceType" validate:"required"`
+	Nonce            string `json:"nonce" validate:"required"`
+	Timestamp        string `json:"timestamp" validate:"required"`
+}
+
+// IdentityRequest exchanges a signed bootstrap payload for an IAM device identity.
+// Payload holds the base64 encoded JSON of the BootstrapPayload which was signed
+type IdentityRequest struct {
+	ResourceType string `json:"resourceType" validate:"required" enum:"IdentityRequest"`
+	Payload      string `json:"payload" validate:"required"`
+	Signature    string `json:"signature" validate:"required"`
+}
+
+// IdentityResponse contains the IAM device identity and its credentials
+type IdentityResponse struct {
+	ResourceType string     `json:"resourceType"`
+	Device       iam.Device `json:"device"`
+}
+
+// DeviceConfig contains the configuration of a DeviceClient
+type DeviceConfig struct {
+	Region           string
+	Environment      string
+	BaseURL          string
+	OrganizationGuid string `validate:"required"`
+	DeviceIdentifier string `validate:"required"`
+	DeviceType       string `validate:"required"`
+	Signer           *Signer
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// DeviceClient is the device side of provisioning. It holds no IAM credentials; the bootstrap
+// signature authenticates the identity request
+type DeviceClient struct {
+	config     *DeviceConfig
+	baseURL    *url.URL
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewDeviceClient returns a client which provisions the device described by config.
+// The config is copied, so defaults are never written back to the caller
+func NewDeviceClient(config *DeviceConfig) (*DeviceClient, error) {
+	if err := validator.New().Struct(config); err != nil {
+		return nil, err
+	}
+	cfg := *config
+	config = &cfg
+	if config.Signer == nil {
+		return nil, ErrMissingSigner
+	}
+	if config.BaseURL == "" {
+		autoconfig := &Config{Region: config.Region, Environment: config.Environment}
+		doAutoconf(autoconfig)
+		config.BaseURL = autoconfig.BaseURL
+	}
+	if config.BaseURL == "" {
+		return nil, ErrBaseURLCannotBeEmpty
+	}
+	baseURL := config.BaseURL
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &DeviceClient{
+		config:     config,
+		baseURL:    u,
+		httpClient: httpClient,
+		now:        time.Now,
+	}, nil
+}
+
+// NewIdentityRequest signs a fresh bootstrap payload for the device
+func (d *DeviceClient) NewIdentityRequest() (*IdentityRequest, error) {
+	nonce := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(BootstrapPayload{
+		OrganizationGuid: d.config.OrganizationGuid,
+		DeviceIdentifier: d.config.DeviceIdentifier,
+		DeviceType:       d.config.DeviceType,
+		Nonce:            hex.EncodeToString(nonce),
+		Timestamp:        d.now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, err
+	}
+	signature, err := d.config.Signer.Sign(payload)
+	if err != nil {
+		return nil, fmt.Errorf("sign bootstrap payload: %w", err)
+	}
+	return &IdentityRequest{
+		ResourceType: "IdentityRequest",
+		Payload:      base64.StdEncoding.EncodeToString(payload),
+		Signature:    signature,
+	}, nil
+}
+
+// RequestIdentity exchanges a signed bootstrap payload for an IAM device identity and returns
+// the device with its login credentials
+func (d *DeviceClient) RequestIdentity(ctx context.Context) (*iam.Device, *Response, error) {
+	identityRequest, err := d.NewIdentityRequest()
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := json.Marshal(identityRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := *d.baseURL
+	u.Path = path.Join(d.baseURL.Path, "IdentityRequest")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("api-version", identityRequestAPIVersion)
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	response := newResponse(resp)
+	if err := internal.CheckResponse(resp); err != nil {
+		return nil, response, err
+	}
+	var identity IdentityResponse
+	if err := json.NewDecoder(resp.Body).Decode(&identity); err != nil {
+		return nil, response, err
+	}
+	if identity.Device.LoginID == "" {
+		return nil, response, ErrEmptyResult
+	}
+	return &identity.Device, response, nil
+}
@@ -19,4 +19,9 @@ var (
 	ErrEmptyResults                   = errors.New("empty results")
 	ErrOperationFailed                = errors.New("operation failed")
 	ErrCouldNoReadResourceAfterCreate = errors.New("could not read resource after create")
+	ErrUnsupportedAlgorithm           = errors.New("unsupported signature algorithm")
+	ErrKeyMismatch                    = errors.New("key does not match signature type")
+	ErrInvalidSignature               = errors.New("invalid bootstrap signature")
+	ErrInvalidPublicKey               = errors.New("invalid public key")
+	ErrMissingSigner                  = errors.New("missing bootstrap signer")
 )
@@ -0,0 +1,191 @@
+package provisioning
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	_ "crypto/md5" // RSA-MD5
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha1" // RSA-SHA1
+	_ "crypto/sha256"
+	_ "crypto/sha3" // RSA-SHA3-*
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+var signatureHashes = map[string]crypto.Hash{
+	"MD5":      crypto.MD5,
+	"SHA1":     crypto.SHA1,
+	"SHA1-2":   crypto.SHA1,
+	"SHA224":   crypto.SHA224,
+	"SHA256":   crypto.SHA256,
+	"SHA384":   crypto.SHA384,
+	"SHA512":   crypto.SHA512,
+	"SHA3-224": crypto.SHA3_224,
+	"SHA3-256": crypto.SHA3_256,
+	"SHA3-384": crypto.SHA3_384,
+	"SHA3-512": crypto.SHA3_512,
+}
+
+// signatureScheme is the parsed form of a BootstrapSignature
+type signatureScheme struct {
+	keyType string
+	hash    crypto.Hash
+	pss     *rsa.PSSOptions
+}
+
+func parseScheme(sig BootstrapSignature) (*signatureScheme, error) {
+	hash, ok := signatureHashes[strings.TrimPrefix(sig.Algorithm, "RSA-")]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, sig.Algorithm)
+	}
+	scheme := &signatureScheme{keyType: sig.Config.Type, hash: hash}
+	if scheme.keyType == "" {
+		scheme.keyType = "RSA"
+	}
+	switch scheme.keyType {
+	case "RSA":
+	case "ECC":
+		return scheme, nil
+	default:
+		return nil, fmt.Errorf("%w: %s keys", ErrUnsupportedAlgorithm, scheme.keyType)
+	}
+	switch sig.Config.Padding {
+	case "":
+		return scheme, nil
+	case "RSA_PKCS1_PSS_PADDING":
+	default:
+		return nil, fmt.Errorf("%w: %s padding", ErrUnsupportedAlgorithm, sig.Config.Padding)
+	}
+	scheme.pss = &rsa.PSSOptions{Hash: hash}
+	switch sig.Config.SaltLength {
+	case "", "RSA_PSS_SALTLEN_DIGEST":
+		scheme.pss.SaltLength = rsa.PSSSaltLengthEqualsHash
+	case "RSA_PSS_SALTLEN_MAX_SIGN", "RSA_PSS_SALTLEN_AUTO":
+		// Auto signs with the maximum salt length and detects it when verifying
+		scheme.pss.SaltLength = rsa.PSSSaltLengthAuto
+	default:
+		return nil, fmt.Errorf("%w: %s salt length", ErrUnsupportedAlgorithm, sig.Config.SaltLength)
+	}
+	return scheme, nil
+}
+
+func (s *signatureScheme) digest(payload []byte) []byte {
+	h := s.hash.New()
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// Signer signs bootstrap payloads as configured in the BootstrapSignature of an OrgConfiguration
+type Signer struct {
+	scheme *signatureScheme
+	key    crypto.Signer
+}
+
+// NewSigner returns a signer for sig using the private key which belongs to sig.PublicKey
+func NewSigner(sig BootstrapSignature, key crypto.Signer) (*Signer, error) {
+	scheme, err := parseScheme(sig)
+	if err != nil {
+		return nil, err
+	}
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		if scheme.keyType != "RSA" {
+			return nil, fmt.Errorf("%w: RSA key for %s signature", ErrKeyMismatch, scheme.keyType)
+		}
+	case *ecdsa.PrivateKey:
+		if scheme.keyType != "ECC" {
+			return nil, fmt.Errorf("%w: ECC key for %s signature", ErrKeyMismatch, scheme.keyType)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedAlgorithm, key)
+	}
+	return &Signer{scheme: scheme, key: key}, nil
+}
+
+// Sign returns the base64 encoded signature of payload
+func (s *Signer) Sign(payload []byte) (string, error) {
+	var opts crypto.SignerOpts = s.scheme.hash
+	if s.scheme.pss != nil {
+		opts = s.scheme.pss
+	}
+	signature, err := s.key.Sign(rand.Reader, s.scheme.digest(payload), opts)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// VerifySignature checks the base64 encoded signature of payload against the public key of sig
+func VerifySignature(sig BootstrapSignature, payload []byte, signature string) error {
+	scheme, err := parseScheme(sig)
+	if err != nil {
+		return err
+	}
+	publicKey, err := ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return err
+	}
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	digest := scheme.digest(payload)
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if scheme.keyType != "RSA" {
+			return ErrKeyMismatch
+		}
+		if scheme.pss != nil {
+			err = rsa.VerifyPSS(key, scheme.hash, digest, raw, scheme.pss)
+		} else {
+			err = rsa.VerifyPKCS1v15(key, scheme.hash, digest, raw)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+	case *ecdsa.PublicKey:
+		if scheme.keyType != "ECC" {
+			return ErrKeyMismatch
+		}
+		if !ecdsa.VerifyASN1(key, digest, raw) {
+			return ErrInvalidSignature
+		}
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedAlgorithm, publicKey)
+	}
+	return nil
+}
+
+// PublicKeyPEM returns the PEM encoded public key of key for use in a BootstrapSignature
+func PublicKeyPEM(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// ParsePublicKey parses a PEM encoded PKIX or PKCS1 public key
+func ParsePublicKey(publicKey string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, ErrInvalidPublicKey
+	}
+	if block.Type == "RSA PUBLIC KEY" {
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+		}
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+	}
+	return key, nil
+}
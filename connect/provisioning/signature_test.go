@@ -0,0 +1,71 @@
+package provisioning_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/connect/provisioning"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bootstrapSignature(t *testing.T, key crypto.Signer, algorithm string, config provisioning.BootStrapSignatureConfig) provisioning.BootstrapSignature {
+	publicKey, err := provisioning.PublicKeyPEM(key)
+	require.NoError(t, err)
+	return provisioning.BootstrapSignature{PublicKey: publicKey, Algorithm: algorithm, Config: config}
+}
+
+func TestSignatures(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	eccKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		key       crypto.Signer
+		algorithm string
+		config    provisioning.BootStrapSignatureConfig
+	}{
+		{"pkcs1", rsaKey, "RSA-SHA256", provisioning.BootStrapSignatureConfig{Type: "RSA"}},
+		{"pss digest", rsaKey, "RSA-SHA256", provisioning.BootStrapSignatureConfig{Type: "RSA", Padding: "RSA_PKCS1_PSS_PADDING", SaltLength: "RSA_PSS_SALTLEN_DIGEST"}},
+		{"pss max", rsaKey, "RSA-SHA512", provisioning.BootStrapSignatureConfig{Type: "RSA", Padding: "RSA_PKCS1_PSS_PADDING", SaltLength: "RSA_PSS_SALTLEN_MAX_SIGN"}},
+		{"sha3", rsaKey, "RSA-SHA3-256", provisioning.BootStrapSignatureConfig{Type: "RSA"}},
+		{"ecc", eccKey, "SHA256", provisioning.BootStrapSignatureConfig{Type: "ECC"}},
+	}
+	payload := []byte(`{"deviceIdentifier":"device-1"}`)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sig := bootstrapSignature(t, c.key, c.algorithm, c.config)
+			signer, err := provisioning.NewSigner(sig, c.key)
+			require.NoError(t, err)
+			signature, err := signer.Sign(payload)
+			require.NoError(t, err)
+			assert.NoError(t, provisioning.VerifySignature(sig, payload, signature))
+			assert.ErrorIs(t, provisioning.VerifySignature(sig, []byte("tampered"), signature), provisioning.ErrInvalidSignature)
+		})
+	}
+}
+
+func TestSignerErrors(t *testing.T) {
+	eccKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	_, err = provisioning.NewSigner(bootstrapSignature(t, eccKey, "RSA-SHA256", provisioning.BootStrapSignatureConfig{Type: "RSA"}), eccKey)
+	assert.ErrorIs(t, err, provisioning.ErrKeyMismatch)
+	_, err = provisioning.NewSigner(bootstrapSignature(t, eccKey, "RSA-RIPEMD160", provisioning.BootStrapSignatureConfig{Type: "ECC"}), eccKey)
+	assert.ErrorIs(t, err, provisioning.ErrUnsupportedAlgorithm)
+	_, err = provisioning.NewSigner(bootstrapSignature(t, eccKey, "SHA256", provisioning.BootStrapSignatureConfig{Type: "DSA"}), eccKey)
+	assert.ErrorIs(t, err, provisioning.ErrUnsupportedAlgorithm)
+
+	sig := bootstrapSignature(t, eccKey, "SHA256", provisioning.BootStrapSignatureConfig{Type: "ECC"})
+	sig.PublicKey = "not a key"
+	assert.ErrorIs(t, provisioning.VerifySignature(sig, nil, ""), provisioning.ErrInvalidPublicKey)
+
+	_, err = provisioning.ParsePublicKey("-----BEGIN RSA PUBLIC KEY-----\nAAAA\n-----END RSA PUBLIC KEY-----\n")
+	assert.ErrorIs(t, err, provisioning.ErrInvalidPublicKey)
+}
@@ -0,0 +1,243 @@
+package provisioning
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dip-software/go-dip-api/iam"
+	"github.com/dip-software/go-dip-api/internal"
+)
+
+const (
+	defaultMaxSkew          = 5 * time.Minute
+	defaultFleetConcurrency = 10
+)
+
+// Simulator is a stand-in for the provisioning identity endpoint. It verifies identity
+// requests against the BootstrapSignature of an OrgConfiguration and issues IAM device
+// identities from memory. Serve it with httptest to run virtual device fleets in tests
+type Simulator struct {
+	// MaxSkew is the accepted clock difference of bootstrap payload timestamps. Defaults to 5 minutes
+	MaxSkew time.Duration
+
+	config  OrgConfiguration
+	now     func() time.Time
+	mu      sync.Mutex
+	devices map[string]iam.Device
+	nonces  map[string]bool
+	nextID  int
+}
+
+// NewSimulator returns a simulator which provisions devices of the configured organization
+func NewSimulator(config OrgConfiguration) (*Simulator, error) {
+	if config.OrganizationGuid == "" {
+		return nil, ErrMissingOrganization
+	}
+	if _, err := parseScheme(config.BootstrapSignature); err != nil {
+		return nil, err
+	}
+	if _, err := ParsePublicKey(config.BootstrapSignature.PublicKey); err != nil {
+		return nil, err
+	}
+	return &Simulator{
+		MaxSkew: defaultMaxSkew,
+		config:  config,
+		now:     time.Now,
+		devices: make(map[string]iam.Device),
+		nonces:  make(map[string]bool),
+	}, nil
+}
+
+// Devices returns the provisioned devices ordered by login ID
+func (s *Simulator) Devices() []iam.Device {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	devices := make([]iam.Device, 0, len(s.devices))
+	for _, d := range s.devices {
+		devices = append(devices, d)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].LoginID < devices[j].LoginID
+	})
+	return devices
+}
+
+// ServeHTTP handles POST .../IdentityRequest
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/IdentityRequest") {
+		writeOutcome(w, http.StatusNotFound, "not-found", "unknown endpoint "+r.Method+" "+r.URL.Path)
+		return
+	}
+	var request IdentityRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeOutcome(w, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+	payload, err := base64.StdEncoding.DecodeString(request.Payload)
+	if err != nil {
+		writeOutcome(w, http.StatusBadRequest, "invalid", "payload: "+err.Error())
+		return
+	}
+	if err := VerifySignature(s.config.BootstrapSignature, payload, request.Signature); err != nil {
+		writeOutcome(w, http.StatusUnauthorized, "security", err.Error())
+		return
+	}
+	var bootstrap BootstrapPayload
+	if err := json.Unmarshal(payload, &bootstrap); err != nil {
+		writeOutcome(w, http.StatusBadRequest, "invalid", "payload: "+err.Error())
+		return
+	}
+	if bootstrap.OrganizationGuid != s.config.OrganizationGuid {
+		writeOutcome(w, http.StatusForbidden, "forbidden", "organization mismatch")
+		return
+	}
+	timestamp, err := time.Parse(time.RFC3339, bootstrap.Timestamp)
+	if err != nil {
+		writeOutcome(w, http.StatusBadRequest, "invalid", "timestamp: "+err.Error())
+		return
+	}
+	if skew := s.now().Sub(timestamp); skew > s.MaxSkew || skew < -s.MaxSkew {
+		writeOutcome(w, http.StatusUnauthorized, "security", "bootstrap payload expired")
+		return
+	}
+
+	device, status, err := s.provision(bootstrap)
+	if err != nil {
+		writeOutcome(w, status, "conflict", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(IdentityResponse{ResourceType: "IdentityResponse", Device: device})
+}
+
+func (s *Simulator) provision(bootstrap BootstrapPayload) (iam.Device, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.nonces[bootstrap.Nonce] {
+		return iam.Device{}, http.StatusConflict, fmt.Errorf("nonce %s was used before", bootstrap.Nonce)
+	}
+	s.nonces[bootstrap.Nonce] = true
+	if _, ok := s.devices[bootstrap.DeviceIdentifier]; ok {
+		return iam.Device{}, http.StatusConflict, fmt.Errorf("device %s is already provisioned", bootstrap.DeviceIdentifier)
+	}
+	password := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, password); err != nil {
+		return iam.Device{}, http.StatusInternalServerError, err
+	}
+	s.nextID++
+	now := s.now().UTC()
+	device := iam.Device{
+		ID:      fmt.Sprintf("%08d-0000-4000-8000-000000000000", s.nextID),
+		LoginID: bootstrap.DeviceIdentifier,
+		DeviceExtID: iam.DeviceIdentifier{
+			System: "urn:provisioning",
+			Value:  bootstrap.DeviceIdentifier,
+			Type:   iam.CodeableConcept{Code: "ID", Text: bootstrap.DeviceType},
+		},
+		Password:          "P" + hex.EncodeToString(password) + "!",
+		Type:              bootstrap.DeviceType,
+		RegistrationDate:  &now,
+		IsActive:          true,
+		OrganizationID:    s.config.OrganizationGuid,
+		GlobalReferenceID: bootstrap.DeviceIdentifier,
+	}
+	s.devices[bootstrap.DeviceIdentifier] = device
+	return device, http.StatusCreated, nil
+}
+
+func writeOutcome(w http.ResponseWriter, status int, code, diagnostics string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(internal.OperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue: []internal.Issue{{
+			Severity:    "error",
+			Code:        code,
+			Diagnostics: diagnostics,
+		}},
+	})
+}
+
+// FleetOptions describes a fleet of virtual devices
+type FleetOptions struct {
+	BaseURL          string
+	OrganizationGuid string
+	DeviceType       string
+	Signer           *Signer
+	// Count is the number of devices in the fleet
+	Count int
+	// Concurrency is the number of devices provisioning at the same time. Defaults to 10
+	Concurrency int
+	// IdentifierPrefix is prepended to the device number. Defaults to "device-"
+	IdentifierPrefix string
+	HTTPClient       *http.Client
+}
+
+// FleetResult is the outcome of provisioning one virtual device
+type FleetResult struct {
+	Identifier string
+	Device     *iam.Device
+	Err        error
+}
+
+// RunFleet provisions Count virtual devices and returns a result per device in order.
+// The error joins the errors of all devices which failed
+func RunFleet(ctx context.Context, opts FleetOptions) ([]FleetResult, error) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultFleetConcurrency
+	}
+	prefix := opts.IdentifierPrefix
+	if prefix == "" {
+		prefix = "device-"
+	}
+	results := make([]FleetResult, opts.Count)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range results {
+		results[i].Identifier = fmt.Sprintf("%s%05d", prefix, i+1)
+		wg.Add(1)
+		go func(result *FleetResult) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				result.Err = ctx.Err()
+				return
+			}
+			client, err := NewDeviceClient(&DeviceConfig{
+				BaseURL:          opts.BaseURL,
+				OrganizationGuid: opts.OrganizationGuid,
+				DeviceIdentifier: result.Identifier,
+				DeviceType:       opts.DeviceType,
+				Signer:           opts.Signer,
+				HTTPClient:       opts.HTTPClient,
+			})
+			if err != nil {
+				result.Err = err
+				return
+			}
+			result.Device, _, result.Err = client.RequestIdentity(ctx)
+		}(&results[i])
+	}
+	wg.Wait()
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Identifier, r.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
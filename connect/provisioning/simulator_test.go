@@ -0,0 +1,142 @@
+package provisioning_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/connect/provisioning"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orgGuid = "22d20214-7879-4e35-923d-f9d4e01c9746"
+
+func simulate(t *testing.T) (*provisioning.Simulator, *provisioning.Signer, *httptest.Server) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	sig := bootstrapSignature(t, key, "SHA256", provisioning.BootStrapSignatureConfig{Type: "ECC"})
+	simulator, err := provisioning.NewSimulator(provisioning.OrgConfiguration{
+		OrganizationGuid:   orgGuid,
+		BootstrapSignature: sig,
+	})
+	require.NoError(t, err)
+	signer, err := provisioning.NewSigner(sig, key)
+	require.NoError(t, err)
+	server := httptest.NewServer(simulator)
+	return simulator, signer, server
+}
+
+func TestRequestIdentity(t *testing.T) {
+	simulator, signer, server := simulate(t)
+	defer server.Close()
+
+	device, err := provisioning.NewDeviceClient(&provisioning.DeviceConfig{
+		BaseURL:          server.URL + "/connect/provisioning",
+		OrganizationGuid: orgGuid,
+		DeviceIdentifier: "device-00001",
+		DeviceType:       "sensor",
+		Signer:           signer,
+	})
+	require.NoError(t, err)
+
+	identity, resp, err := device.RequestIdentity(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode())
+	assert.Equal(t, "device-00001", identity.LoginID)
+	assert.Equal(t, "sensor", identity.Type)
+	assert.Equal(t, orgGuid, identity.OrganizationID)
+	assert.NotEmpty(t, identity.Password)
+	assert.Len(t, simulator.Devices(), 1)
+
+	_, resp, err = device.RequestIdentity(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 409, resp.StatusCode())
+
+	_, otherSigner, otherServer := simulate(t)
+	defer otherServer.Close()
+	impostor, err := provisioning.NewDeviceClient(&provisioning.DeviceConfig{
+		BaseURL:          server.URL + "/connect/provisioning",
+		OrganizationGuid: orgGuid,
+		DeviceIdentifier: "device-00002",
+		DeviceType:       "sensor",
+		Signer:           otherSigner,
+	})
+	require.NoError(t, err)
+	_, resp, err = impostor.RequestIdentity(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 401, resp.StatusCode())
+
+	wrongOrg, err := provisioning.NewDeviceClient(&provisioning.DeviceConfig{
+		BaseURL:          server.URL + "/connect/provisioning",
+		OrganizationGuid: "33d20214-7879-4e35-923d-f9d4e01c9746",
+		DeviceIdentifier: "device-00003",
+		DeviceType:       "sensor",
+		Signer:           signer,
+	})
+	require.NoError(t, err)
+	_, resp, err = wrongOrg.RequestIdentity(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 403, resp.StatusCode())
+
+	_, err = provisioning.NewDeviceClient(&provisioning.DeviceConfig{
+		BaseURL:          server.URL,
+		OrganizationGuid: orgGuid,
+		DeviceIdentifier: "device-00004",
+		DeviceType:       "sensor",
+	})
+	assert.ErrorIs(t, err, provisioning.ErrMissingSigner)
+
+	config := &provisioning.DeviceConfig{
+		Region:           "us-east",
+		Environment:      "client-test",
+		OrganizationGuid: orgGuid,
+		DeviceIdentifier: "device-00005",
+		DeviceType:       "sensor",
+		Signer:           signer,
+	}
+	_, err = provisioning.NewDeviceClient(config)
+	require.NoError(t, err)
+	assert.Empty(t, config.BaseURL)
+}
+
+func TestRunFleet(t *testing.T) {
+	simulator, signer, server := simulate(t)
+	defer server.Close()
+
+	results, err := provisioning.RunFleet(context.Background(), provisioning.FleetOptions{
+		BaseURL:          server.URL + "/connect/provisioning",
+		OrganizationGuid: orgGuid,
+		DeviceType:       "sensor",
+		Signer:           signer,
+		Count:            25,
+		Concurrency:      5,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 25)
+	assert.Equal(t, "device-00001", results[0].Identifier)
+	for _, r := range results {
+		if assert.NotNil(t, r.Device) {
+			assert.Equal(t, r.Identifier, r.Device.LoginID)
+		}
+	}
+	devices := simulator.Devices()
+	assert.Len(t, devices, 25)
+	assert.Equal(t, "device-00025", devices[24].LoginID)
+
+	// A second run collides with the provisioned devices
+	results, err = provisioning.RunFleet(context.Background(), provisioning.FleetOptions{
+		BaseURL:          server.URL + "/connect/provisioning",
+		OrganizationGuid: orgGuid,
+		DeviceType:       "sensor",
+		Signer:           signer,
+		Count:            2,
+	})
+	assert.Error(t, err)
+	for _, r := range results {
+		assert.Error(t, r.Err)
+	}
+}
@@ -0,0 +1,111 @@
+// Package wait polls asynchronously provisioned HSDP Connect resources until they reach a desired state
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+const (
+	defaultInitialInterval = 2 * time.Second
+	defaultMaxInterval     = 30 * time.Second
+)
+
+// Exported Errors
+var (
+	ErrInError  = errors.New("resource is in error")
+	ErrGaveUp   = errors.New("gave up waiting")
+	ErrNotFound = errors.New("resource not found")
+)
+
+// StatusError is returned when a resource reaches an error status such as InError
+type StatusError struct {
+	Status  string
+	Message string
+	// Err optionally identifies the kind of resource which failed
+	Err error
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("status %s", e.Status)
+	}
+	return fmt.Sprintf("status %s: %s", e.Status, e.Message)
+}
+
+// Unwrap returns the underlying error, if any
+func (e *StatusError) Unwrap() error { return e.Err }
+
+// Is reports ErrInError as matching so callers can test for any status error
+func (e *StatusError) Is(target error) bool { return target == ErrInError }
+
+// Getter fetches the current state of a resource
+type Getter[T any] func(ctx context.Context) (T, error)
+
+// Predicate reports whether the resource reached the desired state.
+// A non-nil error stops waiting immediately, e.g. when the resource is in error
+type Predicate[T any] func(T) (bool, error)
+
+// DefaultBackOff returns the back-off used when none is given: exponential
+// from 2 up to 30 seconds between polls, without an overall time limit
+func DefaultBackOff() backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = defaultInitialInterval
+	b.MaxInterval = defaultMaxInterval
+	b.MaxElapsedTime = 0
+	return b
+}
+
+// WaitFor calls get until predicate is satisfied, predicate returns an error, get fails,
+// ctx is done or b stops. The last retrieved value is always returned. Use the context
+// to bound the total waiting time; a nil b uses DefaultBackOff
+func WaitFor[T any](ctx context.Context, get Getter[T], predicate Predicate[T], b backoff.BackOff) (T, error) {
+	if b == nil {
+		b = DefaultBackOff()
+	}
+	b.Reset()
+	for {
+		value, err := get(ctx)
+		if err != nil {
+			return value, err
+		}
+		done, err := predicate(value)
+		if err != nil || done {
+			return value, err
+		}
+		next := b.NextBackOff()
+		if next == backoff.Stop {
+			return value, ErrGaveUp
+		}
+		timer := time.NewTimer(next)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return value, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// UntilGone adapts a getter for waiting on deletion. The returned getter reports
+// whether the resource still exists. The getter must return ErrNotFound once it is gone
+func UntilGone[T any](get Getter[T]) (Getter[bool], Predicate[bool]) {
+	exists := func(ctx context.Context) (bool, error) {
+		_, err := get(ctx)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return false, nil
+			}
+			return true, err
+		}
+		return true, nil
+	}
+	gone := func(exists bool) (bool, error) {
+		return !exists, nil
+	}
+	return exists, gone
+}
@@ -0,0 +1,78 @@
+package wait_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/dip-software/go-dip-api/connect/wait"
+	"github.com/stretchr/testify/assert"
+)
+
+type resource struct {
+	Status       string
+	ErrorMessage string
+}
+
+func statuses(list ...string) wait.Getter[resource] {
+	calls := 0
+	return func(ctx context.Context) (resource, error) {
+		status := list[calls]
+		if calls < len(list)-1 {
+			calls++
+		}
+		if status == "gone" {
+			return resource{}, wait.ErrNotFound
+		}
+		return resource{Status: status, ErrorMessage: "boom"}, nil
+	}
+}
+
+func active(r resource) (bool, error) {
+	if r.Status == "InError" {
+		return false, &wait.StatusError{Status: r.Status, Message: r.ErrorMessage}
+	}
+	return r.Status == "Active", nil
+}
+
+func fast() backoff.BackOff {
+	return backoff.NewConstantBackOff(time.Millisecond)
+}
+
+func TestWaitFor(t *testing.T) {
+	r, err := wait.WaitFor(context.Background(), statuses("Creating", "Creating", "Active"), active, fast())
+	assert.Nil(t, err)
+	assert.Equal(t, "Active", r.Status)
+
+	r, err = wait.WaitFor(context.Background(), statuses("Creating", "InError"), active, fast())
+	assert.ErrorIs(t, err, wait.ErrInError)
+	var statusErr *wait.StatusError
+	if assert.True(t, errors.As(err, &statusErr)) {
+		assert.Equal(t, "boom", statusErr.Message)
+	}
+	assert.Equal(t, "InError", r.Status)
+
+	_, err = wait.WaitFor(context.Background(), statuses("Creating"), active, backoff.WithMaxRetries(fast(), 2))
+	assert.ErrorIs(t, err, wait.ErrGaveUp)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = wait.WaitFor(ctx, statuses("Creating"), active, fast())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestUntilGone(t *testing.T) {
+	get, gone := wait.UntilGone(statuses("Deleting", "Deleting", "gone"))
+	exists, err := wait.WaitFor(context.Background(), get, gone, fast())
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	failing := func(ctx context.Context) (resource, error) {
+		return resource{}, errors.New("unavailable")
+	}
+	get, gone = wait.UntilGone(failing)
+	_, err = wait.WaitFor(context.Background(), get, gone, fast())
+	assert.EqualError(t, err, "unavailable")
+}
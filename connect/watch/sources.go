@@ -0,0 +1,100 @@
+package watch
+
+import (
+	"context"
+	"time"
+
+	"github.com/dip-software/go-dip-api/connect/blr"
+	"github.com/dip-software/go-dip-api/connect/dbs"
+	"github.com/dip-software/go-dip-api/connect/mdm"
+)
+
+func list[T any](result *[]T, err error) ([]T, error) {
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+	return *result, nil
+}
+
+// Blobs watches the BLR blobs matching filter
+func Blobs(client *blr.Client, filter blr.GetBlobOptions) Source[blr.Blob] {
+	return Source[blr.Blob]{
+		Kind: "Blob",
+		List: func(_ context.Context, lastUpdated *string) ([]blr.Blob, error) {
+			opt := filter
+			opt.LastUpdated = lastUpdated
+			result, _, err := client.Blobs.FindAll(&opt)
+			return list(result, err)
+		},
+		Key: func(b blr.Blob) string { return b.ID },
+		Meta: func(b blr.Blob) (time.Time, string) {
+			if b.Meta == nil {
+				return time.Time{}, ""
+			}
+			return b.Meta.LastUpdated, b.Meta.VersionID
+		},
+	}
+}
+
+// Buckets watches the MDM buckets matching filter
+func Buckets(client *mdm.Client, filter mdm.GetBucketOptions) Source[mdm.Bucket] {
+	return Source[mdm.Bucket]{
+		Kind: "Bucket",
+		List: func(_ context.Context, lastUpdated *string) ([]mdm.Bucket, error) {
+			opt := filter
+			opt.LastUpdated = lastUpdated
+			result, _, err := client.Buckets.FindAll(&opt)
+			return list(result, err)
+		},
+		Key: func(b mdm.Bucket) string { return b.ID },
+		Meta: func(b mdm.Bucket) (time.Time, string) {
+			if b.Meta == nil {
+				return time.Time{}, ""
+			}
+			return b.Meta.LastUpdated, b.Meta.VersionID
+		},
+	}
+}
+
+// SQSSubscribers watches the DBS SQS subscribers matching filter
+func SQSSubscribers(client *dbs.Client, filter dbs.GetSQSSubscriberOptions) Source[dbs.SQSSubscriber] {
+	return Source[dbs.SQSSubscriber]{
+		Kind: "SQSSubscriber",
+		List: func(_ context.Context, lastUpdated *string) ([]dbs.SQSSubscriber, error) {
+			opt := filter
+			opt.LastUpdated = lastUpdated
+			result, _, err := client.Subscribers.FindAllSQS(&opt)
+			return list(result, err)
+		},
+		Key: func(s dbs.SQSSubscriber) string { return s.ID },
+		Meta: func(s dbs.SQSSubscriber) (time.Time, string) {
+			if s.Meta == nil {
+				return time.Time{}, ""
+			}
+			return s.Meta.LastUpdated, s.Meta.VersionID
+		},
+	}
+}
+
+// TopicSubscriptions watches the DBS topic subscriptions matching filter
+func TopicSubscriptions(client *dbs.Client, filter dbs.GetTopicSubscriptionOptions) Source[dbs.TopicSubscription] {
+	return Source[dbs.TopicSubscription]{
+		Kind: "TopicSubscription",
+		List: func(_ context.Context, lastUpdated *string) ([]dbs.TopicSubscription, error) {
+			opt := filter
+			opt.LastUpdated = lastUpdated
+			result, _, err := client.Subscriptions.FindAllTopicSubscriptions(&opt)
+			return list(result, err)
+		},
+		Key: func(s dbs.TopicSubscription) string { return s.ID },
+		Meta: func(s dbs.TopicSubscription) (time.Time, string) {
+			if s.Meta == nil {
+				return time.Time{}, ""
+			}
+			return s.Meta.LastUpdated, s.Meta.VersionID
+		},
+	}
+}
@@ -0,0 +1,110 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cursor is the checkpointed position of a watch
+type Cursor struct {
+	// HighWater is the latest lastUpdated of all delivered changes
+	HighWater time.Time `json:"highWater"`
+	// Known maps the key of every known resource to its version
+	Known map[string]string `json:"known"`
+}
+
+func (c *Cursor) clone() *Cursor {
+	known := make(map[string]string, len(c.Known))
+	for k, v := range c.Known {
+		known[k] = v
+	}
+	return &Cursor{HighWater: c.HighWater, Known: known}
+}
+
+// Store persists watch cursors. Load returns nil when there is no checkpoint yet
+type Store interface {
+	Load(ctx context.Context, name string) (*Cursor, error)
+	Save(ctx context.Context, name string, cursor *Cursor) error
+}
+
+// MemoryStore keeps cursors in memory, e.g. to share them between watches of one process
+type MemoryStore struct {
+	mu      sync.Mutex
+	cursors map[string]*Cursor
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{cursors: make(map[string]*Cursor)}
+}
+
+// Load returns a copy of the named cursor
+func (s *MemoryStore) Load(_ context.Context, name string) (*Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.cursors[name]
+	if !ok {
+		return nil, nil
+	}
+	return c.clone(), nil
+}
+
+// Save stores a copy of the cursor
+func (s *MemoryStore) Save(_ context.Context, name string, cursor *Cursor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursors[name] = cursor.clone()
+	return nil
+}
+
+// FileStore keeps every cursor as a JSON file in Dir
+type FileStore struct {
+	Dir string
+}
+
+func (s FileStore) path(name string) string {
+	return filepath.Join(s.Dir, filepath.Base(name)+".json")
+}
+
+// Load reads the named cursor file
+func (s FileStore) Load(_ context.Context, name string) (*Cursor, error) {
+	data, err := os.ReadFile(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// Save replaces the named cursor file atomically
+func (s FileStore) Save(_ context.Context, name string, cursor *Cursor) error {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.Dir, filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(name))
+}
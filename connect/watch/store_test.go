@@ -0,0 +1,48 @@
+package watch_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dip-software/go-dip-api/connect/watch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	store := watch.FileStore{Dir: t.TempDir()}
+	ctx := context.Background()
+
+	cursor, err := store.Load(ctx, "Blob")
+	require.NoError(t, err)
+	assert.Nil(t, cursor)
+
+	saved := &watch.Cursor{
+		HighWater: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Known:     map[string]string{"a": "1"},
+	}
+	require.NoError(t, store.Save(ctx, "Blob", saved))
+	saved.Known["b"] = "2"
+	require.NoError(t, store.Save(ctx, "Blob", saved))
+
+	cursor, err = store.Load(ctx, "Blob")
+	require.NoError(t, err)
+	require.NotNil(t, cursor)
+	assert.True(t, saved.HighWater.Equal(cursor.HighWater))
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, cursor.Known)
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := watch.NewMemoryStore()
+	ctx := context.Background()
+
+	saved := &watch.Cursor{Known: map[string]string{"a": "1"}}
+	require.NoError(t, store.Save(ctx, "Bucket", saved))
+	saved.Known["a"] = "2"
+
+	cursor, err := store.Load(ctx, "Bucket")
+	require.NoError(t, err)
+	require.NotNil(t, cursor)
+	assert.Equal(t, "1", cursor.Known["a"])
+}
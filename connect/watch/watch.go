@@ -0,0 +1,222 @@
+// Package watch turns the _lastUpdated search of HSDP Connect resources into a change feed
+package watch
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	defaultFullSyncEvery = 10
+	defaultOverlap       = 5 * time.Second
+	lastUpdatedFormat    = time.RFC3339
+)
+
+// Exported Errors
+var (
+	ErrMissingSource   = errors.New("source is incomplete")
+	ErrInvalidInterval = errors.New("interval must be positive")
+)
+
+// EventType is the kind of change
+type EventType string
+
+// Event types
+const (
+	Added   EventType = "added"
+	Updated EventType = "updated"
+	Deleted EventType = "deleted"
+)
+
+// Event is a change of a single resource. The Resource of a Deleted event is the zero value
+type Event[T any] struct {
+	Type     EventType
+	Key      string
+	Version  string
+	Resource T
+}
+
+// Source lists the resources of one kind, e.g. the blobs matching a filter
+type Source[T any] struct {
+	// Kind names the source and is the default checkpoint name
+	Kind string
+	// List returns the resources across all result pages. A nil lastUpdated lists all resources,
+	// otherwise it is a _lastUpdated search value such as ge2024-01-01T00:00:00Z. A full listing
+	// must be complete, as every known resource it misses is reported as Deleted. List returns an
+	// error rather than a partial result
+	List func(ctx context.Context, lastUpdated *string) ([]T, error)
+	// Key returns the ID of a resource
+	Key func(T) string
+	// Meta returns the last update time and version of a resource
+	Meta func(T) (time.Time, string)
+}
+
+// Options control a Watch
+type Options struct {
+	// Name of the checkpoint. Defaults to the Kind of the source
+	Name string
+	// Store persists the cursor. Defaults to a new MemoryStore
+	Store Store
+	// FullSyncEvery is the number of polls after which all resources are listed to detect
+	// deletions. Defaults to 10
+	FullSyncEvery int
+	// Overlap is subtracted from the high-water mark to catch late commits. Defaults to 5 seconds
+	Overlap time.Duration
+	// SkipInitial records the existing resources on the first run without emitting Added events
+	SkipInitial bool
+	// OnError is called when a poll or checkpoint fails. The watch continues with the next poll
+	OnError func(error)
+}
+
+// Watch polls source every interval and emits the changes on the returned channel. The cursor
+// is checkpointed after every delivered event, so a restarted watch with the same Store and
+// Name neither repeats nor misses events. The channel is closed when ctx is done
+func Watch[T any](ctx context.Context, source Source[T], interval time.Duration, opts Options) (<-chan Event[T], error) {
+	if source.List == nil || source.Key == nil || source.Meta == nil {
+		return nil, ErrMissingSource
+	}
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	if opts.Name == "" {
+		opts.Name = source.Kind
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore()
+	}
+	if opts.FullSyncEvery <= 0 {
+		opts.FullSyncEvery = defaultFullSyncEvery
+	}
+	if opts.Overlap <= 0 {
+		opts.Overlap = defaultOverlap
+	}
+	cursor, err := opts.Store.Load(ctx, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+	w := &watcher[T]{source: source, opts: opts, cursor: cursor, initial: cursor == nil, events: make(chan Event[T])}
+	if w.cursor == nil {
+		w.cursor = &Cursor{}
+	}
+	if w.cursor.Known == nil {
+		w.cursor.Known = make(map[string]string)
+	}
+	go w.run(ctx, interval)
+	return w.events, nil
+}
+
+type watcher[T any] struct {
+	source Source[T]
+	opts   Options
+	cursor *Cursor
+	events chan Event[T]
+	polls  int
+	// initial is set until the first poll without a checkpoint completed
+	initial bool
+}
+
+func (w *watcher[T]) run(ctx context.Context, interval time.Duration) {
+	defer close(w.events)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := w.poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			w.fail(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *watcher[T]) fail(err error) {
+	if w.opts.OnError != nil {
+		w.opts.OnError(err)
+	}
+}
+
+// poll lists the changes since the high-water mark, or everything on the first and
+// every FullSyncEvery poll. Deletions are only detected after a complete full listing
+func (w *watcher[T]) poll(ctx context.Context) error {
+	full := w.initial || w.polls%w.opts.FullSyncEvery == 0
+	w.polls++
+
+	var since *string
+	if !full {
+		s := "ge" + w.cursor.HighWater.Add(-w.opts.Overlap).UTC().Format(lastUpdatedFormat)
+		since = &s
+	}
+	resources, err := w.source.List(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	highWater := w.cursor.HighWater
+	seen := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		key := w.source.Key(resource)
+		lastUpdated, version := w.source.Meta(resource)
+		if version == "" {
+			version = lastUpdated.UTC().Format(time.RFC3339Nano)
+		}
+		if lastUpdated.After(highWater) {
+			highWater = lastUpdated
+		}
+		seen[key] = true
+		known, ok := w.cursor.Known[key]
+		switch {
+		case ok && known == version:
+			continue
+		case w.initial && w.opts.SkipInitial:
+			w.cursor.Known[key] = version
+			continue
+		}
+		event := Event[T]{Type: Added, Key: key, Version: version, Resource: resource}
+		if ok {
+			event.Type = Updated
+		}
+		if err := w.emit(ctx, event); err != nil {
+			return err
+		}
+	}
+	if full {
+		for key, version := range w.cursor.Known {
+			if !seen[key] {
+				if err := w.emit(ctx, Event[T]{Type: Deleted, Key: key, Version: version}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	// Only advance the high-water mark once every change up to it was delivered
+	w.cursor.HighWater = highWater
+	if err := w.opts.Store.Save(ctx, w.opts.Name, w.cursor); err != nil {
+		return err
+	}
+	w.initial = false
+	return nil
+}
+
+// emit delivers the event and checkpoints it. An event which was not delivered is not checkpointed
+func (w *watcher[T]) emit(ctx context.Context, event Event[T]) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case w.events <- event:
+	}
+	if event.Type == Deleted {
+		delete(w.cursor.Known, event.Key)
+	} else {
+		w.cursor.Known[event.Key] = event.Version
+	}
+	if err := w.opts.Store.Save(ctx, w.opts.Name, w.cursor); err != nil {
+		w.fail(err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package watch_test
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dip-software/go-dip-api/connect/watch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	ID          string
+	Version     string
+	LastUpdated time.Time
+}
+
+type fakeSource struct {
+	mu      sync.Mutex
+	items   map[string]item
+	queries []string
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{items: make(map[string]item)}
+}
+
+func (f *fakeSource) put(id, version string, at time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.items[id] = item{ID: id, Version: version, LastUpdated: at}
+}
+
+func (f *fakeSource) remove(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.items, id)
+}
+
+func (f *fakeSource) source() watch.Source[item] {
+	return watch.Source[item]{
+		Kind: "Item",
+		List: func(_ context.Context, lastUpdated *string) ([]item, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			var since time.Time
+			query := ""
+			if lastUpdated != nil {
+				query = *lastUpdated
+				t, err := time.Parse(time.RFC3339, strings.TrimPrefix(query, "ge"))
+				if err != nil {
+					return nil, err
+				}
+				since = t
+			}
+			f.queries = append(f.queries, query)
+			var items []item
+			for _, i := range f.items {
+				if !i.LastUpdated.Before(since) {
+					items = append(items, i)
+				}
+			}
+			sort.Slice(items, func(a, b int) bool { return items[a].ID < items[b].ID })
+			return items, nil
+		},
+		Key:  func(i item) string { return i.ID },
+		Meta: func(i item) (time.Time, string) { return i.LastUpdated, i.Version },
+	}
+}
+
+func next(t *testing.T, events <-chan watch.Event[item]) watch.Event[item] {
+	t.Helper()
+	select {
+	case e, ok := <-events:
+		require.True(t, ok, "channel closed")
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+	return watch.Event[item]{}
+}
+
+func TestWatch(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	src := newFakeSource()
+	src.put("a", "1", now)
+	src.put("b", "1", now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := watch.Watch(ctx, src.source(), 10*time.Millisecond, watch.Options{FullSyncEvery: 3})
+	require.NoError(t, err)
+
+	e := next(t, events)
+	assert.Equal(t, watch.Added, e.Type)
+	assert.Equal(t, "a", e.Key)
+	e = next(t, events)
+	assert.Equal(t, watch.Added, e.Type)
+	assert.Equal(t, "b", e.Key)
+
+	src.put("a", "2", now.Add(time.Minute))
+	e = next(t, events)
+	assert.Equal(t, watch.Updated, e.Type)
+	assert.Equal(t, "a", e.Key)
+	assert.Equal(t, "2", e.Version)
+	assert.Equal(t, "2", e.Resource.Version)
+
+	src.remove("b")
+	e = next(t, events)
+	assert.Equal(t, watch.Deleted, e.Type)
+	assert.Equal(t, "b", e.Key)
+
+	cancel()
+	for range events {
+	}
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	assert.Equal(t, "", src.queries[0])
+	assert.Contains(t, src.queries, "ge"+now.Add(-5*time.Second).Format(time.RFC3339))
+}
+
+func TestWatchRestart(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	src := newFakeSource()
+	src.put("a", "1", now)
+	src.put("b", "1", now)
+	store := watch.NewMemoryStore()
+
+	// Stop after the first event was delivered
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := watch.Watch(ctx, src.source(), time.Hour, watch.Options{Store: store})
+	require.NoError(t, err)
+	e := next(t, events)
+	assert.Equal(t, "a", e.Key)
+	cancel()
+	for range events {
+	}
+
+	src.put("c", "1", now.Add(time.Second))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	events, err = watch.Watch(ctx, src.source(), 10*time.Millisecond, watch.Options{Store: store})
+	require.NoError(t, err)
+	e = next(t, events)
+	assert.Equal(t, watch.Added, e.Type)
+	assert.Equal(t, "b", e.Key)
+	e = next(t, events)
+	assert.Equal(t, watch.Added, e.Type)
+	assert.Equal(t, "c", e.Key)
+
+	src.put("c", "2", now.Add(2*time.Second))
+	e = next(t, events)
+	assert.Equal(t, watch.Updated, e.Type)
+	assert.Equal(t, "c", e.Key)
+
+	cursor, err := store.Load(context.Background(), "Item")
+	require.NoError(t, err)
+	require.NotNil(t, cursor)
+	assert.Equal(t, map[string]string{"a": "1", "b": "1", "c": "2"}, cursor.Known)
+}
+
+func TestWatchSkipInitial(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	src := newFakeSource()
+	src.put("a", "1", now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := watch.Watch(ctx, src.source(), 10*time.Millisecond, watch.Options{SkipInitial: true})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		src.mu.Lock()
+		defer src.mu.Unlock()
+		return len(src.queries) > 1
+	}, 2*time.Second, 5*time.Millisecond)
+	src.put("b", "1", now.Add(time.Second))
+	e := next(t, events)
+	assert.Equal(t, watch.Added, e.Type)
+	assert.Equal(t, "b", e.Key)
+}
+
+func TestWatchErrors(t *testing.T) {
+	_, err := watch.Watch(context.Background(), watch.Source[item]{}, time.Second, watch.Options{})
+	assert.ErrorIs(t, err, watch.ErrMissingSource)
+
+	_, err = watch.Watch(context.Background(), newFakeSource().source(), 0, watch.Options{})
+	assert.ErrorIs(t, err, watch.ErrInvalidInterval)
+}
@@ -0,0 +1,22 @@
+package autoscaling
+
+import (
+	"errors"
+
+	"github.com/dip-software/go-dip-api/console"
+)
+
+// Exported Errors
+var (
+	ErrMissingMetrics       = errors.New("missing metrics service")
+	ErrMissingApplication   = errors.New("missing application name")
+	ErrApplicationNotFound  = errors.New("application not found")
+	ErrInstanceNotFound     = errors.New("metrics instance not found")
+	ErrUnknownThreshold     = errors.New("unknown threshold")
+	ErrInvalidInstanceRange = errors.New("invalid min/max instances")
+	ErrThresholdOutOfRange  = console.ErrThresholdOutOfRange
+	ErrInvalidUnit          = errors.New("invalid threshold unit")
+	ErrDuplicatePolicy      = errors.New("duplicate policy")
+	ErrMissingSnapshot      = errors.New("missing snapshot")
+	ErrApplyFailed          = errors.New("one or more changes failed")
+)
@@ -0,0 +1,336 @@
+// Package autoscaling manages the autoscaler settings of applications across HSDP Metrics
+// instances declaratively: desired policies are validated, diffed against the current
+// settings and applied, and the settings can be snapshot and restored
+package autoscaling
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dip-software/go-dip-api/console"
+)
+
+// Metrics is the part of console.MetricsService the planner uses
+type Metrics interface {
+	GetInstances(options ...console.OptionFunc) (*[]console.Instance, *console.Response, error)
+	GetApplicationAutoscalers(id string, options ...console.OptionFunc) (*[]console.Application, *console.Response, error)
+	UpdateApplicationAutoscaler(id string, settings console.Application, options ...console.OptionFunc) (*console.Application, *console.Response, error)
+}
+
+var _ Metrics = (*console.MetricsService)(nil)
+
+// ThresholdPolicy is the desired setting of a named threshold, e.g. cpu or memory
+type ThresholdPolicy struct {
+	Name    string  `json:"name" yaml:"name"`
+	Enabled bool    `json:"enabled" yaml:"enabled"`
+	Min     float64 `json:"min" yaml:"min"`
+	Max     float64 `json:"max" yaml:"max"`
+	// Unit, when set, must be one of the units of the threshold
+	Unit string `json:"unit,omitempty" yaml:"unit,omitempty"`
+}
+
+// Policy is the desired autoscaler setting of an application. Thresholds which are
+// not listed keep their current setting
+type Policy struct {
+	// Instance is the GUID or name of the metrics instance. Empty applies the
+	// policy to every instance which has the application
+	Instance     string            `json:"instance,omitempty" yaml:"instance,omitempty"`
+	Application  string            `json:"application" yaml:"application"`
+	Enabled      bool              `json:"enabled" yaml:"enabled"`
+	MinInstances int               `json:"minInstances" yaml:"minInstances"`
+	MaxInstances int               `json:"maxInstances" yaml:"maxInstances"`
+	Thresholds   []ThresholdPolicy `json:"thresholds,omitempty" yaml:"thresholds,omitempty"`
+}
+
+// Limit is the allowed range of a threshold
+type Limit struct {
+	Min float64
+	Max float64
+}
+
+// Options control a Planner
+type Options struct {
+	// Limits narrows the allowed range of thresholds by name. Thresholds without a limit
+	// are validated against the range of their units, see console.Threshold.UnitRange
+	Limits map[string]Limit
+}
+
+// Planner plans and applies autoscaler policies
+type Planner struct {
+	metrics Metrics
+	limits  map[string]Limit
+}
+
+// NewPlanner returns a Planner which uses metrics, usually console.Client.Metrics
+func NewPlanner(metrics Metrics, opts Options) (*Planner, error) {
+	if metrics == nil {
+		return nil, ErrMissingMetrics
+	}
+	return &Planner{metrics: metrics, limits: maps.Clone(opts.Limits)}, nil
+}
+
+// Change is the update of the autoscaler of one application
+type Change struct {
+	InstanceID   string              `json:"instanceId"`
+	InstanceName string              `json:"instanceName"`
+	Current      console.Application `json:"current"`
+	Desired      console.Application `json:"desired"`
+	// Diff describes the changed fields, e.g. "maxInstances: 5 -> 10"
+	Diff []string `json:"diff"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s/%s: %s", c.InstanceName, c.Desired.Name, strings.Join(c.Diff, ", "))
+}
+
+// Plan is the set of changes needed to reach the desired policies
+type Plan struct {
+	Changes []Change `json:"changes"`
+	// Unchanged lists the instance/application pairs already in the desired state
+	Unchanged []string `json:"unchanged,omitempty"`
+}
+
+// Empty returns true if the plan has no changes
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+func (p *Plan) String() string {
+	if p.Empty() {
+		return "no changes"
+	}
+	lines := make([]string, len(p.Changes))
+	for i, c := range p.Changes {
+		lines[i] = "~ " + c.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+type instanceState struct {
+	instance     console.Instance
+	applications []console.Application
+}
+
+// state loads the autoscalers of all metrics instances
+func (p *Planner) state() ([]instanceState, error) {
+	instances, _, err := p.metrics.GetInstances()
+	if err != nil {
+		return nil, fmt.Errorf("get instances: %w", err)
+	}
+	if instances == nil {
+		return nil, nil
+	}
+	states := make([]instanceState, 0, len(*instances))
+	for _, instance := range *instances {
+		apps, _, err := p.metrics.GetApplicationAutoscalers(instance.GUID)
+		if err != nil {
+			return nil, fmt.Errorf("get autoscalers of %s: %w", instance.Name, err)
+		}
+		s := instanceState{instance: instance}
+		if apps != nil {
+			s.applications = *apps
+		}
+		states = append(states, s)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].instance.Name < states[j].instance.Name
+	})
+	return states, nil
+}
+
+// validate checks a policy against the current settings of the application
+func (p *Planner) validate(policy Policy, current console.Application) error {
+	if policy.MinInstances < 1 || policy.MaxInstances < policy.MinInstances {
+		return fmt.Errorf("%w: %s: min %d, max %d", ErrInvalidInstanceRange, policy.Application, policy.MinInstances, policy.MaxInstances)
+	}
+	for _, tp := range policy.Thresholds {
+		i := slices.IndexFunc(current.Thresholds, func(t console.Threshold) bool { return t.Name == tp.Name })
+		if i < 0 {
+			return fmt.Errorf("%w: %s: %s", ErrUnknownThreshold, policy.Application, tp.Name)
+		}
+		threshold := current.Thresholds[i]
+		if tp.Unit != "" && len(threshold.Unit) > 0 && !slices.Contains(threshold.Unit, tp.Unit) {
+			return fmt.Errorf("%w: %s: %s unit %q, allowed: %v", ErrInvalidUnit, policy.Application, tp.Name, tp.Unit, threshold.Unit)
+		}
+		if err := threshold.ValidateRange(tp.Min, tp.Max); err != nil {
+			return fmt.Errorf("%s: %s: %w", policy.Application, tp.Name, err)
+		}
+		if limit, ok := p.limits[tp.Name]; ok && (tp.Min < limit.Min || tp.Max > limit.Max) {
+			return fmt.Errorf("%w: %s: %s [%v, %v] not within [%v, %v]", ErrThresholdOutOfRange, policy.Application, tp.Name, tp.Min, tp.Max, limit.Min, limit.Max)
+		}
+	}
+	return nil
+}
+
+// desired applies the policy to a copy of the current settings
+func desired(policy Policy, current console.Application) console.Application {
+	app := current
+	app.Enabled = policy.Enabled
+	app.MinInstances = policy.MinInstances
+	app.MaxInstances = policy.MaxInstances
+	app.Thresholds = slices.Clone(current.Thresholds)
+	for _, tp := range policy.Thresholds {
+		for i := range app.Thresholds {
+			if app.Thresholds[i].Name == tp.Name {
+				app.Thresholds[i].Enabled = tp.Enabled
+				app.Thresholds[i].Min = tp.Min
+				app.Thresholds[i].Max = tp.Max
+			}
+		}
+	}
+	return app
+}
+
+// diff describes the differences between two settings of an application
+func diff(current, desired console.Application) []string {
+	var d []string
+	if current.Enabled != desired.Enabled {
+		d = append(d, fmt.Sprintf("enabled: %t -> %t", current.Enabled, desired.Enabled))
+	}
+	if current.MinInstances != desired.MinInstances {
+		d = append(d, fmt.Sprintf("minInstances: %d -> %d", current.MinInstances, desired.MinInstances))
+	}
+	if current.MaxInstances != desired.MaxInstances {
+		d = append(d, fmt.Sprintf("maxInstances: %d -> %d", current.MaxInstances, desired.MaxInstances))
+	}
+	for _, want := range desired.Thresholds {
+		i := slices.IndexFunc(current.Thresholds, func(t console.Threshold) bool { return t.Name == want.Name })
+		if i < 0 {
+			d = append(d, fmt.Sprintf("thresholds[%s]: added", want.Name))
+			continue
+		}
+		have := current.Thresholds[i]
+		if have.Enabled != want.Enabled {
+			d = append(d, fmt.Sprintf("thresholds[%s].enabled: %t -> %t", want.Name, have.Enabled, want.Enabled))
+		}
+		if have.Min != want.Min {
+			d = append(d, fmt.Sprintf("thresholds[%s].min: %v -> %v", want.Name, have.Min, want.Min))
+		}
+		if have.Max != want.Max {
+			d = append(d, fmt.Sprintf("thresholds[%s].max: %v -> %v", want.Name, have.Max, want.Max))
+		}
+	}
+	return d
+}
+
+// Plan validates the policies and diffs them against the current autoscaler settings.
+// Validation errors of all policies are returned together
+func (p *Planner) Plan(policies []Policy) (*Plan, error) {
+	states, err := p.state()
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{}
+	var errs []error
+	targeted := make(map[string]bool)
+	for _, policy := range policies {
+		if policy.Application == "" {
+			errs = append(errs, ErrMissingApplication)
+			continue
+		}
+		found := false
+		for _, s := range states {
+			if policy.Instance != "" && policy.Instance != s.instance.GUID && policy.Instance != s.instance.Name {
+				continue
+			}
+			i := slices.IndexFunc(s.applications, func(a console.Application) bool { return a.Name == policy.Application })
+			if i < 0 {
+				continue
+			}
+			found = true
+			key := s.instance.Name + "/" + policy.Application
+			if targeted[key] {
+				errs = append(errs, fmt.Errorf("%w: %s", ErrDuplicatePolicy, key))
+				continue
+			}
+			targeted[key] = true
+			current := s.applications[i]
+			if err := p.validate(policy, current); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.instance.Name, err))
+				continue
+			}
+			plan.add(s.instance, current, desired(policy, current))
+		}
+		if !found {
+			where := "any instance"
+			if policy.Instance != "" {
+				where = policy.Instance
+			}
+			errs = append(errs, fmt.Errorf("%w: %s in %s", ErrApplicationNotFound, policy.Application, where))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return plan, nil
+}
+
+func (p *Plan) add(instance console.Instance, current, desired console.Application) {
+	d := diff(current, desired)
+	if len(d) == 0 {
+		p.Unchanged = append(p.Unchanged, instance.Name+"/"+desired.Name)
+		return
+	}
+	p.Changes = append(p.Changes, Change{
+		InstanceID:   instance.GUID,
+		InstanceName: instance.Name,
+		Current:      current,
+		Desired:      desired,
+		Diff:         d,
+	})
+}
+
+// Result is the outcome of a single change
+type Result struct {
+	Change Change `json:"change"`
+	Error  error  `json:"-"`
+}
+
+// Report is the outcome of applying a plan
+type Report struct {
+	Applied []Change `json:"applied"`
+	Failed  []Result `json:"failed,omitempty"`
+}
+
+// Err returns ErrApplyFailed with the individual errors if any change failed
+func (r *Report) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	errs := make([]error, 0, len(r.Failed)+1)
+	errs = append(errs, ErrApplyFailed)
+	for _, f := range r.Failed {
+		errs = append(errs, fmt.Errorf("%s/%s: %w", f.Change.InstanceName, f.Change.Desired.Name, f.Error))
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "%d applied, %d failed", len(r.Applied), len(r.Failed))
+	for _, c := range r.Applied {
+		_, _ = fmt.Fprintf(&b, "\n  ok   %s", c)
+	}
+	for _, f := range r.Failed {
+		_, _ = fmt.Fprintf(&b, "\n  fail %s: %v", f.Change, f.Error)
+	}
+	return b.String()
+}
+
+// Apply updates the autoscalers of the plan. All changes are attempted, failures are
+// collected in the report
+func (p *Planner) Apply(plan *Plan) *Report {
+	report := &Report{}
+	for _, change := range plan.Changes {
+		if _, _, err := p.metrics.UpdateApplicationAutoscaler(change.InstanceID, change.Desired); err != nil {
+			report.Failed = append(report.Failed, Result{Change: change, Error: err})
+			continue
+		}
+		report.Applied = append(report.Applied, change)
+	}
+	return report
+}
@@ -0,0 +1,194 @@
+package autoscaling_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/console"
+	"github.com/dip-software/go-dip-api/console/autoscaling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMetrics struct {
+	instances []console.Instance
+	apps      map[string][]console.Application
+	updates   []string
+	fail      map[string]error
+}
+
+func (f *fakeMetrics) GetInstances(_ ...console.OptionFunc) (*[]console.Instance, *console.Response, error) {
+	return &f.instances, nil, nil
+}
+
+func (f *fakeMetrics) GetApplicationAutoscalers(id string, _ ...console.OptionFunc) (*[]console.Application, *console.Response, error) {
+	apps := make([]console.Application, len(f.apps[id]))
+	for i, a := range f.apps[id] {
+		a.Thresholds = append([]console.Threshold(nil), a.Thresholds...)
+		apps[i] = a
+	}
+	return &apps, nil, nil
+}
+
+func (f *fakeMetrics) UpdateApplicationAutoscaler(id string, settings console.Application, _ ...console.OptionFunc) (*console.Application, *console.Response, error) {
+	if err := f.fail[settings.Name]; err != nil {
+		return nil, nil, err
+	}
+	f.updates = append(f.updates, id+"/"+settings.Name)
+	for i, a := range f.apps[id] {
+		if a.Name == settings.Name {
+			f.apps[id][i] = settings
+		}
+	}
+	return &settings, nil, nil
+}
+
+func app(name string, min, max int, cpuMax float64) console.Application {
+	return console.Application{
+		Name:         name,
+		Enabled:      true,
+		MinInstances: min,
+		MaxInstances: max,
+		Thresholds: []console.Threshold{
+			{Name: "cpu", Enabled: true, Min: 5, Max: cpuMax, Unit: []string{"%"}},
+			{Name: "memory", Enabled: false, Min: 20, Max: 100, Unit: []string{"%"}},
+			{Name: "http-latency", Enabled: false, Min: 0.01, Max: 10, Unit: []string{"s", "ms"}},
+		},
+	}
+}
+
+func newFake() *fakeMetrics {
+	return &fakeMetrics{
+		instances: []console.Instance{{GUID: "i-2", Name: "prod"}, {GUID: "i-1", Name: "dev"}},
+		apps: map[string][]console.Application{
+			"i-1": {app("api", 1, 2, 90), app("worker", 1, 1, 90)},
+			"i-2": {app("api", 2, 10, 90)},
+		},
+	}
+}
+
+func TestPlanAndApply(t *testing.T) {
+	fake := newFake()
+	planner, err := autoscaling.NewPlanner(fake, autoscaling.Options{})
+	require.NoError(t, err)
+
+	plan, err := planner.Plan([]autoscaling.Policy{
+		{Application: "api", Enabled: true, MinInstances: 2, MaxInstances: 10, Thresholds: []autoscaling.ThresholdPolicy{
+			{Name: "cpu", Enabled: true, Min: 5, Max: 80, Unit: "%"},
+		}},
+		{Instance: "dev", Application: "worker", Enabled: true, MinInstances: 1, MaxInstances: 1, Thresholds: []autoscaling.ThresholdPolicy{
+			{Name: "cpu", Enabled: true, Min: 5, Max: 90},
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 2)
+	assert.Equal(t, []string{"dev/worker"}, plan.Unchanged)
+	assert.Equal(t, "dev", plan.Changes[0].InstanceName)
+	assert.Equal(t, []string{"minInstances: 1 -> 2", "maxInstances: 2 -> 10", "thresholds[cpu].max: 90 -> 80"}, plan.Changes[0].Diff)
+	assert.Equal(t, []string{"thresholds[cpu].max: 90 -> 80"}, plan.Changes[1].Diff)
+	assert.Contains(t, plan.String(), "~ prod/api: thresholds[cpu].max: 90 -> 80")
+
+	report := planner.Apply(plan)
+	require.NoError(t, report.Err())
+	assert.Equal(t, []string{"i-1/api", "i-2/api"}, fake.updates)
+	assert.Equal(t, 80.0, fake.apps["i-2"][0].Thresholds[0].Max)
+	// Thresholds which are not part of the policy are kept
+	assert.Equal(t, 0.01, fake.apps["i-2"][0].Thresholds[2].Min)
+
+	plan, err = planner.Plan([]autoscaling.Policy{
+		{Application: "api", Enabled: true, MinInstances: 2, MaxInstances: 10, Thresholds: []autoscaling.ThresholdPolicy{
+			{Name: "cpu", Enabled: true, Min: 5, Max: 80},
+		}},
+	})
+	require.NoError(t, err)
+	assert.True(t, plan.Empty())
+}
+
+func TestPlanValidation(t *testing.T) {
+	planner, err := autoscaling.NewPlanner(newFake(), autoscaling.Options{
+		Limits: map[string]autoscaling.Limit{"http-latency": {Min: 0.01, Max: 5}},
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		policy autoscaling.Policy
+		err    error
+	}{
+		{autoscaling.Policy{Application: "api", MinInstances: 3, MaxInstances: 2}, autoscaling.ErrInvalidInstanceRange},
+		{autoscaling.Policy{Application: "api", MinInstances: 1, MaxInstances: 2, Thresholds: []autoscaling.ThresholdPolicy{{Name: "disk"}}}, autoscaling.ErrUnknownThreshold},
+		{autoscaling.Policy{Application: "api", MinInstances: 1, MaxInstances: 2, Thresholds: []autoscaling.ThresholdPolicy{{Name: "cpu", Min: 5, Max: 120}}}, autoscaling.ErrThresholdOutOfRange},
+		{autoscaling.Policy{Application: "api", MinInstances: 1, MaxInstances: 2, Thresholds: []autoscaling.ThresholdPolicy{{Name: "cpu", Min: 50, Max: 40}}}, autoscaling.ErrThresholdOutOfRange},
+		{autoscaling.Policy{Application: "api", MinInstances: 1, MaxInstances: 2, Thresholds: []autoscaling.ThresholdPolicy{{Name: "http-latency", Min: 0.1, Max: 8}}}, autoscaling.ErrThresholdOutOfRange},
+		{autoscaling.Policy{Application: "api", MinInstances: 1, MaxInstances: 2, Thresholds: []autoscaling.ThresholdPolicy{{Name: "http-latency", Min: 0.1, Max: 1, Unit: "%"}}}, autoscaling.ErrInvalidUnit},
+		{autoscaling.Policy{Application: "missing", MinInstances: 1, MaxInstances: 2}, autoscaling.ErrApplicationNotFound},
+		{autoscaling.Policy{Instance: "prod", Application: "worker", MinInstances: 1, MaxInstances: 2}, autoscaling.ErrApplicationNotFound},
+		{autoscaling.Policy{MinInstances: 1, MaxInstances: 2}, autoscaling.ErrMissingApplication},
+	}
+	for _, c := range cases {
+		_, err := planner.Plan([]autoscaling.Policy{c.policy})
+		assert.ErrorIs(t, err, c.err, "%+v", c.policy)
+	}
+
+	dup := autoscaling.Policy{Application: "worker", MinInstances: 1, MaxInstances: 2}
+	_, err = planner.Plan([]autoscaling.Policy{dup, dup})
+	assert.ErrorIs(t, err, autoscaling.ErrDuplicatePolicy)
+
+	_, err = autoscaling.NewPlanner(nil, autoscaling.Options{})
+	assert.ErrorIs(t, err, autoscaling.ErrMissingMetrics)
+}
+
+func TestApplyFailure(t *testing.T) {
+	fake := newFake()
+	fake.fail = map[string]error{"worker": errors.New("bad gateway")}
+	planner, err := autoscaling.NewPlanner(fake, autoscaling.Options{})
+	require.NoError(t, err)
+
+	plan, err := planner.Plan([]autoscaling.Policy{
+		{Application: "worker", Enabled: true, MinInstances: 1, MaxInstances: 3},
+		{Instance: "i-2", Application: "api", Enabled: false, MinInstances: 2, MaxInstances: 10},
+	})
+	require.NoError(t, err)
+	report := planner.Apply(plan)
+	assert.Len(t, report.Applied, 1)
+	assert.Len(t, report.Failed, 1)
+	assert.ErrorIs(t, report.Err(), autoscaling.ErrApplyFailed)
+	assert.Contains(t, report.String(), "1 applied, 1 failed")
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	fake := newFake()
+	planner, err := autoscaling.NewPlanner(fake, autoscaling.Options{})
+	require.NoError(t, err)
+
+	snapshot, err := planner.Snapshot()
+	require.NoError(t, err)
+	require.Len(t, snapshot.Instances, 2)
+
+	data, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	var restored autoscaling.Snapshot
+	require.NoError(t, json.Unmarshal(data, &restored))
+
+	plan, err := planner.Plan([]autoscaling.Policy{{Application: "api", Enabled: true, MinInstances: 5, MaxInstances: 20}})
+	require.NoError(t, err)
+	require.NoError(t, planner.Apply(plan).Err())
+	assert.Equal(t, 20, fake.apps["i-2"][0].MaxInstances)
+
+	report, err := planner.Restore(&restored)
+	require.NoError(t, err)
+	assert.Len(t, report.Applied, 2)
+	assert.Equal(t, 2, fake.apps["i-1"][0].MaxInstances)
+	assert.Equal(t, 10, fake.apps["i-2"][0].MaxInstances)
+
+	plan, err = planner.PlanRestore(&restored)
+	require.NoError(t, err)
+	assert.True(t, plan.Empty())
+
+	restored.Instances[0].ID = "gone"
+	_, err = planner.PlanRestore(&restored)
+	assert.ErrorIs(t, err, autoscaling.ErrInstanceNotFound)
+
+	_, err = planner.Restore(nil)
+	assert.ErrorIs(t, err, autoscaling.ErrMissingSnapshot)
+}
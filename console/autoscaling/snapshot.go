@@ -0,0 +1,85 @@
+package autoscaling
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dip-software/go-dip-api/console"
+)
+
+// InstanceSnapshot holds the autoscaler settings of one metrics instance
+type InstanceSnapshot struct {
+	ID           string                `json:"id"`
+	Name         string                `json:"name"`
+	Applications []console.Application `json:"applications"`
+}
+
+// Snapshot holds the autoscaler settings of all metrics instances. It can be
+// stored as JSON, e.g. before a load test, and restored afterwards
+type Snapshot struct {
+	TakenAt   time.Time          `json:"takenAt"`
+	Instances []InstanceSnapshot `json:"instances"`
+}
+
+// Snapshot records the current autoscaler settings
+func (p *Planner) Snapshot() (*Snapshot, error) {
+	states, err := p.state()
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{TakenAt: time.Now().UTC()}
+	for _, s := range states {
+		snapshot.Instances = append(snapshot.Instances, InstanceSnapshot{
+			ID:           s.instance.GUID,
+			Name:         s.instance.Name,
+			Applications: s.applications,
+		})
+	}
+	return snapshot, nil
+}
+
+// PlanRestore diffs the snapshot against the current settings. Applications which were
+// removed since the snapshot was taken are reported as errors
+func (p *Planner) PlanRestore(snapshot *Snapshot) (*Plan, error) {
+	if snapshot == nil {
+		return nil, ErrMissingSnapshot
+	}
+	states, err := p.state()
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{}
+	var errs []error
+	for _, saved := range snapshot.Instances {
+		i := slices.IndexFunc(states, func(s instanceState) bool { return s.instance.GUID == saved.ID })
+		if i < 0 {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrInstanceNotFound, saved.Name))
+			continue
+		}
+		s := states[i]
+		for _, app := range saved.Applications {
+			j := slices.IndexFunc(s.applications, func(a console.Application) bool { return a.Name == app.Name })
+			if j < 0 {
+				errs = append(errs, fmt.Errorf("%w: %s in %s", ErrApplicationNotFound, app.Name, saved.Name))
+				continue
+			}
+			plan.add(s.instance, s.applications[j], app)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return plan, nil
+}
+
+// Restore applies a snapshot
+func (p *Planner) Restore(snapshot *Snapshot) (*Report, error) {
+	plan, err := p.PlanRestore(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	report := p.Apply(plan)
+	return report, report.Err()
+}
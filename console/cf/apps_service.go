@@ -0,0 +1,172 @@
+package cf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dip-software/go-dip-api/console"
+)
+
+// AppsService provides access to Cloud Foundry apps
+type AppsService struct {
+	client *Client
+}
+
+// App is a Cloud Foundry app
+type App struct {
+	GUID      string    `json:"guid"`
+	Name      string    `json:"name"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Lifecycle struct {
+		Type string `json:"type"`
+	} `json:"lifecycle"`
+	Relationships struct {
+		Space Relationship `json:"space"`
+	} `json:"relationships"`
+}
+
+// SpaceGUID returns the GUID of the space the app belongs to
+func (a App) SpaceGUID() string {
+	return a.Relationships.Space.Data.GUID
+}
+
+// AppListOptions filters apps
+type AppListOptions struct {
+	ListOptions
+	SpaceGUIDs        []string `url:"space_guids,omitempty" del:","`
+	OrganizationGUIDs []string `url:"organization_guids,omitempty" del:","`
+}
+
+// ProcessStats are the statistics of a single process instance
+type ProcessStats struct {
+	Type      string `json:"type"`
+	Index     int    `json:"index"`
+	State     string `json:"state"`
+	Host      string `json:"host"`
+	Uptime    int64  `json:"uptime"`
+	MemQuota  int64  `json:"mem_quota"`
+	DiskQuota int64  `json:"disk_quota"`
+	Usage     struct {
+		Time time.Time `json:"time"`
+		CPU  float64   `json:"cpu"`
+		Mem  int64     `json:"mem"`
+		Disk int64     `json:"disk"`
+	} `json:"usage"`
+}
+
+// AppEnvironment is the environment of an app
+type AppEnvironment struct {
+	EnvironmentVariables map[string]string      `json:"environment_variables"`
+	StagingEnv           map[string]interface{} `json:"staging_env_json"`
+	RunningEnv           map[string]interface{} `json:"running_env_json"`
+	SystemEnv            map[string]interface{} `json:"system_env_json"`
+	ApplicationEnv       map[string]interface{} `json:"application_env_json"`
+}
+
+// Process is an app process
+type Process struct {
+	GUID       string `json:"guid"`
+	Type       string `json:"type"`
+	Instances  int    `json:"instances"`
+	MemoryInMB int    `json:"memory_in_mb"`
+	DiskInMB   int    `json:"disk_in_mb"`
+}
+
+// ScaleInput changes the instances or resources of a process. Nil fields are unchanged
+type ScaleInput struct {
+	Instances  *int `json:"instances,omitempty"`
+	MemoryInMB *int `json:"memory_in_mb,omitempty"`
+	DiskInMB   *int `json:"disk_in_mb,omitempty"`
+}
+
+// GetApps lists the apps visible to the user
+func (a *AppsService) GetApps(ctx context.Context, opt *AppListOptions) ([]App, error) {
+	return list[App](ctx, a.client, "v3/apps", opt)
+}
+
+// GetAppByGUID retrieves an app
+func (a *AppsService) GetAppByGUID(ctx context.Context, guid string) (*App, error) {
+	req, err := a.client.newRequest(ctx, http.MethodGet, "v3/apps/"+guid, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var app App
+	if err := a.client.do(req, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// GetAppStats retrieves the statistics of every instance of the web process of an app
+func (a *AppsService) GetAppStats(ctx context.Context, guid string) ([]ProcessStats, error) {
+	req, err := a.client.newRequest(ctx, http.MethodGet, "v3/apps/"+guid+"/processes/web/stats", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Resources []ProcessStats `json:"resources"`
+	}
+	if err := a.client.do(req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Resources, nil
+}
+
+// GetAppEnvironment retrieves the environment of an app. This requires space developer access
+func (a *AppsService) GetAppEnvironment(ctx context.Context, guid string) (*AppEnvironment, error) {
+	req, err := a.client.newRequest(ctx, http.MethodGet, "v3/apps/"+guid+"/env", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var env AppEnvironment
+	if err := a.client.do(req, &env); err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
+
+// ScaleApp scales the web process of an app
+func (a *AppsService) ScaleApp(ctx context.Context, guid string, input ScaleInput) (*Process, error) {
+	if input.Instances == nil && input.MemoryInMB == nil && input.DiskInMB == nil {
+		return nil, ErrInvalidScale
+	}
+	req, err := a.client.newRequest(ctx, http.MethodPost, "v3/apps/"+guid+"/processes/web/actions/scale", nil, input)
+	if err != nil {
+		return nil, err
+	}
+	var process Process
+	if err := a.client.do(req, &process); err != nil {
+		return nil, err
+	}
+	return &process, nil
+}
+
+// RestartApp stops and starts an app
+func (a *AppsService) RestartApp(ctx context.Context, guid string) (*App, error) {
+	req, err := a.client.newRequest(ctx, http.MethodPost, "v3/apps/"+guid+"/actions/restart", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var app App
+	if err := a.client.do(req, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// GetAppsForInstance lists the apps in the organization and space of a console metrics instance
+func (a *AppsService) GetAppsForInstance(ctx context.Context, instance console.Instance) ([]App, error) {
+	org, err := a.client.Organizations.GetOrganizationByName(ctx, instance.Organization)
+	if err != nil {
+		return nil, fmt.Errorf("instance %s: %w", instance.Name, err)
+	}
+	space, err := a.client.Spaces.GetSpaceByName(ctx, org.GUID, instance.Space)
+	if err != nil {
+		return nil, fmt.Errorf("instance %s: %w", instance.Name, err)
+	}
+	return a.GetApps(ctx, &AppListOptions{SpaceGUIDs: []string{space.GUID}})
+}
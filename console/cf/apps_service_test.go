@@ -0,0 +1,100 @@
+package cf_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/console"
+	"github.com/dip-software/go-dip-api/console/cf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const appJSON = `{
+  "guid": "app-1",
+  "name": "api",
+  "state": "STARTED",
+  "lifecycle": {"type": "buildpack"},
+  "relationships": {"space": {"data": {"guid": "space-1"}}}
+}`
+
+func TestApps(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	muxCF.HandleFunc("/v3/apps", authorized(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "space-1", r.URL.Query().Get("space_guids"))
+		_, _ = io.WriteString(w, `{"pagination": {"total_results": 1}, "resources": [`+appJSON+`]}`)
+	}))
+	muxCF.HandleFunc("/v3/apps/app-1", authorized(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, appJSON)
+	}))
+	muxCF.HandleFunc("/v3/apps/app-1/processes/web/stats", authorized(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"resources": [
+  {"type": "web", "index": 0, "state": "RUNNING", "host": "10.0.0.1", "uptime": 3600, "mem_quota": 1073741824, "disk_quota": 1073741824,
+   "usage": {"time": "2024-03-01T12:00:00Z", "cpu": 0.25, "mem": 268435456, "disk": 134217728}},
+  {"type": "web", "index": 1, "state": "CRASHED"}
+]}`)
+	}))
+	muxCF.HandleFunc("/v3/apps/app-1/env", authorized(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{
+  "environment_variables": {"LOG_LEVEL": "debug"},
+  "system_env_json": {"VCAP_SERVICES": {}},
+  "application_env_json": {"VCAP_APPLICATION": {"application_name": "api"}}
+}`)
+	}))
+	muxCF.HandleFunc("/v3/apps/app-1/processes/web/actions/scale", authorized(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		var body map[string]int
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]int{"instances": 3}, body)
+		_, _ = io.WriteString(w, `{"guid": "proc-1", "type": "web", "instances": 3, "memory_in_mb": 1024, "disk_in_mb": 1024}`)
+	}))
+	muxCF.HandleFunc("/v3/apps/app-1/actions/restart", authorized(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		_, _ = io.WriteString(w, appJSON)
+	}))
+	muxCF.HandleFunc("/v3/organizations", authorized(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "client-test", r.URL.Query().Get("names"))
+		_, _ = io.WriteString(w, `{"pagination": {}, "resources": [{"guid": "org-1", "name": "client-test"}]}`)
+	}))
+	muxCF.HandleFunc("/v3/spaces", authorized(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"pagination": {}, "resources": [{"guid": "space-1", "name": "dev"}]}`)
+	}))
+	ctx := context.Background()
+
+	app, err := client.Apps.GetAppByGUID(ctx, "app-1")
+	require.NoError(t, err)
+	assert.Equal(t, "space-1", app.SpaceGUID())
+	assert.Equal(t, "buildpack", app.Lifecycle.Type)
+
+	stats, err := client.Apps.GetAppStats(ctx, "app-1")
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, 0.25, stats[0].Usage.CPU)
+	assert.Equal(t, "CRASHED", stats[1].State)
+
+	env, err := client.Apps.GetAppEnvironment(ctx, "app-1")
+	require.NoError(t, err)
+	assert.Equal(t, "debug", env.EnvironmentVariables["LOG_LEVEL"])
+	assert.Contains(t, env.ApplicationEnv, "VCAP_APPLICATION")
+
+	_, err = client.Apps.ScaleApp(ctx, "app-1", cf.ScaleInput{})
+	assert.ErrorIs(t, err, cf.ErrInvalidScale)
+	instances := 3
+	process, err := client.Apps.ScaleApp(ctx, "app-1", cf.ScaleInput{Instances: &instances})
+	require.NoError(t, err)
+	assert.Equal(t, 3, process.Instances)
+
+	app, err = client.Apps.RestartApp(ctx, "app-1")
+	require.NoError(t, err)
+	assert.Equal(t, "STARTED", app.State)
+
+	apps, err := client.Apps.GetAppsForInstance(ctx, console.Instance{Name: "metrics", Organization: "client-test", Space: "dev"})
+	require.NoError(t, err)
+	require.Len(t, apps, 1)
+	assert.Equal(t, "api", apps[0].Name)
+}
@@ -0,0 +1,263 @@
+// Package cf provides support for the HSDP Cloud Foundry API using the UAA login of a console client
+package cf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	autoconf "github.com/dip-software/go-dip-api/config"
+	"github.com/dip-software/go-dip-api/console"
+	"github.com/dip-software/go-dip-api/internal"
+	"github.com/google/go-querystring/query"
+	"golang.org/x/oauth2"
+)
+
+const (
+	userAgent = "go-dip-api/cf/" + internal.LibraryVersion
+)
+
+// Config contains the configuration of a client
+type Config struct {
+	Region   string
+	CFAPIURL string
+	DebugLog io.Writer
+}
+
+// A Client manages communication with the Cloud Foundry API
+type Client struct {
+	consoleClient *console.Client
+
+	httpClient *http.Client
+
+	config *Config
+
+	baseURL *url.URL
+
+	links   *console.CFLinksResponse
+	linksMu sync.Mutex
+
+	// User agent used when communicating with the Cloud Foundry API
+	UserAgent string
+
+	Organizations   *OrganizationsService
+	Spaces          *SpacesService
+	Apps            *AppsService
+	NetworkPolicies *NetworkPoliciesService
+	LogCache        *LogCacheService
+}
+
+// NewClient returns a new Cloud Foundry API client. A logged in console client must be
+// provided as its UAA token is used to authenticate
+func NewClient(consoleClient *console.Client, config *Config) (*Client, error) {
+	return newClient(consoleClient, config)
+}
+
+func newClient(consoleClient *console.Client, config *Config) (*Client, error) {
+	if consoleClient == nil {
+		return nil, ErrMissingConsoleClient
+	}
+	doAutoconf(config)
+	if config.CFAPIURL == "" {
+		return nil, ErrCFAPIURLCannotBeEmpty
+	}
+	baseURL, err := url.Parse(config.CFAPIURL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path += "/"
+	}
+	c := &Client{consoleClient: consoleClient, config: config, baseURL: baseURL, UserAgent: userAgent}
+
+	httpClient := oauth2.NewClient(context.Background(), consoleClient)
+	if config.DebugLog != nil {
+		httpClient.Transport = internal.NewLoggingRoundTripper(httpClient.Transport, config.DebugLog)
+	}
+	header := make(http.Header)
+	header.Set("User-Agent", userAgent)
+	httpClient.Transport = internal.NewHeaderRoundTripper(httpClient.Transport, header)
+	c.httpClient = httpClient
+
+	c.Organizations = &OrganizationsService{client: c}
+	c.Spaces = &SpacesService{client: c}
+	c.Apps = &AppsService{client: c}
+	c.NetworkPolicies = &NetworkPoliciesService{client: c}
+	c.LogCache = &LogCacheService{client: c}
+	return c, nil
+}
+
+func doAutoconf(config *Config) {
+	if config.Region != "" {
+		c, err := autoconf.New(
+			autoconf.WithRegion(config.Region))
+		if err == nil {
+			cfService := c.Service("cf")
+			if config.CFAPIURL == "" {
+				config.CFAPIURL = cfService.URL
+			}
+		}
+	}
+}
+
+// Links returns the root links of the Cloud Foundry API. The result is cached
+func (c *Client) Links(ctx context.Context) (*console.CFLinksResponse, error) {
+	c.linksMu.Lock()
+	defer c.linksMu.Unlock()
+	if c.links != nil {
+		return c.links, nil
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, c.baseURL.String(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var links console.CFLinksResponse
+	if err := c.do(req, &links); err != nil {
+		return nil, err
+	}
+	c.links = &links
+	return c.links, nil
+}
+
+// APIError is a single error returned by the Cloud Foundry API
+type APIError struct {
+	Code   int    `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+// ErrorResponse is returned for unsuccessful API calls
+type ErrorResponse struct {
+	StatusCode int        `json:"-"`
+	Errors     []APIError `json:"errors"`
+	// Message is set by the network policy API
+	Message string `json:"error"`
+}
+
+func (e *ErrorResponse) Error() string {
+	var details []string
+	for _, err := range e.Errors {
+		details = append(details, fmt.Sprintf("%s (%d): %s", err.Title, err.Code, err.Detail))
+	}
+	if e.Message != "" {
+		details = append(details, e.Message)
+	}
+	return fmt.Sprintf("cf: status %d: %s", e.StatusCode, strings.Join(details, "; "))
+}
+
+// Is maps a 404 response to ErrNotFound
+func (e *ErrorResponse) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// newRequest creates a request for path, which is relative to the API URL unless it is absolute
+func (c *Client) newRequest(ctx context.Context, method, path string, opt interface{}, body interface{}) (*http.Request, error) {
+	u, err := c.baseURL.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	if opt != nil {
+		q, err := query.Values(opt)
+		if err != nil {
+			return nil, err
+		}
+		u.RawQuery = q.Encode()
+	}
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+func (c *Client) do(req *http.Request, v interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errResp := &ErrorResponse{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(data, errResp)
+		return errResp
+	}
+	if v == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Link is a hyperlink in a Cloud Foundry response
+type Link struct {
+	Href string `json:"href"`
+}
+
+// Pagination describes the pages of a list response
+type Pagination struct {
+	TotalResults int   `json:"total_results"`
+	TotalPages   int   `json:"total_pages"`
+	Next         *Link `json:"next"`
+}
+
+// Relationship refers to another resource
+type Relationship struct {
+	Data struct {
+		GUID string `json:"guid"`
+	} `json:"data"`
+}
+
+// ListOptions are the common filters and paging options of list endpoints
+type ListOptions struct {
+	Names   []string `url:"names,omitempty" del:","`
+	PerPage int      `url:"per_page,omitempty"`
+	OrderBy string   `url:"order_by,omitempty"`
+}
+
+// list retrieves all pages of a list endpoint
+func list[T any](ctx context.Context, c *Client, path string, opt interface{}) ([]T, error) {
+	items := make([]T, 0)
+	next := path
+	for next != "" {
+		req, err := c.newRequest(ctx, http.MethodGet, next, opt, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Pagination Pagination `json:"pagination"`
+			Resources  []T        `json:"resources"`
+		}
+		if err := c.do(req, &page); err != nil {
+			return nil, err
+		}
+		items = append(items, page.Resources...)
+		next, opt = "", nil
+		if page.Pagination.Next != nil {
+			next = page.Pagination.Next.Href
+		}
+	}
+	return items, nil
+}
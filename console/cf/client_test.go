@@ -0,0 +1,116 @@
+package cf_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/console"
+	"github.com/dip-software/go-dip-api/console/cf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	muxUAA    *http.ServeMux
+	serverUAA *httptest.Server
+	muxCF     *http.ServeMux
+	serverCF  *httptest.Server
+
+	consoleClient *console.Client
+	client        *cf.Client
+)
+
+const token = "44d20214-7879-4e35-923d-f9d4e01c9746"
+
+func setup(t *testing.T) func() {
+	muxUAA = http.NewServeMux()
+	serverUAA = httptest.NewServer(muxUAA)
+	muxCF = http.NewServeMux()
+	serverCF = httptest.NewServer(muxCF)
+
+	var err error
+	consoleClient, err = console.NewClient(nil, &console.Config{
+		UAAURL:         serverUAA.URL,
+		BaseConsoleURL: serverUAA.URL,
+	})
+	require.NoError(t, err)
+
+	muxUAA.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+  "access_token": "`+token+`",
+  "refresh_token": "31f1a449-ef8e-4bfc-a227-4f2353fde547",
+  "expires_in": 1799,
+  "token_type": "Bearer"
+}`)
+	})
+	require.NoError(t, consoleClient.Login("username", "password"))
+
+	muxCF.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"errors": [{"code": 10000, "title": "CF-NotFound", "detail": "Unknown request"}]}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{
+  "links": {
+    "self": {"href": "`+serverCF.URL+`"},
+    "cloud_controller_v3": {"href": "`+serverCF.URL+`/v3", "meta": {"version": "3.140.0"}},
+    "network_policy_v1": {"href": "`+serverCF.URL+`/networking/v1/external"},
+    "log_cache": {"href": "`+serverCF.URL+`"}
+  }
+}`)
+	})
+
+	client, err = cf.NewClient(consoleClient, &cf.Config{CFAPIURL: serverCF.URL})
+	require.NoError(t, err)
+
+	return func() {
+		serverUAA.Close()
+		serverCF.Close()
+	}
+}
+
+// authorized wraps a handler and checks the UAA token is passed on
+func authorized(t *testing.T, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer "+token, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		handler(w, r)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := cf.NewClient(nil, &cf.Config{CFAPIURL: "https://api.example.com"})
+	assert.ErrorIs(t, err, cf.ErrMissingConsoleClient)
+
+	teardown := setup(t)
+	defer teardown()
+	_, err = cf.NewClient(consoleClient, &cf.Config{})
+	assert.ErrorIs(t, err, cf.ErrCFAPIURLCannotBeEmpty)
+}
+
+func TestLinks(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	links, err := client.Links(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, serverCF.URL+"/networking/v1/external", links.Links.NetworkPolicyV1.Href)
+	assert.Equal(t, "3.140.0", links.Links.CloudControllerV3.Meta.Version)
+}
+
+func TestErrorResponse(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	_, err := client.Apps.GetAppByGUID(context.Background(), "missing")
+	assert.ErrorIs(t, err, cf.ErrNotFound)
+	var errResp *cf.ErrorResponse
+	require.ErrorAs(t, err, &errResp)
+	assert.Equal(t, "CF-NotFound", errResp.Errors[0].Title)
+	assert.Contains(t, err.Error(), "Unknown request")
+}
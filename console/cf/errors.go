@@ -0,0 +1,18 @@
+package cf
+
+import "errors"
+
+// Exported Errors
+var (
+	ErrMissingConsoleClient     = errors.New("missing console client")
+	ErrCFAPIURLCannotBeEmpty    = errors.New("CF API URL cannot be empty")
+	ErrNotFound                 = errors.New("not found")
+	ErrNetworkPolicyUnavailable = errors.New("network policy API not available")
+	ErrInvalidScale             = errors.New("scale needs instances, memory or disk")
+	ErrMissingPolicies          = errors.New("missing policies")
+	ErrLogCacheUnavailable      = errors.New("log-cache not available")
+	ErrMissingSourceID          = errors.New("missing source ID")
+	ErrInvalidEnvelope          = errors.New("invalid envelope")
+	ErrMissingStorer            = errors.New("missing logging storer")
+	ErrForwardFailed            = errors.New("forwarding logs failed")
+)
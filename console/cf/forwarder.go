@@ -0,0 +1,117 @@
+package cf
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/dip-software/go-dip-api/logging"
+	"github.com/google/uuid"
+)
+
+// ForwarderOptions control how envelopes are turned into HSDP logging resources
+type ForwarderOptions struct {
+	// Match selects the log lines to forward. All lines are forwarded when nil
+	Match *regexp.Regexp
+	// EventID of the resources. Defaults to "1"
+	EventID string
+	// Category of the resources. Defaults to TRACELOG
+	Category string
+	// Component of the resources. Defaults to CF
+	Component string
+	// BatchSize is the maximum number of resources per store call. Defaults to 25
+	BatchSize int
+}
+
+// Forwarder stores the log envelopes of CF apps in HSDP logging
+type Forwarder struct {
+	storer logging.Storer
+	opts   ForwarderOptions
+}
+
+// NewForwarder returns a Forwarder which stores resources with storer, e.g. a logging.Client
+func NewForwarder(storer logging.Storer, opts ForwarderOptions) (*Forwarder, error) {
+	if storer == nil {
+		return nil, ErrMissingStorer
+	}
+	if opts.EventID == "" {
+		opts.EventID = "1"
+	}
+	if opts.Category == "" {
+		opts.Category = "TRACELOG"
+	}
+	if opts.Component == "" {
+		opts.Component = "CF"
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 25
+	}
+	return &Forwarder{storer: storer, opts: opts}, nil
+}
+
+// Resource converts a log envelope to a resource with the base64 encoded line as message.
+// It returns false for other envelopes, empty lines and lines which do not match
+func (f *Forwarder) Resource(e Envelope) (logging.Resource, bool) {
+	message := e.Message()
+	if message == "" || (f.opts.Match != nil && !f.opts.Match.MatchString(message)) {
+		return logging.Resource{}, false
+	}
+	severity := "INFO"
+	if e.Log.Type == "ERR" {
+		severity = "ERROR"
+	}
+	applicationName := e.Tags["app_name"]
+	if applicationName == "" {
+		applicationName = e.SourceID
+	}
+	custom, _ := json.Marshal(map[string]string{
+		"sourceId":     e.SourceID,
+		"sourceType":   e.Tags["source_type"],
+		"organization": e.Tags["organization_name"],
+		"space":        e.Tags["space_name"],
+	})
+	return logging.Resource{
+		ID:                  uuid.New().String(),
+		EventID:             f.opts.EventID,
+		TransactionID:       uuid.New().String(),
+		ApplicationName:     applicationName,
+		ApplicationInstance: e.InstanceID,
+		ServiceName:         e.Tags["space_name"],
+		Category:            f.opts.Category,
+		Component:           f.opts.Component,
+		LogTime:             e.Timestamp.UTC().Format(logging.TimeFormat),
+		Severity:            severity,
+		LogData:             logging.LogData{Message: base64.StdEncoding.EncodeToString([]byte(message))},
+		Custom:              custom,
+	}, true
+}
+
+// Forward stores the matching log envelopes in batches and returns the number of stored resources
+func (f *Forwarder) Forward(envelopes []Envelope) (int, error) {
+	resources := make([]logging.Resource, 0, len(envelopes))
+	for _, e := range envelopes {
+		if r, ok := f.Resource(e); ok {
+			resources = append(resources, r)
+		}
+	}
+	stored := 0
+	for start := 0; start < len(resources); start += f.opts.BatchSize {
+		batch := resources[start:min(start+f.opts.BatchSize, len(resources))]
+		if _, err := f.storer.StoreResources(batch, len(batch)); err != nil {
+			return stored, fmt.Errorf("%w: %v", ErrForwardFailed, err)
+		}
+		stored += len(batch)
+	}
+	return stored, nil
+}
+
+// Follow forwards the logs of a source until ctx is done or storing fails
+func (f *Forwarder) Follow(ctx context.Context, logCache *LogCacheService, sourceID string, opt FollowOptions) error {
+	opt.EnvelopeTypes = []EnvelopeType{EnvelopeTypeLog}
+	return logCache.Follow(ctx, sourceID, opt, func(envelopes []Envelope) error {
+		_, err := f.Forward(envelopes)
+		return err
+	})
+}
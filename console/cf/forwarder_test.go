@@ -0,0 +1,123 @@
+package cf_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/dip-software/go-dip-api/console/cf"
+	"github.com/dip-software/go-dip-api/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStorer struct {
+	batches [][]logging.Resource
+	err     error
+	stored  func()
+}
+
+func (f *fakeStorer) StoreResources(msgs []logging.Resource, count int) (*logging.StoreResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.batches = append(f.batches, append([]logging.Resource{}, msgs[:count]...))
+	if f.stored != nil {
+		f.stored()
+	}
+	return &logging.StoreResponse{}, nil
+}
+
+func message(t *testing.T, r logging.Resource) string {
+	data, err := base64.StdEncoding.DecodeString(r.LogData.Message)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func envelope(line, stream string) cf.Envelope {
+	return cf.Envelope{
+		Timestamp:  logStart,
+		SourceID:   "app-1",
+		InstanceID: "2",
+		Tags:       map[string]string{"app_name": "api", "space_name": "dev", "organization_name": "client-test"},
+		Log:        &cf.Log{Payload: line, Type: stream},
+	}
+}
+
+func TestForwarderResource(t *testing.T) {
+	forwarder, err := cf.NewForwarder(&fakeStorer{}, cf.ForwarderOptions{})
+	require.NoError(t, err)
+
+	r, ok := forwarder.Resource(envelope("boom\n", "ERR"))
+	require.True(t, ok)
+	assert.True(t, r.Valid(), r.Error)
+	assert.Equal(t, "boom", message(t, r))
+	assert.Equal(t, "ERROR", r.Severity)
+	assert.Equal(t, "api", r.ApplicationName)
+	assert.Equal(t, "2", r.ApplicationInstance)
+	assert.Equal(t, "2024-03-01T12:00:00.000Z", r.LogTime)
+	var custom map[string]string
+	require.NoError(t, json.Unmarshal(r.Custom, &custom))
+	assert.Equal(t, "client-test", custom["organization"])
+
+	_, ok = forwarder.Resource(envelope("\n", "OUT"))
+	assert.False(t, ok)
+	_, ok = forwarder.Resource(cf.Envelope{Gauge: &cf.Gauge{}})
+	assert.False(t, ok)
+
+	_, err = cf.NewForwarder(nil, cf.ForwarderOptions{})
+	assert.ErrorIs(t, err, cf.ErrMissingStorer)
+}
+
+func TestForward(t *testing.T) {
+	storer := &fakeStorer{}
+	forwarder, err := cf.NewForwarder(storer, cf.ForwarderOptions{Match: regexp.MustCompile(`^ERROR`), BatchSize: 2})
+	require.NoError(t, err)
+
+	var envelopes []cf.Envelope
+	for i := 0; i < 5; i++ {
+		envelopes = append(envelopes, envelope(fmt.Sprintf("ERROR %d", i), "OUT"), envelope("debug", "OUT"))
+	}
+	stored, err := forwarder.Forward(envelopes)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stored)
+	require.Len(t, storer.batches, 3)
+	assert.Len(t, storer.batches[2], 1)
+	assert.Equal(t, "ERROR 4", message(t, storer.batches[2][0]))
+
+	storer.err = logging.ErrBatchErrors
+	_, err = forwarder.Forward(envelopes)
+	assert.ErrorIs(t, err, cf.ErrForwardFailed)
+}
+
+func TestForwarderFollow(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	fake := &fakeLogCache{}
+	fake.add(0, "started", "OUT")
+	fake.add(time.Millisecond, "failed", "ERR")
+	muxCF.HandleFunc("/api/v1/read/app-1", fake.handler(t))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	storer := &fakeStorer{stored: cancel}
+	forwarder, err := cf.NewForwarder(storer, cf.ForwarderOptions{})
+	require.NoError(t, err)
+
+	err = forwarder.Follow(ctx, client.LogCache, "app-1", cf.FollowOptions{StartTime: logStart, Interval: 10 * time.Millisecond})
+	assert.ErrorIs(t, err, context.Canceled)
+	require.Len(t, storer.batches, 1)
+	assert.Equal(t, "INFO", storer.batches[0][0].Severity)
+	assert.Equal(t, "ERROR", storer.batches[0][1].Severity)
+
+	storer.err = errors.New("ingestor down")
+	fake.add(time.Second, "more", "OUT")
+	err = forwarder.Follow(context.Background(), client.LogCache, "app-1", cf.FollowOptions{StartTime: logStart})
+	assert.ErrorIs(t, err, cf.ErrForwardFailed)
+}
@@ -0,0 +1,363 @@
+package cf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogCacheService reads app logs and metrics from log-cache
+type LogCacheService struct {
+	client *Client
+}
+
+// EnvelopeType is the type of an Envelope
+type EnvelopeType string
+
+// Envelope types
+const (
+	EnvelopeTypeLog     EnvelopeType = "LOG"
+	EnvelopeTypeCounter EnvelopeType = "COUNTER"
+	EnvelopeTypeGauge   EnvelopeType = "GAUGE"
+	EnvelopeTypeTimer   EnvelopeType = "TIMER"
+	EnvelopeTypeEvent   EnvelopeType = "EVENT"
+)
+
+// Log is a log line. Type is OUT for stdout and ERR for stderr
+type Log struct {
+	Payload string
+	Type    string
+}
+
+// Counter is a monotonically increasing metric
+type Counter struct {
+	Name  string
+	Delta uint64
+	Total uint64
+}
+
+// GaugeValue is a single gauge metric
+type GaugeValue struct {
+	Unit  string  `json:"unit"`
+	Value float64 `json:"value"`
+}
+
+// Gauge is a set of metrics sampled at the same time
+type Gauge struct {
+	Metrics map[string]GaugeValue
+}
+
+// Timer measures a duration, e.g. of an HTTP request
+type Timer struct {
+	Name  string
+	Start time.Time
+	Stop  time.Time
+}
+
+// Event is a platform event
+type Event struct {
+	Title string
+	Body  string
+}
+
+// Envelope is a loggregator envelope. Exactly one of Log, Counter, Gauge, Timer and Event is set
+type Envelope struct {
+	Timestamp  time.Time
+	SourceID   string
+	InstanceID string
+	Tags       map[string]string
+	Log        *Log
+	Counter    *Counter
+	Gauge      *Gauge
+	Timer      *Timer
+	Event      *Event
+}
+
+// Type returns the type of the envelope
+func (e Envelope) Type() EnvelopeType {
+	switch {
+	case e.Log != nil:
+		return EnvelopeTypeLog
+	case e.Counter != nil:
+		return EnvelopeTypeCounter
+	case e.Gauge != nil:
+		return EnvelopeTypeGauge
+	case e.Timer != nil:
+		return EnvelopeTypeTimer
+	case e.Event != nil:
+		return EnvelopeTypeEvent
+	}
+	return ""
+}
+
+// Message returns the log line without the trailing newline
+func (e Envelope) Message() string {
+	if e.Log == nil {
+		return ""
+	}
+	return strings.TrimRight(e.Log.Payload, "\r\n")
+}
+
+// UnmarshalJSON decodes the protobuf JSON encoding of an envelope, where 64-bit integers are
+// strings and log payloads are base64 encoded
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Timestamp  string            `json:"timestamp"`
+		SourceID   string            `json:"source_id"`
+		InstanceID string            `json:"instance_id"`
+		Tags       map[string]string `json:"tags"`
+		Log        *struct {
+			Payload []byte `json:"payload"`
+			Type    string `json:"type"`
+		} `json:"log"`
+		Counter *struct {
+			Name  string `json:"name"`
+			Delta string `json:"delta"`
+			Total string `json:"total"`
+		} `json:"counter"`
+		Gauge *struct {
+			Metrics map[string]GaugeValue `json:"metrics"`
+		} `json:"gauge"`
+		Timer *struct {
+			Name  string `json:"name"`
+			Start string `json:"start"`
+			Stop  string `json:"stop"`
+		} `json:"timer"`
+		Event *Event `json:"event"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	ts, err := parseNanos(raw.Timestamp)
+	if err != nil {
+		return fmt.Errorf("%w: timestamp: %v", ErrInvalidEnvelope, err)
+	}
+	*e = Envelope{Timestamp: ts, SourceID: raw.SourceID, InstanceID: raw.InstanceID, Tags: raw.Tags, Event: raw.Event}
+	if raw.Log != nil {
+		logType := raw.Log.Type
+		if logType == "" {
+			logType = "OUT"
+		}
+		e.Log = &Log{Payload: string(raw.Log.Payload), Type: logType}
+	}
+	if raw.Counter != nil {
+		e.Counter = &Counter{Name: raw.Counter.Name}
+		if e.Counter.Delta, err = parseUint(raw.Counter.Delta); err != nil {
+			return fmt.Errorf("%w: counter delta: %v", ErrInvalidEnvelope, err)
+		}
+		if e.Counter.Total, err = parseUint(raw.Counter.Total); err != nil {
+			return fmt.Errorf("%w: counter total: %v", ErrInvalidEnvelope, err)
+		}
+	}
+	if raw.Gauge != nil {
+		e.Gauge = &Gauge{Metrics: raw.Gauge.Metrics}
+	}
+	if raw.Timer != nil {
+		e.Timer = &Timer{Name: raw.Timer.Name}
+		if e.Timer.Start, err = parseNanos(raw.Timer.Start); err != nil {
+			return fmt.Errorf("%w: timer start: %v", ErrInvalidEnvelope, err)
+		}
+		if e.Timer.Stop, err = parseNanos(raw.Timer.Stop); err != nil {
+			return fmt.Errorf("%w: timer stop: %v", ErrInvalidEnvelope, err)
+		}
+	}
+	return nil
+}
+
+func parseNanos(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, n).UTC(), nil
+}
+
+func parseUint(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// ReadOptions select envelopes. Zero times are left to log-cache, which defaults to the
+// start of the cache and now respectively
+type ReadOptions struct {
+	StartTime     time.Time
+	EndTime       time.Time
+	EnvelopeTypes []EnvelopeType
+	// Limit is the maximum number of envelopes per request. log-cache allows up to 1000
+	Limit      int
+	Descending bool
+}
+
+type readQuery struct {
+	StartTime     int64    `url:"start_time,omitempty"`
+	EndTime       int64    `url:"end_time,omitempty"`
+	EnvelopeTypes []string `url:"envelope_types,omitempty"`
+	Limit         int      `url:"limit,omitempty"`
+	Descending    bool     `url:"descending,omitempty"`
+}
+
+func (o *ReadOptions) query() *readQuery {
+	q := &readQuery{}
+	if o == nil {
+		return q
+	}
+	if !o.StartTime.IsZero() {
+		q.StartTime = o.StartTime.UnixNano()
+	}
+	if !o.EndTime.IsZero() {
+		q.EndTime = o.EndTime.UnixNano()
+	}
+	for _, t := range o.EnvelopeTypes {
+		q.EnvelopeTypes = append(q.EnvelopeTypes, string(t))
+	}
+	q.Limit = o.Limit
+	q.Descending = o.Descending
+	return q
+}
+
+// Read retrieves a single page of envelopes of a source, e.g. an app GUID
+func (l *LogCacheService) Read(ctx context.Context, sourceID string, opt *ReadOptions) ([]Envelope, error) {
+	if sourceID == "" {
+		return nil, ErrMissingSourceID
+	}
+	links, err := l.client.Links(ctx)
+	if err != nil {
+		return nil, err
+	}
+	base := links.Links.LogCache.Href
+	if base == "" {
+		return nil, ErrLogCacheUnavailable
+	}
+	req, err := l.client.newRequest(ctx, http.MethodGet, strings.TrimSuffix(base, "/")+"/api/v1/read/"+sourceID, opt.query(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Envelopes struct {
+			Batch []Envelope `json:"batch"`
+		} `json:"envelopes"`
+	}
+	if err := l.client.do(req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Envelopes.Batch, nil
+}
+
+// ReadWindow retrieves all envelopes of a source between start and end in ascending order
+func (l *LogCacheService) ReadWindow(ctx context.Context, sourceID string, start, end time.Time, types ...EnvelopeType) ([]Envelope, error) {
+	return l.readWindow(ctx, sourceID, start, end, types, &cursor{})
+}
+
+// readWindow reads the pages of a window. Each page starts at the timestamp of the last
+// delivered envelope, as several envelopes can share it, and c drops the ones already delivered
+func (l *LogCacheService) readWindow(ctx context.Context, sourceID string, start, end time.Time, types []EnvelopeType, c *cursor) ([]Envelope, error) {
+	var all []Envelope
+	opt := &ReadOptions{StartTime: start, EndTime: end, EnvelopeTypes: types, Limit: 1000}
+	for {
+		page, err := l.Read(ctx, sourceID, opt)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, c.next(page)...)
+		if len(page) < opt.Limit {
+			return all, nil
+		}
+		last := page[len(page)-1].Timestamp
+		if last.Equal(opt.StartTime) {
+			// A full page of a single timestamp cannot be paged through
+			last = last.Add(time.Nanosecond)
+		}
+		opt.StartTime = last
+	}
+}
+
+// cursor tracks the timestamp of the last delivered envelope and how often each envelope
+// with that timestamp was delivered
+type cursor struct {
+	at        time.Time
+	delivered map[string]int
+}
+
+// next returns the envelopes of page which were not delivered yet and advances the cursor
+func (c *cursor) next(page []Envelope) []Envelope {
+	var fresh []Envelope
+	seen := make(map[string]int)
+	for _, e := range page {
+		key := e.key()
+		if e.Timestamp.Equal(c.at) {
+			seen[key]++
+			if seen[key] <= c.delivered[key] {
+				continue
+			}
+		} else {
+			c.at, c.delivered = e.Timestamp, make(map[string]int)
+		}
+		c.delivered[key]++
+		fresh = append(fresh, e)
+	}
+	return fresh
+}
+
+// key identifies an envelope among the envelopes sharing its timestamp
+func (e Envelope) key() string {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Sprintf("%s/%s/%s", e.SourceID, e.InstanceID, e.Type())
+	}
+	return string(data)
+}
+
+// FollowOptions control Follow
+type FollowOptions struct {
+	// StartTime is the time of the first envelope. Defaults to now
+	StartTime time.Time
+	// Interval between polls. Defaults to one second
+	Interval      time.Duration
+	EnvelopeTypes []EnvelopeType
+}
+
+// Follow polls log-cache for new envelopes of a source and calls fn with every non-empty batch
+// in ascending order. It returns when ctx is done or fn returns an error. Envelopes which reach
+// log-cache later than an interval after their timestamp can be missed
+func (l *LogCacheService) Follow(ctx context.Context, sourceID string, opt FollowOptions, fn func([]Envelope) error) error {
+	interval := opt.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	start := opt.StartTime
+	if start.IsZero() {
+		start = time.Now()
+	}
+	c := &cursor{}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		batch, err := l.readWindow(ctx, sourceID, start, time.Time{}, opt.EnvelopeTypes, c)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if len(batch) > 0 {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			start = c.at
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
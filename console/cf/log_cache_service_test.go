@@ -0,0 +1,180 @@
+package cf_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dip-software/go-dip-api/console/cf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var logStart = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func logEnvelope(offset time.Duration, line, stream string) string {
+	return fmt.Sprintf(`{"timestamp": "%d", "source_id": "app-1", "instance_id": "0",
+  "tags": {"app_name": "api", "space_name": "dev", "organization_name": "client-test", "source_type": "APP/PROC/WEB"},
+  "log": {"payload": "%s", "type": "%s"}}`, logStart.Add(offset).UnixNano(), base64.StdEncoding.EncodeToString([]byte(line)), stream)
+}
+
+// fakeLogCache serves envelopes with a timestamp at or after start_time, limited to limit
+type fakeLogCache struct {
+	mu        sync.Mutex
+	envelopes []struct {
+		ts   time.Time
+		json string
+	}
+	requests int
+}
+
+func (f *fakeLogCache) add(offset time.Duration, line, stream string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.envelopes = append(f.envelopes, struct {
+		ts   time.Time
+		json string
+	}{logStart.Add(offset), logEnvelope(offset, line, stream)})
+}
+
+func (f *fakeLogCache) handler(t *testing.T) http.HandlerFunc {
+	return authorized(t, func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.requests++
+		q := r.URL.Query()
+		start, _ := strconv.ParseInt(q.Get("start_time"), 10, 64)
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		batch := ""
+		n := 0
+		for _, e := range f.envelopes {
+			if e.ts.UnixNano() < start || (limit > 0 && n == limit) {
+				continue
+			}
+			if n > 0 {
+				batch += ","
+			}
+			batch += e.json
+			n++
+		}
+		_, _ = io.WriteString(w, `{"envelopes": {"batch": [`+batch+`]}}`)
+	})
+}
+
+func TestLogCacheRead(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	muxCF.HandleFunc("/api/v1/read/app-1", authorized(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, strconv.FormatInt(logStart.UnixNano(), 10), q.Get("start_time"))
+		assert.Equal(t, []string{"LOG", "GAUGE", "COUNTER", "TIMER"}, q["envelope_types"])
+		_, _ = io.WriteString(w, `{"envelopes": {"batch": [
+`+logEnvelope(0, "hello\n", "OUT")+`,
+{"timestamp": "`+strconv.FormatInt(logStart.Add(time.Second).UnixNano(), 10)+`", "source_id": "app-1", "instance_id": "0",
+ "gauge": {"metrics": {"cpu": {"unit": "percentage", "value": 12.5}, "memory": {"unit": "bytes", "value": 1024}}}},
+{"timestamp": "`+strconv.FormatInt(logStart.Add(2*time.Second).UnixNano(), 10)+`", "source_id": "app-1",
+ "counter": {"name": "requests", "delta": "5", "total": "18446744073709551615"}},
+{"timestamp": "`+strconv.FormatInt(logStart.Add(3*time.Second).UnixNano(), 10)+`", "source_id": "app-1",
+ "timer": {"name": "http", "start": "`+strconv.FormatInt(logStart.UnixNano(), 10)+`", "stop": "`+strconv.FormatInt(logStart.Add(250*time.Millisecond).UnixNano(), 10)+`"}}
+]}}`)
+	}))
+
+	envelopes, err := client.LogCache.Read(context.Background(), "app-1", &cf.ReadOptions{
+		StartTime:     logStart,
+		EnvelopeTypes: []cf.EnvelopeType{cf.EnvelopeTypeLog, cf.EnvelopeTypeGauge, cf.EnvelopeTypeCounter, cf.EnvelopeTypeTimer},
+	})
+	require.NoError(t, err)
+	require.Len(t, envelopes, 4)
+
+	assert.Equal(t, cf.EnvelopeTypeLog, envelopes[0].Type())
+	assert.Equal(t, logStart, envelopes[0].Timestamp)
+	assert.Equal(t, "hello", envelopes[0].Message())
+	assert.Equal(t, "api", envelopes[0].Tags["app_name"])
+
+	assert.Equal(t, cf.EnvelopeTypeGauge, envelopes[1].Type())
+	assert.Equal(t, 12.5, envelopes[1].Gauge.Metrics["cpu"].Value)
+
+	assert.Equal(t, cf.EnvelopeTypeCounter, envelopes[2].Type())
+	assert.Equal(t, uint64(18446744073709551615), envelopes[2].Counter.Total)
+
+	assert.Equal(t, 250*time.Millisecond, envelopes[3].Timer.Stop.Sub(envelopes[3].Timer.Start))
+
+	_, err = client.LogCache.Read(context.Background(), "", nil)
+	assert.ErrorIs(t, err, cf.ErrMissingSourceID)
+}
+
+func TestLogCacheReadWindow(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	fake := &fakeLogCache{}
+	for i := 0; i < 2500; i++ {
+		fake.add(time.Duration(i)*time.Millisecond, fmt.Sprintf("line %d", i), "OUT")
+	}
+	muxCF.HandleFunc("/api/v1/read/app-1", fake.handler(t))
+
+	envelopes, err := client.LogCache.ReadWindow(context.Background(), "app-1", logStart, logStart.Add(time.Hour), cf.EnvelopeTypeLog)
+	require.NoError(t, err)
+	assert.Len(t, envelopes, 2500)
+	assert.Equal(t, "line 2499", envelopes[2499].Message())
+	assert.Equal(t, 3, fake.requests)
+}
+
+func TestLogCacheReadWindowSharedTimestamp(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	// The envelopes 998 to 1001 share a timestamp across the page boundary
+	fake := &fakeLogCache{}
+	for i := 0; i < 1002; i++ {
+		fake.add(time.Duration(min(i, 998))*time.Millisecond, fmt.Sprintf("line %d", i), "OUT")
+	}
+	fake.add(time.Second, "line 1001", "OUT")
+	muxCF.HandleFunc("/api/v1/read/app-1", fake.handler(t))
+
+	envelopes, err := client.LogCache.ReadWindow(context.Background(), "app-1", logStart, logStart.Add(time.Hour), cf.EnvelopeTypeLog)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 1003)
+	for i := 0; i < 1002; i++ {
+		assert.Equal(t, fmt.Sprintf("line %d", i), envelopes[i].Message())
+	}
+	assert.Equal(t, 2, fake.requests)
+}
+
+func TestLogCacheFollow(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	fake := &fakeLogCache{}
+	fake.add(-time.Second, "before start", "OUT")
+	fake.add(0, "first", "OUT")
+	muxCF.HandleFunc("/api/v1/read/app-1", fake.handler(t))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var lines []string
+	err := client.LogCache.Follow(ctx, "app-1", cf.FollowOptions{StartTime: logStart, Interval: 10 * time.Millisecond}, func(batch []cf.Envelope) error {
+		for _, e := range batch {
+			lines = append(lines, e.Message())
+		}
+		switch len(lines) {
+		case 1:
+			fake.add(time.Second, "second", "ERR")
+		case 2:
+			// Arrives later with the timestamp of the last delivered envelope
+			fake.add(time.Second, "third", "OUT")
+		case 3:
+			cancel()
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"first", "second", "third"}, lines)
+}
@@ -0,0 +1,103 @@
+package cf
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// NetworkPoliciesService manages container to container networking policies
+type NetworkPoliciesService struct {
+	client *Client
+}
+
+// PolicyPorts is an inclusive port range
+type PolicyPorts struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// PolicySource is the app which is allowed to connect
+type PolicySource struct {
+	ID string `json:"id"`
+}
+
+// PolicyDestination is the app which accepts connections
+type PolicyDestination struct {
+	ID       string      `json:"id"`
+	Protocol string      `json:"protocol"`
+	Ports    PolicyPorts `json:"ports"`
+}
+
+// Policy allows traffic from the source app to the destination app
+type Policy struct {
+	Source      PolicySource      `json:"source"`
+	Destination PolicyDestination `json:"destination"`
+}
+
+type policiesRequest struct {
+	Policies []Policy `json:"policies"`
+}
+
+// policyURL returns the URL of path below the network policy API
+func (n *NetworkPoliciesService) policyURL(ctx context.Context, path string) (string, error) {
+	links, err := n.client.Links(ctx)
+	if err != nil {
+		return "", err
+	}
+	base := links.Links.NetworkPolicyV1.Href
+	if base == "" {
+		return "", ErrNetworkPolicyUnavailable
+	}
+	return strings.TrimSuffix(base, "/") + "/" + path, nil
+}
+
+type policyListOptions struct {
+	ID []string `url:"id,omitempty" del:","`
+}
+
+// GetPolicies lists the policies of which any of the apps is source or destination.
+// Without apps all policies visible to the user are returned
+func (n *NetworkPoliciesService) GetPolicies(ctx context.Context, appGUIDs ...string) ([]Policy, error) {
+	u, err := n.policyURL(ctx, "policies")
+	if err != nil {
+		return nil, err
+	}
+	req, err := n.client.newRequest(ctx, http.MethodGet, u, &policyListOptions{ID: appGUIDs}, nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		TotalPolicies int      `json:"total_policies"`
+		Policies      []Policy `json:"policies"`
+	}
+	if err := n.client.do(req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Policies, nil
+}
+
+// CreatePolicies adds policies. Existing policies are left alone
+func (n *NetworkPoliciesService) CreatePolicies(ctx context.Context, policies ...Policy) error {
+	return n.post(ctx, "policies", policies)
+}
+
+// DeletePolicies removes policies
+func (n *NetworkPoliciesService) DeletePolicies(ctx context.Context, policies ...Policy) error {
+	return n.post(ctx, "policies/delete", policies)
+}
+
+func (n *NetworkPoliciesService) post(ctx context.Context, path string, policies []Policy) error {
+	if len(policies) == 0 {
+		return ErrMissingPolicies
+	}
+	u, err := n.policyURL(ctx, path)
+	if err != nil {
+		return err
+	}
+	req, err := n.client.newRequest(ctx, http.MethodPost, u, nil, policiesRequest{Policies: policies})
+	if err != nil {
+		return err
+	}
+	return n.client.do(req, nil)
+}
@@ -0,0 +1,72 @@
+package cf_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/console/cf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkPolicies(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	var created, deleted []cf.Policy
+	muxCF.HandleFunc("/networking/v1/external/policies", authorized(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			assert.Equal(t, "app-1,app-2", r.URL.Query().Get("id"))
+			_, _ = io.WriteString(w, `{"total_policies": 1, "policies": [
+  {"source": {"id": "app-1"}, "destination": {"id": "app-2", "protocol": "tcp", "ports": {"start": 8080, "end": 8080}}}
+]}`)
+		case http.MethodPost:
+			var body struct {
+				Policies []cf.Policy `json:"policies"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			created = body.Policies
+			w.WriteHeader(http.StatusOK)
+			_, _ = io.WriteString(w, `{}`)
+		}
+	}))
+	muxCF.HandleFunc("/networking/v1/external/policies/delete", authorized(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Policies []cf.Policy `json:"policies"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body.Policies[0].Destination.Ports.Start == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"error": "missing ports"}`)
+			return
+		}
+		deleted = body.Policies
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	ctx := context.Background()
+
+	policies, err := client.NetworkPolicies.GetPolicies(ctx, "app-1", "app-2")
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, 8080, policies[0].Destination.Ports.Start)
+
+	policy := cf.Policy{
+		Source:      cf.PolicySource{ID: "app-1"},
+		Destination: cf.PolicyDestination{ID: "app-3", Protocol: "tcp", Ports: cf.PolicyPorts{Start: 9000, End: 9010}},
+	}
+	require.NoError(t, client.NetworkPolicies.CreatePolicies(ctx, policy))
+	assert.Equal(t, []cf.Policy{policy}, created)
+
+	require.NoError(t, client.NetworkPolicies.DeletePolicies(ctx, policy))
+	assert.Equal(t, []cf.Policy{policy}, deleted)
+
+	err = client.NetworkPolicies.DeletePolicies(ctx, cf.Policy{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "missing ports")
+
+	assert.ErrorIs(t, client.NetworkPolicies.CreatePolicies(ctx), cf.ErrMissingPolicies)
+}
@@ -0,0 +1,52 @@
+package cf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// OrganizationsService provides access to Cloud Foundry organizations
+type OrganizationsService struct {
+	client *Client
+}
+
+// Organization is a Cloud Foundry organization
+type Organization struct {
+	GUID      string    `json:"guid"`
+	Name      string    `json:"name"`
+	Suspended bool      `json:"suspended"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GetOrganizations lists the organizations visible to the user
+func (o *OrganizationsService) GetOrganizations(ctx context.Context, opt *ListOptions) ([]Organization, error) {
+	return list[Organization](ctx, o.client, "v3/organizations", opt)
+}
+
+// GetOrganizationByGUID retrieves an organization
+func (o *OrganizationsService) GetOrganizationByGUID(ctx context.Context, guid string) (*Organization, error) {
+	req, err := o.client.newRequest(ctx, http.MethodGet, "v3/organizations/"+guid, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var org Organization
+	if err := o.client.do(req, &org); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// GetOrganizationByName retrieves an organization by its unique name
+func (o *OrganizationsService) GetOrganizationByName(ctx context.Context, name string) (*Organization, error) {
+	orgs, err := o.GetOrganizations(ctx, &ListOptions{Names: []string{name}})
+	if err != nil {
+		return nil, err
+	}
+	if len(orgs) == 0 {
+		return nil, fmt.Errorf("organization %s: %w", name, ErrNotFound)
+	}
+	return &orgs[0], nil
+}
@@ -0,0 +1,60 @@
+package cf_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/console/cf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizationsAndSpaces(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	muxCF.HandleFunc("/v3/organizations", authorized(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "":
+			_, _ = io.WriteString(w, `{
+  "pagination": {"total_results": 2, "total_pages": 2, "next": {"href": "`+serverCF.URL+`/v3/organizations?page=2"}},
+  "resources": [{"guid": "org-1", "name": "client-test", "suspended": false, "created_at": "2024-01-01T00:00:00Z"}]
+}`)
+		default:
+			_, _ = io.WriteString(w, `{
+  "pagination": {"total_results": 2, "total_pages": 2, "next": null},
+  "resources": [{"guid": "org-2", "name": "other"}]
+}`)
+		}
+	}))
+	muxCF.HandleFunc("/v3/spaces", authorized(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "org-1", r.URL.Query().Get("organization_guids"))
+		if r.URL.Query().Get("names") == "missing" {
+			_, _ = io.WriteString(w, `{"pagination": {"total_results": 0}, "resources": []}`)
+			return
+		}
+		assert.Equal(t, "dev", r.URL.Query().Get("names"))
+		_, _ = io.WriteString(w, `{
+  "pagination": {"total_results": 1},
+  "resources": [{"guid": "space-1", "name": "dev", "relationships": {"organization": {"data": {"guid": "org-1"}}}}]
+}`)
+	}))
+	ctx := context.Background()
+
+	orgs, err := client.Organizations.GetOrganizations(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, orgs, 2)
+	assert.Equal(t, "client-test", orgs[0].Name)
+	assert.Equal(t, 2024, orgs[0].CreatedAt.Year())
+	assert.Equal(t, "org-2", orgs[1].GUID)
+
+	space, err := client.Spaces.GetSpaceByName(ctx, "org-1", "dev")
+	require.NoError(t, err)
+	assert.Equal(t, "space-1", space.GUID)
+	assert.Equal(t, "org-1", space.OrganizationGUID())
+
+	_, err = client.Spaces.GetSpaceByName(ctx, "org-1", "missing")
+	assert.ErrorIs(t, err, cf.ErrNotFound)
+}
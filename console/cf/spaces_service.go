@@ -0,0 +1,68 @@
+package cf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SpacesService provides access to Cloud Foundry spaces
+type SpacesService struct {
+	client *Client
+}
+
+// Space is a Cloud Foundry space
+type Space struct {
+	GUID          string    `json:"guid"`
+	Name          string    `json:"name"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Relationships struct {
+		Organization Relationship `json:"organization"`
+	} `json:"relationships"`
+}
+
+// OrganizationGUID returns the GUID of the organization the space belongs to
+func (s Space) OrganizationGUID() string {
+	return s.Relationships.Organization.Data.GUID
+}
+
+// SpaceListOptions filters spaces
+type SpaceListOptions struct {
+	ListOptions
+	OrganizationGUIDs []string `url:"organization_guids,omitempty" del:","`
+}
+
+// GetSpaces lists the spaces visible to the user
+func (s *SpacesService) GetSpaces(ctx context.Context, opt *SpaceListOptions) ([]Space, error) {
+	return list[Space](ctx, s.client, "v3/spaces", opt)
+}
+
+// GetSpaceByGUID retrieves a space
+func (s *SpacesService) GetSpaceByGUID(ctx context.Context, guid string) (*Space, error) {
+	req, err := s.client.newRequest(ctx, http.MethodGet, "v3/spaces/"+guid, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var space Space
+	if err := s.client.do(req, &space); err != nil {
+		return nil, err
+	}
+	return &space, nil
+}
+
+// GetSpaceByName retrieves a space by name within an organization
+func (s *SpacesService) GetSpaceByName(ctx context.Context, orgGUID, name string) (*Space, error) {
+	spaces, err := s.GetSpaces(ctx, &SpaceListOptions{
+		ListOptions:       ListOptions{Names: []string{name}},
+		OrganizationGUIDs: []string{orgGUID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(spaces) == 0 {
+		return nil, fmt.Errorf("space %s: %w", name, ErrNotFound)
+	}
+	return &spaces[0], nil
+}
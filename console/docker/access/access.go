@@ -0,0 +1,337 @@
+// Package access synchronizes the user access of HSDP Docker registry namespaces with a
+// desired state, which can be derived from IAM group membership
+package access
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dip-software/go-dip-api/console/docker"
+	"github.com/dip-software/go-dip-api/iam"
+)
+
+// Desired maps a namespace to the access of its users, keyed by lowercase username. A namespace
+// with an empty user map has no managed users
+type Desired map[string]map[string]docker.UserNamespaceAccessInput
+
+// Grant adds access of username to namespace. Usernames are case-insensitive and access
+// granted more than once is combined
+func (d Desired) Grant(namespace, username string, access docker.UserNamespaceAccessInput) {
+	users := d.namespace(namespace)
+	username = strings.ToLower(username)
+	users[username] = Union(users[username], access)
+}
+
+// namespace returns the users of namespace, adding an empty entry if it is missing
+func (d Desired) namespace(namespace string) map[string]docker.UserNamespaceAccessInput {
+	users, ok := d[namespace]
+	if !ok {
+		users = make(map[string]docker.UserNamespaceAccessInput)
+		d[namespace] = users
+	}
+	return users
+}
+
+// Merge grants all access of other
+func (d Desired) Merge(other Desired) {
+	for namespace, users := range other {
+		d.namespace(namespace)
+		for username, access := range users {
+			d.Grant(namespace, username, access)
+		}
+	}
+}
+
+// Union combines two access settings
+func Union(a, b docker.UserNamespaceAccessInput) docker.UserNamespaceAccessInput {
+	return docker.UserNamespaceAccessInput{
+		CanPull:   a.CanPull || b.CanPull,
+		CanPush:   a.CanPush || b.CanPush,
+		CanDelete: a.CanDelete || b.CanDelete,
+		IsAdmin:   a.IsAdmin || b.IsAdmin,
+	}
+}
+
+// Describe renders an access setting, e.g. pull,push
+func Describe(access docker.UserNamespaceAccessInput) string {
+	var rights []string
+	if access.CanPull {
+		rights = append(rights, "pull")
+	}
+	if access.CanPush {
+		rights = append(rights, "push")
+	}
+	if access.CanDelete {
+		rights = append(rights, "delete")
+	}
+	if access.IsAdmin {
+		rights = append(rights, "admin")
+	}
+	if len(rights) == 0 {
+		return "none"
+	}
+	return strings.Join(rights, ",")
+}
+
+// GroupGrant gives the users of an IAM group access to a namespace
+type GroupGrant struct {
+	GroupID   string
+	Namespace string
+	Access    docker.UserNamespaceAccessInput
+}
+
+// Groups reads IAM group members, e.g. iam.Client.Groups
+type Groups interface {
+	SCIMGetGroupByIDAll(id string, opt *iam.SCIMGetGroupOptions, options ...iam.OptionFunc) (*iam.SCIMGroup, *iam.Response, error)
+}
+
+var _ Groups = (*iam.GroupsService)(nil)
+
+// FromGroups derives the desired access from the user members of IAM groups. Users in
+// several groups granting the same namespace get the union of the access. Every granted
+// namespace is part of the result, so the users of an emptied group are removed
+func FromGroups(groups Groups, grants []GroupGrant) (Desired, error) {
+	desired := make(Desired)
+	members := make(map[string][]string)
+	memberType := iam.GroupMemberTypeUser
+	for _, grant := range grants {
+		if grant.GroupID == "" || grant.Namespace == "" {
+			return nil, ErrInvalidGrant
+		}
+		desired.namespace(grant.Namespace)
+		usernames, ok := members[grant.GroupID]
+		if !ok {
+			group, _, err := groups.SCIMGetGroupByIDAll(grant.GroupID, &iam.SCIMGetGroupOptions{IncludeGroupMembersType: &memberType})
+			if err != nil {
+				return nil, fmt.Errorf("get members of group %s: %w", grant.GroupID, err)
+			}
+			for _, member := range group.ExtensionGroup.GroupMembers.Resources {
+				if member.UserName != "" {
+					usernames = append(usernames, member.UserName)
+				}
+			}
+			members[grant.GroupID] = usernames
+		}
+		for _, username := range usernames {
+			desired.Grant(grant.Namespace, username, grant.Access)
+		}
+	}
+	return desired, nil
+}
+
+// Namespaces manages namespace users, e.g. docker.Client.Namespaces
+type Namespaces interface {
+	GetNamespaceUsers(ctx context.Context, ns docker.Namespace) (*[]docker.NamespaceUser, error)
+	AddNamespaceUser(ctx context.Context, namespaceID, username string, access docker.UserNamespaceAccessInput) (*docker.NamespaceUserResult, error)
+	UpdateNamespaceUserAccess(ctx context.Context, id int, access docker.UserNamespaceAccessInput) error
+	DeleteNamespaceUser(ctx context.Context, namespaceID, userID string) error
+}
+
+var _ Namespaces = (*docker.NamespacesService)(nil)
+
+// Options control a Syncer
+type Options struct {
+	// KeepUnmanaged leaves users which are not in the desired state alone instead of removing them
+	KeepUnmanaged bool
+	// Protected usernames are never updated or removed, e.g. the namespace owner
+	Protected []string
+}
+
+// Syncer reconciles namespace users with a desired state
+type Syncer struct {
+	namespaces Namespaces
+	opts       Options
+}
+
+// NewSyncer returns a Syncer which uses namespaces
+func NewSyncer(namespaces Namespaces, opts Options) (*Syncer, error) {
+	if namespaces == nil {
+		return nil, ErrMissingNamespaces
+	}
+	return &Syncer{namespaces: namespaces, opts: opts}, nil
+}
+
+// Action is the kind of a Change
+type Action string
+
+// Actions
+const (
+	Add    Action = "add"
+	Update Action = "update"
+	Remove Action = "remove"
+)
+
+// Change is a single difference between the current and desired access
+type Change struct {
+	Action    Action                          `json:"action"`
+	Namespace string                          `json:"namespace"`
+	Username  string                          `json:"username"`
+	UserID    string                          `json:"userId,omitempty"`
+	AccessID  int                             `json:"accessId,omitempty"`
+	Current   docker.UserNamespaceAccessInput `json:"current"`
+	Desired   docker.UserNamespaceAccessInput `json:"desired"`
+}
+
+func (c Change) String() string {
+	switch c.Action {
+	case Add:
+		return fmt.Sprintf("+ %s %s (%s)", c.Namespace, c.Username, Describe(c.Desired))
+	case Remove:
+		return fmt.Sprintf("- %s %s (%s)", c.Namespace, c.Username, Describe(c.Current))
+	}
+	return fmt.Sprintf("~ %s %s (%s -> %s)", c.Namespace, c.Username, Describe(c.Current), Describe(c.Desired))
+}
+
+// Plan lists the changes needed to reach the desired state. A non-empty plan is drift
+type Plan struct {
+	Changes []Change `json:"changes"`
+}
+
+// Drift returns true if the current access differs from the desired state
+func (p *Plan) Drift() bool {
+	return len(p.Changes) > 0
+}
+
+func (p *Plan) String() string {
+	if !p.Drift() {
+		return "no drift"
+	}
+	lines := make([]string, len(p.Changes))
+	for i, c := range p.Changes {
+		lines[i] = c.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (s *Syncer) protected(username string) bool {
+	for _, p := range s.opts.Protected {
+		if strings.EqualFold(p, username) {
+			return true
+		}
+	}
+	return false
+}
+
+// Plan compares the users of every namespace in desired with the desired access.
+// Usernames are compared case-insensitively
+func (s *Syncer) Plan(ctx context.Context, desired Desired) (*Plan, error) {
+	namespaces := make([]string, 0, len(desired))
+	for ns := range desired {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	plan := &Plan{}
+	for _, ns := range namespaces {
+		users, err := s.namespaces.GetNamespaceUsers(ctx, docker.Namespace{ID: ns})
+		if err != nil {
+			return nil, fmt.Errorf("get users of namespace %s: %w", ns, err)
+		}
+		current := make(map[string]docker.NamespaceUser, len(*users))
+		for _, u := range *users {
+			current[strings.ToLower(u.Username)] = u
+		}
+		wanted := make(map[string]bool, len(desired[ns]))
+		usernames := make([]string, 0, len(desired[ns]))
+		for username := range desired[ns] {
+			usernames = append(usernames, username)
+		}
+		sort.Strings(usernames)
+		for _, username := range usernames {
+			want := desired[ns][username]
+			wanted[strings.ToLower(username)] = true
+			have, ok := current[strings.ToLower(username)]
+			switch {
+			case !ok:
+				plan.Changes = append(plan.Changes, Change{Action: Add, Namespace: ns, Username: username, Desired: want})
+			case have.NamespaceAccess.UserNamespaceAccessInput != want && !s.protected(username):
+				plan.Changes = append(plan.Changes, Change{
+					Action:    Update,
+					Namespace: ns,
+					Username:  have.Username,
+					UserID:    have.ID,
+					AccessID:  have.NamespaceAccess.ID,
+					Current:   have.NamespaceAccess.UserNamespaceAccessInput,
+					Desired:   want,
+				})
+			}
+		}
+		if s.opts.KeepUnmanaged {
+			continue
+		}
+		extra := make([]docker.NamespaceUser, 0)
+		for key, u := range current {
+			if !wanted[key] && !s.protected(u.Username) {
+				extra = append(extra, u)
+			}
+		}
+		sort.Slice(extra, func(i, j int) bool { return extra[i].Username < extra[j].Username })
+		for _, u := range extra {
+			plan.Changes = append(plan.Changes, Change{
+				Action:    Remove,
+				Namespace: ns,
+				Username:  u.Username,
+				UserID:    u.ID,
+				AccessID:  u.NamespaceAccess.ID,
+				Current:   u.NamespaceAccess.UserNamespaceAccessInput,
+			})
+		}
+	}
+	return plan, nil
+}
+
+// Result is the outcome of a change
+type Result struct {
+	Change Change `json:"change"`
+	Error  error  `json:"-"`
+}
+
+// Report is the outcome of applying a plan
+type Report struct {
+	Applied []Change `json:"applied"`
+	Failed  []Result `json:"failed,omitempty"`
+}
+
+// Err returns ErrSyncFailed if any change failed
+func (r *Report) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %d of %d changes, first: %s: %v", ErrSyncFailed, len(r.Failed), len(r.Failed)+len(r.Applied), r.Failed[0].Change, r.Failed[0].Error)
+}
+
+// Apply performs the changes of the plan. Failed changes do not stop the others
+func (s *Syncer) Apply(ctx context.Context, plan *Plan) *Report {
+	report := &Report{}
+	for _, change := range plan.Changes {
+		var err error
+		switch change.Action {
+		case Add:
+			_, err = s.namespaces.AddNamespaceUser(ctx, change.Namespace, change.Username, change.Desired)
+		case Update:
+			err = s.namespaces.UpdateNamespaceUserAccess(ctx, change.AccessID, change.Desired)
+		case Remove:
+			err = s.namespaces.DeleteNamespaceUser(ctx, change.Namespace, change.UserID)
+		default:
+			err = fmt.Errorf("%w: %q", ErrUnknownAction, change.Action)
+		}
+		if err != nil {
+			report.Failed = append(report.Failed, Result{Change: change, Error: err})
+			continue
+		}
+		report.Applied = append(report.Applied, change)
+	}
+	return report
+}
+
+// Sync plans and applies the desired state. Running it again without outside changes is a no-op
+func (s *Syncer) Sync(ctx context.Context, desired Desired) (*Plan, *Report, error) {
+	plan, err := s.Plan(ctx, desired)
+	if err != nil {
+		return nil, nil, err
+	}
+	report := s.Apply(ctx, plan)
+	return plan, report, report.Err()
+}
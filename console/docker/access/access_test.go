@@ -0,0 +1,210 @@
+package access_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/console/docker"
+	"github.com/dip-software/go-dip-api/console/docker/access"
+	"github.com/dip-software/go-dip-api/iam"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	pull     = docker.UserNamespaceAccessInput{CanPull: true}
+	pullPush = docker.UserNamespaceAccessInput{CanPull: true, CanPush: true}
+	admin    = docker.UserNamespaceAccessInput{CanPull: true, CanPush: true, CanDelete: true, IsAdmin: true}
+)
+
+type fakeNamespaces struct {
+	users  map[string][]docker.NamespaceUser
+	nextID int
+	fail   map[string]error
+	calls  int
+}
+
+func (f *fakeNamespaces) GetNamespaceUsers(_ context.Context, ns docker.Namespace) (*[]docker.NamespaceUser, error) {
+	users := append([]docker.NamespaceUser{}, f.users[ns.ID]...)
+	return &users, nil
+}
+
+func (f *fakeNamespaces) AddNamespaceUser(_ context.Context, namespaceID, username string, a docker.UserNamespaceAccessInput) (*docker.NamespaceUserResult, error) {
+	f.calls++
+	if err := f.fail[username]; err != nil {
+		return nil, err
+	}
+	f.nextID++
+	f.users[namespaceID] = append(f.users[namespaceID], docker.NamespaceUser{
+		ID:       fmt.Sprintf("u-%d", f.nextID),
+		Username: username,
+		NamespaceAccess: docker.NamespaceAccess{
+			ID:                       f.nextID,
+			UserNamespaceAccessInput: a,
+		},
+	})
+	return &docker.NamespaceUserResult{}, nil
+}
+
+func (f *fakeNamespaces) UpdateNamespaceUserAccess(_ context.Context, id int, a docker.UserNamespaceAccessInput) error {
+	f.calls++
+	for ns, users := range f.users {
+		for i, u := range users {
+			if u.NamespaceAccess.ID == id {
+				f.users[ns][i].NamespaceAccess.UserNamespaceAccessInput = a
+				return nil
+			}
+		}
+	}
+	return errors.New("not found")
+}
+
+func (f *fakeNamespaces) DeleteNamespaceUser(_ context.Context, namespaceID, userID string) error {
+	f.calls++
+	users := f.users[namespaceID]
+	for i, u := range users {
+		if u.ID == userID {
+			f.users[namespaceID] = append(users[:i], users[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
+func user(id, username string, accessID int, a docker.UserNamespaceAccessInput) docker.NamespaceUser {
+	return docker.NamespaceUser{ID: id, Username: username, NamespaceAccess: docker.NamespaceAccess{ID: accessID, UserNamespaceAccessInput: a}}
+}
+
+func newNamespaces() *fakeNamespaces {
+	return &fakeNamespaces{
+		nextID: 100,
+		users: map[string][]docker.NamespaceUser{
+			"ns1": {
+				user("u-1", "Alice", 1, pull),
+				user("u-2", "bob", 2, pull),
+				user("u-3", "owner", 3, admin),
+				user("u-4", "mallory", 4, pullPush),
+			},
+			"ns2": {},
+		},
+	}
+}
+
+func TestSync(t *testing.T) {
+	namespaces := newNamespaces()
+	syncer, err := access.NewSyncer(namespaces, access.Options{Protected: []string{"owner"}})
+	require.NoError(t, err)
+
+	desired := access.Desired{}
+	desired.Grant("ns1", "alice", pull)
+	desired.Grant("ns1", "bob", pullPush)
+	desired.Grant("ns2", "carol", pull)
+
+	plan, err := syncer.Plan(context.Background(), desired)
+	require.NoError(t, err)
+	assert.True(t, plan.Drift())
+	assert.Equal(t, "~ ns1 bob (pull -> pull,push)\n- ns1 mallory (pull,push)\n+ ns2 carol (pull)", plan.String())
+
+	report := syncer.Apply(context.Background(), plan)
+	require.NoError(t, report.Err())
+	assert.Len(t, report.Applied, 3)
+
+	// A second run finds nothing to do
+	calls := namespaces.calls
+	plan, report, err = syncer.Sync(context.Background(), desired)
+	require.NoError(t, err)
+	assert.False(t, plan.Drift())
+	assert.Equal(t, "no drift", plan.String())
+	assert.Empty(t, report.Applied)
+	assert.Equal(t, calls, namespaces.calls)
+}
+
+func TestKeepUnmanaged(t *testing.T) {
+	syncer, err := access.NewSyncer(newNamespaces(), access.Options{KeepUnmanaged: true})
+	require.NoError(t, err)
+	plan, err := syncer.Plan(context.Background(), access.Desired{"ns1": {"bob": pull, "owner": pull}})
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, access.Update, plan.Changes[0].Action)
+	assert.Equal(t, 3, plan.Changes[0].AccessID)
+}
+
+func TestApplyFailure(t *testing.T) {
+	namespaces := newNamespaces()
+	namespaces.fail = map[string]error{"carol": errors.New("denied")}
+	syncer, err := access.NewSyncer(namespaces, access.Options{KeepUnmanaged: true})
+	require.NoError(t, err)
+	_, report, err := syncer.Sync(context.Background(), access.Desired{"ns2": {"carol": pull, "dave": pull}})
+	assert.ErrorIs(t, err, access.ErrSyncFailed)
+	assert.Len(t, report.Applied, 1)
+	require.Len(t, report.Failed, 1)
+	assert.Equal(t, "carol", report.Failed[0].Change.Username)
+}
+
+type fakeGroups struct {
+	members map[string][]string
+	calls   int
+}
+
+func (f *fakeGroups) SCIMGetGroupByIDAll(id string, opt *iam.SCIMGetGroupOptions, _ ...iam.OptionFunc) (*iam.SCIMGroup, *iam.Response, error) {
+	f.calls++
+	if opt == nil || opt.IncludeGroupMembersType == nil || *opt.IncludeGroupMembersType != iam.GroupMemberTypeUser {
+		return nil, nil, errors.New("expected user members")
+	}
+	usernames, ok := f.members[id]
+	if !ok {
+		return nil, nil, errors.New("group not found")
+	}
+	group := &iam.SCIMGroup{ID: id}
+	for _, username := range usernames {
+		var member iam.SCIMListResource
+		member.UserName = username
+		group.ExtensionGroup.GroupMembers.Resources = append(group.ExtensionGroup.GroupMembers.Resources, member)
+	}
+	return group, nil, nil
+}
+
+func TestFromGroups(t *testing.T) {
+	groups := &fakeGroups{members: map[string][]string{
+		"readers": {"alice", "bob"},
+		"writers": {"Bob"},
+		"empty":   {},
+	}}
+	desired, err := access.FromGroups(groups, []access.GroupGrant{
+		{GroupID: "readers", Namespace: "ns1", Access: pull},
+		{GroupID: "writers", Namespace: "ns1", Access: docker.UserNamespaceAccessInput{CanPush: true}},
+		{GroupID: "readers", Namespace: "ns2", Access: pull},
+		{GroupID: "empty", Namespace: "ns3", Access: pull},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, access.Desired{
+		"ns1": {"alice": pull, "bob": pullPush},
+		"ns2": {"alice": pull, "bob": pull},
+		"ns3": {},
+	}, desired)
+	assert.Equal(t, 3, groups.calls)
+
+	// The users of a namespace whose group was emptied are removed
+	namespaces := &fakeNamespaces{users: map[string][]docker.NamespaceUser{
+		"ns3": {{ID: "u-1", Username: "dave", NamespaceAccess: docker.NamespaceAccess{ID: 1, UserNamespaceAccessInput: pull}}},
+	}}
+	syncer, err := access.NewSyncer(namespaces, access.Options{})
+	require.NoError(t, err)
+	plan, err := syncer.Plan(context.Background(), access.Desired{"ns3": desired["ns3"]})
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, access.Remove, plan.Changes[0].Action)
+	assert.Equal(t, "dave", plan.Changes[0].Username)
+
+	_, err = access.FromGroups(groups, []access.GroupGrant{{GroupID: "readers"}})
+	assert.ErrorIs(t, err, access.ErrInvalidGrant)
+	_, err = access.FromGroups(groups, []access.GroupGrant{{GroupID: "missing", Namespace: "ns1"}})
+	assert.Error(t, err)
+}
+
+func TestNewSyncer(t *testing.T) {
+	_, err := access.NewSyncer(nil, access.Options{})
+	assert.ErrorIs(t, err, access.ErrMissingNamespaces)
+}
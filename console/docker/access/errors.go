@@ -0,0 +1,11 @@
+package access
+
+import "errors"
+
+// Exported Errors
+var (
+	ErrMissingNamespaces = errors.New("missing namespaces service")
+	ErrInvalidGrant      = errors.New("grant needs a group ID and namespace")
+	ErrUnknownAction     = errors.New("unknown action")
+	ErrSyncFailed        = errors.New("namespace access sync failed")
+)
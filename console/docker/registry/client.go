@@ -0,0 +1,263 @@
+// Package registry is a Docker Registry HTTP API v2 client for the HSDP Docker registry.
+// It authenticates with a service key and reads manifests and image configurations
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/dip-software/go-dip-api/console/docker"
+	"github.com/dip-software/go-dip-api/internal"
+)
+
+const (
+	userAgent = "go-dip-api/registry/" + internal.LibraryVersion
+)
+
+// Config contains the configuration of a Client
+type Config struct {
+	// Host of the registry, e.g. docker.na1.hsdp.io
+	Host string
+	// Username and Password of a service key
+	Username string
+	Password string
+	// Insecure uses http instead of https
+	Insecure bool
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// Client is a Docker Registry v2 client
+type Client struct {
+	config  Config
+	baseURL *url.URL
+	http    *http.Client
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// NewClient returns a registry client
+func NewClient(config Config) (*Client, error) {
+	if config.Host == "" {
+		return nil, ErrMissingHost
+	}
+	if config.Username == "" || config.Password == "" {
+		return nil, ErrMissingCredentials
+	}
+	scheme := "https"
+	if config.Insecure {
+		scheme = "http"
+	}
+	baseURL, err := url.Parse(scheme + "://" + config.Host + "/")
+	if err != nil {
+		return nil, err
+	}
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{config: config, baseURL: baseURL, http: httpClient, tokens: make(map[string]string)}, nil
+}
+
+// NewServiceKeyClient returns a registry client for host authenticated with key, e.g.
+// the result of docker.ServiceKeysService.CreateServiceKey
+func NewServiceKeyClient(host string, key docker.ServiceKey) (*Client, error) {
+	return NewClient(Config{Host: host, Username: key.Username, Password: key.Password})
+}
+
+// do sends a request for the repository name. A bearer token is requested and cached
+// when the registry challenges the request
+func (c *Client) do(ctx context.Context, method, name, path string, header http.Header) (*http.Response, error) {
+	u := c.baseURL.JoinPath("v2", name, path)
+	scope := "repository:" + name + ":pull"
+	if method == http.MethodDelete {
+		scope = "repository:" + name + ":delete"
+	}
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		req.Header.Set("User-Agent", userAgent)
+		c.mu.Lock()
+		token, ok := c.tokens[scope]
+		c.mu.Unlock()
+		if ok {
+			req.Header.Set("Authorization", "Bearer "+token)
+		} else {
+			req.SetBasicAuth(c.config.Username, c.config.Password)
+		}
+		return req, nil
+	}
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, checkResponse(resp)
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	_ = resp.Body.Close()
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return nil, fmt.Errorf("%w: %s", ErrUnauthorized, challenge)
+	}
+	if err := c.authenticate(ctx, challenge, scope); err != nil {
+		return nil, err
+	}
+	if req, err = newRequest(); err != nil {
+		return nil, err
+	}
+	if resp, err = c.http.Do(req); err != nil {
+		return nil, err
+	}
+	return resp, checkResponse(resp)
+}
+
+// parseChallenge parses the parameters of a Bearer WWW-Authenticate header
+func parseChallenge(challenge string) map[string]string {
+	params := make(map[string]string)
+	_, rest, _ := strings.Cut(challenge, " ")
+	for _, part := range strings.Split(rest, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			params[strings.ToLower(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return params
+}
+
+// authenticate fetches a token for scope from the realm of the challenge
+func (c *Client) authenticate(ctx context.Context, challenge, scope string) error {
+	params := parseChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return fmt.Errorf("%w: missing realm in %q", ErrUnauthorized, challenge)
+	}
+	u, err := url.Parse(realm)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	if service := params["service"]; service != "" {
+		q.Set("service", service)
+	}
+	q.Set("scope", scope)
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.config.Username, c.config.Password)
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: token request returned %s", ErrUnauthorized, resp.Status)
+	}
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return err
+	}
+	token := tokenResponse.Token
+	if token == "" {
+		token = tokenResponse.AccessToken
+	}
+	if token == "" {
+		return fmt.Errorf("%w: empty token", ErrUnauthorized)
+	}
+	c.mu.Lock()
+	c.tokens[scope] = token
+	c.mu.Unlock()
+	return nil
+}
+
+// Error is an error returned by the registry
+type Error struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Detail  json.RawMessage `json:"detail,omitempty"`
+}
+
+// ResponseError is a non-2xx response of the registry
+type ResponseError struct {
+	StatusCode int
+	Errors     []Error `json:"errors"`
+}
+
+func (e *ResponseError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("registry: %s", http.StatusText(e.StatusCode))
+	}
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Code + ": " + err.Message
+	}
+	return fmt.Sprintf("registry: %s: %s", http.StatusText(e.StatusCode), strings.Join(messages, "; "))
+}
+
+// Is matches ErrNotFound for 404 responses
+func (e *ResponseError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	respErr := &ResponseError{StatusCode: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	_ = json.Unmarshal(data, respErr)
+	return respErr
+}
+
+// Tags lists the tags of a repository, e.g. namespace/app
+func (c *Client) Tags(ctx context.Context, name string) ([]string, error) {
+	resp, err := c.do(ctx, http.MethodGet, name, "tags/list", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	var list struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	return list.Tags, nil
+}
+
+// DeleteManifest deletes the manifest with digest and with it all tags pointing to it
+func (c *Client) DeleteManifest(ctx context.Context, name, digest string) error {
+	resp, err := c.do(ctx, http.MethodDelete, name, "manifests/"+digest, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
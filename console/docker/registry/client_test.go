@@ -0,0 +1,176 @@
+package registry_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/console/docker"
+	"github.com/dip-software/go-dip-api/console/docker/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	indexDigest    = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	amd64Digest    = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	arm64Digest    = "sha256:3333333333333333333333333333333333333333333333333333333333333333"
+	configDigest   = "sha256:4444444444444444444444444444444444444444444444444444444444444444"
+	serviceKeyUser = "svc-user"
+	serviceKeyPass = "svc-pass"
+)
+
+type fakeRegistry struct {
+	server     *httptest.Server
+	tokenCalls int
+	scopes     []string
+	deleted    []string
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	f := &fakeRegistry{}
+	mux := http.NewServeMux()
+	f.server = httptest.NewServer(mux)
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != serviceKeyUser || password != serviceKeyPass {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "registry", r.URL.Query().Get("service"))
+		f.tokenCalls++
+		f.scopes = append(f.scopes, r.URL.Query().Get("scope"))
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "tok-" + r.URL.Query().Get("scope")})
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer tok-repository:ns/app:") {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+f.server.URL+`/token",service="registry",scope="repository:ns/app:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"errors": [{"code": "UNAUTHORIZED", "message": "authentication required"}]}`)
+			return
+		}
+		switch strings.TrimPrefix(r.URL.Path, "/v2/ns/app/") {
+		case "tags/list":
+			_, _ = io.WriteString(w, `{"name": "ns/app", "tags": ["1.0.0", "latest"]}`)
+		case "manifests/latest":
+			assert.Contains(t, r.Header.Get("Accept"), registry.MediaTypeManifestList)
+			w.Header().Set("Content-Type", registry.MediaTypeManifestList)
+			w.Header().Set("Docker-Content-Digest", indexDigest)
+			_, _ = io.WriteString(w, `{
+  "schemaVersion": 2,
+  "mediaType": "`+registry.MediaTypeManifestList+`",
+  "manifests": [
+    {"mediaType": "`+registry.MediaTypeManifest+`", "size": 500, "digest": "`+amd64Digest+`", "platform": {"architecture": "amd64", "os": "linux"}},
+    {"mediaType": "`+registry.MediaTypeManifest+`", "size": 500, "digest": "`+arm64Digest+`", "platform": {"architecture": "arm64", "os": "linux", "variant": "v8"}}
+  ]
+}`)
+		case "manifests/" + amd64Digest:
+			if r.Method == http.MethodDelete {
+				f.deleted = append(f.deleted, amd64Digest)
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			w.Header().Set("Content-Type", registry.MediaTypeManifest)
+			w.Header().Set("Docker-Content-Digest", amd64Digest)
+			_, _ = io.WriteString(w, `{
+  "schemaVersion": 2,
+  "mediaType": "`+registry.MediaTypeManifest+`",
+  "config": {"mediaType": "application/vnd.docker.container.image.v1+json", "size": 100, "digest": "`+configDigest+`"},
+  "layers": [
+    {"mediaType": "application/vnd.docker.image.rootfs.diff.tar.gzip", "size": 1000, "digest": "sha256:aa"},
+    {"mediaType": "application/vnd.docker.image.rootfs.diff.tar.gzip", "size": 2000, "digest": "sha256:bb"}
+  ]
+}`)
+		case "blobs/" + configDigest:
+			_, _ = io.WriteString(w, `{
+  "architecture": "amd64",
+  "os": "linux",
+  "created": "2024-03-01T12:00:00Z",
+  "config": {"Labels": {"org.opencontainers.image.version": "1.0.0"}, "Entrypoint": ["/app"]},
+  "rootfs": {"type": "layers", "diff_ids": ["sha256:cc", "sha256:dd"]}
+}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"errors": [{"code": "MANIFEST_UNKNOWN", "message": "manifest unknown"}]}`)
+		}
+	})
+	return f
+}
+
+func newClient(t *testing.T, f *fakeRegistry) *registry.Client {
+	client, err := registry.NewClient(registry.Config{
+		Host:     strings.TrimPrefix(f.server.URL, "http://"),
+		Username: serviceKeyUser,
+		Password: serviceKeyPass,
+		Insecure: true,
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func TestInspect(t *testing.T) {
+	f := newFakeRegistry(t)
+	defer f.server.Close()
+	client := newClient(t, f)
+	ctx := context.Background()
+
+	image, err := client.Inspect(ctx, "ns/app", "latest", nil)
+	require.NoError(t, err)
+	assert.Equal(t, amd64Digest, image.Digest)
+	assert.Equal(t, "linux/amd64", image.Platform.String())
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64/v8"}, []string{image.Platforms[0].String(), image.Platforms[1].String()})
+	assert.Equal(t, "1.0.0", image.Labels["org.opencontainers.image.version"])
+	assert.Len(t, image.Layers, 2)
+	assert.Equal(t, int64(3100), image.Size)
+	assert.Equal(t, 2024, image.Created.Year())
+	// The token is cached per scope
+	assert.Equal(t, 1, f.tokenCalls)
+
+	_, err = client.Inspect(ctx, "ns/app", "latest", &registry.Platform{OS: "windows", Architecture: "amd64"})
+	assert.ErrorIs(t, err, registry.ErrPlatformNotFound)
+
+	_, err = client.Manifest(ctx, "ns/app", "missing")
+	assert.ErrorIs(t, err, registry.ErrNotFound)
+	assert.Contains(t, err.Error(), "MANIFEST_UNKNOWN")
+
+	tags, err := client.Tags(ctx, "ns/app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.0.0", "latest"}, tags)
+}
+
+func TestDeleteManifest(t *testing.T) {
+	f := newFakeRegistry(t)
+	defer f.server.Close()
+	client := newClient(t, f)
+
+	require.NoError(t, client.DeleteManifest(context.Background(), "ns/app", amd64Digest))
+	assert.Equal(t, []string{amd64Digest}, f.deleted)
+	assert.Equal(t, []string{"repository:ns/app:delete"}, f.scopes)
+}
+
+func TestAuthentication(t *testing.T) {
+	f := newFakeRegistry(t)
+	defer f.server.Close()
+
+	client, err := registry.NewServiceKeyClient("localhost", docker.ServiceKey{Username: "u"})
+	assert.ErrorIs(t, err, registry.ErrMissingCredentials)
+	assert.Nil(t, client)
+
+	_, err = registry.NewClient(registry.Config{Username: "u", Password: "p"})
+	assert.ErrorIs(t, err, registry.ErrMissingHost)
+
+	client, err = registry.NewClient(registry.Config{
+		Host:     strings.TrimPrefix(f.server.URL, "http://"),
+		Username: serviceKeyUser,
+		Password: "wrong",
+		Insecure: true,
+	})
+	require.NoError(t, err)
+	_, err = client.Tags(context.Background(), "ns/app")
+	assert.ErrorIs(t, err, registry.ErrUnauthorized)
+}
@@ -0,0 +1,12 @@
+package registry
+
+import "errors"
+
+// Exported Errors
+var (
+	ErrMissingHost        = errors.New("missing registry host")
+	ErrMissingCredentials = errors.New("missing registry credentials")
+	ErrUnauthorized       = errors.New("registry authentication failed")
+	ErrNotFound           = errors.New("not found")
+	ErrPlatformNotFound   = errors.New("platform not found in manifest list")
+)
@@ -0,0 +1,197 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Manifest media types
+const (
+	MediaTypeManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest  = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex     = "application/vnd.oci.image.index.v1+json"
+)
+
+var acceptedManifests = strings.Join([]string{MediaTypeManifest, MediaTypeManifestList, MediaTypeOCIManifest, MediaTypeOCIIndex}, ", ")
+
+// Platform identifies the target of an image
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// Descriptor references a blob or manifest by digest
+type Descriptor struct {
+	MediaType string    `json:"mediaType"`
+	Size      int64     `json:"size"`
+	Digest    string    `json:"digest"`
+	Platform  *Platform `json:"platform,omitempty"`
+}
+
+// Manifest is an image manifest or, if Manifests is set, a manifest list / image index
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers,omitempty"`
+	Manifests     []Descriptor `json:"manifests,omitempty"`
+	// Digest is the content digest reported by the registry
+	Digest string `json:"-"`
+}
+
+// IsIndex returns true for manifest lists and image indexes
+func (m *Manifest) IsIndex() bool {
+	return m.MediaType == MediaTypeManifestList || m.MediaType == MediaTypeOCIIndex || len(m.Manifests) > 0
+}
+
+// ImageConfig is the configuration blob of an image
+type ImageConfig struct {
+	Architecture string    `json:"architecture"`
+	OS           string    `json:"os"`
+	Variant      string    `json:"variant,omitempty"`
+	Created      time.Time `json:"created"`
+	Config       struct {
+		Env        []string          `json:"Env,omitempty"`
+		Entrypoint []string          `json:"Entrypoint,omitempty"`
+		Cmd        []string          `json:"Cmd,omitempty"`
+		WorkingDir string            `json:"WorkingDir,omitempty"`
+		User       string            `json:"User,omitempty"`
+		Labels     map[string]string `json:"Labels,omitempty"`
+	} `json:"config"`
+	RootFS struct {
+		Type    string   `json:"type"`
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+// Manifest fetches the manifest of reference, which is a tag or a digest
+func (c *Client) Manifest(ctx context.Context, name, reference string) (*Manifest, error) {
+	resp, err := c.do(ctx, http.MethodGet, name, "manifests/"+reference, http.Header{"Accept": {acceptedManifests}})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	var manifest Manifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, err
+	}
+	if manifest.MediaType == "" {
+		manifest.MediaType = resp.Header.Get("Content-Type")
+	}
+	manifest.Digest = resp.Header.Get("Docker-Content-Digest")
+	return &manifest, nil
+}
+
+// Blob opens the blob with digest. The caller must close it
+func (c *Client) Blob(ctx context.Context, name, digest string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, name, "blobs/"+digest, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Config fetches and decodes the configuration blob with digest
+func (c *Client) Config(ctx context.Context, name, digest string) (*ImageConfig, error) {
+	blob, err := c.Blob(ctx, name, digest)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = blob.Close()
+	}()
+	var config ImageConfig
+	if err := json.NewDecoder(blob).Decode(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Image summarizes an image for one platform
+type Image struct {
+	Name      string
+	Reference string
+	// Digest of the platform manifest
+	Digest    string
+	MediaType string
+	Platform  Platform
+	Created   time.Time
+	Labels    map[string]string
+	Layers    []Descriptor
+	Size      int64
+	Config    *ImageConfig
+	// Platforms lists all platforms when the reference is a manifest list
+	Platforms []Platform
+}
+
+// DefaultPlatform is selected from manifest lists when Inspect is called without a platform
+var DefaultPlatform = Platform{OS: "linux", Architecture: "amd64"}
+
+// Inspect fetches the manifest and configuration of name:reference. For manifest lists
+// the image of platform is selected, or DefaultPlatform when platform is nil
+func (c *Client) Inspect(ctx context.Context, name, reference string, platform *Platform) (*Image, error) {
+	manifest, err := c.Manifest(ctx, name, reference)
+	if err != nil {
+		return nil, err
+	}
+	image := &Image{Name: name, Reference: reference}
+	if manifest.IsIndex() {
+		want := DefaultPlatform
+		if platform != nil {
+			want = *platform
+		}
+		var selected *Descriptor
+		for i, m := range manifest.Manifests {
+			if m.Platform == nil {
+				continue
+			}
+			image.Platforms = append(image.Platforms, *m.Platform)
+			if selected == nil && m.Platform.OS == want.OS && m.Platform.Architecture == want.Architecture &&
+				(want.Variant == "" || m.Platform.Variant == want.Variant) {
+				selected = &manifest.Manifests[i]
+			}
+		}
+		if selected == nil {
+			return nil, fmt.Errorf("%w: %s in %s:%s", ErrPlatformNotFound, want, name, reference)
+		}
+		if manifest, err = c.Manifest(ctx, name, selected.Digest); err != nil {
+			return nil, err
+		}
+		if manifest.Digest == "" {
+			manifest.Digest = selected.Digest
+		}
+	}
+	config, err := c.Config(ctx, name, manifest.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+	image.Digest = manifest.Digest
+	image.MediaType = manifest.MediaType
+	image.Platform = Platform{OS: config.OS, Architecture: config.Architecture, Variant: config.Variant}
+	image.Created = config.Created
+	image.Labels = config.Config.Labels
+	image.Layers = manifest.Layers
+	image.Config = config
+	image.Size = manifest.Config.Size
+	for _, l := range manifest.Layers {
+		image.Size += l.Size
+	}
+	return image, nil
+}
@@ -0,0 +1,14 @@
+package retention
+
+import "errors"
+
+// Exported Errors
+var (
+	ErrMissingSource  = errors.New("missing source")
+	ErrMissingDeleter = errors.New("missing deleter")
+	ErrMissingRules   = errors.New("missing rules")
+	ErrInvalidRule    = errors.New("invalid rule")
+	ErrMissingMaxAge  = errors.New("rule must set a positive deleteOlderThan")
+	ErrMissingDigest  = errors.New("tag has no digest")
+	ErrDeleteFailed   = errors.New("deleting manifests failed")
+)
@@ -0,0 +1,59 @@
+package retention
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dip-software/go-dip-api/console/docker"
+)
+
+// PullRecord is the last seen pull count of a tag
+type PullRecord struct {
+	NumPulls int       `json:"numPulls"`
+	PulledAt time.Time `json:"pulledAt,omitempty"`
+}
+
+// PullHistory derives the last pull time of tags from their pull counts. The registry only
+// reports the number of pulls, so a tag counts as pulled when its count grew since the
+// previous observation. Persist it as JSON between runs
+type PullHistory struct {
+	mu      sync.Mutex
+	Records map[string]PullRecord `json:"records"`
+}
+
+// NewPullHistory returns an empty history
+func NewPullHistory() *PullHistory {
+	return &PullHistory{Records: make(map[string]PullRecord)}
+}
+
+func pullKey(name string, tag docker.Tag) string {
+	return name + ":" + tag.Name
+}
+
+// Observe records the pull count of a tag. A tag seen for the first time with pulls
+// is treated as pulled at now, so it is only deleted once it stays unpulled long enough
+func (h *PullHistory) Observe(name string, tag docker.Tag, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.Records == nil {
+		h.Records = make(map[string]PullRecord)
+	}
+	key := pullKey(name, tag)
+	record, ok := h.Records[key]
+	if (!ok && tag.NumPulls > 0) || tag.NumPulls > record.NumPulls {
+		record.PulledAt = now
+	}
+	record.NumPulls = tag.NumPulls
+	h.Records[key] = record
+}
+
+// LastPulled returns the time a pull of the tag was last observed
+func (h *PullHistory) LastPulled(name string, tag docker.Tag) (time.Time, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	record, ok := h.Records[pullKey(name, tag)]
+	if !ok || record.PulledAt.IsZero() {
+		return time.Time{}, false
+	}
+	return record.PulledAt, true
+}
@@ -0,0 +1,350 @@
+// Package retention cleans up HSDP Docker registry tags according to retention rules. A
+// plan shows which tags are kept or deleted and why, before Apply deletes them
+package retention
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dip-software/go-dip-api/console/docker"
+)
+
+var semverPattern = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// IsSemver returns true if tag is a semantic version, optionally prefixed with v
+func IsSemver(tag string) bool {
+	return semverPattern.MatchString(tag)
+}
+
+// Days returns a duration of n days
+func Days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// Rule decides which tags of the matching repositories are kept. A tag is deleted only
+// if no keep condition applies to it
+type Rule struct {
+	// Namespace and Repository are path.Match patterns. Empty matches all
+	Namespace  string `json:"namespace,omitempty"`
+	Repository string `json:"repository,omitempty"`
+	// KeepLast keeps the N most recently updated tags
+	KeepLast int `json:"keepLast,omitempty"`
+	// KeepSemver keeps all tags which are semantic versions
+	KeepSemver bool `json:"keepSemver,omitempty"`
+	// KeepTags keeps the tags matching any of these regular expressions, e.g. ^latest$
+	KeepTags []string `json:"keepTags,omitempty"`
+	// DeleteOlderThan only deletes tags not updated within this duration. It is required,
+	// so a rule never deletes recently pushed tags
+	DeleteOlderThan time.Duration `json:"deleteOlderThan,omitempty"`
+	// KeepPulledWithin keeps tags pulled within this duration. Pulls are derived from
+	// changes of the pull count recorded in the PullHistory
+	KeepPulledWithin time.Duration `json:"keepPulledWithin,omitempty"`
+
+	keepTags []*regexp.Regexp
+}
+
+// Matches returns true if the rule applies to namespace/repository
+func (r Rule) Matches(namespace, repository string) bool {
+	return match(r.Namespace, namespace) && match(r.Repository, repository)
+}
+
+func match(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
+
+func (r *Rule) compile() error {
+	if _, err := path.Match(r.Namespace, ""); err != nil {
+		return fmt.Errorf("%w: namespace %q", ErrInvalidRule, r.Namespace)
+	}
+	if _, err := path.Match(r.Repository, ""); err != nil {
+		return fmt.Errorf("%w: repository %q", ErrInvalidRule, r.Repository)
+	}
+	if r.KeepLast < 0 || r.DeleteOlderThan < 0 || r.KeepPulledWithin < 0 {
+		return fmt.Errorf("%w: negative value", ErrInvalidRule)
+	}
+	if r.DeleteOlderThan == 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidRule, ErrMissingMaxAge)
+	}
+	r.keepTags = nil
+	for _, expr := range r.KeepTags {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+		r.keepTags = append(r.keepTags, re)
+	}
+	return nil
+}
+
+// Source lists the namespaces, repositories and tags of the registry
+type Source interface {
+	GetNamespaces(ctx context.Context) (*[]docker.Namespace, error)
+	GetRepositories(ctx context.Context, namespaceID string) (*[]docker.Repository, error)
+	GetTags(ctx context.Context, repositoryID string) (*[]docker.Tag, error)
+}
+
+type clientSource struct {
+	*docker.NamespacesService
+	*docker.RepositoriesService
+}
+
+// ClientSource returns the Source of a docker.Client
+func ClientSource(client *docker.Client) Source {
+	return clientSource{client.Namespaces, client.Repositories}
+}
+
+// Deleter deletes the manifest with digest, e.g. *registry.Client
+type Deleter interface {
+	DeleteManifest(ctx context.Context, name, digest string) error
+}
+
+// Options control an Engine
+type Options struct {
+	// Pulls records pull counts between runs. Defaults to a new empty history, in which
+	// case KeepPulledWithin keeps every tag that was ever pulled
+	Pulls *PullHistory
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// Engine evaluates retention rules. The first matching rule applies to a repository;
+// repositories without a matching rule are left alone
+type Engine struct {
+	source  Source
+	deleter Deleter
+	rules   []Rule
+	pulls   *PullHistory
+	now     func() time.Time
+}
+
+// NewEngine returns an Engine for rules
+func NewEngine(source Source, deleter Deleter, rules []Rule, opts Options) (*Engine, error) {
+	if source == nil {
+		return nil, ErrMissingSource
+	}
+	if deleter == nil {
+		return nil, ErrMissingDeleter
+	}
+	if len(rules) == 0 {
+		return nil, ErrMissingRules
+	}
+	compiled := make([]Rule, len(rules))
+	for i, r := range rules {
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		compiled[i] = r
+	}
+	if opts.Pulls == nil {
+		opts.Pulls = NewPullHistory()
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Engine{source: source, deleter: deleter, rules: compiled, pulls: opts.Pulls, now: opts.Now}, nil
+}
+
+// Decision is the verdict on a single tag
+type Decision struct {
+	Tag    docker.Tag `json:"tag"`
+	Reason string     `json:"reason"`
+}
+
+// RepositoryPlan lists the kept and deleted tags of a repository
+type RepositoryPlan struct {
+	Namespace    string     `json:"namespace"`
+	Repository   string     `json:"repository"`
+	RepositoryID string     `json:"repositoryId"`
+	Keep         []Decision `json:"keep"`
+	Delete       []Decision `json:"delete"`
+}
+
+// Name returns the registry name of the repository, namespace/repository
+func (p RepositoryPlan) Name() string {
+	return p.Namespace + "/" + p.Repository
+}
+
+// Plan is the dry-run result of the retention rules
+type Plan struct {
+	Repositories []RepositoryPlan `json:"repositories"`
+}
+
+// Deletions returns the number of tags to delete
+func (p *Plan) Deletions() int {
+	n := 0
+	for _, r := range p.Repositories {
+		n += len(r.Delete)
+	}
+	return n
+}
+
+func (p *Plan) String() string {
+	var b strings.Builder
+	for _, r := range p.Repositories {
+		_, _ = fmt.Fprintf(&b, "%s: keep %d, delete %d\n", r.Name(), len(r.Keep), len(r.Delete))
+		for _, d := range r.Delete {
+			_, _ = fmt.Fprintf(&b, "  - %s (%s)\n", d.Tag.Name, d.Reason)
+		}
+	}
+	_, _ = fmt.Fprintf(&b, "%d tags to delete", p.Deletions())
+	return b.String()
+}
+
+func (e *Engine) ruleFor(namespace, repository string) *Rule {
+	for i := range e.rules {
+		if e.rules[i].Matches(namespace, repository) {
+			return &e.rules[i]
+		}
+	}
+	return nil
+}
+
+// Plan evaluates the rules against all repositories. The pull history is updated with
+// the current pull counts
+func (e *Engine) Plan(ctx context.Context) (*Plan, error) {
+	namespaces, err := e.source.GetNamespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get namespaces: %w", err)
+	}
+	now := e.now()
+	plan := &Plan{}
+	for _, ns := range *namespaces {
+		repositories, err := e.source.GetRepositories(ctx, ns.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get repositories of %s: %w", ns.ID, err)
+		}
+		for _, repo := range *repositories {
+			rule := e.ruleFor(ns.ID, repo.Name)
+			if rule == nil {
+				continue
+			}
+			tags, err := e.source.GetTags(ctx, repo.ID)
+			if err != nil {
+				return nil, fmt.Errorf("get tags of %s/%s: %w", ns.ID, repo.Name, err)
+			}
+			name := ns.ID + "/" + repo.Name
+			for _, tag := range *tags {
+				e.pulls.Observe(name, tag, now)
+			}
+			rp := e.evaluate(*rule, name, *tags, now)
+			rp.Namespace, rp.Repository, rp.RepositoryID = ns.ID, repo.Name, repo.ID
+			plan.Repositories = append(plan.Repositories, rp)
+		}
+	}
+	sort.Slice(plan.Repositories, func(i, j int) bool {
+		return plan.Repositories[i].Name() < plan.Repositories[j].Name()
+	})
+	return plan, nil
+}
+
+// keepReason returns why a tag is kept, or an empty string
+func (e *Engine) keepReason(rule Rule, name string, tag docker.Tag, rank int, now time.Time) string {
+	if rank < rule.KeepLast {
+		return fmt.Sprintf("within last %d", rule.KeepLast)
+	}
+	if rule.KeepSemver && IsSemver(tag.Name) {
+		return "semantic version"
+	}
+	for _, re := range rule.keepTags {
+		if re.MatchString(tag.Name) {
+			return "matches " + re.String()
+		}
+	}
+	if rule.KeepPulledWithin > 0 {
+		if pulled, ok := e.pulls.LastPulled(name, tag); ok && now.Sub(pulled) < rule.KeepPulledWithin {
+			return "pulled " + pulled.UTC().Format(time.RFC3339)
+		}
+	}
+	if now.Sub(tag.UpdatedAt) < rule.DeleteOlderThan {
+		return "updated " + tag.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	return ""
+}
+
+func (e *Engine) evaluate(rule Rule, name string, tags []docker.Tag, now time.Time) RepositoryPlan {
+	sorted := append([]docker.Tag(nil), tags...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].UpdatedAt.After(sorted[j].UpdatedAt)
+	})
+	var rp RepositoryPlan
+	keptDigests := make(map[string]string)
+	var candidates []Decision
+	for rank, tag := range sorted {
+		if reason := e.keepReason(rule, name, tag, rank, now); reason != "" {
+			rp.Keep = append(rp.Keep, Decision{Tag: tag, Reason: reason})
+			keptDigests[tag.Digest] = tag.Name
+			continue
+		}
+		age := now.Sub(tag.UpdatedAt).Truncate(time.Hour)
+		candidates = append(candidates, Decision{Tag: tag, Reason: fmt.Sprintf("not updated for %s", age)})
+	}
+	// Deleting a manifest removes every tag pointing to it
+	for _, d := range candidates {
+		if kept, ok := keptDigests[d.Tag.Digest]; ok && d.Tag.Digest != "" {
+			rp.Keep = append(rp.Keep, Decision{Tag: d.Tag, Reason: "same digest as " + kept})
+			continue
+		}
+		rp.Delete = append(rp.Delete, d)
+	}
+	return rp
+}
+
+// Result is the outcome of a deletion
+type Result struct {
+	Repository string   `json:"repository"`
+	Digest     string   `json:"digest"`
+	Tags       []string `json:"tags"`
+	Error      error    `json:"-"`
+}
+
+// Report is the outcome of applying a plan
+type Report struct {
+	Deleted []Result `json:"deleted"`
+	Failed  []Result `json:"failed,omitempty"`
+}
+
+// Err returns ErrDeleteFailed if any deletion failed
+func (r *Report) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %d of %d manifests, first: %s: %v", ErrDeleteFailed, len(r.Failed), len(r.Failed)+len(r.Deleted), r.Failed[0].Repository, r.Failed[0].Error)
+}
+
+// Apply deletes the tags of the plan. Tags are deleted by manifest digest, once per digest
+func (e *Engine) Apply(ctx context.Context, plan *Plan) *Report {
+	report := &Report{}
+	for _, rp := range plan.Repositories {
+		var digests []string
+		tags := make(map[string][]string)
+		for _, d := range rp.Delete {
+			if d.Tag.Digest == "" {
+				report.Failed = append(report.Failed, Result{Repository: rp.Name(), Tags: []string{d.Tag.Name}, Error: ErrMissingDigest})
+				continue
+			}
+			if _, ok := tags[d.Tag.Digest]; !ok {
+				digests = append(digests, d.Tag.Digest)
+			}
+			tags[d.Tag.Digest] = append(tags[d.Tag.Digest], d.Tag.Name)
+		}
+		for _, digest := range digests {
+			result := Result{Repository: rp.Name(), Digest: digest, Tags: tags[digest]}
+			if err := e.deleter.DeleteManifest(ctx, rp.Name(), digest); err != nil {
+				result.Error = err
+				report.Failed = append(report.Failed, result)
+				continue
+			}
+			report.Deleted = append(report.Deleted, result)
+		}
+	}
+	return report
+}
@@ -0,0 +1,200 @@
+package retention_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dip-software/go-dip-api/console/docker"
+	"github.com/dip-software/go-dip-api/console/docker/registry"
+	"github.com/dip-software/go-dip-api/console/docker/retention"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ retention.Deleter = (*registry.Client)(nil)
+
+var now = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+type fakeSource struct {
+	namespaces   []docker.Namespace
+	repositories map[string][]docker.Repository
+	tags         map[string][]docker.Tag
+}
+
+func (f *fakeSource) GetNamespaces(_ context.Context) (*[]docker.Namespace, error) {
+	return &f.namespaces, nil
+}
+
+func (f *fakeSource) GetRepositories(_ context.Context, namespaceID string) (*[]docker.Repository, error) {
+	repos := f.repositories[namespaceID]
+	return &repos, nil
+}
+
+func (f *fakeSource) GetTags(_ context.Context, repositoryID string) (*[]docker.Tag, error) {
+	tags := f.tags[repositoryID]
+	return &tags, nil
+}
+
+type fakeDeleter struct {
+	deleted []string
+	fail    map[string]error
+}
+
+func (f *fakeDeleter) DeleteManifest(_ context.Context, name, digest string) error {
+	if err := f.fail[digest]; err != nil {
+		return err
+	}
+	f.deleted = append(f.deleted, name+"@"+digest)
+	return nil
+}
+
+func tag(name, digest string, age time.Duration, pulls int) docker.Tag {
+	return docker.Tag{Name: name, Digest: digest, UpdatedAt: now.Add(-age), NumPulls: pulls}
+}
+
+func newSource() *fakeSource {
+	return &fakeSource{
+		namespaces: []docker.Namespace{{ID: "team"}, {ID: "sandbox"}},
+		repositories: map[string][]docker.Repository{
+			"team":    {{ID: "r1", Name: "api"}, {ID: "r2", Name: "base"}},
+			"sandbox": {{ID: "r3", Name: "tmp"}},
+		},
+		tags: map[string][]docker.Tag{
+			"r1": {
+				tag("latest", "sha256:a", retention.Days(1), 0),
+				tag("build-5", "sha256:a", retention.Days(1), 0),
+				tag("build-4", "sha256:b", retention.Days(40), 0),
+				tag("1.2.0", "sha256:c", retention.Days(90), 0),
+				tag("release", "sha256:c", retention.Days(90), 0),
+				tag("build-3", "sha256:d", retention.Days(60), 3),
+				tag("build-2", "sha256:e", retention.Days(61), 0),
+				tag("build-1", "sha256:f", retention.Days(10), 0),
+			},
+			"r2": {tag("old", "sha256:z", retention.Days(400), 0)},
+			"r3": {
+				tag("a", "sha256:1", retention.Days(3), 0),
+				tag("b", "sha256:2", retention.Days(2), 0),
+			},
+		},
+	}
+}
+
+func TestPlanAndApply(t *testing.T) {
+	source := newSource()
+	deleter := &fakeDeleter{}
+	engine, err := retention.NewEngine(source, deleter, []retention.Rule{
+		{Namespace: "team", Repository: "base", DeleteOlderThan: retention.Days(365)},
+		{Namespace: "team", KeepLast: 1, KeepSemver: true, KeepTags: []string{"^latest$"}, DeleteOlderThan: retention.Days(30), KeepPulledWithin: retention.Days(7)},
+		{Namespace: "sand*", KeepLast: 1, DeleteOlderThan: retention.Days(1)},
+	}, retention.Options{Now: func() time.Time { return now }})
+	require.NoError(t, err)
+
+	plan, err := engine.Plan(context.Background())
+	require.NoError(t, err)
+	require.Len(t, plan.Repositories, 3)
+
+	assert.Equal(t, "sandbox/tmp", plan.Repositories[0].Name())
+	assert.Equal(t, []string{"a"}, names(plan.Repositories[0].Delete))
+
+	api := plan.Repositories[1]
+	assert.Equal(t, "team/api", api.Name())
+	assert.Equal(t, []string{"build-4", "build-2"}, names(api.Delete))
+	reasons := make(map[string]string)
+	for _, d := range api.Keep {
+		reasons[d.Tag.Name] = d.Reason
+	}
+	assert.Equal(t, "within last 1", reasons["latest"])
+	assert.Contains(t, reasons["build-5"], "updated ")
+	assert.Equal(t, "same digest as 1.2.0", reasons["release"])
+	assert.Equal(t, "semantic version", reasons["1.2.0"])
+	assert.Contains(t, reasons["build-3"], "pulled ")
+	assert.Contains(t, reasons["build-1"], "updated ")
+
+	// A rule without keep conditions deletes everything older than DeleteOlderThan
+	assert.Equal(t, []string{"old"}, names(plan.Repositories[2].Delete))
+	assert.Equal(t, 4, plan.Deletions())
+	assert.Contains(t, plan.String(), "team/api: keep 6, delete 2")
+
+	report := engine.Apply(context.Background(), plan)
+	require.NoError(t, report.Err())
+	assert.Equal(t, []string{"sandbox/tmp@sha256:1", "team/api@sha256:b", "team/api@sha256:e", "team/base@sha256:z"}, deleter.deleted)
+}
+
+func TestPullHistory(t *testing.T) {
+	source := newSource()
+	pulls := retention.NewPullHistory()
+	clock := now
+	rules := []retention.Rule{{Namespace: "team", Repository: "api", KeepPulledWithin: retention.Days(7), DeleteOlderThan: retention.Days(30)}}
+	engine, err := retention.NewEngine(source, &fakeDeleter{}, rules, retention.Options{Pulls: pulls, Now: func() time.Time { return clock }})
+	require.NoError(t, err)
+
+	plan, err := engine.Plan(context.Background())
+	require.NoError(t, err)
+	assert.NotContains(t, names(plan.Repositories[0].Delete), "build-3")
+
+	// Persist and reload the history
+	data, err := json.Marshal(pulls)
+	require.NoError(t, err)
+	restored := retention.NewPullHistory()
+	require.NoError(t, json.Unmarshal(data, restored))
+
+	clock = now.Add(retention.Days(8))
+	engine, err = retention.NewEngine(source, &fakeDeleter{}, rules, retention.Options{Pulls: restored, Now: func() time.Time { return clock }})
+	require.NoError(t, err)
+	plan, err = engine.Plan(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, names(plan.Repositories[0].Delete), "build-3")
+
+	// A new pull protects the tag again
+	source.tags["r1"][5].NumPulls = 4
+	plan, err = engine.Plan(context.Background())
+	require.NoError(t, err)
+	assert.NotContains(t, names(plan.Repositories[0].Delete), "build-3")
+}
+
+func TestApplyFailure(t *testing.T) {
+	deleter := &fakeDeleter{fail: map[string]error{"sha256:1": errors.New("denied")}}
+	engine, err := retention.NewEngine(newSource(), deleter, []retention.Rule{{Namespace: "sandbox", DeleteOlderThan: retention.Days(1)}}, retention.Options{Now: func() time.Time { return now }})
+	require.NoError(t, err)
+	plan, err := engine.Plan(context.Background())
+	require.NoError(t, err)
+	report := engine.Apply(context.Background(), plan)
+	assert.Len(t, report.Deleted, 1)
+	assert.Len(t, report.Failed, 1)
+	assert.ErrorIs(t, report.Err(), retention.ErrDeleteFailed)
+}
+
+func TestNewEngine(t *testing.T) {
+	_, err := retention.NewEngine(nil, &fakeDeleter{}, []retention.Rule{{}}, retention.Options{})
+	assert.ErrorIs(t, err, retention.ErrMissingSource)
+	_, err = retention.NewEngine(newSource(), nil, []retention.Rule{{}}, retention.Options{})
+	assert.ErrorIs(t, err, retention.ErrMissingDeleter)
+	_, err = retention.NewEngine(newSource(), &fakeDeleter{}, nil, retention.Options{})
+	assert.ErrorIs(t, err, retention.ErrMissingRules)
+	_, err = retention.NewEngine(newSource(), &fakeDeleter{}, []retention.Rule{{KeepTags: []string{"("}}}, retention.Options{})
+	assert.ErrorIs(t, err, retention.ErrInvalidRule)
+	_, err = retention.NewEngine(newSource(), &fakeDeleter{}, []retention.Rule{{Namespace: "["}}, retention.Options{})
+	assert.ErrorIs(t, err, retention.ErrInvalidRule)
+	_, err = retention.NewEngine(newSource(), &fakeDeleter{}, []retention.Rule{{Namespace: "sandbox"}}, retention.Options{})
+	assert.ErrorIs(t, err, retention.ErrMissingMaxAge)
+}
+
+func TestIsSemver(t *testing.T) {
+	for _, tag := range []string{"1.0.0", "v2.10.3", "1.0.0-rc.1", "1.0.0+build.5"} {
+		assert.True(t, retention.IsSemver(tag), tag)
+	}
+	for _, tag := range []string{"latest", "1.0", "01.0.0", "build-1"} {
+		assert.False(t, retention.IsSemver(tag), tag)
+	}
+}
+
+func names(decisions []retention.Decision) []string {
+	var n []string
+	for _, d := range decisions {
+		n = append(n, d.Tag.Name)
+	}
+	return n
+}
@@ -8,4 +8,12 @@ var (
 	ErrUAAURLCannotBeEmpty     = errors.New("UAA URL cannot be empty")
 	ErrMissingRefreshToken     = errors.New("missing refresh token")
 	ErrNotAuthorized           = errors.New("not authorized")
+	ErrQueryFailed             = errors.New("prometheus query failed")
+	ErrUnknownResultType       = errors.New("unknown result type")
+	ErrInvalidStep             = errors.New("step must be positive")
+	ErrInvalidOperator         = errors.New("invalid operator")
+	ErrThresholdOutOfRange     = errors.New("threshold out of range")
+	ErrMissingVariable         = errors.New("missing template variable")
+	ErrInvalidVariable         = errors.New("invalid template variable value")
+	ErrMissingRuleInstanceID   = errors.New("missing rule instance ID")
 )
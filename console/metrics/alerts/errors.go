@@ -0,0 +1,15 @@
+package alerts
+
+import "errors"
+
+// Exported Errors
+var (
+	ErrMissingSink    = errors.New("missing sink")
+	ErrMissingRoutes  = errors.New("missing routes or default sink")
+	ErrMissingAuth    = errors.New("missing secret or basic auth credentials")
+	ErrMissingClient  = errors.New("missing client")
+	ErrMissingTopicID = errors.New("missing topic ID")
+	ErrMissingURL     = errors.New("missing URL")
+	ErrInvalidPayload = errors.New("invalid payload")
+	ErrForwardFailed  = errors.New("forwarding failed")
+)
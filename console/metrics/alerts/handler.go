@@ -0,0 +1,259 @@
+package alerts
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SecretHeader carries the shared secret of a webhook call. A bearer token is accepted as well
+	SecretHeader = "X-Webhook-Secret"
+
+	defaultDedupWindow = 5 * time.Minute
+	maxPayloadSize     = 1 << 20
+)
+
+// Alert statuses
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// Sink receives the alerts routed to it. The Alerts of the payload only contain the matched alerts
+type Sink interface {
+	Send(ctx context.Context, payload Payload) error
+}
+
+// SinkFunc adapts a function to a Sink
+type SinkFunc func(ctx context.Context, payload Payload) error
+
+// Send calls f
+func (f SinkFunc) Send(ctx context.Context, payload Payload) error {
+	return f(ctx, payload)
+}
+
+// Route sends the alerts matching all of its matchers to Sink. An empty matcher matches any value
+type Route struct {
+	Name        string
+	Severity    []string
+	Application []string
+	Space       []string
+	Sink        Sink
+	// Continue also evaluates the next routes after a match
+	Continue bool
+}
+
+// Matches returns true if the labels of the alert match the route
+func (r Route) Matches(alert Alert) bool {
+	return matches(r.Severity, alert.Labels.Severity) &&
+		matches(r.Application, alert.Labels.Application) &&
+		matches(r.Space, alert.Labels.Space)
+}
+
+func matches(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Config is the configuration of a Handler. At least one of Secret and basic authentication
+// must be configured. When both are, a call is accepted with either of them
+type Config struct {
+	// Secret is the shared secret callers send in SecretHeader or as bearer token
+	Secret string
+	// Username and Password enable basic authentication
+	Username string
+	Password string
+	// Routes are evaluated in order
+	Routes []Route
+	// Default receives the alerts no route matched
+	Default Sink
+	// DedupWindow is the time a GroupKey and status combination is suppressed after delivery. Defaults to 5 minutes
+	DedupWindow time.Duration
+	// OnError is called when a sink fails
+	OnError func(route string, err error)
+}
+
+// Handler is a http.Handler which receives HSDP Metrics webhook alerts
+type Handler struct {
+	config Config
+	now    func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// NewHandler returns a Handler for config
+func NewHandler(config Config) (*Handler, error) {
+	if len(config.Routes) == 0 && config.Default == nil {
+		return nil, ErrMissingRoutes
+	}
+	for _, r := range config.Routes {
+		if r.Sink == nil {
+			return nil, fmt.Errorf("%w: route %q", ErrMissingSink, r.Name)
+		}
+	}
+	if config.Secret == "" && config.Username == "" && config.Password == "" {
+		return nil, ErrMissingAuth
+	}
+	if config.DedupWindow <= 0 {
+		config.DedupWindow = defaultDedupWindow
+	}
+	return &Handler{config: config, now: time.Now, seen: make(map[string]time.Time)}, nil
+}
+
+// authorized accepts a call with valid basic authentication or the shared secret
+func (h *Handler) authorized(r *http.Request) bool {
+	if h.config.Username != "" || h.config.Password != "" {
+		username, password, ok := r.BasicAuth()
+		if ok &&
+			subtle.ConstantTimeCompare([]byte(username), []byte(h.config.Username)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(password), []byte(h.config.Password)) == 1 {
+			return true
+		}
+	}
+	if h.config.Secret != "" {
+		secret := r.Header.Get(SecretHeader)
+		if secret == "" {
+			secret, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(h.config.Secret)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the required fields of a payload
+func (p Payload) Validate() error {
+	if p.GroupKey == "" {
+		return fmt.Errorf("%w: missing groupKey", ErrInvalidPayload)
+	}
+	if p.Status != StatusFiring && p.Status != StatusResolved {
+		return fmt.Errorf("%w: status %q", ErrInvalidPayload, p.Status)
+	}
+	if len(p.Alerts) == 0 {
+		return fmt.Errorf("%w: no alerts", ErrInvalidPayload)
+	}
+	return nil
+}
+
+// ServeHTTP authenticates, decodes and dispatches a webhook call. A failing sink results in
+// a 502 response so the sender retries
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.authorized(r) {
+		if h.config.Username != "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="alerts"`)
+		}
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var payload Payload
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxPayloadSize)).Decode(&payload); err != nil {
+		http.Error(w, fmt.Sprintf("%v: %v", ErrInvalidPayload, err), http.StatusBadRequest)
+		return
+	}
+	if err := payload.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key := payload.GroupKey + "|" + payload.Status
+	if !h.reserve(key) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err := h.Dispatch(r.Context(), payload); err != nil {
+		h.release(key)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// reserve records key and returns false if it was already delivered or is being delivered
+// within the dedup window
+func (h *Handler) reserve(key string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	for k, at := range h.seen {
+		if now.Sub(at) >= h.config.DedupWindow {
+			delete(h.seen, k)
+		}
+	}
+	if _, ok := h.seen[key]; ok {
+		return false
+	}
+	h.seen[key] = now
+	return true
+}
+
+// release forgets key after a failed delivery so the retry is dispatched
+func (h *Handler) release(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.seen, key)
+}
+
+// Dispatch routes the alerts of payload to the sinks. Every sink is called once with its share of the alerts
+func (h *Handler) Dispatch(ctx context.Context, payload Payload) error {
+	routed := make([][]Alert, len(h.config.Routes))
+	var unmatched []Alert
+	for _, alert := range payload.Alerts {
+		matched := false
+		for i, route := range h.config.Routes {
+			if !route.Matches(alert) {
+				continue
+			}
+			routed[i] = append(routed[i], alert)
+			matched = true
+			if !route.Continue {
+				break
+			}
+		}
+		if !matched {
+			unmatched = append(unmatched, alert)
+		}
+	}
+	var errs []error
+	send := func(name string, sink Sink, alerts []Alert) {
+		if len(alerts) == 0 || sink == nil {
+			return
+		}
+		p := payload
+		p.Alerts = alerts
+		if err := sink.Send(ctx, p); err != nil {
+			err = fmt.Errorf("route %s: %w", name, err)
+			if h.config.OnError != nil {
+				h.config.OnError(name, err)
+			}
+			errs = append(errs, err)
+		}
+	}
+	for i, route := range h.config.Routes {
+		send(route.Name, route.Sink, routed[i])
+	}
+	send("default", h.config.Default, unmatched)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,206 @@
+package alerts_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/console/metrics/alerts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const payload = `{
+  "receiver": "webhook",
+  "status": "firing",
+  "groupKey": "{}:{alertname=\"HighCPU\"}",
+  "groupLabels": {"alertname": "HighCPU", "application": "api"},
+  "version": "4",
+  "alerts": [
+    {"status": "firing", "labels": {"alertname": "HighCPU", "severity": "critical", "application": "api", "space": "prod"}, "annotations": {"summary": "CPU above 90%"}, "startsAt": "2024-01-01T00:00:00Z"},
+    {"status": "firing", "labels": {"alertname": "HighCPU", "severity": "warning", "application": "worker", "space": "prod"}, "annotations": {"summary": "CPU above 70%"}, "startsAt": "2024-01-01T00:00:00Z"},
+    {"status": "firing", "labels": {"alertname": "HighCPU", "severity": "info", "application": "web", "space": "test"}, "annotations": {"summary": "CPU above 50%"}, "startsAt": "2024-01-01T00:00:00Z"}
+  ]
+}`
+
+type recorder struct {
+	mu       sync.Mutex
+	payloads []alerts.Payload
+	err      error
+}
+
+func (r *recorder) Send(_ context.Context, p alerts.Payload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.payloads = append(r.payloads, p)
+	return nil
+}
+
+func (r *recorder) applications() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var apps []string
+	for _, p := range r.payloads {
+		for _, a := range p.Alerts {
+			apps = append(apps, a.Labels.Application)
+		}
+	}
+	return apps
+}
+
+func post(h http.Handler, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/alerts", strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandlerRouting(t *testing.T) {
+	critical, prod, fallback := &recorder{}, &recorder{}, &recorder{}
+	h, err := alerts.NewHandler(alerts.Config{
+		Secret: "s3cret",
+		Routes: []alerts.Route{
+			{Name: "critical", Severity: []string{"critical"}, Sink: critical, Continue: true},
+			{Name: "prod", Space: []string{"prod"}, Sink: prod},
+		},
+		Default: fallback,
+	})
+	require.NoError(t, err)
+
+	auth := http.Header{alerts.SecretHeader: {"s3cret"}}
+	w := post(h, payload, auth)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"api"}, critical.applications())
+	assert.Equal(t, []string{"api", "worker"}, prod.applications())
+	assert.Equal(t, []string{"web"}, fallback.applications())
+
+	// Redelivery of the same group and status is suppressed
+	w = post(h, payload, http.Header{"Authorization": {"Bearer s3cret"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, prod.payloads, 1)
+
+	w = post(h, strings.Replace(payload, `"status": "firing",
+  "groupKey"`, `"status": "resolved",
+  "groupKey"`, 1), auth)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, prod.payloads, 2)
+}
+
+func TestHandlerRejects(t *testing.T) {
+	sink := &recorder{}
+	h, err := alerts.NewHandler(alerts.Config{Username: "am", Password: "pw", Default: sink})
+	require.NoError(t, err)
+
+	w := post(h, payload, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+	req.SetBasicAuth("am", "wrong")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"status": "firing", "alerts": []}`))
+	req.SetBasicAuth("am", "pw")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Empty(t, sink.payloads)
+}
+
+func TestHandlerSinkFailure(t *testing.T) {
+	sink := &recorder{err: errors.New("down")}
+	var failed []string
+	h, err := alerts.NewHandler(alerts.Config{
+		Secret:  "s3cret",
+		Default: sink,
+		OnError: func(route string, _ error) { failed = append(failed, route) },
+	})
+	require.NoError(t, err)
+
+	auth := http.Header{alerts.SecretHeader: {"s3cret"}}
+	w := post(h, payload, auth)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, []string{"default"}, failed)
+
+	// A failed delivery is not deduplicated
+	sink.err = nil
+	w = post(h, payload, auth)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, sink.payloads, 1)
+}
+
+// blockingSink holds the first delivery until release is closed
+type blockingSink struct {
+	recorder
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (b *blockingSink) Send(ctx context.Context, p alerts.Payload) error {
+	b.once.Do(func() {
+		close(b.started)
+		<-b.release
+	})
+	return b.recorder.Send(ctx, p)
+}
+
+func TestHandlerConcurrentDuplicate(t *testing.T) {
+	sink := &blockingSink{started: make(chan struct{}), release: make(chan struct{})}
+	h, err := alerts.NewHandler(alerts.Config{Secret: "s3cret", Default: sink})
+	require.NoError(t, err)
+	auth := http.Header{alerts.SecretHeader: {"s3cret"}}
+
+	done := make(chan int)
+	go func() {
+		done <- post(h, payload, auth).Code
+	}()
+	<-sink.started
+	// The group is reserved while the first delivery is in flight
+	assert.Equal(t, http.StatusOK, post(h, payload, auth).Code)
+	close(sink.release)
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Len(t, sink.payloads, 1)
+}
+
+func TestHandlerEitherCredential(t *testing.T) {
+	h, err := alerts.NewHandler(alerts.Config{Secret: "s3cret", Username: "am", Password: "pw", Default: &recorder{}})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, post(h, payload, http.Header{alerts.SecretHeader: {"s3cret"}}).Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Replace(payload, "firing", "resolved", 1)))
+	req.SetBasicAuth("am", "pw")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, post(h, payload, http.Header{alerts.SecretHeader: {"wrong"}}).Code)
+}
+
+func TestNewHandler(t *testing.T) {
+	_, err := alerts.NewHandler(alerts.Config{})
+	assert.ErrorIs(t, err, alerts.ErrMissingRoutes)
+
+	_, err = alerts.NewHandler(alerts.Config{Routes: []alerts.Route{{Name: "x"}}})
+	assert.ErrorIs(t, err, alerts.ErrMissingSink)
+
+	_, err = alerts.NewHandler(alerts.Config{Default: &recorder{}})
+	assert.ErrorIs(t, err, alerts.ErrMissingAuth)
+}
@@ -0,0 +1,181 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dip-software/go-dip-api/logging"
+	"github.com/dip-software/go-dip-api/notification"
+	"github.com/google/uuid"
+)
+
+// Message formats a payload as plain text, one line per alert
+func Message(payload Payload) string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "[%s] %s", strings.ToUpper(payload.Status), payload.GroupLabels.AlertName)
+	if payload.GroupLabels.Application != "" {
+		_, _ = fmt.Fprintf(&b, " (%s)", payload.GroupLabels.Application)
+	}
+	for _, a := range payload.Alerts {
+		summary := a.Annotations.Summary
+		if summary == "" {
+			summary = a.Annotations.Description
+		}
+		_, _ = fmt.Fprintf(&b, "\n- %s %s/%s/%s: %s", a.Labels.Severity, a.Labels.Organization, a.Labels.Space, a.Labels.Application, summary)
+	}
+	return b.String()
+}
+
+// Publisher publishes notification messages, e.g. *notification.Client
+type Publisher interface {
+	Publish(request notification.PublishRequest) (*notification.PublishResponse, *notification.Response, error)
+}
+
+var _ Publisher = (*notification.Client)(nil)
+
+// NotificationSink publishes the alerts to a HSDP Notification topic
+type NotificationSink struct {
+	Client  Publisher
+	TopicID string
+	// Format renders the message. Defaults to Message
+	Format func(Payload) string
+}
+
+// Send publishes the payload
+func (s NotificationSink) Send(_ context.Context, payload Payload) error {
+	if s.Client == nil {
+		return ErrMissingClient
+	}
+	if s.TopicID == "" {
+		return ErrMissingTopicID
+	}
+	format := s.Format
+	if format == nil {
+		format = Message
+	}
+	_, _, err := s.Client.Publish(notification.PublishRequest{
+		TopicID: s.TopicID,
+		Message: format(payload),
+	})
+	return err
+}
+
+// LoggingSink stores every alert as a log event in HSDP Logging
+type LoggingSink struct {
+	Storer          logging.Storer
+	ApplicationName string
+	ServerName      string
+	// EventID of the log events. Defaults to "1"
+	EventID string
+}
+
+// Severity maps an alert to a log severity
+func Severity(alert Alert) string {
+	if alert.Status == StatusResolved {
+		return "INFO"
+	}
+	switch strings.ToLower(alert.Labels.Severity) {
+	case "critical", "error":
+		return "ERROR"
+	case "warning":
+		return "WARNING"
+	}
+	return "INFO"
+}
+
+// Send stores the alerts of the payload
+func (s LoggingSink) Send(_ context.Context, payload Payload) error {
+	if s.Storer == nil {
+		return ErrMissingClient
+	}
+	eventID := s.EventID
+	if eventID == "" {
+		eventID = "1"
+	}
+	transactionID := uuid.NewString()
+	resources := make([]logging.Resource, 0, len(payload.Alerts))
+	for _, alert := range payload.Alerts {
+		data, err := json.Marshal(alert)
+		if err != nil {
+			return err
+		}
+		applicationName := s.ApplicationName
+		if applicationName == "" {
+			applicationName = alert.Labels.Application
+		}
+		logTime := alert.StartsAt
+		if alert.Status == StatusResolved && !alert.EndsAt.IsZero() {
+			logTime = alert.EndsAt
+		}
+		if logTime.IsZero() {
+			logTime = time.Now()
+		}
+		resources = append(resources, logging.Resource{
+			ID:                  uuid.NewString(),
+			ResourceType:        "LogEvent",
+			EventID:             eventID,
+			TransactionID:       transactionID,
+			ApplicationName:     applicationName,
+			ApplicationInstance: alert.Labels.Instance,
+			Component:           alert.Labels.AlertName,
+			Category:            "Alert",
+			ServiceName:         alert.Labels.Job,
+			ServerName:          s.ServerName,
+			LogTime:             logTime.UTC().Format(logging.TimeFormat),
+			Severity:            Severity(alert),
+			LogData:             logging.LogData{Message: base64.StdEncoding.EncodeToString(data)},
+		})
+	}
+	_, err := s.Storer.StoreResources(resources, len(resources))
+	return err
+}
+
+// HTTPSink forwards the payload as JSON to another webhook
+type HTTPSink struct {
+	URL    string
+	Header http.Header
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// Send posts the payload to URL
+func (s HTTPSink) Send(ctx context.Context, payload Payload) error {
+	if s.URL == "" {
+		return ErrMissingURL
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range s.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: %s", ErrForwardFailed, resp.Status)
+	}
+	return nil
+}
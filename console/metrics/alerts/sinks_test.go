@@ -0,0 +1,96 @@
+package alerts_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/console/metrics/alerts"
+	"github.com/dip-software/go-dip-api/logging"
+	"github.com/dip-software/go-dip-api/notification"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPayload(t *testing.T) alerts.Payload {
+	var p alerts.Payload
+	require.NoError(t, json.Unmarshal([]byte(payload), &p))
+	return p
+}
+
+type fakePublisher struct {
+	requests []notification.PublishRequest
+}
+
+func (f *fakePublisher) Publish(request notification.PublishRequest) (*notification.PublishResponse, *notification.Response, error) {
+	f.requests = append(f.requests, request)
+	return &notification.PublishResponse{TopicID: request.TopicID}, nil, nil
+}
+
+type fakeStorer struct {
+	resources []logging.Resource
+}
+
+func (f *fakeStorer) StoreResources(msgs []logging.Resource, count int) (*logging.StoreResponse, error) {
+	f.resources = append(f.resources, msgs[:count]...)
+	return &logging.StoreResponse{}, nil
+}
+
+func TestNotificationSink(t *testing.T) {
+	publisher := &fakePublisher{}
+	sink := alerts.NotificationSink{Client: publisher, TopicID: "topic"}
+	require.NoError(t, sink.Send(context.Background(), testPayload(t)))
+	require.Len(t, publisher.requests, 1)
+	assert.Equal(t, "topic", publisher.requests[0].TopicID)
+	assert.Contains(t, publisher.requests[0].Message, "[FIRING] HighCPU (api)")
+	assert.Contains(t, publisher.requests[0].Message, "critical /prod/api: CPU above 90%")
+
+	err := alerts.NotificationSink{Client: publisher}.Send(context.Background(), testPayload(t))
+	assert.ErrorIs(t, err, alerts.ErrMissingTopicID)
+}
+
+func TestLoggingSink(t *testing.T) {
+	storer := &fakeStorer{}
+	sink := alerts.LoggingSink{Storer: storer, ServerName: "alerts.example.com"}
+	require.NoError(t, sink.Send(context.Background(), testPayload(t)))
+	require.Len(t, storer.resources, 3)
+
+	r := storer.resources[0]
+	assert.True(t, r.Valid(), r.Error)
+	assert.Equal(t, "ERROR", r.Severity)
+	assert.Equal(t, "api", r.ApplicationName)
+	assert.Equal(t, "2024-01-01T00:00:00.000Z", r.LogTime)
+	assert.Equal(t, "WARNING", storer.resources[1].Severity)
+	assert.Equal(t, r.TransactionID, storer.resources[2].TransactionID)
+
+	data, err := base64.StdEncoding.DecodeString(r.LogData.Message)
+	require.NoError(t, err)
+	var alert alerts.Alert
+	require.NoError(t, json.Unmarshal(data, &alert))
+	assert.Equal(t, "CPU above 90%", alert.Annotations.Summary)
+}
+
+func TestHTTPSink(t *testing.T) {
+	var received alerts.Payload
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get("X-Token"))
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := alerts.HTTPSink{URL: server.URL, Header: http.Header{"X-Token": {"token"}}}
+	require.NoError(t, sink.Send(context.Background(), testPayload(t)))
+	assert.Equal(t, "webhook", received.Receiver)
+	assert.Len(t, received.Alerts, 3)
+
+	status = http.StatusInternalServerError
+	err := sink.Send(context.Background(), testPayload(t))
+	assert.ErrorIs(t, err, alerts.ErrForwardFailed)
+}
@@ -0,0 +1,226 @@
+package console
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// RuleInstance is a rule enabled and configured on a metrics instance
+type RuleInstance struct {
+	ID          string            `json:"id,omitempty"`
+	RuleID      string            `json:"ruleId"`
+	Enabled     bool              `json:"enabled"`
+	Operator    string            `json:"operator"`
+	Threshold   float64           `json:"threshold"`
+	Variables   map[string]string `json:"variables,omitempty"`
+	Expression  string            `json:"expression,omitempty"`
+	Annotations RuleAnnotations   `json:"annotations"`
+}
+
+// RuleParams are the settings used to instantiate a rule template
+type RuleParams struct {
+	// Operator defaults to the first operator of the rule
+	Operator string
+	// Threshold defaults to the default threshold of the rule
+	Threshold *float64
+	// Variables holds a value for every extra of the rule, keyed by VariableName
+	Variables map[string]string
+	// Disabled creates the instance without activating it
+	Disabled bool
+}
+
+var templateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// render replaces the {{name}} placeholders of template. Prometheus template
+// expressions such as {{ $labels.instance }} are left untouched
+func render(template string, variables map[string]string) (string, error) {
+	var missing error
+	result := templateVariable.ReplaceAllStringFunc(template, func(match string) string {
+		name := templateVariable.FindStringSubmatch(match)[1]
+		value, ok := variables[name]
+		if !ok && missing == nil {
+			missing = fmt.Errorf("%w: %s", ErrMissingVariable, name)
+		}
+		return value
+	})
+	return result, missing
+}
+
+// ValidateThreshold checks value against the Min and Max of the threshold
+func (t Threshold) ValidateThreshold(value float64) error {
+	if t.Max > t.Min && (value < t.Min || value > t.Max) {
+		return fmt.Errorf("%w: %v not in [%v, %v]", ErrThresholdOutOfRange, value, t.Min, t.Max)
+	}
+	return nil
+}
+
+// UnitRange returns the range any Min and Max of the threshold must lie in based on its
+// units: [0, 100] for a percentage, otherwise [0, +Inf)
+func (t Threshold) UnitRange() (lower, upper float64) {
+	if slices.Contains(t.Unit, "%") {
+		return 0, 100
+	}
+	return 0, math.Inf(1)
+}
+
+// ValidateRange checks that lower and upper are ordered and lie within the UnitRange of the threshold
+func (t Threshold) ValidateRange(lower, upper float64) error {
+	minimum, maximum := t.UnitRange()
+	if lower > upper || lower < minimum || upper > maximum {
+		return fmt.Errorf("%w: [%v, %v] not within [%v, %v]", ErrThresholdOutOfRange, lower, upper, minimum, maximum)
+	}
+	return nil
+}
+
+// Instantiate creates a RuleInstance from the rule template. The operator, threshold and
+// extras are validated and substituted in the template and annotations
+func (r Rule) Instantiate(params RuleParams) (*RuleInstance, error) {
+	operator := params.Operator
+	if operator == "" && len(r.Rule.Operators) > 0 {
+		operator = r.Rule.Operators[0]
+	}
+	if len(r.Rule.Operators) > 0 && !slices.Contains(r.Rule.Operators, operator) {
+		return nil, fmt.Errorf("%w: %q, allowed: %v", ErrInvalidOperator, operator, r.Rule.Operators)
+	}
+	threshold := float64(r.Rule.Threshold.Default)
+	if params.Threshold != nil {
+		threshold = *params.Threshold
+	}
+	if err := r.Rule.Threshold.ValidateThreshold(threshold); err != nil {
+		return nil, err
+	}
+
+	variables := make(map[string]string)
+	for _, extra := range append(append([]RuleExtra(nil), r.Rule.Extras...), r.Rule.ExtraFor...) {
+		value, ok := params.Variables[extra.VariableName]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMissingVariable, extra.VariableName)
+		}
+		if len(extra.Options) > 0 && !slices.Contains(extra.Options, value) {
+			return nil, fmt.Errorf("%w: %s=%q, allowed: %v", ErrInvalidVariable, extra.VariableName, value, extra.Options)
+		}
+		variables[extra.VariableName] = value
+	}
+	substitutions := make(map[string]string, len(variables)+3)
+	for k, v := range variables {
+		substitutions[k] = v
+	}
+	substitutions["metric"] = r.Metric
+	substitutions["operator"] = operator
+	substitutions["threshold"] = strconv.FormatFloat(threshold, 'f', -1, 64)
+
+	instance := &RuleInstance{
+		RuleID:    r.ID,
+		Enabled:   !params.Disabled,
+		Operator:  operator,
+		Threshold: threshold,
+		Variables: variables,
+	}
+	var err error
+	for _, field := range []struct {
+		in  string
+		out *string
+	}{
+		{r.Template, &instance.Expression},
+		{r.Annotations.Summary, &instance.Annotations.Summary},
+		{r.Annotations.Description, &instance.Annotations.Description},
+		{r.Annotations.Resolved, &instance.Annotations.Resolved},
+	} {
+		if *field.out, err = render(field.in, substitutions); err != nil {
+			return nil, err
+		}
+	}
+	return instance, nil
+}
+
+// GetRuleInstances lists the rules enabled on a metrics instance
+func (c *MetricsService) GetRuleInstances(id string, options ...OptionFunc) (*[]RuleInstance, *Response, error) {
+	req, err := c.client.newRequest(CONSOLE, "GET", "v3/metrics/"+id+"/rules", nil, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var jsonResponse struct {
+		Data struct {
+			Rules []RuleInstance `json:"rules"`
+		} `json:"data"`
+		Status string `json:"status"`
+		Error  Error  `json:"error,omitempty"`
+	}
+	var response bytes.Buffer
+
+	resp, err := c.client.do(req, &response)
+	jsonErr := json.NewDecoder(&response).Decode(&jsonResponse)
+	if err != nil {
+		if jsonErr == nil {
+			return nil, resp, fmt.Errorf("status: %s, code: %s, message: %s, error: %w", jsonResponse.Status, jsonResponse.Error.Code, jsonResponse.Error.Message, err)
+		}
+		return nil, resp, err
+	}
+	if jsonErr != nil {
+		return nil, resp, fmt.Errorf("decoding jsonResponse: %w", jsonErr)
+	}
+	return &jsonResponse.Data.Rules, resp, err
+}
+
+func (c *MetricsService) writeRuleInstance(method, path string, instance RuleInstance, options []OptionFunc) (*RuleInstance, *Response, error) {
+	req, err := c.client.newRequest(CONSOLE, method, path, &instance, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var jsonResponse struct {
+		Data struct {
+			Rule RuleInstance `json:"rule"`
+		} `json:"data,omitempty"`
+		Status string `json:"status,omitempty"`
+		Error  Error  `json:"error,omitempty"`
+	}
+	var response bytes.Buffer
+
+	resp, err := c.client.do(req, &response)
+	jsonErr := json.NewDecoder(&response).Decode(&jsonResponse)
+	if err != nil {
+		if jsonErr == nil {
+			return nil, resp, fmt.Errorf("status: %s, code: %s, message: %s, error: %w", jsonResponse.Status, jsonResponse.Error.Code, jsonResponse.Error.Message, err)
+		}
+		return nil, resp, err
+	}
+	if jsonErr != nil {
+		return nil, resp, fmt.Errorf("decoding jsonResponse: %w", jsonErr)
+	}
+	return &jsonResponse.Data.Rule, resp, err
+}
+
+// CreateRuleInstance enables a rule on a metrics instance
+func (c *MetricsService) CreateRuleInstance(id string, instance RuleInstance, options ...OptionFunc) (*RuleInstance, *Response, error) {
+	return c.writeRuleInstance("POST", "v3/metrics/"+id+"/rules", instance, options)
+}
+
+// UpdateRuleInstance updates the configuration of a rule on a metrics instance
+func (c *MetricsService) UpdateRuleInstance(id string, instance RuleInstance, options ...OptionFunc) (*RuleInstance, *Response, error) {
+	if instance.ID == "" {
+		return nil, nil, ErrMissingRuleInstanceID
+	}
+	return c.writeRuleInstance("PUT", "v3/metrics/"+id+"/rules/"+instance.ID, instance, options)
+}
+
+// DeleteRuleInstance removes a rule from a metrics instance
+func (c *MetricsService) DeleteRuleInstance(id, ruleInstanceID string, options ...OptionFunc) (bool, *Response, error) {
+	req, err := c.client.newRequest(CONSOLE, "DELETE", "v3/metrics/"+id+"/rules/"+ruleInstanceID, nil, options)
+	if err != nil {
+		return false, nil, err
+	}
+	resp, err := c.client.do(req, nil)
+	if err != nil {
+		return false, resp, err
+	}
+	return true, resp, nil
+}
@@ -0,0 +1,155 @@
+package console_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/console"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ruleTemplate = `{
+  "annotations": {
+    "description": "Load of {{ $labels.instance }} is {{ operator }} {{threshold}}%",
+    "resolved": "",
+    "summary": "{{queue}} load is high"
+  },
+  "description": "RabbitMQ instance load is high",
+  "id": "rabbit_load_is_high",
+  "metric": "node_load1",
+  "rule": {
+    "extras": [
+      {"name": "Queue", "options": ["orders", "events"], "type": "select", "variableName": "queue"}
+    ],
+    "operators": [">", ">="],
+    "subject": "RabbitMQ instance",
+    "threshold": {"default": 75, "max": 100, "min": 0, "type": "range", "unit": ["%"]}
+  },
+  "template": "{{metric}}{queue=\"{{queue}}\"} {{operator}} {{threshold}}"
+}`
+
+func testRule(t *testing.T) console.Rule {
+	var rule console.Rule
+	require.NoError(t, json.Unmarshal([]byte(ruleTemplate), &rule))
+	return rule
+}
+
+func TestRuleInstantiate(t *testing.T) {
+	rule := testRule(t)
+
+	instance, err := rule.Instantiate(console.RuleParams{Variables: map[string]string{"queue": "orders"}})
+	require.NoError(t, err)
+	assert.Equal(t, "rabbit_load_is_high", instance.RuleID)
+	assert.True(t, instance.Enabled)
+	assert.Equal(t, ">", instance.Operator)
+	assert.Equal(t, 75.0, instance.Threshold)
+	assert.Equal(t, `node_load1{queue="orders"} > 75`, instance.Expression)
+	assert.Equal(t, "orders load is high", instance.Annotations.Summary)
+	assert.Equal(t, "Load of {{ $labels.instance }} is > 75%", instance.Annotations.Description)
+
+	threshold := 90.5
+	instance, err = rule.Instantiate(console.RuleParams{Operator: ">=", Threshold: &threshold, Variables: map[string]string{"queue": "events"}})
+	require.NoError(t, err)
+	assert.Equal(t, `node_load1{queue="events"} >= 90.5`, instance.Expression)
+
+	_, err = rule.Instantiate(console.RuleParams{Operator: "<", Variables: map[string]string{"queue": "orders"}})
+	assert.ErrorIs(t, err, console.ErrInvalidOperator)
+
+	threshold = 101
+	_, err = rule.Instantiate(console.RuleParams{Threshold: &threshold, Variables: map[string]string{"queue": "orders"}})
+	assert.ErrorIs(t, err, console.ErrThresholdOutOfRange)
+
+	_, err = rule.Instantiate(console.RuleParams{})
+	assert.ErrorIs(t, err, console.ErrMissingVariable)
+
+	_, err = rule.Instantiate(console.RuleParams{Variables: map[string]string{"queue": "jobs"}})
+	assert.ErrorIs(t, err, console.ErrInvalidVariable)
+
+	rule.Template = "{{unknown}}"
+	_, err = rule.Instantiate(console.RuleParams{Variables: map[string]string{"queue": "orders"}})
+	assert.ErrorIs(t, err, console.ErrMissingVariable)
+}
+
+func TestThresholdValidateRange(t *testing.T) {
+	cpu := console.Threshold{Name: "cpu", Min: 20, Max: 80, Unit: []string{"%"}}
+	assert.Nil(t, cpu.ValidateRange(5, 95))
+	assert.ErrorIs(t, cpu.ValidateRange(5, 120), console.ErrThresholdOutOfRange)
+	assert.ErrorIs(t, cpu.ValidateRange(50, 40), console.ErrThresholdOutOfRange)
+
+	latency := console.Threshold{Name: "http-latency", Unit: []string{"s", "ms"}}
+	assert.Nil(t, latency.ValidateRange(0.1, 5000))
+	assert.ErrorIs(t, latency.ValidateRange(-1, 1), console.ErrThresholdOutOfRange)
+}
+
+func TestRuleInstanceCalls(t *testing.T) {
+	teardown, err := setup(t)
+	require.NoError(t, err)
+	defer teardown()
+
+	instanceJSON := `{"id": "ri-1", "ruleId": "rabbit_load_is_high", "enabled": true, "operator": ">", "threshold": 75, "variables": {"queue": "orders"}}`
+	muxCONSOLE.HandleFunc("/v3/metrics/1/rules", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case "GET":
+			_, _ = io.WriteString(w, `{"status": "success", "data": {"rules": [`+instanceJSON+`]}}`)
+		case "POST":
+			var received console.RuleInstance
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			assert.Equal(t, "orders", received.Variables["queue"])
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"status": "success", "data": {"rule": `+instanceJSON+`}}`)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	muxCONSOLE.HandleFunc("/v3/metrics/1/rules/ri-1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case "PUT":
+			var received console.RuleInstance
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			assert.False(t, received.Enabled)
+			_, _ = io.WriteString(w, `{"status": "success", "data": {"rule": {"id": "ri-1", "ruleId": "rabbit_load_is_high", "enabled": false}}}`)
+		case "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	muxCONSOLE.HandleFunc("/v3/metrics/2/rules", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"status": "error", "error": {"code": "INVALID", "message": "unknown rule"}}`)
+	})
+
+	instances, _, err := client.Metrics.GetRuleInstances("1")
+	require.NoError(t, err)
+	require.Len(t, *instances, 1)
+	assert.Equal(t, "ri-1", (*instances)[0].ID)
+
+	instance, err := testRule(t).Instantiate(console.RuleParams{Variables: map[string]string{"queue": "orders"}})
+	require.NoError(t, err)
+	created, resp, err := client.Metrics.CreateRuleInstance("1", *instance)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode())
+	assert.Equal(t, "ri-1", created.ID)
+
+	created.Enabled = false
+	updated, _, err := client.Metrics.UpdateRuleInstance("1", *created)
+	require.NoError(t, err)
+	assert.False(t, updated.Enabled)
+
+	_, _, err = client.Metrics.UpdateRuleInstance("1", console.RuleInstance{})
+	assert.ErrorIs(t, err, console.ErrMissingRuleInstanceID)
+
+	ok, _, err := client.Metrics.DeleteRuleInstance("1", "ri-1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, _, err = client.Metrics.CreateRuleInstance("2", *instance)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown rule")
+}
@@ -68,6 +68,9 @@ type DataResponse struct {
 	Status string `json:"status"`
 }
 
+// Threshold is a threshold of a rule template or an application autoscaler. Min and Max
+// are the range of the threshold: the values a rule threshold may take, or the values
+// between which an autoscaler neither scales in nor out
 type Threshold struct {
 	Default int      `json:"default,omitempty"`
 	Enabled bool     `json:"enabled"`
@@ -94,31 +97,32 @@ type AutoscalersResponse struct {
 	Error  Error  `json:"error,omitempty"`
 }
 
-type Rule struct {
-	Annotations struct {
-		Description string `json:"description"`
-		Resolved    string `json:"resolved"`
-		Summary     string `json:"summary"`
-	} `json:"annotations"`
+// RuleAnnotations are the texts of an alert raised by a rule
+type RuleAnnotations struct {
 	Description string `json:"description"`
-	ID          string `json:"id"`
-	Metric      string `json:"metric"`
+	Resolved    string `json:"resolved"`
+	Summary     string `json:"summary"`
+}
+
+// RuleExtra is an additional variable of a rule template
+type RuleExtra struct {
+	Name         string   `json:"name"`
+	Options      []string `json:"options"`
+	Type         string   `json:"type"`
+	VariableName string   `json:"variableName"`
+}
+
+type Rule struct {
+	Annotations RuleAnnotations `json:"annotations"`
+	Description string          `json:"description"`
+	ID          string          `json:"id"`
+	Metric      string          `json:"metric"`
 	Rule        struct {
-		ExtraFor []struct {
-			Name         string   `json:"name"`
-			Options      []string `json:"options"`
-			Type         string   `json:"type"`
-			VariableName string   `json:"variableName"`
-		} `json:"extraFor,omitempty"`
-		Extras []struct {
-			Name         string   `json:"name"`
-			Options      []string `json:"options"`
-			Type         string   `json:"type"`
-			VariableName string   `json:"variableName"`
-		} `json:"extras"`
-		Operators []string  `json:"operators"`
-		Subject   string    `json:"subject"`
-		Threshold Threshold `json:"threshold"`
+		ExtraFor  []RuleExtra `json:"extraFor,omitempty"`
+		Extras    []RuleExtra `json:"extras"`
+		Operators []string    `json:"operators"`
+		Subject   string      `json:"subject"`
+		Threshold Threshold   `json:"threshold"`
 	} `json:"rule"`
 	Template string `json:"template"`
 }
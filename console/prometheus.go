@@ -0,0 +1,202 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+func formatTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', -1, 64)
+}
+
+// decodeValue decodes the result of a query into the model.Value of its type
+func decodeValue(resultType string, result json.RawMessage) (model.Value, error) {
+	var v model.Value
+	var err error
+	switch resultType {
+	case model.ValScalar.String():
+		var scalar model.Scalar
+		err = json.Unmarshal(result, &scalar)
+		v = &scalar
+	case model.ValVector.String():
+		var vector model.Vector
+		err = json.Unmarshal(result, &vector)
+		v = vector
+	case model.ValMatrix.String():
+		var matrix model.Matrix
+		err = json.Unmarshal(result, &matrix)
+		v = matrix
+	case model.ValString.String():
+		var str model.String
+		err = json.Unmarshal(result, &str)
+		v = &str
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownResultType, resultType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Value decodes the result of PrometheusGetData into a model.Value
+func (d Data) Value() (model.Value, error) {
+	result, err := json.Marshal(d.Result)
+	if err != nil {
+		return nil, err
+	}
+	return decodeValue(d.ResultType, result)
+}
+
+// Range is the time range and resolution of a range query
+type Range struct {
+	Start time.Time
+	End   time.Time
+	Step  time.Duration
+}
+
+type prometheusResponse struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"`
+	ErrorType string          `json:"errorType,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Warnings  []string        `json:"warnings,omitempty"`
+}
+
+func withParams(params url.Values) OptionFunc {
+	return func(req *http.Request) error {
+		if req.URL == nil {
+			req.URL = &url.URL{}
+		}
+		q := req.URL.Query()
+		for key, values := range params {
+			for _, value := range values {
+				q.Add(key, value)
+			}
+		}
+		req.URL.RawQuery = q.Encode()
+		return nil
+	}
+}
+
+// prometheusGet calls a Prometheus HTTP API endpoint of host and decodes its data into v
+func (c *MetricsService) prometheusGet(ctx context.Context, host, path string, params url.Values, v interface{}, options []OptionFunc) (*Response, error) {
+	options = append(options, WithHost(host), withParams(params))
+	req, err := c.client.newRequest(PROMETHEUS, "GET", path, nil, options)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	var jsonResponse prometheusResponse
+	var response bytes.Buffer
+
+	resp, err := c.client.do(req, &response)
+	jsonErr := json.NewDecoder(&response).Decode(&jsonResponse)
+	if err != nil {
+		if jsonErr == nil && jsonResponse.Status == "error" {
+			return resp, fmt.Errorf("type: %s, message: %s, error: %w", jsonResponse.ErrorType, jsonResponse.Error, err)
+		}
+		return resp, err
+	}
+	if jsonErr != nil {
+		return resp, fmt.Errorf("decoding jsonResponse: %w", jsonErr)
+	}
+	if jsonResponse.Status != "success" {
+		return resp, fmt.Errorf("%w: type: %s, message: %s", ErrQueryFailed, jsonResponse.ErrorType, jsonResponse.Error)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, v); err != nil {
+		return resp, fmt.Errorf("decoding data: %w", err)
+	}
+	return resp, nil
+}
+
+func (c *MetricsService) prometheusQuery(ctx context.Context, host, path string, params url.Values, options []OptionFunc) (model.Value, *Response, error) {
+	var data struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	}
+	resp, err := c.prometheusGet(ctx, host, path, params, &data, options)
+	if err != nil {
+		return nil, resp, err
+	}
+	value, err := decodeValue(data.ResultType, data.Result)
+	return value, resp, err
+}
+
+// PrometheusQuery evaluates an instant query at ts. A zero ts evaluates at the current server time
+func (c *MetricsService) PrometheusQuery(ctx context.Context, host, query string, ts time.Time, options ...OptionFunc) (model.Value, *Response, error) {
+	params := url.Values{"query": {query}}
+	if !ts.IsZero() {
+		params.Set("time", formatTime(ts))
+	}
+	return c.prometheusQuery(ctx, host, "/api/v1/query", params, options)
+}
+
+// PrometheusQueryRange evaluates a query over a range of time. The result is a model.Matrix
+func (c *MetricsService) PrometheusQueryRange(ctx context.Context, host, query string, r Range, options ...OptionFunc) (model.Value, *Response, error) {
+	if r.Step <= 0 {
+		return nil, nil, ErrInvalidStep
+	}
+	params := url.Values{
+		"query": {query},
+		"start": {formatTime(r.Start)},
+		"end":   {formatTime(r.End)},
+		"step":  {strconv.FormatFloat(r.Step.Seconds(), 'f', -1, 64)},
+	}
+	return c.prometheusQuery(ctx, host, "/api/v1/query_range", params, options)
+}
+
+func timeRange(start, end time.Time) url.Values {
+	params := url.Values{}
+	if !start.IsZero() {
+		params.Set("start", formatTime(start))
+	}
+	if !end.IsZero() {
+		params.Set("end", formatTime(end))
+	}
+	return params
+}
+
+// PrometheusSeries returns the series matching any of the selectors between start and end.
+// Zero times leave the range open
+func (c *MetricsService) PrometheusSeries(ctx context.Context, host string, matches []string, start, end time.Time, options ...OptionFunc) ([]model.Metric, *Response, error) {
+	params := timeRange(start, end)
+	for _, match := range matches {
+		params.Add("match[]", match)
+	}
+	var series []model.Metric
+	resp, err := c.prometheusGet(ctx, host, "/api/v1/series", params, &series, options)
+	if err != nil {
+		return nil, resp, err
+	}
+	return series, resp, nil
+}
+
+// PrometheusLabelNames returns the label names between start and end. Zero times leave the range open
+func (c *MetricsService) PrometheusLabelNames(ctx context.Context, host string, start, end time.Time, options ...OptionFunc) ([]string, *Response, error) {
+	var names []string
+	resp, err := c.prometheusGet(ctx, host, "/api/v1/labels", timeRange(start, end), &names, options)
+	if err != nil {
+		return nil, resp, err
+	}
+	return names, resp, nil
+}
+
+// PrometheusLabelValues returns the values of label between start and end. Zero times leave the range open
+func (c *MetricsService) PrometheusLabelValues(ctx context.Context, host, label string, start, end time.Time, options ...OptionFunc) ([]string, *Response, error) {
+	var values []string
+	resp, err := c.prometheusGet(ctx, host, "/api/v1/label/"+url.PathEscape(label)+"/values", timeRange(start, end), &values, options)
+	if err != nil {
+		return nil, resp, err
+	}
+	return values, resp, nil
+}
@@ -0,0 +1,179 @@
+package console_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dip-software/go-dip-api/console"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusQuery(t *testing.T) {
+	teardown, err := setup(t)
+	require.NoError(t, err)
+	defer teardown()
+
+	muxCONSOLE.HandleFunc("/api/prometheus/api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "metrics.example.com", r.URL.Query().Get("host"))
+		assert.Equal(t, "up", r.URL.Query().Get("query"))
+		assert.Equal(t, "1700000000.5", r.URL.Query().Get("time"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+  "status": "success",
+  "data": {
+    "resultType": "vector",
+    "result": [
+      {"metric": {"__name__": "up", "job": "api"}, "value": [1700000000.5, "1"]},
+      {"metric": {"__name__": "up", "job": "db"}, "value": [1700000000.5, "NaN"]}
+    ]
+  }
+}`)
+	})
+
+	ts := time.UnixMilli(1700000000500)
+	value, resp, err := client.Metrics.PrometheusQuery(context.Background(), "metrics.example.com", "up", ts)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	require.Equal(t, model.ValVector, value.Type())
+	vector := value.(model.Vector)
+	require.Len(t, vector, 2)
+	assert.Equal(t, model.LabelValue("api"), vector[0].Metric["job"])
+	assert.Equal(t, model.LabelValue("up"), vector[0].Metric[model.MetricNameLabel])
+	assert.Equal(t, model.SampleValue(1), vector[0].Value)
+	assert.Equal(t, model.TimeFromUnixNano(ts.UnixNano()), vector[0].Timestamp)
+	assert.True(t, math.IsNaN(float64(vector[1].Value)))
+	assert.Contains(t, vector.String(), `up{job="api"} => 1 @[`)
+}
+
+func TestPrometheusQueryRange(t *testing.T) {
+	teardown, err := setup(t)
+	require.NoError(t, err)
+	defer teardown()
+
+	muxCONSOLE.HandleFunc("/api/prometheus/api/v1/query_range", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, "1700000000", q.Get("start"))
+		assert.Equal(t, "1700000060", q.Get("end"))
+		assert.Equal(t, "30", q.Get("step"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+  "status": "success",
+  "data": {
+    "resultType": "matrix",
+    "result": [
+      {"metric": {"instance": "0"}, "values": [[1700000000, "0.5"], [1700000030, "+Inf"], [1700000060, "2.25"]]}
+    ]
+  }
+}`)
+	})
+
+	start := time.Unix(1700000000, 0)
+	value, _, err := client.Metrics.PrometheusQueryRange(context.Background(), "metrics.example.com", "cpu", console.Range{
+		Start: start,
+		End:   start.Add(time.Minute),
+		Step:  30 * time.Second,
+	})
+	require.NoError(t, err)
+	matrix, ok := value.(model.Matrix)
+	require.True(t, ok)
+	require.Len(t, matrix, 1)
+	require.Len(t, matrix[0].Values, 3)
+	assert.Equal(t, model.LabelValue("0"), matrix[0].Metric["instance"])
+	assert.Equal(t, model.SampleValue(0.5), matrix[0].Values[0].Value)
+	assert.True(t, math.IsInf(float64(matrix[0].Values[1].Value), 1))
+	assert.Equal(t, model.SampleValue(2.25), matrix[0].Values[2].Value)
+	assert.True(t, start.Add(time.Minute).Equal(matrix[0].Values[2].Timestamp.Time()))
+
+	_, _, err = client.Metrics.PrometheusQueryRange(context.Background(), "metrics.example.com", "cpu", console.Range{Start: start, End: start})
+	assert.ErrorIs(t, err, console.ErrInvalidStep)
+}
+
+func TestPrometheusScalarAndErrors(t *testing.T) {
+	teardown, err := setup(t)
+	require.NoError(t, err)
+	defer teardown()
+
+	muxCONSOLE.HandleFunc("/api/prometheus/api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("query") {
+		case "scalar(1)":
+			_, _ = io.WriteString(w, `{"status": "success", "data": {"resultType": "scalar", "result": [1700000000, "1"]}}`)
+		case "bogus":
+			_, _ = io.WriteString(w, `{"status": "success", "data": {"resultType": "bogus", "result": []}}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"status": "error", "errorType": "bad_data", "error": "parse error"}`)
+		}
+	})
+
+	value, _, err := client.Metrics.PrometheusQuery(context.Background(), "host", "scalar(1)", time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, &model.Scalar{Value: 1, Timestamp: model.TimeFromUnix(1700000000)}, value)
+
+	_, _, err = client.Metrics.PrometheusQuery(context.Background(), "host", "bogus", time.Time{})
+	assert.ErrorIs(t, err, console.ErrUnknownResultType)
+
+	_, resp, err := client.Metrics.PrometheusQuery(context.Background(), "host", "up{", time.Time{})
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+	assert.Contains(t, err.Error(), "parse error")
+}
+
+func TestPrometheusMetadata(t *testing.T) {
+	teardown, err := setup(t)
+	require.NoError(t, err)
+	defer teardown()
+
+	muxCONSOLE.HandleFunc("/api/prometheus/api/v1/series", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, []string{"up", `cpu{job="api"}`}, r.URL.Query()["match[]"])
+		assert.Equal(t, "", r.URL.Query().Get("start"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"status": "success", "data": [{"__name__": "up", "job": "api"}]}`)
+	})
+	muxCONSOLE.HandleFunc("/api/prometheus/api/v1/labels", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"status": "success", "data": ["__name__", "job"]}`)
+	})
+	muxCONSOLE.HandleFunc("/api/prometheus/api/v1/label/job/values", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"status": "success", "data": ["api", "db"]}`)
+	})
+
+	ctx := context.Background()
+	series, _, err := client.Metrics.PrometheusSeries(ctx, "host", []string{"up", `cpu{job="api"}`}, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, series, 1)
+	assert.Equal(t, `up{job="api"}`, series[0].String())
+
+	names, _, err := client.Metrics.PrometheusLabelNames(ctx, "host", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"__name__", "job"}, names)
+
+	values, _, err := client.Metrics.PrometheusLabelValues(ctx, "host", "job", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api", "db"}, values)
+}
+
+func TestDataValue(t *testing.T) {
+	var data console.Data
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "resultType": "matrix",
+  "result": [{"metric": {"app": "web"}, "values": [[1700000000, "42"]]}]
+}`), &data))
+
+	value, err := data.Value()
+	require.NoError(t, err)
+	matrix := value.(model.Matrix)
+	require.Len(t, matrix, 1)
+	assert.Equal(t, model.LabelValue("web"), matrix[0].Metric["app"])
+	assert.Equal(t, model.SampleValue(42), matrix[0].Values[0].Value)
+}
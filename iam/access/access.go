@@ -0,0 +1,286 @@
+// Package access resolves the effective IAM permissions of users, services and devices
+// and explains through which groups and roles each permission is granted
+package access
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dip-software/go-dip-api/iam"
+)
+
+// Identity describes an IAM identity which can be a member of groups
+type Identity struct {
+	ID   string
+	Type string
+}
+
+// User returns the Identity of a user
+func User(id string) Identity {
+	return Identity{ID: id, Type: iam.GroupMemberTypeUser}
+}
+
+// Service returns the Identity of a service identity
+func Service(id string) Identity {
+	return Identity{ID: id, Type: iam.GroupMemberTypeService}
+}
+
+// Device returns the Identity of a device
+func Device(id string) Identity {
+	return Identity{ID: id, Type: iam.GroupMemberTypeDevice}
+}
+
+// Valid returns true if the identity can be resolved
+func (i Identity) Valid() bool {
+	if i.ID == "" {
+		return false
+	}
+	switch i.Type {
+	case iam.GroupMemberTypeUser, iam.GroupMemberTypeService, iam.GroupMemberTypeDevice:
+		return true
+	}
+	return false
+}
+
+func (i Identity) String() string {
+	return strings.ToLower(i.Type) + "/" + i.ID
+}
+
+// Path explains how a single permission is granted to an identity
+type Path struct {
+	OrganizationID string `json:"organizationId"`
+	GroupID        string `json:"groupId"`
+	GroupName      string `json:"groupName"`
+	RoleID         string `json:"roleId"`
+	RoleName       string `json:"roleName"`
+	Permission     string `json:"permission"`
+}
+
+func (p Path) String() string {
+	return fmt.Sprintf("group %s -> role %s -> %s", p.GroupName, p.RoleName, p.Permission)
+}
+
+// OrganizationAccess holds the resolved access of an identity in a single organization
+type OrganizationAccess struct {
+	OrganizationID string              `json:"organizationId"`
+	Groups         []iam.GroupResource `json:"groups"`
+	Roles          []iam.Role          `json:"roles"`
+	Grants         map[string][]Path   `json:"grants"`
+}
+
+// Permissions returns the sorted effective permissions in the organization
+func (o *OrganizationAccess) Permissions() []string {
+	if o == nil {
+		return []string{}
+	}
+	permissions := make([]string, 0, len(o.Grants))
+	for p := range o.Grants {
+		permissions = append(permissions, p)
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// Access is the identity -> group -> role -> permission graph of an identity
+type Access struct {
+	Identity      Identity                       `json:"identity"`
+	Organizations map[string]*OrganizationAccess `json:"organizations"`
+}
+
+// Organization returns the access in the given organization, nil if the identity has none
+func (a *Access) Organization(orgID string) *OrganizationAccess {
+	if a == nil {
+		return nil
+	}
+	return a.Organizations[orgID]
+}
+
+// OrganizationIDs returns the sorted IDs of all organizations the identity has access to
+func (a *Access) OrganizationIDs() []string {
+	ids := make([]string, 0, len(a.Organizations))
+	for id := range a.Organizations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Permissions returns the effective permissions of the identity in the organization
+func (a *Access) Permissions(orgID string) []string {
+	return a.Organization(orgID).Permissions()
+}
+
+// HasPermissions returns true if all permissions are granted in the organization
+func (a *Access) HasPermissions(orgID string, permissions ...string) bool {
+	org := a.Organization(orgID)
+	if org == nil {
+		return false
+	}
+	for _, p := range permissions {
+		if _, ok := org.Grants[p]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Explain returns all paths through which the permission is granted in the organization
+func (a *Access) Explain(orgID, permission string) ([]Path, error) {
+	org := a.Organization(orgID)
+	if org == nil {
+		return nil, fmt.Errorf("%s in org %s: %w", permission, orgID, ErrPermissionNotFound)
+	}
+	paths, ok := org.Grants[permission]
+	if !ok {
+		return nil, fmt.Errorf("%s in org %s: %w", permission, orgID, ErrPermissionNotFound)
+	}
+	return paths, nil
+}
+
+// Resolver builds Access graphs using the IAM Groups and Roles services.
+// Role permissions are cached for the lifetime of the Resolver
+type Resolver struct {
+	client *iam.Client
+
+	mu              sync.Mutex
+	rolePermissions map[string][]string
+}
+
+// NewResolver returns a Resolver which uses the given IAM client
+func NewResolver(client *iam.Client) (*Resolver, error) {
+	if client == nil {
+		return nil, ErrMissingIAMClient
+	}
+	return &Resolver{
+		client:          client,
+		rolePermissions: make(map[string][]string),
+	}, nil
+}
+
+// Resolve builds the Access graph of the identity. When orgID is empty the
+// access in all organizations the identity has group memberships in is resolved
+func (r *Resolver) Resolve(ctx context.Context, identity Identity, orgID string) (*Access, error) {
+	if !identity.Valid() {
+		return nil, ErrInvalidIdentity
+	}
+	opt := &iam.GetGroupOptions{
+		MemberType: &identity.Type,
+		MemberID:   &identity.ID,
+	}
+	if orgID != "" {
+		opt.OrganizationID = &orgID
+	}
+	groups, _, err := r.client.Groups.GetAllGroups(opt, iam.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("groups of %s: %w", identity, err)
+	}
+	access := &Access{
+		Identity:      identity,
+		Organizations: make(map[string]*OrganizationAccess),
+	}
+	if groups == nil {
+		return access, nil
+	}
+	for _, group := range *groups {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if orgID != "" && group.OrgID != orgID {
+			continue
+		}
+		org, ok := access.Organizations[group.OrgID]
+		if !ok {
+			org = &OrganizationAccess{
+				OrganizationID: group.OrgID,
+				Groups:         []iam.GroupResource{},
+				Roles:          []iam.Role{},
+				Grants:         make(map[string][]Path),
+			}
+			access.Organizations[group.OrgID] = org
+		}
+		org.Groups = append(org.Groups, group)
+		roles, _, err := r.client.Groups.GetRoles(iam.Group{ID: group.ID}, iam.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("roles of group %s: %w", group.GroupName, err)
+		}
+		if roles == nil {
+			continue
+		}
+		for _, role := range *roles {
+			if !containsRole(org.Roles, role.ID) {
+				org.Roles = append(org.Roles, role)
+			}
+			permissions, err := r.permissionsOf(ctx, role)
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range permissions {
+				org.Grants[p] = append(org.Grants[p], Path{
+					OrganizationID: group.OrgID,
+					GroupID:        group.ID,
+					GroupName:      group.GroupName,
+					RoleID:         role.ID,
+					RoleName:       role.Name,
+					Permission:     p,
+				})
+			}
+		}
+	}
+	return access, nil
+}
+
+// Explain resolves the identity in the organization and returns the paths granting the permission
+func (r *Resolver) Explain(ctx context.Context, identity Identity, orgID, permission string) ([]Path, error) {
+	access, err := r.Resolve(ctx, identity, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return access.Explain(orgID, permission)
+}
+
+// Diff resolves both identities and compares their access.
+// When orgID is empty all organizations of both identities are compared
+func (r *Resolver) Diff(ctx context.Context, a, b Identity, orgID string) (*Diff, error) {
+	accessA, err := r.Resolve(ctx, a, orgID)
+	if err != nil {
+		return nil, err
+	}
+	accessB, err := r.Resolve(ctx, b, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return Compare(accessA, accessB), nil
+}
+
+func (r *Resolver) permissionsOf(ctx context.Context, role iam.Role) ([]string, error) {
+	r.mu.Lock()
+	cached, ok := r.rolePermissions[role.ID]
+	r.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+	permissions, _, err := r.client.Roles.GetRolePermissions(role, iam.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("permissions of role %s: %w", role.Name, err)
+	}
+	var result []string
+	if permissions != nil {
+		result = *permissions
+	}
+	r.mu.Lock()
+	r.rolePermissions[role.ID] = result
+	r.mu.Unlock()
+	return result, nil
+}
+
+func containsRole(roles []iam.Role, id string) bool {
+	for _, r := range roles {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
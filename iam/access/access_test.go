@@ -0,0 +1,207 @@
+package access_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/iam"
+	"github.com/dip-software/go-dip-api/iam/access"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	muxIAM    *http.ServeMux
+	serverIAM *httptest.Server
+	muxIDM    *http.ServeMux
+	serverIDM *httptest.Server
+
+	iamClient *iam.Client
+)
+
+const (
+	orgID      = "c57b2625-eda3-4b27-a8e6-86f0a0e76afc"
+	userID     = "f5fe538f-c3b5-4454-8774-cd3789f59b9f"
+	pagedID    = "6a2c7c0e-5d1b-4f0e-9a55-2f1b8d0c7e33"
+	serviceID  = "b1d9b5c0-3a07-4a1f-9bd2-a1b7c7e7e0b2"
+	adminGroup = "dbf1d779-ab9f-4c27-b4aa-ea75f9efbbc0"
+	readGroup  = "0e1f6e0c-4b55-4b80-b73e-4b26ba0a3c4d"
+	adminRole  = "4c5f3fb6-6b6e-4c0b-9b4e-3a8e4f0f2f11"
+	readRole   = "7a0d1b55-8a23-4f0f-a0b2-0d3f2cfc9f22"
+)
+
+func setup(t *testing.T) func() {
+	muxIAM = http.NewServeMux()
+	serverIAM = httptest.NewServer(muxIAM)
+	muxIDM = http.NewServeMux()
+	serverIDM = httptest.NewServer(muxIDM)
+
+	var err error
+
+	iamClient, err = iam.NewClient(nil, &iam.Config{
+		OAuth2ClientID: "TestClient",
+		OAuth2Secret:   "Secret",
+		IAMURL:         serverIAM.URL,
+		IDMURL:         serverIDM.URL,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create iamClient: %v", err)
+	}
+	iamClient.SetToken("44d20214-7879-4e35-923d-f9d4e01c9746")
+
+	muxIDM.HandleFunc("/authorize/identity/Group", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		switch r.URL.Query().Get("memberId") {
+		case userID:
+			_, _ = io.WriteString(w, `{
+  "total": 2,
+  "entry": [
+    {"resource": {"_id": "`+adminGroup+`", "resourceType": "Group", "groupName": "AdminGroup", "orgId": "`+orgID+`"}},
+    {"resource": {"_id": "`+readGroup+`", "resourceType": "Group", "groupName": "ReadGroup", "orgId": "`+orgID+`"}}
+  ]
+}`)
+		case pagedID:
+			// A full first page of groups without roles, the read group is on the second page
+			if r.URL.Query().Get("_page") == "2" {
+				_, _ = io.WriteString(w, `{"total": 1, "entry": [
+    {"resource": {"_id": "`+readGroup+`", "resourceType": "Group", "groupName": "ReadGroup", "orgId": "`+orgID+`"}}
+]}`)
+				return
+			}
+			entries := ""
+			for i := 0; i < 100; i++ {
+				if i > 0 {
+					entries += ","
+				}
+				entries += fmt.Sprintf(`{"resource": {"_id": "group-%d", "resourceType": "Group", "groupName": "Group%d", "orgId": "%s"}}`, i, i, orgID)
+			}
+			_, _ = io.WriteString(w, `{"total": 100, "entry": [`+entries+`]}`)
+		case serviceID:
+			_, _ = io.WriteString(w, `{
+  "total": 1,
+  "entry": [
+    {"resource": {"_id": "`+readGroup+`", "resourceType": "Group", "groupName": "ReadGroup", "orgId": "`+orgID+`"}}
+  ]
+}`)
+		default:
+			_, _ = io.WriteString(w, `{"total": 0, "entry": []}`)
+		}
+	})
+	muxIDM.HandleFunc("/authorize/identity/Role", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		switch r.URL.Query().Get("groupId") {
+		case adminGroup:
+			_, _ = io.WriteString(w, `{"total": 2, "entry": [
+  {"id": "`+adminRole+`", "name": "ADMIN", "managingOrganization": "`+orgID+`"},
+  {"id": "`+readRole+`", "name": "READER", "managingOrganization": "`+orgID+`"}
+]}`)
+		case readGroup:
+			_, _ = io.WriteString(w, `{"total": 1, "entry": [
+  {"id": "`+readRole+`", "name": "READER", "managingOrganization": "`+orgID+`"}
+]}`)
+		default:
+			_, _ = io.WriteString(w, `{"total": 0, "entry": []}`)
+		}
+	})
+	muxIDM.HandleFunc("/authorize/identity/Permission", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		switch r.URL.Query().Get("roleId") {
+		case adminRole:
+			_, _ = io.WriteString(w, `{"total": 2, "entry": [{"name": "GROUP.WRITE"}, {"name": "USER.READ"}]}`)
+		case readRole:
+			_, _ = io.WriteString(w, `{"total": 1, "entry": [{"name": "USER.READ"}]}`)
+		}
+	})
+
+	return func() {
+		serverIAM.Close()
+		serverIDM.Close()
+	}
+}
+
+func TestResolve(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	resolver, err := access.NewResolver(iamClient)
+	if !assert.Nil(t, err) {
+		return
+	}
+	result, err := resolver.Resolve(context.Background(), access.User(userID), orgID)
+	if !assert.Nil(t, err) || !assert.NotNil(t, result) {
+		return
+	}
+	assert.Equal(t, []string{orgID}, result.OrganizationIDs())
+	assert.Equal(t, []string{"GROUP.WRITE", "USER.READ"}, result.Permissions(orgID))
+	assert.True(t, result.HasPermissions(orgID, "USER.READ", "GROUP.WRITE"))
+	assert.False(t, result.HasPermissions(orgID, "DEVICE.READ"))
+	assert.Len(t, result.Organization(orgID).Roles, 2)
+
+	paths, err := result.Explain(orgID, "USER.READ")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, paths, 3)
+	assert.Equal(t, "group AdminGroup -> role ADMIN -> USER.READ", paths[0].String())
+
+	_, err = result.Explain(orgID, "DEVICE.READ")
+	assert.True(t, errors.Is(err, access.ErrPermissionNotFound))
+}
+
+func TestResolvePagedGroups(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	resolver, err := access.NewResolver(iamClient)
+	if !assert.Nil(t, err) {
+		return
+	}
+	result, err := resolver.Resolve(context.Background(), access.User(pagedID), orgID)
+	if !assert.Nil(t, err) || !assert.NotNil(t, result) {
+		return
+	}
+	assert.Len(t, result.Organization(orgID).Groups, 101)
+	assert.Equal(t, []string{"USER.READ"}, result.Permissions(orgID))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = resolver.Resolve(ctx, access.User(userID), orgID)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDiff(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	resolver, err := access.NewResolver(iamClient)
+	if !assert.Nil(t, err) {
+		return
+	}
+	diff, err := resolver.Diff(context.Background(), access.User(userID), access.Service(serviceID), orgID)
+	if !assert.Nil(t, err) || !assert.NotNil(t, diff) {
+		return
+	}
+	assert.False(t, diff.Equal())
+	assert.Equal(t, []string{"GROUP.WRITE"}, diff.Organizations[orgID].OnlyA)
+	assert.Equal(t, []string{}, diff.Organizations[orgID].OnlyB)
+	assert.Equal(t, []string{"USER.READ"}, diff.Organizations[orgID].Both)
+}
+
+func TestInvalidIdentity(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	_, err := access.NewResolver(nil)
+	assert.Equal(t, access.ErrMissingIAMClient, err)
+
+	resolver, _ := access.NewResolver(iamClient)
+	_, err = resolver.Resolve(context.Background(), access.Identity{ID: userID, Type: "ROBOT"}, orgID)
+	assert.Equal(t, access.ErrInvalidIdentity, err)
+}
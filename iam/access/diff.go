@@ -0,0 +1,79 @@
+package access
+
+import (
+	"sort"
+)
+
+// PermissionDiff describes the difference in permissions within one organization
+type PermissionDiff struct {
+	OnlyA []string `json:"onlyA"`
+	OnlyB []string `json:"onlyB"`
+	Both  []string `json:"both"`
+}
+
+// Equal returns true if both identities have the same permissions
+func (p PermissionDiff) Equal() bool {
+	return len(p.OnlyA) == 0 && len(p.OnlyB) == 0
+}
+
+// Diff describes the difference in access between two identities
+type Diff struct {
+	A             Identity                  `json:"a"`
+	B             Identity                  `json:"b"`
+	Organizations map[string]PermissionDiff `json:"organizations"`
+}
+
+// Equal returns true if both identities have the same permissions in all organizations
+func (d *Diff) Equal() bool {
+	for _, p := range d.Organizations {
+		if !p.Equal() {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare computes the Diff between two resolved Access graphs
+func Compare(a, b *Access) *Diff {
+	diff := &Diff{
+		A:             a.Identity,
+		B:             b.Identity,
+		Organizations: make(map[string]PermissionDiff),
+	}
+	orgs := make(map[string]bool)
+	for id := range a.Organizations {
+		orgs[id] = true
+	}
+	for id := range b.Organizations {
+		orgs[id] = true
+	}
+	for orgID := range orgs {
+		diff.Organizations[orgID] = comparePermissions(a.Permissions(orgID), b.Permissions(orgID))
+	}
+	return diff
+}
+
+func comparePermissions(a, b []string) PermissionDiff {
+	inB := make(map[string]bool, len(b))
+	for _, p := range b {
+		inB[p] = true
+	}
+	diff := PermissionDiff{
+		OnlyA: []string{},
+		OnlyB: []string{},
+		Both:  []string{},
+	}
+	for _, p := range a {
+		if inB[p] {
+			diff.Both = append(diff.Both, p)
+			delete(inB, p)
+			continue
+		}
+		diff.OnlyA = append(diff.OnlyA, p)
+	}
+	for p := range inB {
+		diff.OnlyB = append(diff.OnlyB, p)
+	}
+	sort.Strings(diff.OnlyB)
+	return diff
+}
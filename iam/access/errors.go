@@ -0,0 +1,12 @@
+package access
+
+import (
+	"errors"
+)
+
+// Exported Errors
+var (
+	ErrMissingIAMClient   = errors.New("missing IAM client")
+	ErrInvalidIdentity    = errors.New("invalid identity, need an ID and a USER, SERVICE or DEVICE type")
+	ErrPermissionNotFound = errors.New("permission not granted")
+)
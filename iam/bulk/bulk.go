@@ -0,0 +1,341 @@
+// Package bulk imports and exports IAM users in bulk using CSV or SCIM 2.0 User documents
+package bulk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format is the serialization format of user records
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatSCIM Format = "scim"
+)
+
+const (
+	// SCIMUserSchema is the SCIM 2.0 core User schema
+	SCIMUserSchema = "urn:ietf:params:scim:schemas:core:2.0:User"
+	// SCIMListResponseSchema is the SCIM 2.0 list response schema
+	SCIMListResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	// SCIMExtensionSchema carries IAM attributes which have no SCIM core equivalent
+	SCIMExtensionSchema = "urn:ietf:params:scim:schemas:extension:philips:hsdp:2.0:User"
+)
+
+// CSVHeader lists the columns of the CSV format in the order they are written
+var CSVHeader = []string{
+	"loginId",
+	"email",
+	"givenName",
+	"familyName",
+	"mobilePhone",
+	"preferredLanguage",
+	"preferredCommunicationChannel",
+	"groups",
+	"mfa",
+	"disabled",
+}
+
+// groupSeparator separates group names within the groups CSV column
+const groupSeparator = ";"
+
+// Record describes a single IAM user. Groups are referenced by name
+type Record struct {
+	LoginID                       string
+	Email                         string
+	GivenName                     string
+	FamilyName                    string
+	MobilePhone                   string
+	PreferredLanguage             string
+	PreferredCommunicationChannel string
+	Groups                        []string
+	// MFA activates or deactivates multi-factor authentication. Nil leaves it untouched
+	MFA      *bool
+	Disabled bool
+}
+
+// Validate checks that the mandatory fields of the record are set
+func (r Record) Validate() error {
+	switch {
+	case r.LoginID == "":
+		return ErrMissingLoginID
+	case r.Email == "":
+		return ErrMissingEmail
+	case r.GivenName == "" || r.FamilyName == "":
+		return ErrMissingName
+	}
+	return nil
+}
+
+// Read reads records in the given format
+func Read(r io.Reader, format Format) ([]Record, error) {
+	switch format {
+	case FormatCSV:
+		return ReadCSV(r)
+	case FormatSCIM:
+		return ReadSCIM(r)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// Write writes records in the given format
+func Write(w io.Writer, records []Record, format Format) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, records)
+	case FormatSCIM:
+		return WriteSCIM(w, records)
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// ReadCSV reads records from CSV. The first line must be a header naming the columns,
+// see CSVHeader. Column names are case-insensitive and unknown columns are ignored
+func ReadCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["loginid"]; !ok {
+		return nil, fmt.Errorf("%w: loginId", ErrMissingColumn)
+	}
+	var records []Record
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		get := func(column string) string {
+			if i, ok := columns[strings.ToLower(column)]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		record := Record{
+			LoginID:                       get("loginId"),
+			Email:                         get("email"),
+			GivenName:                     get("givenName"),
+			FamilyName:                    get("familyName"),
+			MobilePhone:                   get("mobilePhone"),
+			PreferredLanguage:             get("preferredLanguage"),
+			PreferredCommunicationChannel: get("preferredCommunicationChannel"),
+		}
+		for _, group := range strings.Split(get("groups"), groupSeparator) {
+			if group = strings.TrimSpace(group); group != "" {
+				record.Groups = append(record.Groups, group)
+			}
+		}
+		if mfa := get("mfa"); mfa != "" {
+			value, err := strconv.ParseBool(mfa)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: mfa: %w", line, err)
+			}
+			record.MFA = &value
+		}
+		if disabled := get("disabled"); disabled != "" {
+			record.Disabled, err = strconv.ParseBool(disabled)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: disabled: %w", line, err)
+			}
+		}
+		records = append(records, record)
+	}
+}
+
+// WriteCSV writes records as CSV including a header line
+func WriteCSV(w io.Writer, records []Record) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		mfa := ""
+		if r.MFA != nil {
+			mfa = strconv.FormatBool(*r.MFA)
+		}
+		if err := writer.Write([]string{
+			r.LoginID,
+			r.Email,
+			r.GivenName,
+			r.FamilyName,
+			r.MobilePhone,
+			r.PreferredLanguage,
+			r.PreferredCommunicationChannel,
+			strings.Join(r.Groups, groupSeparator),
+			mfa,
+			strconv.FormatBool(r.Disabled),
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// SCIMUser is a SCIM 2.0 User resource
+type SCIMUser struct {
+	Schemas           []string       `json:"schemas"`
+	ID                string         `json:"id,omitempty"`
+	UserName          string         `json:"userName"`
+	Name              SCIMName       `json:"name"`
+	Emails            []SCIMValue    `json:"emails,omitempty"`
+	PhoneNumbers      []SCIMValue    `json:"phoneNumbers,omitempty"`
+	PreferredLanguage string         `json:"preferredLanguage,omitempty"`
+	Active            *bool          `json:"active,omitempty"`
+	Groups            []SCIMValue    `json:"groups,omitempty"`
+	Extension         *SCIMExtension `json:"urn:ietf:params:scim:schemas:extension:philips:hsdp:2.0:User,omitempty"`
+}
+
+// SCIMName is the name of a SCIMUser
+type SCIMName struct {
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// SCIMValue is a multi-valued SCIM attribute such as an email address or group reference
+type SCIMValue struct {
+	Value   string `json:"value,omitempty"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// SCIMExtension holds the IAM specific attributes of a SCIMUser
+type SCIMExtension struct {
+	PreferredCommunicationChannel string `json:"preferredCommunicationChannel,omitempty"`
+	MFA                           *bool  `json:"mfa,omitempty"`
+}
+
+// SCIMListResponse is a SCIM 2.0 list of users
+type SCIMListResponse struct {
+	Schemas      []string   `json:"schemas"`
+	TotalResults int        `json:"totalResults"`
+	Resources    []SCIMUser `json:"Resources"`
+}
+
+// ToSCIM converts the record to a SCIM User
+func (r Record) ToSCIM() SCIMUser {
+	active := !r.Disabled
+	user := SCIMUser{
+		Schemas:           []string{SCIMUserSchema},
+		UserName:          r.LoginID,
+		Name:              SCIMName{GivenName: r.GivenName, FamilyName: r.FamilyName},
+		PreferredLanguage: r.PreferredLanguage,
+		Active:            &active,
+	}
+	if r.Email != "" {
+		user.Emails = []SCIMValue{{Value: r.Email, Type: "work", Primary: true}}
+	}
+	if r.MobilePhone != "" {
+		user.PhoneNumbers = []SCIMValue{{Value: r.MobilePhone, Type: "mobile"}}
+	}
+	for _, g := range r.Groups {
+		user.Groups = append(user.Groups, SCIMValue{Display: g})
+	}
+	if r.PreferredCommunicationChannel != "" || r.MFA != nil {
+		user.Schemas = append(user.Schemas, SCIMExtensionSchema)
+		user.Extension = &SCIMExtension{
+			PreferredCommunicationChannel: r.PreferredCommunicationChannel,
+			MFA:                           r.MFA,
+		}
+	}
+	return user
+}
+
+// FromSCIM converts a SCIM User to a record. The primary email is used when
+// present, the first one otherwise. Groups are referenced by their display name
+func FromSCIM(user SCIMUser) Record {
+	r := Record{
+		LoginID:           user.UserName,
+		GivenName:         user.Name.GivenName,
+		FamilyName:        user.Name.FamilyName,
+		PreferredLanguage: user.PreferredLanguage,
+		Disabled:          user.Active != nil && !*user.Active,
+	}
+	for i, email := range user.Emails {
+		if i == 0 || email.Primary {
+			r.Email = email.Value
+		}
+	}
+	for _, phone := range user.PhoneNumbers {
+		if phone.Type == "mobile" || r.MobilePhone == "" {
+			r.MobilePhone = phone.Value
+		}
+	}
+	for _, g := range user.Groups {
+		if g.Display != "" {
+			r.Groups = append(r.Groups, g.Display)
+		}
+	}
+	if user.Extension != nil {
+		r.PreferredCommunicationChannel = user.Extension.PreferredCommunicationChannel
+		r.MFA = user.Extension.MFA
+	}
+	return r
+}
+
+// ReadSCIM reads records from a SCIM ListResponse, a JSON array of SCIM Users or a single SCIM User
+func ReadSCIM(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var users []SCIMUser
+	switch trimmed := strings.TrimSpace(string(data)); {
+	case trimmed == "":
+		return nil, nil
+	case strings.HasPrefix(trimmed, "["):
+		if err := json.Unmarshal(data, &users); err != nil {
+			return nil, err
+		}
+	default:
+		var doc struct {
+			SCIMUser
+			Resources []SCIMUser `json:"Resources"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		users = doc.Resources
+		if doc.Resources == nil {
+			users = []SCIMUser{doc.SCIMUser}
+		}
+	}
+	records := make([]Record, 0, len(users))
+	for _, u := range users {
+		records = append(records, FromSCIM(u))
+	}
+	return records, nil
+}
+
+// WriteSCIM writes records as a SCIM ListResponse
+func WriteSCIM(w io.Writer, records []Record) error {
+	list := SCIMListResponse{
+		Schemas:      []string{SCIMListResponseSchema},
+		TotalResults: len(records),
+		Resources:    make([]SCIMUser, 0, len(records)),
+	}
+	for _, r := range records {
+		list.Resources = append(list.Resources, r.ToSCIM())
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(list)
+}
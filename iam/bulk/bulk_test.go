@@ -0,0 +1,384 @@
+package bulk_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/iam"
+	"github.com/dip-software/go-dip-api/iam/bulk"
+	signer "github.com/dip-software/go-dip-signer"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	muxIAM    *http.ServeMux
+	serverIAM *httptest.Server
+	muxIDM    *http.ServeMux
+	serverIDM *httptest.Server
+
+	iamClient *iam.Client
+)
+
+const (
+	orgID       = "c57b2625-eda3-4b27-a8e6-86f0a0e76afc"
+	adminsGroup = "dbf1d779-ab9f-4c27-b4aa-ea75f9efbbc0"
+)
+
+// fakeIAM is an in-memory user store behind the IAM user and group APIs
+type fakeIAM struct {
+	mu          sync.Mutex
+	users       map[string]*iam.User // by ID
+	updates     int
+	activations []string
+	mfa         map[string]bool
+}
+
+func (f *fakeIAM) find(key string) *iam.User {
+	for _, u := range f.users {
+		if u.ID == key || u.LoginID == key {
+			return u
+		}
+	}
+	return nil
+}
+
+func setup(t *testing.T) (*fakeIAM, func()) {
+	muxIAM = http.NewServeMux()
+	serverIAM = httptest.NewServer(muxIAM)
+	muxIDM = http.NewServeMux()
+	serverIDM = httptest.NewServer(muxIDM)
+
+	sign, err := signer.New("SharedKey", "SecretKey")
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	iamClient, err = iam.NewClient(nil, &iam.Config{
+		OAuth2ClientID: "TestClient",
+		OAuth2Secret:   "Secret",
+		SharedKey:      "SharedKey",
+		SecretKey:      "SecretKey",
+		IAMURL:         serverIAM.URL,
+		IDMURL:         serverIDM.URL,
+		Signer:         sign,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create iamClient: %v", err)
+	}
+	iamClient.SetToken("44d20214-7879-4e35-923d-f9d4e01c9746")
+
+	f := &fakeIAM{
+		users: map[string]*iam.User{
+			"bob-id": {
+				ID:           "bob-id",
+				LoginID:      "bob",
+				EmailAddress: "bob@example.com",
+				Name:         iam.Name{Given: "Bob", Family: "Old"},
+				Memberships: []iam.UserMembership{
+					{OrganizationID: orgID, Groups: []string{"Admins"}},
+				},
+				AccountStatus: iam.UserAccountStatus{MFAStatus: "ACTIVE"},
+			},
+		},
+		mfa: make(map[string]bool),
+	}
+
+	muxIDM.HandleFunc("/authorize/identity/User", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			user := f.find(r.URL.Query().Get("userId"))
+			if user == nil {
+				_, _ = io.WriteString(w, `{"total": 0, "entry": []}`)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"total": 1,
+				"entry": []*iam.User{user},
+			})
+		case http.MethodPost:
+			var person iam.Person
+			_ = json.NewDecoder(r.Body).Decode(&person)
+			assert.Equal(t, orgID, person.ManagingOrganization)
+			id := "new-" + person.LoginID
+			f.users[id] = &iam.User{
+				ID:           id,
+				LoginID:      person.LoginID,
+				EmailAddress: person.Telecom[0].Value,
+				Name:         person.Name,
+			}
+			w.Header().Set("Location", "/authorize/identity/User/"+id)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{}`)
+		}
+	})
+	muxIDM.HandleFunc("/authorize/identity/User/$resend-activation", func(w http.ResponseWriter, r *http.Request) {
+		var params iam.Parameters
+		_ = json.NewDecoder(r.Body).Decode(&params)
+		f.mu.Lock()
+		f.activations = append(f.activations, params.Parameter[0].Resource.LoginID)
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{}`)
+	})
+	muxIDM.HandleFunc("/authorize/identity/User/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/authorize/identity/User/"), "/$mfa")
+		var body struct {
+			Activate string `json:"activate"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.mu.Lock()
+		f.mfa[id] = body.Activate == "true"
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(w, `{}`)
+	})
+	muxIDM.HandleFunc("/security/users", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var users []map[string]string
+		for id := range f.users {
+			users = append(users, map[string]string{"userUUID": id})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"exchange":     map[string]interface{}{"users": users, "nextPageExists": false},
+			"responseCode": "200",
+		})
+	})
+	muxIDM.HandleFunc("/security/users/", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		user := f.find(strings.TrimPrefix(r.URL.Path, "/security/users/"))
+		if user == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		profile := iam.Profile{GivenName: user.Name.Given, FamilyName: user.Name.Family}
+		if r.Method == http.MethodPut {
+			_ = json.NewDecoder(r.Body).Decode(&profile)
+			user.Name.Given, user.Name.Family = profile.GivenName, profile.FamilyName
+			user.EmailAddress = profile.Contact.EmailAddress
+			user.AccountStatus.Disabled = profile.Disabled != nil && *profile.Disabled
+			f.updates++
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"exchange":     map[string]interface{}{"profile": profile},
+			"responseCode": "200",
+		})
+	})
+	muxIDM.HandleFunc("/authorize/identity/Group", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, orgID, r.URL.Query().Get("orgID"))
+		w.Header().Set("Content-Type", "application/json")
+		// Admins is only on the second page, after a full page of other groups
+		if r.URL.Query().Get("_page") != "2" {
+			entries := make([]string, 100)
+			for i := range entries {
+				entries[i] = fmt.Sprintf(`{"resource": {"_id": "group-%d", "groupName": "Group%d", "orgId": "%s"}}`, i, i, orgID)
+			}
+			_, _ = io.WriteString(w, `{"total": 100, "entry": [`+strings.Join(entries, ",")+`]}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{
+  "total": 1,
+  "entry": [{"resource": {"_id": "`+adminsGroup+`", "groupName": "Admins", "orgId": "`+orgID+`"}}]
+}`)
+	})
+	muxIDM.HandleFunc("/authorize/identity/Group/"+adminsGroup+"/$add-members", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Parameter []iam.Parameter `json:"parameter"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.mu.Lock()
+		for _, ref := range body.Parameter[0].References {
+			if user := f.find(ref.Reference); user != nil {
+				user.Memberships = append(user.Memberships, iam.UserMembership{OrganizationID: orgID, Groups: []string{"Admins"}})
+			}
+		}
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{}`)
+	})
+
+	return f, func() {
+		serverIAM.Close()
+		serverIDM.Close()
+	}
+}
+
+const importCSV = `loginId,email,givenName,familyName,groups,mfa
+alice,alice@example.com,Alice,Smith,Admins,true
+bob,bob@example.com,Bob,New,Admins,
+carol,,Carol,Jones,,
+dave,dave@example.com,Dave,Brown,Unknown,
+`
+
+func TestImport(t *testing.T) {
+	f, teardown := setup(t)
+	defer teardown()
+
+	records, err := bulk.ReadCSV(strings.NewReader(importCSV))
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.Len(t, records, 4) {
+		return
+	}
+
+	report, err := bulk.Import(context.Background(), iamClient, orgID, records, &bulk.ImportOptions{
+		Concurrency:      2,
+		ResendActivation: true,
+		SetMFA:           true,
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.Len(t, report.Results, 4) {
+		return
+	}
+	alice := report.Results[0]
+	assert.Equal(t, 1, alice.Row)
+	assert.Equal(t, bulk.ActionCreated, alice.Action)
+	assert.Equal(t, "new-alice", alice.UserID)
+	assert.Equal(t, []string{"Admins"}, alice.GroupsAdded)
+	assert.True(t, alice.ActivationSent)
+	assert.True(t, alice.MFASet)
+	assert.Nil(t, alice.Err)
+
+	bob := report.Results[1]
+	assert.Equal(t, bulk.ActionUpdated, bob.Action)
+	assert.Empty(t, bob.GroupsAdded)
+	assert.False(t, bob.ActivationSent)
+
+	assert.ErrorIs(t, report.Results[2].Err, bulk.ErrMissingEmail)
+	assert.Equal(t, bulk.ActionFailed, report.Results[2].Action)
+	assert.ErrorIs(t, report.Results[3].Err, bulk.ErrUnknownGroup)
+	assert.Equal(t, 2, report.Failed())
+	assert.Equal(t, []string{"alice"}, f.activations)
+	assert.True(t, f.mfa["new-alice"])
+	assert.Equal(t, 1, f.updates)
+
+	var out bytes.Buffer
+	assert.Nil(t, report.WriteCSV(&out))
+	assert.Contains(t, out.String(), "1,alice,new-alice,created,Admins,true,true,")
+	assert.Contains(t, out.String(), "missing email")
+
+	// Importing again is idempotent
+	report, err = bulk.Import(context.Background(), iamClient, orgID, records[:2], nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 2, report.Count(bulk.ActionUnchanged))
+	assert.Empty(t, report.Results[0].GroupsAdded)
+	assert.Equal(t, 1, f.updates)
+
+	// Disabling is an update of its own
+	records[1].Disabled = true
+	report, err = bulk.Import(context.Background(), iamClient, orgID, records[1:2], nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, bulk.ActionUpdated, report.Results[0].Action)
+		assert.True(t, f.users["bob-id"].AccountStatus.Disabled)
+	}
+
+	_, err = bulk.Import(context.Background(), iamClient, "", records, nil)
+	assert.ErrorIs(t, err, bulk.ErrMissingOrganization)
+
+	duplicate := records[0]
+	duplicate.LoginID = "ALICE"
+	_, err = bulk.Import(context.Background(), iamClient, orgID, append(records[:2:2], duplicate), nil)
+	assert.ErrorIs(t, err, bulk.ErrDuplicateLoginID)
+	assert.Equal(t, 2, f.updates)
+}
+
+func TestExport(t *testing.T) {
+	f, teardown := setup(t)
+	defer teardown()
+
+	records, err := bulk.Export(context.Background(), iamClient, orgID, nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.Len(t, records, 1) {
+		return
+	}
+	bob := records[0]
+	assert.Equal(t, "bob", bob.LoginID)
+	assert.Equal(t, "bob@example.com", bob.Email)
+	assert.Equal(t, []string{"Admins"}, bob.Groups)
+	if assert.NotNil(t, bob.MFA) {
+		assert.True(t, *bob.MFA)
+	}
+
+	var out bytes.Buffer
+	assert.Nil(t, bulk.Write(&out, records, bulk.FormatCSV))
+	assert.Equal(t, "loginId,email,givenName,familyName,mobilePhone,preferredLanguage,preferredCommunicationChannel,groups,mfa,disabled\n"+
+		"bob,bob@example.com,Bob,Old,,,,Admins,true,false\n", out.String())
+
+	// An inactive MFA is exported as false so an import deactivates it
+	f.users["bob-id"].AccountStatus.MFAStatus = "INACTIVE"
+	records, err = bulk.Export(context.Background(), iamClient, orgID, nil)
+	if assert.Nil(t, err) && assert.NotNil(t, records[0].MFA) {
+		assert.False(t, *records[0].MFA)
+	}
+}
+
+func TestSCIMRoundTrip(t *testing.T) {
+	mfa := false
+	records := []bulk.Record{
+		{
+			LoginID:                       "alice",
+			Email:                         "alice@example.com",
+			GivenName:                     "Alice",
+			FamilyName:                    "Smith",
+			MobilePhone:                   "+31612345678",
+			PreferredCommunicationChannel: "email",
+			Groups:                        []string{"Admins", "Readers"},
+			MFA:                           &mfa,
+		},
+		{
+			LoginID:    "bob",
+			Email:      "bob@example.com",
+			GivenName:  "Bob",
+			FamilyName: "Jones",
+			Disabled:   true,
+		},
+	}
+	for _, format := range []bulk.Format{bulk.FormatSCIM, bulk.FormatCSV} {
+		var buf bytes.Buffer
+		if !assert.Nil(t, bulk.Write(&buf, records, format)) {
+			return
+		}
+		read, err := bulk.Read(&buf, format)
+		if !assert.Nil(t, err) {
+			return
+		}
+		assert.Equal(t, records, read, string(format))
+	}
+
+	single := `{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "userName": "carol",
+  "name": {"givenName": "Carol", "familyName": "White"},
+  "emails": [{"value": "other@example.com"}, {"value": "carol@example.com", "primary": true}]
+}`
+	read, err := bulk.ReadSCIM(strings.NewReader(single))
+	if assert.Nil(t, err) && assert.Len(t, read, 1) {
+		assert.Equal(t, "carol@example.com", read[0].Email)
+	}
+
+	_, err = bulk.Read(strings.NewReader(""), "xml")
+	assert.ErrorIs(t, err, bulk.ErrUnsupportedFormat)
+	_, err = bulk.ReadCSV(strings.NewReader("email\nfoo@example.com\n"))
+	assert.ErrorIs(t, err, bulk.ErrMissingColumn)
+}
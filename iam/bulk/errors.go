@@ -0,0 +1,19 @@
+package bulk
+
+import (
+	"errors"
+)
+
+// Exported Errors
+var (
+	ErrMissingIAMClient    = errors.New("missing IAM client")
+	ErrMissingOrganization = errors.New("missing organization")
+	ErrUnsupportedFormat   = errors.New("unsupported format")
+	ErrMissingColumn       = errors.New("missing column")
+	ErrMissingLoginID      = errors.New("missing loginId")
+	ErrMissingEmail        = errors.New("missing email")
+	ErrMissingName         = errors.New("missing given or family name")
+	ErrUnknownGroup        = errors.New("unknown group")
+	ErrOperationFailed     = errors.New("operation failed")
+	ErrDuplicateLoginID    = errors.New("duplicate loginId")
+)
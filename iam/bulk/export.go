@@ -0,0 +1,91 @@
+package bulk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/dip-software/go-dip-api/iam"
+)
+
+// ExportOptions controls an export
+type ExportOptions struct {
+	// Concurrency is the maximum number of users fetched in parallel. Defaults to 5
+	Concurrency int
+}
+
+// Export returns a record for every user of the organization orgID, sorted by login ID.
+// Only group memberships within the organization are included
+func Export(ctx context.Context, client *iam.Client, orgID string, opts *ExportOptions) ([]Record, error) {
+	if client == nil {
+		return nil, ErrMissingIAMClient
+	}
+	if orgID == "" {
+		return nil, ErrMissingOrganization
+	}
+	concurrency := defaultConcurrency
+	if opts != nil && opts.Concurrency > 0 {
+		concurrency = opts.Concurrency
+	}
+	userIDs, _, err := client.Users.GetAllUsers(&iam.GetUserOptions{OrganizationID: &orgID}, iam.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("users of organization %s: %w", orgID, err)
+	}
+
+	records := make([]Record, len(userIDs))
+	errs := make([]error, len(userIDs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, userID := range userIDs {
+		wg.Add(1)
+		go func(i int, userID string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+			user, _, err := client.Users.GetUserByID(userID)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			records[i] = toRecord(user, orgID)
+		}(i, userID)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].LoginID < records[j].LoginID
+	})
+	return records, nil
+}
+
+func toRecord(user *iam.User, orgID string) Record {
+	r := Record{
+		LoginID:                       user.LoginID,
+		Email:                         user.EmailAddress,
+		GivenName:                     user.Name.Given,
+		FamilyName:                    user.Name.Family,
+		MobilePhone:                   user.PhoneNumber,
+		PreferredLanguage:             user.PreferredLanguage,
+		PreferredCommunicationChannel: user.PreferredCommunicationChannel,
+		Disabled:                      user.AccountStatus.Disabled,
+	}
+	for _, m := range user.Memberships {
+		if m.OrganizationID == orgID {
+			r.Groups = append(r.Groups, m.Groups...)
+		}
+	}
+	sort.Strings(r.Groups)
+	mfa := user.AccountStatus.MFAStatus == "ACTIVE"
+	r.MFA = &mfa
+	return r
+}
@@ -0,0 +1,339 @@
+package bulk
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dip-software/go-dip-api/iam"
+)
+
+const defaultConcurrency = 5
+
+// Action describes what happened to a user during an import
+type Action string
+
+const (
+	ActionCreated   Action = "created"
+	ActionUpdated   Action = "updated"
+	ActionUnchanged Action = "unchanged"
+	ActionFailed    Action = "failed"
+)
+
+// ImportOptions controls an import
+type ImportOptions struct {
+	// Concurrency is the maximum number of users processed in parallel. Defaults to 5
+	Concurrency int
+	// ResendActivation sends the activation email to newly created users
+	ResendActivation bool
+	// SetMFA applies the MFA setting of records which have one
+	SetMFA bool
+}
+
+// Result is the outcome of importing a single record
+type Result struct {
+	// Row is the 1-based position of the record in the input
+	Row            int
+	LoginID        string
+	UserID         string
+	Action         Action
+	GroupsAdded    []string
+	ActivationSent bool
+	MFASet         bool
+	Err            error
+}
+
+// Report contains the results of an import in input order
+type Report struct {
+	Results []Result
+}
+
+// Failed returns the number of records which could not be imported
+func (r *Report) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Count returns the number of records for which action was taken
+func (r *Report) Count(action Action) int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// WriteCSV writes the report as CSV with one line per record
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"row", "loginId", "userId", "action", "groupsAdded", "activationSent", "mfaSet", "error"}); err != nil {
+		return err
+	}
+	for _, result := range r.Results {
+		errString := ""
+		if result.Err != nil {
+			errString = result.Err.Error()
+		}
+		if err := writer.Write([]string{
+			strconv.Itoa(result.Row),
+			result.LoginID,
+			result.UserID,
+			string(result.Action),
+			strings.Join(result.GroupsAdded, groupSeparator),
+			strconv.FormatBool(result.ActivationSent),
+			strconv.FormatBool(result.MFASet),
+			errString,
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Import creates or updates the users described by records in the organization orgID.
+// Existing users are matched by login ID and only updated when their profile differs.
+// Errors of individual records are reported in the Report; the returned error is
+// only set when the import could not start at all, e.g. when records share a login ID
+func Import(ctx context.Context, client *iam.Client, orgID string, records []Record, opts *ImportOptions) (*Report, error) {
+	if client == nil {
+		return nil, ErrMissingIAMClient
+	}
+	if orgID == "" {
+		return nil, ErrMissingOrganization
+	}
+	var options ImportOptions
+	if opts != nil {
+		options = *opts
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = defaultConcurrency
+	}
+	if err := checkDuplicates(records); err != nil {
+		return nil, err
+	}
+	groups, err := orgGroups(ctx, client, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("groups of organization %s: %w", orgID, err)
+	}
+
+	report := &Report{Results: make([]Result, len(records))}
+	sem := make(chan struct{}, options.Concurrency)
+	var wg sync.WaitGroup
+	for i, record := range records {
+		wg.Add(1)
+		go func(i int, record Record) {
+			defer wg.Done()
+			result := &report.Results[i]
+			result.Row = i + 1
+			result.LoginID = record.LoginID
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				result.Action, result.Err = ActionFailed, ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+			if err := importRecord(ctx, client, orgID, groups, record, options, result); err != nil {
+				result.Err = err
+				if result.Action == "" {
+					result.Action = ActionFailed
+				}
+			}
+		}(i, record)
+	}
+	wg.Wait()
+	return report, nil
+}
+
+// checkDuplicates rejects records which share a login ID, as concurrent workers would
+// otherwise race to create or update the same user
+func checkDuplicates(records []Record) error {
+	rows := make(map[string]int)
+	for i, record := range records {
+		key := strings.ToLower(record.LoginID)
+		if key == "" {
+			continue
+		}
+		if row, ok := rows[key]; ok {
+			return fmt.Errorf("%w: %s in rows %d and %d", ErrDuplicateLoginID, record.LoginID, row, i+1)
+		}
+		rows[key] = i + 1
+	}
+	return nil
+}
+
+func orgGroups(ctx context.Context, client *iam.Client, orgID string) (map[string]iam.Group, error) {
+	resources, _, err := client.Groups.GetAllGroups(&iam.GetGroupOptions{OrganizationID: &orgID}, iam.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string]iam.Group)
+	for _, g := range *resources {
+		groups[g.GroupName] = iam.Group{
+			ID:                   g.ID,
+			Name:                 g.GroupName,
+			Description:          g.GroupDescription,
+			ManagingOrganization: g.OrgID,
+		}
+	}
+	return groups, nil
+}
+
+func importRecord(ctx context.Context, client *iam.Client, orgID string, groups map[string]iam.Group, record Record, opts ImportOptions, result *Result) error {
+	if err := record.Validate(); err != nil {
+		return err
+	}
+	for _, name := range record.Groups {
+		if _, ok := groups[name]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownGroup, name)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var user *iam.User
+	userID, _, err := client.Users.GetUserIDByLoginID(record.LoginID)
+	switch {
+	case errors.Is(err, iam.ErrEmptyResults):
+		user, _, err = client.Users.CreateUser(toPerson(record, orgID))
+		if err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+		result.Action = ActionCreated
+	case err != nil:
+		return fmt.Errorf("lookup: %w", err)
+	default:
+		user, _, err = client.Users.GetUserByID(userID)
+		if err != nil {
+			return fmt.Errorf("lookup: %w", err)
+		}
+		result.Action = ActionUnchanged
+		if !profileMatches(user, record) {
+			if err := updateProfile(client, user.ID, record); err != nil {
+				return fmt.Errorf("update: %w", err)
+			}
+			result.Action = ActionUpdated
+		}
+	}
+	result.UserID = user.ID
+
+	member := make(map[string]bool)
+	for _, m := range user.Memberships {
+		if m.OrganizationID != orgID {
+			continue
+		}
+		for _, g := range m.Groups {
+			member[g] = true
+		}
+	}
+	for _, name := range record.Groups {
+		if member[name] {
+			continue
+		}
+		if _, _, err := client.Groups.AddMembers(ctx, groups[name], user.ID); err != nil {
+			return fmt.Errorf("add to group %s: %w", name, err)
+		}
+		result.GroupsAdded = append(result.GroupsAdded, name)
+	}
+
+	if opts.ResendActivation && result.Action == ActionCreated {
+		ok, _, err := client.Users.ResendActivation(record.LoginID)
+		if err == nil && !ok {
+			err = ErrOperationFailed
+		}
+		if err != nil {
+			return fmt.Errorf("resend activation: %w", err)
+		}
+		result.ActivationSent = true
+	}
+	if opts.SetMFA && record.MFA != nil {
+		ok, _, err := client.Users.SetMFA(user.ID, *record.MFA)
+		if err == nil && !ok {
+			err = ErrOperationFailed
+		}
+		if err != nil {
+			return fmt.Errorf("set MFA: %w", err)
+		}
+		result.MFASet = true
+	}
+	return nil
+}
+
+func toPerson(record Record, orgID string) iam.Person {
+	person := iam.Person{
+		LoginID:      record.LoginID,
+		ResourceType: "Person",
+		Name: iam.Name{
+			Given:  record.GivenName,
+			Family: record.FamilyName,
+		},
+		Telecom: []iam.TelecomEntry{
+			{System: "email", Value: record.Email},
+		},
+		ManagingOrganization:          orgID,
+		PreferredLanguage:             record.PreferredLanguage,
+		PreferredCommunicationChannel: record.PreferredCommunicationChannel,
+		Disabled:                      record.Disabled,
+	}
+	if record.MobilePhone != "" {
+		person.Telecom = append(person.Telecom, iam.TelecomEntry{System: "mobile", Value: record.MobilePhone})
+	}
+	return person
+}
+
+// profileMatches compares the fields which can be updated, including the disabled state.
+// Empty optional fields are ignored
+func profileMatches(user *iam.User, record Record) bool {
+	optional := func(want, have string) bool {
+		return want == "" || want == have
+	}
+	return user.Name.Given == record.GivenName &&
+		user.Name.Family == record.FamilyName &&
+		user.EmailAddress == record.Email &&
+		optional(record.MobilePhone, user.PhoneNumber) &&
+		optional(record.PreferredLanguage, user.PreferredLanguage) &&
+		optional(record.PreferredCommunicationChannel, user.PreferredCommunicationChannel) &&
+		user.AccountStatus.Disabled == record.Disabled
+}
+
+func updateProfile(client *iam.Client, userID string, record Record) error {
+	profile, _, err := client.Users.LegacyGetUserByUUID(userID)
+	if err != nil {
+		return err
+	}
+	profile.ID = userID
+	profile.GivenName = record.GivenName
+	profile.FamilyName = record.FamilyName
+	// See INC0058741 for background for this workaround
+	if profile.MiddleName == "" {
+		profile.MiddleName = " "
+	}
+	profile.Contact.EmailAddress = record.Email
+	if record.MobilePhone != "" {
+		profile.Contact.MobilePhone = record.MobilePhone
+	}
+	if record.PreferredLanguage != "" {
+		profile.PreferredLanguage = record.PreferredLanguage
+	}
+	if record.PreferredCommunicationChannel != "" {
+		profile.PreferredCommunicationChannel = record.PreferredCommunicationChannel
+	}
+	disabled := record.Disabled
+	profile.Disabled = &disabled
+	_, _, err = client.Users.LegacyUpdateUser(*profile)
+	return err
+}
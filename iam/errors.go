@@ -25,6 +25,7 @@ var (
 	ErrNotAuthorized                  = errors.New("not authorized")
 	ErrNoValidSignerAvailable         = errors.New("no valid HSDP signer available")
 	ErrMissingOAuth2Credentials       = errors.New("missing OAuth2 credentials")
+	ErrDeleteFailed                   = errors.New("delete failed")
 )
 
 type UserError struct {
@@ -20,6 +20,8 @@ type GetGroupOptions struct {
 	Name           *string `url:"name,omitempty"`
 	MemberType     *string `url:"memberType,omitempty"`
 	MemberID       *string `url:"memberId,omitempty"`
+	Count          *int    `url:"_count,omitempty"`
+	Page           *int    `url:"_page,omitempty"`
 }
 
 // SCIMGetGroupOptions describes the query fields to use for querying SCIM Groups
@@ -84,6 +86,18 @@ func (g *GroupsService) GetGroups(opt *GetGroupOptions, options ...OptionFunc) (
 	return &groups, resp, nil
 }
 
+// GetAllGroups retrieves the groups matching opt from all result pages. The Page and Count of opt are ignored
+func (g *GroupsService) GetAllGroups(opt *GetGroupOptions, options ...OptionFunc) (*[]GroupResource, *Response, error) {
+	var pageOpt GetGroupOptions
+	if opt != nil {
+		pageOpt = *opt
+	}
+	return allPages(func(page, count int) (*[]GroupResource, *Response, error) {
+		pageOpt.Page, pageOpt.Count = &page, &count
+		return g.GetGroups(&pageOpt, options...)
+	})
+}
+
 // CreateGroup creates a Group
 func (g *GroupsService) CreateGroup(group Group) (*Group, *Response, error) {
 	if err := g.client.validate.Struct(group); err != nil {
@@ -146,11 +160,11 @@ func (g *GroupsService) DeleteGroup(group Group) (bool, *Response, error) {
 }
 
 // GetRoles returns the roles assigned to this group
-func (g *GroupsService) GetRoles(group Group) (*[]Role, *Response, error) {
+func (g *GroupsService) GetRoles(group Group, options ...OptionFunc) (*[]Role, *Response, error) {
 	opt := &GetRolesOptions{
 		GroupID: &group.ID,
 	}
-	req, err := g.client.newRequest(IDM, "GET", "authorize/identity/Role", opt, nil)
+	req, err := g.client.newRequest(IDM, "GET", "authorize/identity/Role", opt, options)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -51,6 +51,26 @@ func WithOrgContext(organizationId string) OptionFunc {
 	}
 }
 
+// WithIntrospectToken introspects the given token instead of the token of the client.
+// This allows services to validate bearer tokens presented by their callers
+func WithIntrospectToken(token string) OptionFunc {
+	return func(req *http.Request) error {
+		req.Form, req.PostForm = nil, nil
+		if err := req.ParseForm(); err != nil {
+			return err
+		}
+		form := url.Values{}
+		for k, v := range req.PostForm {
+			form[k] = v
+		}
+		form.Set("token", token)
+		req.Body = io.NopCloser(strings.NewReader(form.Encode()))
+		req.ContentLength = int64(len(form.Encode()))
+		req.Form, req.PostForm = nil, nil
+		return nil
+	}
+}
+
 // Introspect introspects the current logged-in user
 func (c *Client) Introspect(opts ...OptionFunc) (*IntrospectResponse, *Response, error) {
 	var val IntrospectResponse
@@ -269,3 +269,37 @@ func TestIntrospectWithOrgContext(t *testing.T) {
 	assert.Equal(t, 1, len(introspectResponse.Organizations.OrganizationList))
 	assert.False(t, client.HasPermissions("bogus", "SERVICE.SCOPE"))
 }
+
+func TestIntrospectWithToken(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	callerToken := "7cf84b41-9e20-4bb4-95f2-6b4a5c7e3d10"
+	orgID := "46323bb4-ebba-4387-a339-252b5aa0755f"
+
+	muxIAM.HandleFunc("/authorize/oauth2/introspect", func(w http.ResponseWriter, r *http.Request) {
+		if !assert.Nil(t, r.ParseForm()) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		assert.Equal(t, callerToken, r.Form.Get("token"))
+		assert.Equal(t, orgID, r.Form.Get("org_ctx"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, `{"active": true, "scope": "mail", "sub": "caller"}`)
+	})
+
+	err := client.Login("username", "password")
+	if !assert.Nil(t, err) {
+		return
+	}
+	resp, _, err := client.Introspect(WithOrgContext(orgID), WithIntrospectToken(callerToken))
+	if !assert.Nil(t, err) || !assert.NotNil(t, resp) {
+		return
+	}
+	assert.True(t, resp.Active)
+	assert.Equal(t, "caller", resp.Sub)
+
+	_, _, err = client.Introspect(WithIntrospectToken(callerToken), WithOrgContext(orgID))
+	assert.Nil(t, err)
+}
@@ -19,6 +19,42 @@ type MFAPoliciesService struct {
 	validate *validator.Validate
 }
 
+// GetMFAPolicyOptions describes the criteria for looking up MFA policies
+type GetMFAPolicyOptions struct {
+	Filter             *string `url:"filter,omitempty"`
+	Attributes         *string `url:"attributes,omitempty"`
+	ExcludedAttributes *string `url:"excludedAttributes,omitempty"`
+}
+
+// MFAPolicyFilterOrg returns options to find the MFA policies of an organization
+func MFAPolicyFilterOrg(orgID string) *GetMFAPolicyOptions {
+	query := "resource.type eq \"Organization\" and resource.value eq \"" + orgID + "\""
+	return &GetMFAPolicyOptions{
+		Filter: &query,
+	}
+}
+
+// GetMFAPolicies looks up MFA policies based on the GetMFAPolicyOptions parameters
+func (p *MFAPoliciesService) GetMFAPolicies(opt *GetMFAPolicyOptions, options ...OptionFunc) (*[]MFAPolicy, *Response, error) {
+	req, err := p.client.newRequest(IDM, "GET", scimBasePath+"MFAPolicies", opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("api-version", mfaPoliciesAPIVersion)
+	req.Header.Set("Content-Type", "application/scim+json")
+
+	var bundleResponse struct {
+		TotalResults int         `json:"totalResults"`
+		Resources    []MFAPolicy `json:"Resources"`
+	}
+
+	resp, err := p.client.do(req, &bundleResponse)
+	if err != nil {
+		return nil, resp, err
+	}
+	return &bundleResponse.Resources, resp, nil
+}
+
 // GetMFAPolicyByID retrieves a MFAPolicy by ID
 func (p *MFAPoliciesService) GetMFAPolicyByID(MFAPolicyID string) (*MFAPolicy, *Response, error) {
 	req, err := p.client.newRequest(IDM, "GET", scimBasePath+"MFAPolicies/"+MFAPolicyID, nil, nil)
@@ -161,6 +161,47 @@ func TestGetMFAPolicyByID(t *testing.T) {
 	}
 }
 
+func TestGetMFAPolicies(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	policyID := "400f1adb-bba6-4f52-8d04-f78ecd3833da"
+	orgID := "c57b2625-eda3-4b27-a8e6-86f0a0e76afc"
+	muxIDM.HandleFunc("/authorize/scim/v2/MFAPolicies", func(w http.ResponseWriter, r *http.Request) {
+		if ok := assert.Equal(t, "GET", r.Method); !ok {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		assert.Equal(t, `resource.type eq "Organization" and resource.value eq "`+orgID+`"`, r.URL.Query().Get("filter"))
+		w.Header().Set("Content-Type", "application/scim+json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:ListResponse"],
+			"totalResults": 1,
+			"Resources": [{
+				"schemas": ["urn:ietf:params:scim:schemas:core:philips:hsdp:2.0:MFAPolicy"],
+				"id": "`+policyID+`",
+				"name": "OrgPolicy",
+				"resource": {"type": "Organization", "value": "`+orgID+`"},
+				"types": ["SOFT_OTP"],
+				"active": true
+			}]
+		}`)
+	})
+
+	policies, resp, err := client.MFAPolicies.GetMFAPolicies(MFAPolicyFilterOrg(orgID))
+	if !assert.Nil(t, err) {
+		return
+	}
+	if ok := assert.NotNil(t, resp); ok {
+		assert.Equal(t, http.StatusOK, resp.StatusCode())
+	}
+	if ok := assert.NotNil(t, policies); ok && assert.Len(t, *policies, 1) {
+		assert.Equal(t, policyID, (*policies)[0].ID)
+		assert.Equal(t, "OrgPolicy", (*policies)[0].Name)
+	}
+}
+
 func TestUpdateMFAPolicy(t *testing.T) {
 	teardown := setup(t)
 	defer teardown()
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"errors"
+)
+
+// Exported Errors
+var (
+	ErrMissingIAMClient        = errors.New("missing IAM client")
+	ErrMissingToken            = errors.New("missing bearer token")
+	ErrInactiveToken           = errors.New("token is not active")
+	ErrExpiredToken            = errors.New("token is expired")
+	ErrInsufficientScope       = errors.New("insufficient scope")
+	ErrInsufficientPermissions = errors.New("insufficient permissions")
+	ErrMissingOrganization     = errors.New("missing organization")
+	ErrNotAuthenticated        = errors.New("request was not authenticated")
+)
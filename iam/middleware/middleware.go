@@ -0,0 +1,281 @@
+// Package middleware provides net/http middleware which validates HSDP IAM bearer tokens
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dip-software/go-dip-api/iam"
+)
+
+const (
+	defaultCacheSize = 1000
+	defaultCacheTTL  = 5 * time.Minute
+	defaultTimeout   = 10 * time.Second
+)
+
+type contextKey struct{}
+
+// Config contains the configuration of an Authenticator
+type Config struct {
+	// Client is used to introspect tokens. It must be configured with OAuth2 client credentials
+	Client *iam.Client
+	// CacheSize is the maximum number of cached introspection results. Defaults to 1000
+	CacheSize int
+	// CacheTTL is the maximum time an introspection result is cached. Results are
+	// never cached beyond the expiry of the token. Defaults to 5 minutes
+	CacheTTL time.Duration
+	// Timeout limits a single introspection call. As the call is shared by concurrent
+	// requests for the same token it is not bound to any of their contexts. Defaults to 10 seconds
+	Timeout time.Duration
+	// ErrorHandler writes the response for rejected requests. Defaults to DefaultErrorHandler
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Authenticator validates bearer tokens of incoming requests using IAM introspection
+type Authenticator struct {
+	client       *iam.Client
+	ttl          time.Duration
+	timeout      time.Duration
+	errorHandler func(w http.ResponseWriter, r *http.Request, err error)
+	cache        *cache
+	inflight     group
+
+	now func() time.Time
+}
+
+// New returns an Authenticator based on the Config
+func New(config Config) (*Authenticator, error) {
+	if config.Client == nil {
+		return nil, ErrMissingIAMClient
+	}
+	a := &Authenticator{
+		client:       config.Client,
+		ttl:          config.CacheTTL,
+		timeout:      config.Timeout,
+		errorHandler: config.ErrorHandler,
+		now:          time.Now,
+	}
+	if a.ttl <= 0 {
+		a.ttl = defaultCacheTTL
+	}
+	if a.timeout <= 0 {
+		a.timeout = defaultTimeout
+	}
+	size := config.CacheSize
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+	a.cache = newCache(size)
+	if a.errorHandler == nil {
+		a.errorHandler = DefaultErrorHandler
+	}
+	return a, nil
+}
+
+// Introspect returns the introspection result of the token, using the cache when possible.
+// Concurrent lookups of the same token result in a single IAM call, which is not cancelled
+// when ctx is done as other lookups may still wait for it
+func (a *Authenticator) Introspect(ctx context.Context, token string) (*iam.IntrospectResponse, error) {
+	key := hashToken(token)
+	if resp, ok := a.cache.get(key, a.now()); ok {
+		return resp, nil
+	}
+	callCtx := context.WithoutCancel(ctx)
+	return a.inflight.do(ctx, key, func() (*iam.IntrospectResponse, error) {
+		callCtx, cancel := context.WithTimeout(callCtx, a.timeout)
+		defer cancel()
+		resp, _, err := a.client.Introspect(iam.WithIntrospectToken(token), iam.WithContext(callCtx))
+		if err != nil {
+			return nil, err
+		}
+		now := a.now()
+		expiresAt := now.Add(a.ttl)
+		if resp.Active && resp.Expires > 0 {
+			if exp := time.Unix(resp.Expires, 0); exp.Before(expiresAt) {
+				expiresAt = exp
+			}
+		}
+		a.cache.set(key, resp, expiresAt)
+		return resp, nil
+	})
+}
+
+// Validate introspects the token and returns an error if it is inactive or expired
+func (a *Authenticator) Validate(ctx context.Context, token string) (*iam.IntrospectResponse, error) {
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+	resp, err := a.Introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Active {
+		return nil, ErrInactiveToken
+	}
+	if resp.Expires > 0 && !a.now().Before(time.Unix(resp.Expires, 0)) {
+		return nil, ErrExpiredToken
+	}
+	return resp, nil
+}
+
+// Handler rejects requests without a valid bearer token and stores the
+// introspection result in the request context for use by FromContext
+func (a *Authenticator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, err := a.Validate(r.Context(), BearerToken(r))
+		if err != nil {
+			a.errorHandler(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), resp)))
+	})
+}
+
+// RequireScopes returns middleware which rejects requests whose token lacks any of the scopes.
+// It must be chained after Handler
+func (a *Authenticator) RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			resp, ok := FromContext(r.Context())
+			if !ok {
+				a.errorHandler(w, r, ErrNotAuthenticated)
+				return
+			}
+			if !HasScopes(resp, scopes...) {
+				a.errorHandler(w, r, ErrInsufficientScope)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// OrganizationFunc extracts the organization ID a request operates on
+type OrganizationFunc func(r *http.Request) string
+
+// OrganizationFromHeader returns an OrganizationFunc which reads the organization from a header
+func OrganizationFromHeader(header string) OrganizationFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(header)
+	}
+}
+
+// OrganizationFromQuery returns an OrganizationFunc which reads the organization from a query parameter
+func OrganizationFromQuery(param string) OrganizationFunc {
+	return func(r *http.Request) string {
+		return r.URL.Query().Get(param)
+	}
+}
+
+// RequirePermissions returns middleware which rejects requests whose token lacks any of
+// the permissions in the organization returned by orgFunc. It must be chained after Handler
+func (a *Authenticator) RequirePermissions(orgFunc OrganizationFunc, permissions ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			resp, ok := FromContext(r.Context())
+			if !ok {
+				a.errorHandler(w, r, ErrNotAuthenticated)
+				return
+			}
+			orgID := orgFunc(r)
+			if orgID == "" {
+				a.errorHandler(w, r, ErrMissingOrganization)
+				return
+			}
+			if !HasPermissions(resp, orgID, permissions...) {
+				a.errorHandler(w, r, ErrInsufficientPermissions)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// NewContext returns a context carrying the introspection result
+func NewContext(ctx context.Context, resp *iam.IntrospectResponse) context.Context {
+	return context.WithValue(ctx, contextKey{}, resp)
+}
+
+// FromContext returns the introspection result stored by Handler
+func FromContext(ctx context.Context) (*iam.IntrospectResponse, bool) {
+	resp, ok := ctx.Value(contextKey{}).(*iam.IntrospectResponse)
+	return resp, ok && resp != nil
+}
+
+// BearerToken returns the bearer token of the Authorization header
+func BearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(auth[7:])
+}
+
+// HasScopes returns true if the token has all the scopes
+func HasScopes(resp *iam.IntrospectResponse, scopes ...string) bool {
+	granted := make(map[string]bool)
+	for _, s := range strings.Fields(resp.Scope) {
+		granted[s] = true
+	}
+	for _, s := range scopes {
+		if !granted[s] {
+			return false
+		}
+	}
+	return true
+}
+
+// HasPermissions returns true if the token has all the permissions in the organization.
+// Effective permissions are used when IAM returns them, the direct permissions otherwise
+func HasPermissions(resp *iam.IntrospectResponse, orgID string, permissions ...string) bool {
+	for _, org := range resp.Organizations.OrganizationList {
+		if org.OrganizationID != orgID {
+			continue
+		}
+		granted := make(map[string]bool)
+		list := org.EffectivePermissions
+		if len(list) == 0 {
+			list = org.Permissions
+		}
+		for _, p := range list {
+			granted[p] = true
+		}
+		for _, p := range permissions {
+			if !granted[p] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// DefaultErrorHandler responds with 401 for authentication failures and 403 for authorization failures
+func DefaultErrorHandler(w http.ResponseWriter, _ *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrMissingToken), errors.Is(err, ErrNotAuthenticated):
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, ErrInactiveToken), errors.Is(err, ErrExpiredToken):
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, ErrInsufficientScope):
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrInsufficientPermissions), errors.Is(err, ErrMissingOrganization):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, "token introspection failed", http.StatusBadGateway)
+	}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,275 @@
+package middleware_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dip-software/go-dip-api/iam"
+	"github.com/dip-software/go-dip-api/iam/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	muxIAM    *http.ServeMux
+	serverIAM *httptest.Server
+	muxIDM    *http.ServeMux
+	serverIDM *httptest.Server
+
+	iamClient      *iam.Client
+	introspections int32
+)
+
+const (
+	orgID         = "c57b2625-eda3-4b27-a8e6-86f0a0e76afc"
+	validToken    = "valid-token"
+	inactiveToken = "inactive-token"
+	slowToken     = "slow-token"
+	expiredToken  = "expired-token"
+)
+
+func setup(t *testing.T) func() {
+	muxIAM = http.NewServeMux()
+	serverIAM = httptest.NewServer(muxIAM)
+	muxIDM = http.NewServeMux()
+	serverIDM = httptest.NewServer(muxIDM)
+	atomic.StoreInt32(&introspections, 0)
+
+	var err error
+
+	iamClient, err = iam.NewClient(nil, &iam.Config{
+		OAuth2ClientID: "TestClient",
+		OAuth2Secret:   "Secret",
+		IAMURL:         serverIAM.URL,
+		IDMURL:         serverIDM.URL,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create iamClient: %v", err)
+	}
+	iamClient.SetToken("44d20214-7879-4e35-923d-f9d4e01c9746")
+
+	muxIAM.HandleFunc("/authorize/oauth2/introspect", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST request, got ‘%s’", r.Method)
+		}
+		atomic.AddInt32(&introspections, 1)
+		_ = r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		switch r.PostForm.Get("token") {
+		case validToken:
+			_, _ = io.WriteString(w, fmt.Sprintf(`{
+  "active": true,
+  "scope": "mail tdr.contract",
+  "username": "foo.bar@example.com",
+  "exp": %d,
+  "organizations": {
+    "managingOrganization": "%s",
+    "organizationList": [
+      {
+        "organizationId": "%s",
+        "permissions": ["DEVICE.READ"],
+        "effectivePermissions": ["DEVICE.READ", "DEVICE.WRITE"]
+      }
+    ]
+  }
+}`, time.Now().Add(time.Hour).Unix(), orgID, orgID))
+		case slowToken:
+			time.Sleep(100 * time.Millisecond)
+			_, _ = io.WriteString(w, `{"active": false}`)
+		case expiredToken:
+			_, _ = io.WriteString(w, fmt.Sprintf(`{"active": true, "exp": %d}`, time.Now().Add(-time.Minute).Unix()))
+		default:
+			_, _ = io.WriteString(w, `{"active": false}`)
+		}
+	})
+
+	return func() {
+		serverIAM.Close()
+		serverIDM.Close()
+	}
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, ok := middleware.FromContext(r.Context())
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = io.WriteString(w, resp.Username)
+	})
+}
+
+func serve(h http.Handler, token string, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNew(t *testing.T) {
+	_, err := middleware.New(middleware.Config{})
+	assert.ErrorIs(t, err, middleware.ErrMissingIAMClient)
+}
+
+func TestHandler(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	auth, err := middleware.New(middleware.Config{Client: iamClient})
+	if !assert.Nil(t, err) {
+		return
+	}
+	h := auth.Handler(okHandler())
+
+	rec := serve(h, validToken, "/")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "foo.bar@example.com", rec.Body.String())
+
+	rec = serve(h, validToken, "/")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&introspections))
+
+	rec = serve(h, "", "/")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+
+	rec = serve(h, inactiveToken, "/")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "invalid_token")
+
+	rec = serve(h, expiredToken, "/")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = serve(h, inactiveToken, "/")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&introspections))
+}
+
+func TestHandlerConcurrent(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	auth, err := middleware.New(middleware.Config{Client: iamClient})
+	if !assert.Nil(t, err) {
+		return
+	}
+	h := auth.Handler(okHandler())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := serve(h, validToken, "/")
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&introspections))
+}
+
+func TestIntrospectCancelledCaller(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	auth, err := middleware.New(middleware.Config{Client: iamClient})
+	if !assert.Nil(t, err) {
+		return
+	}
+	first, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := auth.Introspect(first, slowToken)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	second := make(chan error, 1)
+	go func() {
+		resp, err := auth.Introspect(context.Background(), slowToken)
+		if err == nil && resp.Active {
+			err = fmt.Errorf("unexpected active token")
+		}
+		second <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Nil(t, <-second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&introspections))
+}
+
+func TestRequireScopes(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	auth, err := middleware.New(middleware.Config{Client: iamClient})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	rec := serve(auth.Handler(auth.RequireScopes("mail")(okHandler())), validToken, "/")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serve(auth.Handler(auth.RequireScopes("mail", "tdr.dataitem")(okHandler())), validToken, "/")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "insufficient_scope")
+
+	rec = serve(auth.RequireScopes("mail")(okHandler()), validToken, "/")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRequirePermissions(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	auth, err := middleware.New(middleware.Config{Client: iamClient})
+	if !assert.Nil(t, err) {
+		return
+	}
+	org := middleware.OrganizationFromQuery("org")
+
+	rec := serve(auth.Handler(auth.RequirePermissions(org, "DEVICE.WRITE")(okHandler())), validToken, "/?org="+orgID)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serve(auth.Handler(auth.RequirePermissions(org, "DEVICE.DELETE")(okHandler())), validToken, "/?org="+orgID)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serve(auth.Handler(auth.RequirePermissions(org, "DEVICE.READ")(okHandler())), validToken, "/?org=other")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serve(auth.Handler(auth.RequirePermissions(org, "DEVICE.READ")(okHandler())), validToken, "/")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestErrorHandler(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	var handled error
+	auth, err := middleware.New(middleware.Config{
+		Client: iamClient,
+		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+			handled = err
+			w.WriteHeader(http.StatusTeapot)
+		},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	rec := serve(auth.Handler(okHandler()), inactiveToken, "/")
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+	assert.ErrorIs(t, handled, middleware.ErrInactiveToken)
+}
@@ -20,6 +20,8 @@ type GetOrganizationOptions struct {
 	Filter             *string `url:"filter,omitempty"`
 	Attributes         *string `url:"attributes,omitempty"`
 	ExcludedAttributes *string `url:"excludedAttributes,omitempty"`
+	StartIndex         *int    `url:"startIndex,omitempty"`
+	Count              *int    `url:"count,omitempty"`
 }
 
 func FilterOrgEq(orgID string) *GetOrganizationOptions {
@@ -155,6 +157,25 @@ func (o *OrganizationsService) GetOrganization(opt *GetOrganizationOptions, opti
 	return o.GetOrganizationByID(bundleResponse.Resources[0].ID)
 }
 
+// GetOrganizations retrieves a page of organizations based on the GetOrganizationOptions parameters.
+func (o *OrganizationsService) GetOrganizations(opt *GetOrganizationOptions, options ...OptionFunc) (*[]Organization, *Response, error) {
+	req, err := o.client.newRequest(IDM, "GET", "authorize/scim/v2/Organizations", opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("api-version", organizationAPIVersion)
+
+	var bundleResponse struct {
+		TotalResults int            `json:"totalResults"`
+		Resources    []Organization `json:"Resources"`
+	}
+	resp, err := o.client.do(req, &bundleResponse)
+	if err != nil {
+		return nil, resp, err
+	}
+	return &bundleResponse.Resources, resp, nil
+}
+
 // DeleteStatus returns the status of a delete operation on an organization
 func (o *OrganizationsService) DeleteStatus(id string) (*OrganizationStatus, *Response, error) {
 	req, err := o.client.newRequest(IDM, "GET", "authorize/scim/v2/Organizations/"+id+"/deleteStatus", nil, nil)
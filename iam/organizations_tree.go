@@ -0,0 +1,310 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	organizationTreePageSize    = 100
+	defaultTreeConcurrency      = 5
+	defaultDeletePollInterval   = 5 * time.Second
+	organizationDeleteSucceeded = "SUCCESS"
+	organizationDeleteFailed    = "FAILED"
+)
+
+// OrganizationNode is an organization in a hierarchy together with its sub organizations
+type OrganizationNode struct {
+	Organization Organization
+	Depth        int
+	Children     []*OrganizationNode
+	Groups       int
+	Propositions int
+	Services     int
+}
+
+// Walk calls fn for the node and all its descendants, parents before children.
+// Walking stops at the first error returned by fn
+func (n *OrganizationNode) Walk(fn func(node *OrganizationNode) error) error {
+	if err := fn(n); err != nil {
+		return err
+	}
+	for _, child := range n.Children {
+		if err := child.Walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LeafFirst returns the node and all its descendants, children before parents
+func (n *OrganizationNode) LeafFirst() []*OrganizationNode {
+	var nodes []*OrganizationNode
+	for _, child := range n.Children {
+		nodes = append(nodes, child.LeafFirst()...)
+	}
+	return append(nodes, n)
+}
+
+// Size returns the number of organizations in the tree
+func (n *OrganizationNode) Size() int {
+	size := 1
+	for _, child := range n.Children {
+		size += child.Size()
+	}
+	return size
+}
+
+// OrganizationTreeOptions controls how an organization hierarchy is retrieved
+type OrganizationTreeOptions struct {
+	// Concurrency is the maximum number of organizations fetched in parallel. Defaults to 5
+	Concurrency int
+	// MaxDepth limits the depth of the tree. The root has depth 0. Zero means unlimited
+	MaxDepth int
+	// SkipCounts skips counting groups, propositions and services of each organization
+	SkipCounts bool
+}
+
+type treeWalker struct {
+	orgs   *OrganizationsService
+	opts   OrganizationTreeOptions
+	sem    chan struct{}
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	err    error
+	cancel context.CancelFunc
+}
+
+// GetOrganizationTree retrieves the organization rootID and its complete sub hierarchy.
+// Sub organizations are fetched concurrently and sorted by name
+func (o *OrganizationsService) GetOrganizationTree(ctx context.Context, rootID string, opts *OrganizationTreeOptions) (*OrganizationNode, error) {
+	if rootID == "" {
+		return nil, ErrMissingOrganization
+	}
+	root, _, err := o.GetOrganizationByID(rootID)
+	if err != nil {
+		return nil, err
+	}
+	w := &treeWalker{orgs: o}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Concurrency <= 0 {
+		w.opts.Concurrency = defaultTreeConcurrency
+	}
+	w.sem = make(chan struct{}, w.opts.Concurrency)
+	ctx, w.cancel = context.WithCancel(ctx)
+	defer w.cancel()
+
+	node := &OrganizationNode{Organization: *root}
+	w.wg.Add(1)
+	go w.visit(ctx, node)
+	w.wg.Wait()
+	if w.err != nil {
+		return nil, w.err
+	}
+	return node, nil
+}
+
+func (w *treeWalker) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+		w.cancel()
+	}
+}
+
+func (w *treeWalker) visit(ctx context.Context, node *OrganizationNode) {
+	defer w.wg.Done()
+
+	select {
+	case w.sem <- struct{}{}:
+	case <-ctx.Done():
+		w.fail(ctx.Err())
+		return
+	}
+	var children []Organization
+	err := w.count(ctx, node)
+	if err == nil && (w.opts.MaxDepth == 0 || node.Depth < w.opts.MaxDepth) {
+		children, err = w.orgs.getChildren(ctx, node.Organization.ID)
+	}
+	<-w.sem
+	if err != nil {
+		w.fail(fmt.Errorf("organization %s: %w", node.Organization.ID, err))
+		return
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Name < children[j].Name
+	})
+	for _, child := range children {
+		childNode := &OrganizationNode{Organization: child, Depth: node.Depth + 1}
+		node.Children = append(node.Children, childNode)
+	}
+	for _, childNode := range node.Children {
+		w.wg.Add(1)
+		go w.visit(ctx, childNode)
+	}
+}
+
+func (w *treeWalker) count(ctx context.Context, node *OrganizationNode) error {
+	if w.opts.SkipCounts {
+		return nil
+	}
+	client := w.orgs.client
+	orgID := node.Organization.ID
+	groups, err := countPages(func(page, count int) (int, error) {
+		groups, _, err := client.Groups.GetGroups(&GetGroupOptions{OrganizationID: &orgID, Page: &page, Count: &count}, WithContext(ctx))
+		if err != nil {
+			return 0, err
+		}
+		return len(*groups), nil
+	})
+	if err != nil {
+		return err
+	}
+	props, err := countPages(func(page, count int) (int, error) {
+		props, _, err := client.Propositions.GetPropositions(&GetPropositionsOptions{OrganizationID: &orgID, Page: &page, Count: &count}, WithContext(ctx))
+		if err != nil {
+			return 0, err
+		}
+		return len(*props), nil
+	})
+	if err != nil {
+		return err
+	}
+	services, err := countPages(func(page, count int) (int, error) {
+		services, _, err := client.Services.GetServices(&GetServiceOptions{OrganizationID: &orgID, Page: &page, Count: &count}, WithContext(ctx))
+		if err != nil {
+			return 0, err
+		}
+		return len(*services), nil
+	})
+	if err != nil {
+		return err
+	}
+	node.Groups, node.Propositions, node.Services = groups, props, services
+	return nil
+}
+
+// countPages sums the number of results of fetch until a page is not full
+func countPages(fetch func(page, count int) (int, error)) (int, error) {
+	total := 0
+	count := organizationTreePageSize
+	for page := 1; ; page++ {
+		n, err := fetch(page, count)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+		if n < count {
+			return total, nil
+		}
+	}
+}
+
+// getChildren returns all direct sub organizations of parentID
+func (o *OrganizationsService) getChildren(ctx context.Context, parentID string) ([]Organization, error) {
+	var children []Organization
+	filter := "parent.value eq \"" + parentID + "\""
+	count := organizationTreePageSize
+	for startIndex := 1; ; startIndex += count {
+		start := startIndex
+		page, _, err := o.GetOrganizations(&GetOrganizationOptions{
+			Filter:     &filter,
+			StartIndex: &start,
+			Count:      &count,
+		}, WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, *page...)
+		if len(*page) < count {
+			return children, nil
+		}
+	}
+}
+
+// CascadeDeleteOptions controls a cascading organization delete
+type CascadeDeleteOptions struct {
+	// DryRun only returns the organizations which would be deleted
+	DryRun bool
+	// PollInterval is the interval at which the delete status is checked. Defaults to 5 seconds
+	PollInterval time.Duration
+	// OnDeleted is called after each organization has been deleted
+	OnDeleted func(org Organization)
+}
+
+// CascadeDeleteResult lists the organizations deleted by a cascading delete
+type CascadeDeleteResult struct {
+	DryRun        bool
+	Organizations []Organization
+}
+
+// CascadeDelete deletes the organization rootID including all its sub organizations.
+// Organizations are deleted leaf first, waiting for each delete to complete before
+// deleting the parent. The returned result contains the organizations deleted so far,
+// also when an error occurs
+func (o *OrganizationsService) CascadeDelete(ctx context.Context, rootID string, opts *CascadeDeleteOptions) (*CascadeDeleteResult, error) {
+	var options CascadeDeleteOptions
+	if opts != nil {
+		options = *opts
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = defaultDeletePollInterval
+	}
+	tree, err := o.GetOrganizationTree(ctx, rootID, &OrganizationTreeOptions{SkipCounts: true})
+	if err != nil {
+		return nil, err
+	}
+	result := &CascadeDeleteResult{DryRun: options.DryRun}
+	for _, node := range tree.LeafFirst() {
+		if options.DryRun {
+			result.Organizations = append(result.Organizations, node.Organization)
+			continue
+		}
+		if err := o.deleteAndWait(ctx, node.Organization, options.PollInterval); err != nil {
+			return result, fmt.Errorf("organization %s: %w", node.Organization.ID, err)
+		}
+		result.Organizations = append(result.Organizations, node.Organization)
+		if options.OnDeleted != nil {
+			options.OnDeleted(node.Organization)
+		}
+	}
+	return result, nil
+}
+
+func (o *OrganizationsService) deleteAndWait(ctx context.Context, org Organization, interval time.Duration) error {
+	ok, _, err := o.DeleteOrganization(org)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrDeleteFailed
+	}
+	for {
+		status, resp, err := o.DeleteStatus(org.ID)
+		if err != nil {
+			if resp != nil && resp.StatusCode() == http.StatusNotFound {
+				return nil
+			}
+			return err
+		}
+		switch status.Status {
+		case organizationDeleteSucceeded:
+			return nil
+		case organizationDeleteFailed:
+			return ErrDeleteFailed
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
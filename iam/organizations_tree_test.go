@@ -0,0 +1,202 @@
+package iam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupOrganizationTree serves the hierarchy root -> (a -> a1, b) and returns the
+// IDs of deleted organizations in the order they were deleted
+func setupOrganizationTree(t *testing.T) func() []string {
+	parents := map[string]string{
+		"root": "",
+		"a":    "root",
+		"a1":   "a",
+		"b":    "root",
+	}
+	var mu sync.Mutex
+	var deleted []string
+	polled := make(map[string]int)
+
+	org := func(id string) Organization {
+		return Organization{
+			ID:     id,
+			Name:   "org-" + id,
+			Parent: Attribute{Value: parents[id]},
+		}
+	}
+
+	muxIDM.HandleFunc("/authorize/scim/v2/Organizations", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected ‘GET’ request, got ‘%s’", r.Method)
+		}
+		filter := r.URL.Query().Get("filter")
+		var resources []Organization
+		for _, id := range []string{"b", "a", "a1", "root"} {
+			if parents[id] != "" && filter == `parent.value eq "`+parents[id]+`"` {
+				resources = append(resources, org(id))
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"totalResults": len(resources),
+			"Resources":    resources,
+		})
+	})
+	muxIDM.HandleFunc("/authorize/scim/v2/Organizations/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/authorize/scim/v2/Organizations/")
+		id, status, isStatus := strings.Cut(path, "/")
+		if _, ok := parents[id]; !ok || (isStatus && status != "deleteStatus") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case isStatus:
+			polled[id]++
+			state := "IN_PROGRESS"
+			if polled[id] > 1 {
+				state = "SUCCESS"
+			}
+			w.WriteHeader(http.StatusOK)
+			_, _ = io.WriteString(w, `{"id": "`+id+`", "status": "`+state+`"}`)
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, id)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusOK)
+			_ = json.NewEncoder(w).Encode(org(id))
+		}
+	})
+	muxIDM.HandleFunc("/authorize/identity/Group", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		// root has 102 groups, served in pages of _count
+		var entries []string
+		if r.URL.Query().Get("orgID") == "root" {
+			page, _ := strconv.Atoi(r.URL.Query().Get("_page"))
+			count, _ := strconv.Atoi(r.URL.Query().Get("_count"))
+			for i := (page - 1) * count; i < min(page*count, 102); i++ {
+				entries = append(entries, fmt.Sprintf(`{"resource": {"id": "g%d", "groupName": "group-%d"}}`, i, i))
+			}
+		}
+		_, _ = io.WriteString(w, `{"total": 0, "entry": [`+strings.Join(entries, ",")+`]}`)
+	})
+	muxIDM.HandleFunc("/authorize/identity/Proposition", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		entries := ""
+		if r.URL.Query().Get("organizationId") == "a" {
+			entries = `{"id": "p1", "name": "prop"}`
+		}
+		_, _ = io.WriteString(w, `{"total": 0, "entry": [`+entries+`]}`)
+	})
+	muxIDM.HandleFunc("/authorize/identity/Service", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		entries := ""
+		if r.URL.Query().Get("organizationId") == "a1" {
+			entries = `{"id": "s1", "name": "svc"}`
+		}
+		_, _ = io.WriteString(w, `{"total": 0, "entry": [`+entries+`]}`)
+	})
+
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), deleted...)
+	}
+}
+
+func TestGetOrganizationTree(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+	setupOrganizationTree(t)
+
+	tree, err := client.Organizations.GetOrganizationTree(context.Background(), "root", nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.NotNil(t, tree) {
+		return
+	}
+	assert.Equal(t, 4, tree.Size())
+	assert.Equal(t, 102, tree.Groups)
+	if !assert.Len(t, tree.Children, 2) {
+		return
+	}
+	a := tree.Children[0]
+	assert.Equal(t, "a", a.Organization.ID)
+	assert.Equal(t, 1, a.Depth)
+	assert.Equal(t, 1, a.Propositions)
+	if assert.Len(t, a.Children, 1) {
+		assert.Equal(t, 1, a.Children[0].Services)
+		assert.Equal(t, 2, a.Children[0].Depth)
+	}
+
+	var ids []string
+	for _, node := range tree.LeafFirst() {
+		ids = append(ids, node.Organization.ID)
+	}
+	assert.Equal(t, []string{"a1", "a", "b", "root"}, ids)
+
+	ids = nil
+	_ = tree.Walk(func(node *OrganizationNode) error {
+		ids = append(ids, node.Organization.ID)
+		return nil
+	})
+	assert.Equal(t, []string{"root", "a", "a1", "b"}, ids)
+
+	shallow, err := client.Organizations.GetOrganizationTree(context.Background(), "root", &OrganizationTreeOptions{
+		MaxDepth:   1,
+		SkipCounts: true,
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, 3, shallow.Size())
+		assert.Equal(t, 0, shallow.Groups)
+	}
+
+	_, err = client.Organizations.GetOrganizationTree(context.Background(), "missing", nil)
+	assert.NotNil(t, err)
+}
+
+func TestCascadeDelete(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+	deleted := setupOrganizationTree(t)
+
+	result, err := client.Organizations.CascadeDelete(context.Background(), "root", &CascadeDeleteOptions{DryRun: true})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, result.DryRun)
+	assert.Len(t, result.Organizations, 4)
+	assert.Empty(t, deleted())
+
+	var notified []string
+	result, err = client.Organizations.CascadeDelete(context.Background(), "root", &CascadeDeleteOptions{
+		PollInterval: time.Millisecond,
+		OnDeleted: func(org Organization) {
+			notified = append(notified, org.ID)
+		},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.False(t, result.DryRun)
+	assert.Equal(t, []string{"a1", "a", "b", "root"}, deleted())
+	assert.Equal(t, deleted(), notified)
+}
@@ -0,0 +1,22 @@
+package iam
+
+const allPagesSize = 100
+
+// allPages calls fetch with increasing page numbers until a page is not full and collects the results
+func allPages[T any](fetch func(page, count int) (*[]T, *Response, error)) (*[]T, *Response, error) {
+	all := make([]T, 0)
+	count := allPagesSize
+	for page := 1; ; page++ {
+		results, resp, err := fetch(page, count)
+		if err != nil {
+			return nil, resp, err
+		}
+		if results == nil {
+			return &all, resp, nil
+		}
+		all = append(all, *results...)
+		if len(*results) < count {
+			return &all, resp, nil
+		}
+	}
+}
@@ -97,6 +97,18 @@ func (p *PropositionsService) GetPropositions(opt *GetPropositionsOptions, optio
 	return &bundleResponse.Entry, resp, err
 }
 
+// GetAllPropositions retrieves the propositions matching opt from all result pages. The Page and Count of opt are ignored
+func (p *PropositionsService) GetAllPropositions(opt *GetPropositionsOptions, options ...OptionFunc) (*[]Proposition, *Response, error) {
+	var pageOpt GetPropositionsOptions
+	if opt != nil {
+		pageOpt = *opt
+	}
+	return allPages(func(page, count int) (*[]Proposition, *Response, error) {
+		pageOpt.Page, pageOpt.Count = &page, &count
+		return p.GetPropositions(&pageOpt, options...)
+	})
+}
+
 // CreateProposition creates a Proposition
 func (p *PropositionsService) CreateProposition(prop Proposition) (*Proposition, *Response, error) {
 	if err := prop.validate(); err != nil {
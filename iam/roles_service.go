@@ -27,6 +27,8 @@ type GetRolesOptions struct {
 	GroupID        *string `url:"groupId,omitempty"`
 	OrganizationID *string `url:"organizationId,omitempty"`
 	RoleID         *string `url:"roleId,omitempty"`
+	Count          *int    `url:"_count,omitempty"`
+	Page           *int    `url:"_page,omitempty"`
 }
 
 // ListSharingPoliciesOptions describes search criteria for listing RoleSharingPolicy resources
@@ -50,8 +52,8 @@ type RoleSharingPolicy struct {
 }
 
 // GetRoles retries based on GetRolesOptions
-func (p *RolesService) GetRoles(opt *GetRolesOptions) (*[]Role, *Response, error) {
-	req, err := p.client.newRequest(IDM, http.MethodGet, "authorize/identity/Role", opt, nil)
+func (p *RolesService) GetRoles(opt *GetRolesOptions, options ...OptionFunc) (*[]Role, *Response, error) {
+	req, err := p.client.newRequest(IDM, http.MethodGet, "authorize/identity/Role", opt, options)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -70,6 +72,18 @@ func (p *RolesService) GetRoles(opt *GetRolesOptions) (*[]Role, *Response, error
 	return &responseStruct.Entry, resp, err
 }
 
+// GetAllRoles retrieves the roles matching opt from all result pages. The Page and Count of opt are ignored
+func (p *RolesService) GetAllRoles(opt *GetRolesOptions, options ...OptionFunc) (*[]Role, *Response, error) {
+	var pageOpt GetRolesOptions
+	if opt != nil {
+		pageOpt = *opt
+	}
+	return allPages(func(page, count int) (*[]Role, *Response, error) {
+		pageOpt.Page, pageOpt.Count = &page, &count
+		return p.GetRoles(&pageOpt, options...)
+	})
+}
+
 // GetRolesByGroupID retrieves Roles based on group ID
 func (p *RolesService) GetRolesByGroupID(groupID string) (*[]Role, *Response, error) {
 	opt := &GetRolesOptions{
@@ -136,10 +150,10 @@ func (p *RolesService) DeleteRole(role Role) (RoleResponse, *Response, error) {
 }
 
 // GetRolePermissions retrieves the permissions associated with the Role
-func (p *RolesService) GetRolePermissions(role Role) (*[]string, *Response, error) {
+func (p *RolesService) GetRolePermissions(role Role, options ...OptionFunc) (*[]string, *Response, error) {
 	opt := &GetRolesOptions{RoleID: &role.ID}
 
-	req, err := p.client.newRequest(IDM, http.MethodGet, "authorize/identity/Permission", opt, nil)
+	req, err := p.client.newRequest(IDM, http.MethodGet, "authorize/identity/Permission", opt, options)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1,8 +1,10 @@
 package iam
 
 import (
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -214,3 +216,35 @@ func TestGetRolePermissions(t *testing.T) {
 	}
 	assert.Contains(t, *permissions, permissionName)
 }
+
+func TestGetAllRoles(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	var pages []string
+	muxIDM.HandleFunc("/authorize/identity/Role", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, "org", q.Get("organizationId"))
+		pages = append(pages, q.Get("_page"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if q.Get("_page") == "2" {
+			_, _ = io.WriteString(w, `{"total": 1, "entry": [{"id": "last", "name": "LAST"}]}`)
+			return
+		}
+		entries := make([]string, 100)
+		for i := range entries {
+			entries[i] = fmt.Sprintf(`{"id": "role-%d", "name": "ROLE%d"}`, i, i)
+		}
+		_, _ = io.WriteString(w, `{"total": 100, "entry": [`+strings.Join(entries, ",")+`]}`)
+	})
+
+	org := "org"
+	roles, _, err := client.Roles.GetAllRoles(&GetRolesOptions{OrganizationID: &org})
+	if !assert.Nil(t, err) || !assert.NotNil(t, roles) {
+		return
+	}
+	assert.Len(t, *roles, 101)
+	assert.Equal(t, "LAST", (*roles)[100].Name)
+	assert.Equal(t, []string{"1", "2"}, pages)
+}
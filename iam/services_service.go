@@ -46,6 +46,8 @@ type GetServiceOptions struct {
 	ApplicationID  *string `url:"applicationId,omitempty"`
 	OrganizationID *string `url:"organizationId,omitempty"`
 	ServiceID      *string `url:"serviceId,omitempty"`
+	Count          *int    `url:"_count,omitempty"`
+	Page           *int    `url:"_page,omitempty"`
 }
 
 type CertificateOptionFunc func(cert *x509.Certificate) error
@@ -189,6 +191,18 @@ func (p *ServicesService) GetServices(opt *GetServiceOptions, options ...OptionF
 	return &bundleResponse.Entry, resp, err
 }
 
+// GetAllServices retrieves the services matching opt from all result pages. The Page and Count of opt are ignored
+func (p *ServicesService) GetAllServices(opt *GetServiceOptions, options ...OptionFunc) (*[]Service, *Response, error) {
+	var pageOpt GetServiceOptions
+	if opt != nil {
+		pageOpt = *opt
+	}
+	return allPages(func(page, count int) (*[]Service, *Response, error) {
+		pageOpt.Page, pageOpt.Count = &page, &count
+		return p.GetServices(&pageOpt, options...)
+	})
+}
+
 type ServiceUpdateResponse struct {
 	Service
 	TokenValidity int `json:"tokenValidity"`
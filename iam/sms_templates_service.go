@@ -49,6 +49,14 @@ func SMSTemplateFilterOrgTypeLang(orgID, templateType, locale string) *GetSMSTem
 	}
 }
 
+// SMSTemplateFilterOrg returns options to find all SMS templates of an organization
+func SMSTemplateFilterOrg(orgID string) *GetSMSTemplateOptions {
+	query := "organization.value eq \"" + orgID + "\""
+	return &GetSMSTemplateOptions{
+		Filter: &query,
+	}
+}
+
 // CreateSMSTemplate creates a SMS template for IAM
 func (o *SMSTemplatesService) CreateSMSTemplate(template SMSTemplate) (*SMSTemplate, *Response, error) {
 	template.Schemas = []string{
@@ -160,3 +168,22 @@ func (o *SMSTemplatesService) GetSMSTemplate(opt *GetSMSTemplateOptions, options
 
 	return o.GetSMSTemplateByID(bundleResponse.Resources[0].ID)
 }
+
+// GetSMSTemplates retrieves all SMS templates matching the GetSMSTemplateOptions parameters.
+func (o *SMSTemplatesService) GetSMSTemplates(opt *GetSMSTemplateOptions, options ...OptionFunc) (*[]SMSTemplate, *Response, error) {
+	req, err := o.client.newRequest(IDM, "GET", "authorize/scim/v2/Configurations/SMSTemplate", opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("api-version", smsServicesAPIVersion)
+
+	var bundleResponse struct {
+		TotalResults int           `json:"totalResults"`
+		Resources    []SMSTemplate `json:"Resources"`
+	}
+	resp, err := o.client.do(req, &bundleResponse)
+	if err != nil {
+		return nil, resp, err
+	}
+	return &bundleResponse.Resources, resp, nil
+}
@@ -92,3 +92,35 @@ func TestCreateSMSTemplate(t *testing.T) {
 	assert.Equal(t, TypePhoneVerification, createdTemplate.Type)
 	assert.Equal(t, orgID, createdTemplate.Organization.Value)
 }
+
+func TestGetSMSTemplates(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	orgID := "c57b2625-eda3-4b27-a8e6-86f0a0e76afc"
+
+	muxIDM.HandleFunc("/authorize/scim/v2/Configurations/SMSTemplate", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("Expected GET request, got ‘%s’", r.Method)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		assert.Equal(t, `organization.value eq "`+orgID+`"`, r.URL.Query().Get("filter"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, `{"totalResults": 1, "Resources": [`+testTemplate+`]}`)
+	})
+
+	templates, resp, err := client.SMSTemplates.GetSMSTemplates(SMSTemplateFilterOrg(orgID))
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.NotNil(t, resp) {
+		return
+	}
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	if assert.NotNil(t, templates) && assert.Len(t, *templates, 1) {
+		assert.Equal(t, "PHONE_VERIFICATION", (*templates)[0].Type)
+		assert.Equal(t, "en-US", (*templates)[0].Locale)
+	}
+}
@@ -0,0 +1,18 @@
+package snapshot
+
+import (
+	"errors"
+)
+
+// Exported Errors
+var (
+	ErrMissingIAMClient    = errors.New("missing IAM client")
+	ErrMissingOrganization = errors.New("missing organization")
+	ErrUnsupportedFormat   = errors.New("unsupported snapshot format")
+	ErrUnsupportedVersion  = errors.New("unsupported snapshot version")
+	ErrUnresolvedSecret    = errors.New("secret could not be resolved")
+	ErrUnknownRole         = errors.New("group references unknown role")
+	ErrMultiplePolicies    = errors.New("only one password policy per organization is supported")
+	ErrInvalidPrivateKey   = errors.New("invalid RSA private key")
+	ErrUnmappedOrg         = errors.New("sharing policy targets an organization of another tenant")
+)
@@ -0,0 +1,267 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dip-software/go-dip-api/iam"
+)
+
+// ExportOptions controls the behaviour of Export
+type ExportOptions struct {
+	// Secrets determines how client passwords and service private keys are written
+	Secrets SecretMode
+}
+
+// Export walks the organization using the IAM services and returns a normalized Snapshot
+func Export(ctx context.Context, client *iam.Client, orgID string, opts *ExportOptions) (*Snapshot, error) {
+	if client == nil {
+		return nil, ErrMissingIAMClient
+	}
+	if orgID == "" {
+		return nil, ErrMissingOrganization
+	}
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+	withCtx := iam.WithContext(ctx)
+	s := &Snapshot{
+		Version:        CurrentVersion,
+		OrganizationID: orgID,
+	}
+
+	props, _, err := client.Propositions.GetAllPropositions(&iam.GetPropositionsOptions{OrganizationID: &orgID}, withCtx)
+	if err != nil {
+		return nil, fmt.Errorf("propositions: %w", err)
+	}
+	for _, p := range *props {
+		prop := Proposition{
+			Name:              p.Name,
+			Description:       p.Description,
+			GlobalReferenceID: p.GlobalReferenceID,
+		}
+		apps, err := getApplications(client, p.ID, "", withCtx)
+		if err != nil {
+			return nil, fmt.Errorf("applications of %s: %w", p.Name, err)
+		}
+		for _, a := range apps {
+			app, err := exportApplication(client, *a, opts.Secrets, withCtx)
+			if err != nil {
+				return nil, fmt.Errorf("application %s: %w", a.Name, err)
+			}
+			prop.Applications = append(prop.Applications, *app)
+		}
+		s.Propositions = append(s.Propositions, prop)
+	}
+
+	roles, _, err := client.Roles.GetAllRoles(&iam.GetRolesOptions{OrganizationID: &orgID}, withCtx)
+	if err != nil {
+		return nil, fmt.Errorf("roles: %w", err)
+	}
+	roleNames := make(map[string]string)
+	for _, r := range *roles {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		roleNames[r.ID] = r.Name
+		role := Role{
+			Name:        r.Name,
+			Description: r.Description,
+		}
+		permissions, _, err := client.Roles.GetRolePermissions(r, withCtx)
+		if err != nil {
+			return nil, fmt.Errorf("permissions of role %s: %w", r.Name, err)
+		}
+		if permissions != nil {
+			role.Permissions = append(role.Permissions, *permissions...)
+		}
+		policies, _, err := client.Roles.ListSharingPolicies(r, nil)
+		if err != nil {
+			return nil, fmt.Errorf("sharing policies of role %s: %w", r.Name, err)
+		}
+		for _, sp := range *policies {
+			role.SharingPolicies = append(role.SharingPolicies, SharingPolicy{
+				TargetOrganizationID: sp.TargetOrganizationID,
+				SharingPolicy:        sp.SharingPolicy,
+				Purpose:              sp.Purpose,
+			})
+		}
+		s.Roles = append(s.Roles, role)
+	}
+
+	groups, _, err := client.Groups.GetAllGroups(&iam.GetGroupOptions{OrganizationID: &orgID}, withCtx)
+	if err != nil {
+		return nil, fmt.Errorf("groups: %w", err)
+	}
+	for _, g := range *groups {
+		group := Group{
+			Name:        g.GroupName,
+			Description: g.GroupDescription,
+		}
+		groupRoles, _, err := client.Groups.GetRoles(iam.Group{ID: g.ID}, withCtx)
+		if err != nil {
+			return nil, fmt.Errorf("roles of group %s: %w", g.GroupName, err)
+		}
+		for _, r := range *groupRoles {
+			group.Roles = append(group.Roles, r.Name)
+		}
+		s.Groups = append(s.Groups, group)
+	}
+
+	passwordPolicies, _, err := client.PasswordPolicies.GetPasswordPolicies(&iam.GetPasswordPolicyOptions{OrganizationID: &orgID}, withCtx)
+	if err != nil {
+		return nil, fmt.Errorf("password policies: %w", err)
+	}
+	for _, p := range *passwordPolicies {
+		s.PasswordPolicies = append(s.PasswordPolicies, fromPasswordPolicy(p))
+	}
+
+	mfaPolicies, _, err := client.MFAPolicies.GetMFAPolicies(iam.MFAPolicyFilterOrg(orgID), withCtx)
+	if err != nil {
+		return nil, fmt.Errorf("MFA policies: %w", err)
+	}
+	for _, p := range *mfaPolicies {
+		s.MFAPolicies = append(s.MFAPolicies, fromMFAPolicy(p))
+	}
+
+	emailTemplates, err := getEmailTemplates(client, orgID, withCtx)
+	if err != nil {
+		return nil, fmt.Errorf("email templates: %w", err)
+	}
+	for _, t := range emailTemplates {
+		s.EmailTemplates = append(s.EmailTemplates, fromEmailTemplate(t))
+	}
+
+	smsTemplates, _, err := client.SMSTemplates.GetSMSTemplates(iam.SMSTemplateFilterOrg(orgID), withCtx)
+	if err != nil {
+		return nil, fmt.Errorf("SMS templates: %w", err)
+	}
+	for _, t := range *smsTemplates {
+		s.SMSTemplates = append(s.SMSTemplates, SMSTemplate{
+			Type:    t.Type,
+			Locale:  t.Locale,
+			Message: t.Message,
+		})
+	}
+
+	s.Normalize()
+	return s, nil
+}
+
+func exportApplication(client *iam.Client, a iam.Application, mode SecretMode, withCtx iam.OptionFunc) (*Application, error) {
+	app := &Application{
+		Name:              a.Name,
+		Description:       a.Description,
+		GlobalReferenceID: a.GlobalReferenceID,
+	}
+	services, _, err := client.Services.GetAllServices(&iam.GetServiceOptions{ApplicationID: &a.ID}, withCtx)
+	if err != nil {
+		return nil, fmt.Errorf("services: %w", err)
+	}
+	for _, svc := range *services {
+		app.Services = append(app.Services, Service{
+			Name:          svc.Name,
+			Description:   svc.Description,
+			Validity:      svc.Validity,
+			Scopes:        svc.Scopes,
+			DefaultScopes: svc.DefaultScopes,
+			PrivateKey:    secretValue(mode, ServicePrivateKeyVariable(svc.Name)),
+		})
+	}
+	clients, _, err := client.Clients.GetClients(&iam.GetClientsOptions{ApplicationID: &a.ID}, withCtx)
+	if err != nil {
+		return nil, fmt.Errorf("clients: %w", err)
+	}
+	for _, c := range *clients {
+		app.Clients = append(app.Clients, fromApplicationClient(c, secretValue(mode, ClientPasswordVariable(c.ClientID))))
+	}
+	return app, nil
+}
+
+func getApplications(client *iam.Client, propID, name string, options ...iam.OptionFunc) ([]*iam.Application, error) {
+	opt := &iam.GetApplicationsOptions{PropositionID: &propID}
+	if name != "" {
+		opt.Name = &name
+	}
+	apps, _, err := client.Applications.GetApplications(opt, options...)
+	if errors.Is(err, iam.ErrEmptyResults) {
+		return []*iam.Application{}, nil
+	}
+	return apps, err
+}
+
+func getEmailTemplates(client *iam.Client, orgID string, options ...iam.OptionFunc) ([]iam.EmailTemplate, error) {
+	templates, _, err := client.EmailTemplates.GetTemplates(&iam.GetEmailTemplatesOptions{OrganizationID: &orgID}, options...)
+	if errors.Is(err, iam.ErrNotFound) {
+		return []iam.EmailTemplate{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return *templates, nil
+}
+
+func fromApplicationClient(c iam.ApplicationClient, password string) Client {
+	return Client{
+		ClientID:             c.ClientID,
+		Name:                 c.Name,
+		Type:                 c.Type,
+		Password:             password,
+		Description:          c.Description,
+		GlobalReferenceID:    c.GlobalReferenceID,
+		RedirectionURIs:      c.RedirectionURIs,
+		ResponseTypes:        c.ResponseTypes,
+		Scopes:               c.Scopes,
+		DefaultScopes:        c.DefaultScopes,
+		ConsentImplied:       c.ConsentImplied,
+		Disabled:             c.Disabled,
+		AccessTokenLifetime:  c.AccessTokenLifetime,
+		RefreshTokenLifetime: c.RefreshTokenLifetime,
+		IDTokenLifetime:      c.IDTokenLifetime,
+	}
+}
+
+func fromPasswordPolicy(p iam.PasswordPolicy) PasswordPolicy {
+	policy := PasswordPolicy{
+		ExpiryPeriodInDays: p.ExpiryPeriodInDays,
+		HistoryCount:       p.HistoryCount,
+		MinLength:          p.Complexity.MinLength,
+		MaxLength:          p.Complexity.MaxLength,
+		MinNumerics:        p.Complexity.MinNumerics,
+		MinUpperCase:       p.Complexity.MinUpperCase,
+		MinLowerCase:       p.Complexity.MinLowerCase,
+		MinSpecialChars:    p.Complexity.MinSpecialChars,
+		ChallengesEnabled:  p.ChallengesEnabled,
+	}
+	if p.ChallengePolicy != nil {
+		policy.ChallengePolicy = &PasswordChallenges{
+			DefaultQuestions:     p.ChallengePolicy.DefaultQuestions,
+			MinQuestionCount:     p.ChallengePolicy.MinQuestionCount,
+			MinAnswerCount:       p.ChallengePolicy.MinAnswerCount,
+			MaxIncorrectAttempts: p.ChallengePolicy.MaxIncorrectAttempts,
+		}
+	}
+	return policy
+}
+
+func fromMFAPolicy(p iam.MFAPolicy) MFAPolicy {
+	return MFAPolicy{
+		Name:        p.Name,
+		Description: p.Description,
+		Types:       p.Types,
+		Active:      p.Active != nil && *p.Active,
+	}
+}
+
+func fromEmailTemplate(t iam.EmailTemplate) EmailTemplate {
+	return EmailTemplate{
+		Type:    t.Type,
+		Locale:  t.Locale,
+		Format:  t.Format,
+		From:    t.From,
+		Subject: t.Subject,
+		Message: t.Message,
+		Link:    t.Link,
+	}
+}
@@ -0,0 +1,1030 @@
+package snapshot
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dip-software/go-dip-api/iam"
+)
+
+// Action describes what Apply does with a resource
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionReplace Action = "replace"
+	ActionNoop    Action = "noop"
+)
+
+// Change is a single planned change to an IAM resource
+type Change struct {
+	Action  Action   `json:"action"`
+	Kind    string   `json:"kind"`
+	Name    string   `json:"name"`
+	Details []string `json:"details,omitempty"`
+
+	apply func(ctx context.Context, st *state) error
+}
+
+func (c Change) String() string {
+	symbol := map[Action]string{
+		ActionCreate:  "+",
+		ActionUpdate:  "~",
+		ActionReplace: "-/+",
+		ActionNoop:    "=",
+	}[c.Action]
+	line := fmt.Sprintf("%s %s %s %q", symbol, c.Action, c.Kind, c.Name)
+	if len(c.Details) > 0 {
+		line += " (" + strings.Join(c.Details, ", ") + ")"
+	}
+	return line
+}
+
+// Plan holds the changes needed to make an organization match a Snapshot
+type Plan struct {
+	OrganizationID string   `json:"organizationId"`
+	Changes        []Change `json:"changes"`
+}
+
+// HasChanges returns true if applying the plan would modify the organization
+func (p *Plan) HasChanges() bool {
+	for _, c := range p.Changes {
+		if c.Action != ActionNoop {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the dry-run output of the plan
+func (p *Plan) String() string {
+	var b strings.Builder
+	for _, c := range p.Changes {
+		b.WriteString(c.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Report describes the outcome of applying a Plan
+type Report struct {
+	Applied []Change `json:"applied"`
+	// Secrets holds the private keys of created services, either generated by IAM
+	// or supplied in the snapshot, keyed by their ServicePrivateKeyVariable name
+	Secrets map[string]string `json:"-"`
+}
+
+// ImportOptions controls the behaviour of the Importer
+type ImportOptions struct {
+	// Secrets resolves ${NAME} placeholders in the snapshot. Defaults to os.LookupEnv
+	Secrets func(name string) (string, bool)
+	// Organizations maps the organization IDs of the snapshot, e.g. sharing policy targets,
+	// to the target tenant. The organization of the snapshot always maps to the imported one.
+	// Importing into another organization fails for sharing policies with an unmapped target
+	Organizations map[string]string
+}
+
+// Importer plans and applies snapshots idempotently, matching resources by name
+type Importer struct {
+	client        *iam.Client
+	secrets       func(name string) (string, bool)
+	organizations map[string]string
+}
+
+// NewImporter returns an Importer which uses the given IAM client
+func NewImporter(client *iam.Client, opts *ImportOptions) (*Importer, error) {
+	if client == nil {
+		return nil, ErrMissingIAMClient
+	}
+	i := &Importer{client: client, secrets: os.LookupEnv}
+	if opts != nil && opts.Secrets != nil {
+		i.secrets = opts.Secrets
+	}
+	if opts != nil {
+		i.organizations = opts.Organizations
+	}
+	return i, nil
+}
+
+// targetOrganization maps the organization id of snapshot s to the tenant of orgID
+func (i *Importer) targetOrganization(s *Snapshot, orgID, id string) (string, error) {
+	if mapped, ok := i.organizations[id]; ok {
+		return mapped, nil
+	}
+	if id == s.OrganizationID {
+		return orgID, nil
+	}
+	if s.OrganizationID == "" || s.OrganizationID == orgID {
+		return id, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnmappedOrg, id)
+}
+
+type state struct {
+	orgID        string
+	propositions map[string]string
+	applications map[string]string
+	roles        map[string]iam.Role
+	report       *Report
+}
+
+// Apply plans and applies the snapshot to the organization in one go
+func (i *Importer) Apply(ctx context.Context, s *Snapshot, orgID string) (*Report, error) {
+	plan, err := i.Plan(ctx, s, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Apply(ctx)
+}
+
+// Apply executes the planned changes in order. On error the report
+// contains the changes which were applied before the failure
+func (p *Plan) Apply(ctx context.Context) (*Report, error) {
+	st := &state{
+		orgID:        p.OrganizationID,
+		propositions: make(map[string]string),
+		applications: make(map[string]string),
+		roles:        make(map[string]iam.Role),
+		report:       &Report{Applied: []Change{}, Secrets: make(map[string]string)},
+	}
+	for _, c := range p.Changes {
+		if err := ctx.Err(); err != nil {
+			return st.report, err
+		}
+		if c.apply != nil {
+			if err := c.apply(ctx, st); err != nil {
+				return st.report, fmt.Errorf("%s %s %q: %w", c.Action, c.Kind, c.Name, err)
+			}
+		}
+		if c.Action != ActionNoop {
+			st.report.Applied = append(st.report.Applied, c)
+		}
+	}
+	return st.report, nil
+}
+
+// Plan compares the snapshot with the organization and returns the changes needed
+func (i *Importer) Plan(ctx context.Context, s *Snapshot, orgID string) (*Plan, error) {
+	if orgID == "" {
+		return nil, ErrMissingOrganization
+	}
+	if len(s.PasswordPolicies) > 1 {
+		return nil, ErrMultiplePolicies
+	}
+	plan := &Plan{OrganizationID: orgID}
+	withCtx := iam.WithContext(ctx)
+	steps := []func(context.Context, *Snapshot, string, iam.OptionFunc) ([]Change, error){
+		i.planPropositions,
+		i.planRoles,
+		i.planGroups,
+		i.planPasswordPolicies,
+		i.planMFAPolicies,
+		i.planEmailTemplates,
+		i.planSMSTemplates,
+	}
+	for _, step := range steps {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		changes, err := step(ctx, s, orgID, withCtx)
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+	return plan, nil
+}
+
+func (i *Importer) planPropositions(_ context.Context, s *Snapshot, orgID string, withCtx iam.OptionFunc) ([]Change, error) {
+	var changes []Change
+	for _, p := range s.Propositions {
+		props, _, err := i.client.Propositions.GetPropositions(&iam.GetPropositionsOptions{
+			OrganizationID: &orgID,
+			Name:           &p.Name,
+		}, withCtx)
+		if err != nil {
+			return nil, fmt.Errorf("proposition %s: %w", p.Name, err)
+		}
+		var existing *iam.Proposition
+		for _, e := range *props {
+			if e.Name == p.Name {
+				existing = &e
+				break
+			}
+		}
+		if existing != nil {
+			id := existing.ID
+			changes = append(changes, Change{Action: ActionNoop, Kind: "proposition", Name: p.Name,
+				apply: func(_ context.Context, st *state) error {
+					st.propositions[p.Name] = id
+					return nil
+				}})
+		} else {
+			changes = append(changes, Change{Action: ActionCreate, Kind: "proposition", Name: p.Name,
+				apply: func(_ context.Context, st *state) error {
+					created, _, err := i.client.Propositions.CreateProposition(iam.Proposition{
+						Name:              p.Name,
+						Description:       p.Description,
+						OrganizationID:    st.orgID,
+						GlobalReferenceID: p.GlobalReferenceID,
+					})
+					if err != nil {
+						return err
+					}
+					st.propositions[p.Name] = created.ID
+					return nil
+				}})
+		}
+		for _, a := range p.Applications {
+			appChanges, err := i.planApplication(p.Name, existing, a, withCtx)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, appChanges...)
+		}
+	}
+	return changes, nil
+}
+
+func (i *Importer) planApplication(propName string, prop *iam.Proposition, a Application, withCtx iam.OptionFunc) ([]Change, error) {
+	key := propName + "/" + a.Name
+	var existing *iam.Application
+	if prop != nil {
+		apps, err := getApplications(i.client, prop.ID, a.Name, withCtx)
+		if err != nil {
+			return nil, fmt.Errorf("application %s: %w", key, err)
+		}
+		for _, e := range apps {
+			if e.Name == a.Name {
+				existing = e
+				break
+			}
+		}
+	}
+	var changes []Change
+	if existing != nil {
+		id := existing.ID
+		changes = append(changes, Change{Action: ActionNoop, Kind: "application", Name: key,
+			apply: func(_ context.Context, st *state) error {
+				st.applications[key] = id
+				return nil
+			}})
+	} else {
+		changes = append(changes, Change{Action: ActionCreate, Kind: "application", Name: key,
+			apply: func(_ context.Context, st *state) error {
+				created, _, err := i.client.Applications.CreateApplication(iam.Application{
+					Name:              a.Name,
+					Description:       a.Description,
+					PropositionID:     st.propositions[propName],
+					GlobalReferenceID: a.GlobalReferenceID,
+				})
+				if err != nil {
+					return err
+				}
+				st.applications[key] = created.ID
+				return nil
+			}})
+	}
+	for _, svc := range a.Services {
+		change, err := i.planService(key, existing, svc, withCtx)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *change)
+	}
+	for _, c := range a.Clients {
+		change, err := i.planClient(key, existing, c, withCtx)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *change)
+	}
+	return changes, nil
+}
+
+func (i *Importer) planService(appKey string, app *iam.Application, svc Service, withCtx iam.OptionFunc) (*Change, error) {
+	name := appKey + "/" + svc.Name
+	var existing *iam.Service
+	if app != nil {
+		services, _, err := i.client.Services.GetServices(&iam.GetServiceOptions{ApplicationID: &app.ID, Name: &svc.Name}, withCtx)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		for _, e := range *services {
+			if e.Name == svc.Name {
+				existing = &e
+				break
+			}
+		}
+	}
+	if existing == nil {
+		// A supplied private key replaces the one IAM generates, an unresolved one is left to IAM
+		var privateKey *rsa.PrivateKey
+		pemKey, supplied := i.resolveSecret(svc.PrivateKey)
+		if supplied {
+			key, err := parsePrivateKey(pemKey)
+			if err != nil {
+				return nil, fmt.Errorf("private key of service %s: %w", name, err)
+			}
+			privateKey = key
+		}
+		return &Change{Action: ActionCreate, Kind: "service", Name: name,
+			apply: func(_ context.Context, st *state) error {
+				created, _, err := i.client.Services.CreateService(iam.Service{
+					Name:          svc.Name,
+					Description:   svc.Description,
+					ApplicationID: st.applications[appKey],
+					Validity:      svc.Validity,
+					Scopes:        svc.Scopes,
+					DefaultScopes: svc.DefaultScopes,
+				})
+				if err != nil {
+					return err
+				}
+				if privateKey == nil {
+					st.report.Secrets[ServicePrivateKeyVariable(svc.Name)] = created.PrivateKey
+					return nil
+				}
+				if _, _, err := i.client.Services.UpdateServiceCertificate(*created, privateKey); err != nil {
+					return fmt.Errorf("install private key: %w", err)
+				}
+				st.report.Secrets[ServicePrivateKeyVariable(svc.Name)] = pemKey
+				return nil
+			}}, nil
+	}
+	addScopes, removeScopes := difference(existing.Scopes, svc.Scopes)
+	addDefaults, removeDefaults := difference(existing.DefaultScopes, svc.DefaultScopes)
+	details := describe("scope", addScopes, removeScopes)
+	details = append(details, describe("default scope", addDefaults, removeDefaults)...)
+	updateDescription := existing.Description != svc.Description
+	if updateDescription {
+		details = append(details, "description")
+	}
+	// The validity of a service is that of its certificate, which is reissued with its
+	// private key. Zero leaves the validity alone
+	var privateKey *rsa.PrivateKey
+	updateValidity := svc.Validity != 0 && existing.Validity != svc.Validity
+	if updateValidity {
+		pemKey, supplied := i.resolveSecret(svc.PrivateKey)
+		if !supplied {
+			return nil, fmt.Errorf("validity of service %s: private key: %w", name, ErrUnresolvedSecret)
+		}
+		key, err := parsePrivateKey(pemKey)
+		if err != nil {
+			return nil, fmt.Errorf("private key of service %s: %w", name, err)
+		}
+		privateKey = key
+		details = append(details, fmt.Sprintf("validity %d -> %d months", existing.Validity, svc.Validity))
+	}
+	if len(details) == 0 {
+		return &Change{Action: ActionNoop, Kind: "service", Name: name}, nil
+	}
+	current := *existing
+	return &Change{Action: ActionUpdate, Kind: "service", Name: name, Details: details,
+		apply: func(_ context.Context, _ *state) error {
+			if len(addScopes) > 0 || len(addDefaults) > 0 {
+				if _, _, err := i.client.Services.AddScopes(current, addScopes, addDefaults); err != nil {
+					return err
+				}
+			}
+			if len(removeScopes) > 0 || len(removeDefaults) > 0 {
+				if _, _, err := i.client.Services.RemoveScopes(current, removeScopes, removeDefaults); err != nil {
+					return err
+				}
+			}
+			if updateDescription {
+				current.Description = svc.Description
+				if _, _, err := i.client.Services.UpdateService(current); err != nil {
+					return err
+				}
+			}
+			if updateValidity {
+				validFor := func(cert *x509.Certificate) error {
+					cert.NotAfter = cert.NotBefore.AddDate(0, svc.Validity, 0)
+					return nil
+				}
+				if _, _, err := i.client.Services.UpdateServiceCertificate(current, privateKey, validFor); err != nil {
+					return fmt.Errorf("reissue certificate: %w", err)
+				}
+			}
+			return nil
+		}}, nil
+}
+
+func (i *Importer) planClient(appKey string, app *iam.Application, c Client, withCtx iam.OptionFunc) (*Change, error) {
+	name := appKey + "/" + c.Name
+	var existing *iam.ApplicationClient
+	if app != nil {
+		clients, _, err := i.client.Clients.GetClients(&iam.GetClientsOptions{ApplicationID: &app.ID, Name: &c.Name}, withCtx)
+		if err != nil {
+			return nil, fmt.Errorf("client %s: %w", name, err)
+		}
+		for _, e := range *clients {
+			if e.Name == c.Name {
+				existing = &e
+				break
+			}
+		}
+	}
+	if existing == nil {
+		password, ok := i.resolveSecret(c.Password)
+		if !ok {
+			return nil, fmt.Errorf("password of client %s: %w", name, ErrUnresolvedSecret)
+		}
+		return &Change{Action: ActionCreate, Kind: "client", Name: name,
+			apply: func(_ context.Context, st *state) error {
+				_, _, err := i.client.Clients.CreateClient(iam.ApplicationClient{
+					ClientID:             c.ClientID,
+					Type:                 c.Type,
+					Name:                 c.Name,
+					Password:             password,
+					RedirectionURIs:      c.RedirectionURIs,
+					ResponseTypes:        c.ResponseTypes,
+					Scopes:               c.Scopes,
+					DefaultScopes:        c.DefaultScopes,
+					Disabled:             c.Disabled,
+					Description:          c.Description,
+					ApplicationID:        st.applications[appKey],
+					GlobalReferenceID:    c.GlobalReferenceID,
+					ConsentImplied:       c.ConsentImplied,
+					AccessTokenLifetime:  c.AccessTokenLifetime,
+					RefreshTokenLifetime: c.RefreshTokenLifetime,
+					IDTokenLifetime:      c.IDTokenLifetime,
+				})
+				return err
+			}}, nil
+	}
+	current := fromApplicationClient(*existing, c.Password)
+	addScopes, removeScopes := difference(current.Scopes, c.Scopes)
+	addDefaults, removeDefaults := difference(current.DefaultScopes, c.DefaultScopes)
+	updateScopes := len(addScopes)+len(removeScopes)+len(addDefaults)+len(removeDefaults) > 0
+	details := describe("scope", addScopes, removeScopes)
+	details = append(details, describe("default scope", addDefaults, removeDefaults)...)
+	current.Scopes, current.DefaultScopes = c.Scopes, c.DefaultScopes
+	updateClient := !reflect.DeepEqual(normalizeClient(current), normalizeClient(c))
+	if updateClient {
+		details = append(details, "properties")
+	}
+	if len(details) == 0 {
+		return &Change{Action: ActionNoop, Kind: "client", Name: name}, nil
+	}
+	updated := *existing
+	return &Change{Action: ActionUpdate, Kind: "client", Name: name, Details: details,
+		apply: func(_ context.Context, _ *state) error {
+			if updateClient {
+				updated.Type = c.Type
+				updated.RedirectionURIs = c.RedirectionURIs
+				updated.ResponseTypes = c.ResponseTypes
+				updated.Description = c.Description
+				updated.ConsentImplied = c.ConsentImplied
+				updated.Disabled = c.Disabled
+				updated.AccessTokenLifetime = c.AccessTokenLifetime
+				updated.RefreshTokenLifetime = c.RefreshTokenLifetime
+				updated.IDTokenLifetime = c.IDTokenLifetime
+				updated.Scopes, updated.DefaultScopes = nil, nil
+				if _, _, err := i.client.Clients.UpdateClient(updated); err != nil {
+					return err
+				}
+			}
+			if updateScopes {
+				if _, _, err := i.client.Clients.UpdateScopes(updated, c.Scopes, c.DefaultScopes); err != nil {
+					return err
+				}
+			}
+			return nil
+		}}, nil
+}
+
+func (i *Importer) planRoles(_ context.Context, s *Snapshot, orgID string, withCtx iam.OptionFunc) ([]Change, error) {
+	var changes []Change
+	for _, r := range s.Roles {
+		mapped := make([]SharingPolicy, 0, len(r.SharingPolicies))
+		for _, p := range r.SharingPolicies {
+			target, err := i.targetOrganization(s, orgID, p.TargetOrganizationID)
+			if err != nil {
+				return nil, fmt.Errorf("role %s: %w", r.Name, err)
+			}
+			p.TargetOrganizationID = target
+			mapped = append(mapped, p)
+		}
+		r.SharingPolicies = mapped
+		roles, _, err := i.client.Roles.GetRoles(&iam.GetRolesOptions{OrganizationID: &orgID, Name: &r.Name}, withCtx)
+		if err != nil {
+			return nil, fmt.Errorf("role %s: %w", r.Name, err)
+		}
+		var existing *iam.Role
+		for _, e := range *roles {
+			if e.Name == r.Name {
+				existing = &e
+				break
+			}
+		}
+		if existing == nil {
+			changes = append(changes, Change{Action: ActionCreate, Kind: "role", Name: r.Name,
+				Details: describe("permission", r.Permissions, nil),
+				apply: func(_ context.Context, st *state) error {
+					created, _, err := i.client.Roles.CreateRole(r.Name, r.Description, st.orgID)
+					if err != nil {
+						return err
+					}
+					st.roles[r.Name] = *created
+					return i.applyRole(*created, r.Permissions, nil, r.SharingPolicies)
+				}})
+			continue
+		}
+		role := *existing
+		permissions, _, err := i.client.Roles.GetRolePermissions(role, withCtx)
+		if err != nil {
+			return nil, fmt.Errorf("permissions of role %s: %w", r.Name, err)
+		}
+		var current []string
+		if permissions != nil {
+			current = *permissions
+		}
+		add, remove := difference(current, r.Permissions)
+		policies, _, err := i.client.Roles.ListSharingPolicies(role, nil)
+		if err != nil {
+			return nil, fmt.Errorf("sharing policies of role %s: %w", r.Name, err)
+		}
+		var missing []SharingPolicy
+		for _, desired := range r.SharingPolicies {
+			found := false
+			for _, p := range *policies {
+				if p.TargetOrganizationID == desired.TargetOrganizationID && p.SharingPolicy == desired.SharingPolicy {
+					found = true
+					break
+				}
+			}
+			if !found {
+				missing = append(missing, desired)
+			}
+		}
+		var extra []iam.RoleSharingPolicy
+		for _, p := range *policies {
+			found := false
+			for _, desired := range r.SharingPolicies {
+				if p.TargetOrganizationID == desired.TargetOrganizationID && p.SharingPolicy == desired.SharingPolicy {
+					found = true
+					break
+				}
+			}
+			if !found {
+				extra = append(extra, p)
+			}
+		}
+		details := describe("permission", add, remove)
+		for _, p := range missing {
+			details = append(details, "share with "+p.TargetOrganizationID)
+		}
+		for _, p := range extra {
+			details = append(details, "stop sharing with "+p.TargetOrganizationID)
+		}
+		action := ActionUpdate
+		if len(details) == 0 {
+			action = ActionNoop
+		}
+		changes = append(changes, Change{Action: action, Kind: "role", Name: r.Name, Details: details,
+			apply: func(_ context.Context, st *state) error {
+				st.roles[r.Name] = role
+				if err := i.applyRole(role, add, remove, missing); err != nil {
+					return err
+				}
+				for _, p := range extra {
+					if _, _, err := i.client.Roles.RemoveSharingPolicy(role, p); err != nil {
+						return err
+					}
+				}
+				return nil
+			}})
+	}
+	return changes, nil
+}
+
+func (i *Importer) applyRole(role iam.Role, add, remove []string, policies []SharingPolicy) error {
+	for _, p := range add {
+		if _, _, err := i.client.Roles.AddRolePermission(role, p); err != nil {
+			return err
+		}
+	}
+	for _, p := range remove {
+		if _, _, err := i.client.Roles.RemoveRolePermission(role, p); err != nil {
+			return err
+		}
+	}
+	for _, p := range policies {
+		if _, _, err := i.client.Roles.ApplySharingPolicy(role, iam.RoleSharingPolicy{
+			SharingPolicy:        p.SharingPolicy,
+			Purpose:              p.Purpose,
+			TargetOrganizationID: p.TargetOrganizationID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Importer) planGroups(_ context.Context, s *Snapshot, orgID string, withCtx iam.OptionFunc) ([]Change, error) {
+	known := make(map[string]bool)
+	for _, r := range s.Roles {
+		known[r.Name] = true
+	}
+	var changes []Change
+	for _, g := range s.Groups {
+		for _, r := range g.Roles {
+			if !known[r] {
+				return nil, fmt.Errorf("group %s role %s: %w", g.Name, r, ErrUnknownRole)
+			}
+		}
+		groups, _, err := i.client.Groups.GetGroups(&iam.GetGroupOptions{OrganizationID: &orgID, Name: &g.Name}, withCtx)
+		if err != nil {
+			return nil, fmt.Errorf("group %s: %w", g.Name, err)
+		}
+		var existing *iam.GroupResource
+		for _, e := range *groups {
+			if e.GroupName == g.Name {
+				existing = &e
+				break
+			}
+		}
+		if existing == nil {
+			changes = append(changes, Change{Action: ActionCreate, Kind: "group", Name: g.Name,
+				Details: describe("role", g.Roles, nil),
+				apply: func(ctx context.Context, st *state) error {
+					created, _, err := i.client.Groups.CreateGroup(iam.Group{
+						Name:                 g.Name,
+						Description:          g.Description,
+						ManagingOrganization: st.orgID,
+					})
+					if err != nil {
+						return err
+					}
+					return i.applyGroup(ctx, st, *created, g.Roles, nil)
+				}})
+			continue
+		}
+		group := iam.Group{
+			ID:                   existing.ID,
+			Name:                 existing.GroupName,
+			Description:          existing.GroupDescription,
+			ManagingOrganization: existing.OrgID,
+		}
+		roles, _, err := i.client.Groups.GetRoles(group, withCtx)
+		if err != nil {
+			return nil, fmt.Errorf("roles of group %s: %w", g.Name, err)
+		}
+		var current []string
+		for _, r := range *roles {
+			current = append(current, r.Name)
+		}
+		add, remove := difference(current, g.Roles)
+		details := describe("role", add, remove)
+		updateDescription := group.Description != g.Description
+		if updateDescription {
+			details = append(details, "description")
+		}
+		action := ActionUpdate
+		if len(details) == 0 {
+			action = ActionNoop
+		}
+		currentRoles := *roles
+		changes = append(changes, Change{Action: action, Kind: "group", Name: g.Name, Details: details,
+			apply: func(ctx context.Context, st *state) error {
+				if updateDescription {
+					group.Description = g.Description
+					if _, _, err := i.client.Groups.UpdateGroup(group); err != nil {
+						return err
+					}
+				}
+				var removeRoles []iam.Role
+				for _, r := range currentRoles {
+					if contains(remove, r.Name) {
+						removeRoles = append(removeRoles, r)
+					}
+				}
+				return i.applyGroup(ctx, st, group, add, removeRoles)
+			}})
+	}
+	return changes, nil
+}
+
+func (i *Importer) applyGroup(ctx context.Context, st *state, group iam.Group, add []string, remove []iam.Role) error {
+	for _, name := range add {
+		role, ok := st.roles[name]
+		if !ok {
+			return fmt.Errorf("%s: %w", name, ErrUnknownRole)
+		}
+		if _, _, err := i.client.Groups.AssignRole(ctx, group, role); err != nil {
+			return err
+		}
+	}
+	for _, role := range remove {
+		if _, _, err := i.client.Groups.RemoveRole(ctx, group, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Importer) planPasswordPolicies(_ context.Context, s *Snapshot, orgID string, withCtx iam.OptionFunc) ([]Change, error) {
+	if len(s.PasswordPolicies) == 0 {
+		return nil, nil
+	}
+	desired := s.PasswordPolicies[0]
+	policies, _, err := i.client.PasswordPolicies.GetPasswordPolicies(&iam.GetPasswordPolicyOptions{OrganizationID: &orgID}, withCtx)
+	if err != nil {
+		return nil, fmt.Errorf("password policies: %w", err)
+	}
+	if len(*policies) == 0 {
+		return []Change{{Action: ActionCreate, Kind: "passwordPolicy", Name: orgID,
+			apply: func(_ context.Context, st *state) error {
+				_, _, err := i.client.PasswordPolicies.CreatePasswordPolicy(toPasswordPolicy(desired, st.orgID))
+				return err
+			}}}, nil
+	}
+	existing := (*policies)[0]
+	if reflect.DeepEqual(fromPasswordPolicy(existing), desired) {
+		return []Change{{Action: ActionNoop, Kind: "passwordPolicy", Name: orgID}}, nil
+	}
+	return []Change{{Action: ActionUpdate, Kind: "passwordPolicy", Name: orgID,
+		apply: func(_ context.Context, st *state) error {
+			policy := toPasswordPolicy(desired, st.orgID)
+			policy.ID = existing.ID
+			policy.Meta = existing.Meta
+			_, _, err := i.client.PasswordPolicies.UpdatePasswordPolicy(policy)
+			return err
+		}}}, nil
+}
+
+func (i *Importer) planMFAPolicies(_ context.Context, s *Snapshot, orgID string, withCtx iam.OptionFunc) ([]Change, error) {
+	if len(s.MFAPolicies) == 0 {
+		return nil, nil
+	}
+	policies, _, err := i.client.MFAPolicies.GetMFAPolicies(iam.MFAPolicyFilterOrg(orgID), withCtx)
+	if err != nil {
+		return nil, fmt.Errorf("MFA policies: %w", err)
+	}
+	var changes []Change
+	for _, p := range s.MFAPolicies {
+		var existing *iam.MFAPolicy
+		for _, e := range *policies {
+			if e.Name == p.Name {
+				existing = &e
+				break
+			}
+		}
+		if existing == nil {
+			changes = append(changes, Change{Action: ActionCreate, Kind: "mfaPolicy", Name: p.Name,
+				apply: func(_ context.Context, st *state) error {
+					policy := iam.MFAPolicy{
+						Name:        p.Name,
+						Description: p.Description,
+						Types:       p.Types,
+					}
+					policy.SetResourceOrganization(st.orgID)
+					created, _, err := i.client.MFAPolicies.CreateMFAPolicy(policy)
+					if err != nil {
+						return err
+					}
+					if !p.Active {
+						created.SetActive(false)
+						_, _, err = i.client.MFAPolicies.UpdateMFAPolicy(created)
+					}
+					return err
+				}})
+			continue
+		}
+		if reflect.DeepEqual(normalizeMFAPolicy(fromMFAPolicy(*existing)), normalizeMFAPolicy(p)) {
+			changes = append(changes, Change{Action: ActionNoop, Kind: "mfaPolicy", Name: p.Name})
+			continue
+		}
+		policy := *existing
+		changes = append(changes, Change{Action: ActionUpdate, Kind: "mfaPolicy", Name: p.Name,
+			apply: func(_ context.Context, _ *state) error {
+				policy.Description = p.Description
+				policy.Types = p.Types
+				policy.SetActive(p.Active)
+				_, _, err := i.client.MFAPolicies.UpdateMFAPolicy(&policy)
+				return err
+			}})
+	}
+	return changes, nil
+}
+
+func (i *Importer) planEmailTemplates(_ context.Context, s *Snapshot, orgID string, withCtx iam.OptionFunc) ([]Change, error) {
+	if len(s.EmailTemplates) == 0 {
+		return nil, nil
+	}
+	templates, err := getEmailTemplates(i.client, orgID, withCtx)
+	if err != nil {
+		return nil, fmt.Errorf("email templates: %w", err)
+	}
+	var changes []Change
+	for _, t := range s.EmailTemplates {
+		key := templateKey(t.Type, t.Locale)
+		var existing *iam.EmailTemplate
+		for _, e := range templates {
+			if templateKey(e.Type, e.Locale) == key {
+				existing = &e
+				break
+			}
+		}
+		create := func(_ context.Context, st *state) error {
+			_, _, err := i.client.EmailTemplates.CreateTemplate(iam.EmailTemplate{
+				Type:                 t.Type,
+				ManagingOrganization: st.orgID,
+				From:                 t.From,
+				Format:               t.Format,
+				Locale:               t.Locale,
+				Subject:              t.Subject,
+				Message:              t.Message,
+				Link:                 t.Link,
+			})
+			return err
+		}
+		switch {
+		case existing == nil:
+			changes = append(changes, Change{Action: ActionCreate, Kind: "emailTemplate", Name: key, apply: create})
+		case fromEmailTemplate(*existing) == t:
+			changes = append(changes, Change{Action: ActionNoop, Kind: "emailTemplate", Name: key})
+		default:
+			// Email templates cannot be updated in place
+			current := *existing
+			changes = append(changes, Change{Action: ActionReplace, Kind: "emailTemplate", Name: key,
+				apply: func(ctx context.Context, st *state) error {
+					if _, _, err := i.client.EmailTemplates.DeleteTemplate(current); err != nil {
+						return err
+					}
+					return create(ctx, st)
+				}})
+		}
+	}
+	return changes, nil
+}
+
+func (i *Importer) planSMSTemplates(_ context.Context, s *Snapshot, orgID string, withCtx iam.OptionFunc) ([]Change, error) {
+	if len(s.SMSTemplates) == 0 {
+		return nil, nil
+	}
+	templates, _, err := i.client.SMSTemplates.GetSMSTemplates(iam.SMSTemplateFilterOrg(orgID), withCtx)
+	if err != nil && !errors.Is(err, iam.ErrNotFound) {
+		return nil, fmt.Errorf("SMS templates: %w", err)
+	}
+	var changes []Change
+	for _, t := range s.SMSTemplates {
+		key := templateKey(t.Type, t.Locale)
+		var existing *iam.SMSTemplate
+		if templates != nil {
+			for _, e := range *templates {
+				if templateKey(e.Type, e.Locale) == key {
+					existing = &e
+					break
+				}
+			}
+		}
+		switch {
+		case existing == nil:
+			changes = append(changes, Change{Action: ActionCreate, Kind: "smsTemplate", Name: key,
+				apply: func(_ context.Context, st *state) error {
+					_, _, err := i.client.SMSTemplates.CreateSMSTemplate(iam.SMSTemplate{
+						Organization: iam.OrganizationValue{Value: st.orgID},
+						Type:         t.Type,
+						Message:      t.Message,
+						Locale:       t.Locale,
+					})
+					return err
+				}})
+		case existing.Message == t.Message:
+			changes = append(changes, Change{Action: ActionNoop, Kind: "smsTemplate", Name: key})
+		default:
+			current := *existing
+			changes = append(changes, Change{Action: ActionUpdate, Kind: "smsTemplate", Name: key,
+				apply: func(_ context.Context, _ *state) error {
+					current.Message = t.Message
+					_, _, err := i.client.SMSTemplates.UpdateSMSTemplate(current)
+					return err
+				}})
+		}
+	}
+	return changes, nil
+}
+
+// parsePrivateKey decodes a PEM encoded PKCS#1 or PKCS#8 RSA private key
+func parsePrivateKey(value string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(value))
+	if block == nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an RSA key", ErrInvalidPrivateKey)
+	}
+	return rsaKey, nil
+}
+
+func (i *Importer) resolveSecret(value string) (string, bool) {
+	if value == "" || value == Redacted {
+		return "", false
+	}
+	if m := placeholderRegex.FindStringSubmatch(value); m != nil {
+		return i.secrets(m[1])
+	}
+	return value, true
+}
+
+func toPasswordPolicy(p PasswordPolicy, orgID string) iam.PasswordPolicy {
+	policy := iam.PasswordPolicy{
+		ManagingOrganization: orgID,
+		ExpiryPeriodInDays:   p.ExpiryPeriodInDays,
+		HistoryCount:         p.HistoryCount,
+		ChallengesEnabled:    p.ChallengesEnabled,
+	}
+	policy.Complexity.MinLength = p.MinLength
+	policy.Complexity.MaxLength = p.MaxLength
+	policy.Complexity.MinNumerics = p.MinNumerics
+	policy.Complexity.MinUpperCase = p.MinUpperCase
+	policy.Complexity.MinLowerCase = p.MinLowerCase
+	policy.Complexity.MinSpecialChars = p.MinSpecialChars
+	if p.ChallengePolicy != nil {
+		policy.ChallengePolicy = &iam.ChallengePolicy{
+			DefaultQuestions:     p.ChallengePolicy.DefaultQuestions,
+			MinQuestionCount:     p.ChallengePolicy.MinQuestionCount,
+			MinAnswerCount:       p.ChallengePolicy.MinAnswerCount,
+			MaxIncorrectAttempts: p.ChallengePolicy.MaxIncorrectAttempts,
+		}
+	}
+	return policy
+}
+
+func normalizeClient(c Client) Client {
+	c.Password = ""
+	c.Scopes = sortedCopy(c.Scopes)
+	c.DefaultScopes = sortedCopy(c.DefaultScopes)
+	c.RedirectionURIs = sortedCopy(c.RedirectionURIs)
+	c.ResponseTypes = sortedCopy(c.ResponseTypes)
+	return c
+}
+
+func normalizeMFAPolicy(p MFAPolicy) MFAPolicy {
+	p.Types = sortedCopy(p.Types)
+	return p
+}
+
+func sortedCopy(list []string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	out := append([]string{}, list...)
+	sort.Strings(out)
+	return out
+}
+
+// difference returns the elements to add to and remove from current to get desired
+func difference(current, desired []string) (add, remove []string) {
+	for _, d := range desired {
+		if !contains(current, d) {
+			add = append(add, d)
+		}
+	}
+	for _, c := range current {
+		if !contains(desired, c) {
+			remove = append(remove, c)
+		}
+	}
+	return add, remove
+}
+
+func describe(kind string, add, remove []string) []string {
+	var details []string
+	for _, a := range add {
+		details = append(details, "add "+kind+" "+a)
+	}
+	for _, r := range remove {
+		details = append(details, "remove "+kind+" "+r)
+	}
+	return details
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,279 @@
+// Package snapshot exports the IAM configuration of an organization to a
+// deterministic YAML or JSON document and imports it again into another organization
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the version of the snapshot format written by Export
+const CurrentVersion = 1
+
+// Format is the serialization format of a Snapshot
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// Snapshot describes the IAM configuration of an organization by names
+type Snapshot struct {
+	Version          int              `json:"version" yaml:"version"`
+	OrganizationID   string           `json:"organizationId,omitempty" yaml:"organizationId,omitempty"`
+	Propositions     []Proposition    `json:"propositions,omitempty" yaml:"propositions,omitempty"`
+	Roles            []Role           `json:"roles,omitempty" yaml:"roles,omitempty"`
+	Groups           []Group          `json:"groups,omitempty" yaml:"groups,omitempty"`
+	PasswordPolicies []PasswordPolicy `json:"passwordPolicies,omitempty" yaml:"passwordPolicies,omitempty"`
+	MFAPolicies      []MFAPolicy      `json:"mfaPolicies,omitempty" yaml:"mfaPolicies,omitempty"`
+	EmailTemplates   []EmailTemplate  `json:"emailTemplates,omitempty" yaml:"emailTemplates,omitempty"`
+	SMSTemplates     []SMSTemplate    `json:"smsTemplates,omitempty" yaml:"smsTemplates,omitempty"`
+}
+
+// Proposition describes an IAM proposition and its applications
+type Proposition struct {
+	Name              string        `json:"name" yaml:"name"`
+	Description       string        `json:"description,omitempty" yaml:"description,omitempty"`
+	GlobalReferenceID string        `json:"globalReferenceId" yaml:"globalReferenceId"`
+	Applications      []Application `json:"applications,omitempty" yaml:"applications,omitempty"`
+}
+
+// Application describes an IAM application with its services and OAuth clients
+type Application struct {
+	Name              string    `json:"name" yaml:"name"`
+	Description       string    `json:"description,omitempty" yaml:"description,omitempty"`
+	GlobalReferenceID string    `json:"globalReferenceId" yaml:"globalReferenceId"`
+	Services          []Service `json:"services,omitempty" yaml:"services,omitempty"`
+	Clients           []Client  `json:"clients,omitempty" yaml:"clients,omitempty"`
+}
+
+// Service describes an IAM service identity. The PrivateKey is never exported. When it
+// resolves on import, the key is installed on newly created services instead of the
+// one IAM generates
+type Service struct {
+	Name          string   `json:"name" yaml:"name"`
+	Description   string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Validity      int      `json:"validity,omitempty" yaml:"validity,omitempty"`
+	Scopes        []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	DefaultScopes []string `json:"defaultScopes,omitempty" yaml:"defaultScopes,omitempty"`
+	PrivateKey    string   `json:"privateKey,omitempty" yaml:"privateKey,omitempty"`
+}
+
+// Client describes an IAM OAuth client. The Password is never exported
+type Client struct {
+	ClientID             string   `json:"clientId" yaml:"clientId"`
+	Name                 string   `json:"name" yaml:"name"`
+	Type                 string   `json:"type" yaml:"type"`
+	Password             string   `json:"password,omitempty" yaml:"password,omitempty"`
+	Description          string   `json:"description,omitempty" yaml:"description,omitempty"`
+	GlobalReferenceID    string   `json:"globalReferenceId" yaml:"globalReferenceId"`
+	RedirectionURIs      []string `json:"redirectionURIs,omitempty" yaml:"redirectionURIs,omitempty"`
+	ResponseTypes        []string `json:"responseTypes,omitempty" yaml:"responseTypes,omitempty"`
+	Scopes               []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	DefaultScopes        []string `json:"defaultScopes,omitempty" yaml:"defaultScopes,omitempty"`
+	ConsentImplied       bool     `json:"consentImplied,omitempty" yaml:"consentImplied,omitempty"`
+	Disabled             bool     `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	AccessTokenLifetime  int      `json:"accessTokenLifetime,omitempty" yaml:"accessTokenLifetime,omitempty"`
+	RefreshTokenLifetime int      `json:"refreshTokenLifetime,omitempty" yaml:"refreshTokenLifetime,omitempty"`
+	IDTokenLifetime      int      `json:"idTokenLifetime,omitempty" yaml:"idTokenLifetime,omitempty"`
+}
+
+// Role describes an IAM role with its permissions and sharing policies
+type Role struct {
+	Name            string          `json:"name" yaml:"name"`
+	Description     string          `json:"description,omitempty" yaml:"description,omitempty"`
+	Permissions     []string        `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+	SharingPolicies []SharingPolicy `json:"sharingPolicies,omitempty" yaml:"sharingPolicies,omitempty"`
+}
+
+// SharingPolicy describes a role sharing policy
+type SharingPolicy struct {
+	TargetOrganizationID string `json:"targetOrganizationId" yaml:"targetOrganizationId"`
+	SharingPolicy        string `json:"sharingPolicy" yaml:"sharingPolicy"`
+	Purpose              string `json:"purpose,omitempty" yaml:"purpose,omitempty"`
+}
+
+// Group describes an IAM group and the names of the roles assigned to it
+type Group struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Roles       []string `json:"roles,omitempty" yaml:"roles,omitempty"`
+}
+
+// PasswordPolicy describes the password policy of the organization
+type PasswordPolicy struct {
+	ExpiryPeriodInDays int                 `json:"expiryPeriodInDays" yaml:"expiryPeriodInDays"`
+	HistoryCount       int                 `json:"historyCount" yaml:"historyCount"`
+	MinLength          int                 `json:"minLength" yaml:"minLength"`
+	MaxLength          int                 `json:"maxLength" yaml:"maxLength"`
+	MinNumerics        int                 `json:"minNumerics" yaml:"minNumerics"`
+	MinUpperCase       int                 `json:"minUpperCase" yaml:"minUpperCase"`
+	MinLowerCase       int                 `json:"minLowerCase" yaml:"minLowerCase"`
+	MinSpecialChars    int                 `json:"minSpecialChars" yaml:"minSpecialChars"`
+	ChallengesEnabled  bool                `json:"challengesEnabled,omitempty" yaml:"challengesEnabled,omitempty"`
+	ChallengePolicy    *PasswordChallenges `json:"challengePolicy,omitempty" yaml:"challengePolicy,omitempty"`
+}
+
+// PasswordChallenges describes the challenge questions of a password policy
+type PasswordChallenges struct {
+	DefaultQuestions     []string `json:"defaultQuestions,omitempty" yaml:"defaultQuestions,omitempty"`
+	MinQuestionCount     int      `json:"minQuestionCount" yaml:"minQuestionCount"`
+	MinAnswerCount       int      `json:"minAnswerCount" yaml:"minAnswerCount"`
+	MaxIncorrectAttempts int      `json:"maxIncorrectAttempts" yaml:"maxIncorrectAttempts"`
+}
+
+// MFAPolicy describes an organization level MFA policy
+type MFAPolicy struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Types       []string `json:"types" yaml:"types"`
+	Active      bool     `json:"active" yaml:"active"`
+}
+
+// EmailTemplate describes an email template, identified by Type and Locale
+type EmailTemplate struct {
+	Type    string `json:"type" yaml:"type"`
+	Locale  string `json:"locale,omitempty" yaml:"locale,omitempty"`
+	Format  string `json:"format" yaml:"format"`
+	From    string `json:"from,omitempty" yaml:"from,omitempty"`
+	Subject string `json:"subject" yaml:"subject"`
+	Message string `json:"message" yaml:"message"`
+	Link    string `json:"link,omitempty" yaml:"link,omitempty"`
+}
+
+// SMSTemplate describes an SMS template, identified by Type and Locale
+type SMSTemplate struct {
+	Type    string `json:"type" yaml:"type"`
+	Locale  string `json:"locale,omitempty" yaml:"locale,omitempty"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// Normalize sorts all collections so serialized snapshots are deterministic
+func (s *Snapshot) Normalize() {
+	sort.Slice(s.Propositions, func(i, j int) bool { return s.Propositions[i].Name < s.Propositions[j].Name })
+	for i := range s.Propositions {
+		apps := s.Propositions[i].Applications
+		sort.Slice(apps, func(i, j int) bool { return apps[i].Name < apps[j].Name })
+		for j := range apps {
+			services := apps[j].Services
+			sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+			for k := range services {
+				sort.Strings(services[k].Scopes)
+				sort.Strings(services[k].DefaultScopes)
+			}
+			clients := apps[j].Clients
+			sort.Slice(clients, func(i, j int) bool { return clients[i].Name < clients[j].Name })
+			for k := range clients {
+				sort.Strings(clients[k].Scopes)
+				sort.Strings(clients[k].DefaultScopes)
+			}
+		}
+	}
+	sort.Slice(s.Roles, func(i, j int) bool { return s.Roles[i].Name < s.Roles[j].Name })
+	for i := range s.Roles {
+		sort.Strings(s.Roles[i].Permissions)
+		policies := s.Roles[i].SharingPolicies
+		sort.Slice(policies, func(i, j int) bool { return policies[i].TargetOrganizationID < policies[j].TargetOrganizationID })
+	}
+	sort.Slice(s.Groups, func(i, j int) bool { return s.Groups[i].Name < s.Groups[j].Name })
+	for i := range s.Groups {
+		sort.Strings(s.Groups[i].Roles)
+	}
+	sort.Slice(s.MFAPolicies, func(i, j int) bool { return s.MFAPolicies[i].Name < s.MFAPolicies[j].Name })
+	sort.Slice(s.EmailTemplates, func(i, j int) bool {
+		return templateKey(s.EmailTemplates[i].Type, s.EmailTemplates[i].Locale) < templateKey(s.EmailTemplates[j].Type, s.EmailTemplates[j].Locale)
+	})
+	sort.Slice(s.SMSTemplates, func(i, j int) bool {
+		return templateKey(s.SMSTemplates[i].Type, s.SMSTemplates[i].Locale) < templateKey(s.SMSTemplates[j].Type, s.SMSTemplates[j].Locale)
+	})
+}
+
+// Write serializes the normalized snapshot in the given format
+func (s *Snapshot) Write(w io.Writer, format Format) error {
+	s.Normalize()
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(s); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// Read parses a snapshot in the given format
+func Read(r io.Reader, format Format) (*Snapshot, error) {
+	var s Snapshot
+	switch format {
+	case FormatJSON:
+		if err := json.NewDecoder(r).Decode(&s); err != nil {
+			return nil, err
+		}
+	case FormatYAML:
+		if err := yaml.NewDecoder(r).Decode(&s); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	if s.Version > CurrentVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, s.Version)
+	}
+	return &s, nil
+}
+
+func templateKey(templateType, locale string) string {
+	return templateType + "/" + locale
+}
+
+// SecretMode determines how secrets are written by Export
+type SecretMode int
+
+const (
+	// RedactSecrets replaces secrets with the Redacted marker
+	RedactSecrets SecretMode = iota
+	// TemplateSecrets replaces secrets with ${NAME} placeholders which are resolved on import
+	TemplateSecrets
+)
+
+// Redacted is the marker written in place of redacted secrets
+const Redacted = "<redacted>"
+
+var (
+	placeholderRegex = regexp.MustCompile(`^\$\{([A-Za-z0-9_]+)\}$`)
+	nonAlphaNumRegex = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// ClientPasswordVariable returns the template variable name of a client password
+func ClientPasswordVariable(clientID string) string {
+	return "CLIENT_" + variablePart(clientID) + "_PASSWORD"
+}
+
+// ServicePrivateKeyVariable returns the template variable name of a service private key
+func ServicePrivateKeyVariable(serviceName string) string {
+	return "SERVICE_" + variablePart(serviceName) + "_PRIVATE_KEY"
+}
+
+func variablePart(name string) string {
+	return strings.Trim(strings.ToUpper(nonAlphaNumRegex.ReplaceAllString(name, "_")), "_")
+}
+
+func secretValue(mode SecretMode, variable string) string {
+	if mode == TemplateSecrets {
+		return "${" + variable + "}"
+	}
+	return Redacted
+}
@@ -0,0 +1,405 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/iam"
+	"github.com/dip-software/go-dip-api/iam/snapshot"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	muxIAM    *http.ServeMux
+	serverIAM *httptest.Server
+	muxIDM    *http.ServeMux
+	serverIDM *httptest.Server
+
+	iamClient *iam.Client
+
+	// sharingPolicies is the $list-sharing-policies response of the ADMIN role
+	sharingPolicies string
+)
+
+const (
+	orgID     = "c57b2625-eda3-4b27-a8e6-86f0a0e76afc"
+	propID    = "8e4e7b7e-3e47-4c2f-8a4c-1f2b7b2b6a01"
+	appID     = "0b8d2b8e-2b6b-4b9a-9c43-5a1f0b0f7a02"
+	roleID    = "dbf1d779-ab9f-4c27-b4aa-ea75f9efbbc0"
+	groupID   = "f5fe538f-c3b5-4454-8774-cd3789f59b9f"
+	newGroup  = "7d0a3c55-8a23-4f0f-a0b2-0d3f2cfc9f99"
+	serviceID = "a2c9b9b2-5f2c-4c8d-9f0e-0f6a0e3b1a03"
+)
+
+func setup(t *testing.T) func() {
+	muxIAM = http.NewServeMux()
+	serverIAM = httptest.NewServer(muxIAM)
+	muxIDM = http.NewServeMux()
+	serverIDM = httptest.NewServer(muxIDM)
+
+	var err error
+
+	iamClient, err = iam.NewClient(nil, &iam.Config{
+		OAuth2ClientID: "TestClient",
+		OAuth2Secret:   "Secret",
+		IAMURL:         serverIAM.URL,
+		IDMURL:         serverIDM.URL,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create iamClient: %v", err)
+	}
+	iamClient.SetToken("44d20214-7879-4e35-923d-f9d4e01c9746")
+	sharingPolicies = `{"total": 0, "entry": []}`
+
+	writeJSON := func(w http.ResponseWriter, body string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, body)
+	}
+	muxIDM.HandleFunc("/authorize/identity/Proposition", func(w http.ResponseWriter, r *http.Request) {
+		if name := r.URL.Query().Get("name"); name != "" && name != "PROP" {
+			writeJSON(w, `{"total": 0, "entry": []}`)
+			return
+		}
+		writeJSON(w, `{"total": 1, "entry": [{"id": "`+propID+`", "name": "PROP", "description": "Proposition", "organizationId": "`+orgID+`", "globalReferenceId": "prop-ref"}]}`)
+	})
+	muxIDM.HandleFunc("/authorize/identity/Application", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"total": 1, "entry": [{"id": "`+appID+`", "name": "APP", "propositionId": "`+propID+`", "globalReferenceId": "app-ref"}]}`)
+	})
+	muxIDM.HandleFunc("/authorize/identity/Service", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"total": 1, "entry": [{"id": "`+serviceID+`", "name": "svc", "applicationId": "`+appID+`", "scopes": ["openid", "cn"], "defaultScopes": ["cn"]}]}`)
+	})
+	muxIDM.HandleFunc("/authorize/identity/Client", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"total": 1, "entry": [{"id": "c1", "clientId": "myclient", "name": "My Client", "type": "Public", "applicationId": "`+appID+`", "globalReferenceId": "client-ref", "scopes": ["cn"], "defaultScopes": ["cn"], "redirectionURIs": ["https://example.com"], "responseTypes": ["code"]}]}`)
+	})
+	muxIDM.HandleFunc("/authorize/identity/Role", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"total": 1, "entry": [{"id": "`+roleID+`", "name": "ADMIN", "description": "Admin role", "managingOrganization": "`+orgID+`"}]}`)
+	})
+	muxIDM.HandleFunc("/authorize/identity/Role/"+roleID+"/$list-sharing-policies", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, sharingPolicies)
+	})
+	muxIDM.HandleFunc("/authorize/identity/Permission", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"total": 2, "entry": [{"name": "USER.READ"}, {"name": "GROUP.WRITE"}]}`)
+	})
+	muxIDM.HandleFunc("/authorize/identity/Group", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"id": "`+newGroup+`", "name": "READERS", "managingOrganization": "`+orgID+`"}`)
+			return
+		}
+		if name := r.URL.Query().Get("name"); name != "" && name != "ADMINS" {
+			writeJSON(w, `{"total": 0, "entry": []}`)
+			return
+		}
+		writeJSON(w, `{"total": 1, "entry": [{"resource": {"_id": "`+groupID+`", "groupName": "ADMINS", "groupDescription": "Admins", "orgId": "`+orgID+`"}}]}`)
+	})
+	muxIDM.HandleFunc("/authorize/identity/PasswordPolicy", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"total": 1, "entry": [{"id": "pp1", "managingOrganization": "`+orgID+`", "expiryPeriodInDays": 90, "historyCount": 5, "complexity": {"minLength": 8, "maxLength": 32, "minNumerics": 1, "minUpperCase": 1, "minLowerCase": 1, "minSpecialChars": 1}}]}`)
+	})
+	muxIDM.HandleFunc("/authorize/scim/v2/MFAPolicies", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"totalResults": 1, "Resources": [{"id": "mfa1", "name": "OrgMFA", "resource": {"type": "Organization", "value": "`+orgID+`"}, "types": ["SOFT_OTP"], "active": true}]}`)
+	})
+	muxIDM.HandleFunc("/authorize/identity/EmailTemplate", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"total": 0, "entry": []}`)
+	})
+	muxIDM.HandleFunc("/authorize/scim/v2/Configurations/SMSTemplate", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"totalResults": 1, "Resources": [{"id": "sms1", "organization": {"value": "`+orgID+`"}, "type": "PHONE_VERIFICATION", "message": "SGVsbG8=", "locale": "en-US"}]}`)
+	})
+
+	return func() {
+		serverIAM.Close()
+		serverIDM.Close()
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	s, err := snapshot.Export(context.Background(), iamClient, orgID, &snapshot.ExportOptions{
+		Secrets: snapshot.TemplateSecrets,
+	})
+	if !assert.Nil(t, err) || !assert.NotNil(t, s) {
+		return
+	}
+	if !assert.Len(t, s.Propositions, 1) || !assert.Len(t, s.Propositions[0].Applications, 1) {
+		return
+	}
+	app := s.Propositions[0].Applications[0]
+	if assert.Len(t, app.Services, 1) {
+		assert.Equal(t, []string{"cn", "openid"}, app.Services[0].Scopes)
+		assert.Equal(t, "${SERVICE_SVC_PRIVATE_KEY}", app.Services[0].PrivateKey)
+	}
+	if assert.Len(t, app.Clients, 1) {
+		assert.Equal(t, "${CLIENT_MYCLIENT_PASSWORD}", app.Clients[0].Password)
+	}
+	if assert.Len(t, s.Roles, 1) {
+		assert.Equal(t, []string{"GROUP.WRITE", "USER.READ"}, s.Roles[0].Permissions)
+	}
+	if assert.Len(t, s.Groups, 1) {
+		assert.Equal(t, []string{"ADMIN"}, s.Groups[0].Roles)
+	}
+	assert.Len(t, s.PasswordPolicies, 1)
+	assert.Len(t, s.MFAPolicies, 1)
+	assert.Len(t, s.SMSTemplates, 1)
+	assert.Len(t, s.EmailTemplates, 0)
+
+	for _, format := range []snapshot.Format{snapshot.FormatYAML, snapshot.FormatJSON} {
+		var first, second bytes.Buffer
+		if !assert.Nil(t, s.Write(&first, format)) {
+			return
+		}
+		read, err := snapshot.Read(bytes.NewReader(first.Bytes()), format)
+		if !assert.Nil(t, err) {
+			return
+		}
+		assert.Equal(t, s, read)
+		_ = read.Write(&second, format)
+		assert.Equal(t, first.String(), second.String())
+	}
+
+	redacted, err := snapshot.Export(context.Background(), iamClient, orgID, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, snapshot.Redacted, redacted.Propositions[0].Applications[0].Clients[0].Password)
+	}
+}
+
+func TestPlanAndApply(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	var assigned, permissionsAdded []string
+	muxIDM.HandleFunc("/authorize/identity/Role/"+roleID+"/$assign-permission", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		permissionsAdded = append(permissionsAdded, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, `{}`)
+	})
+	muxIDM.HandleFunc("/authorize/identity/Group/"+newGroup+"/$assign-role", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assigned = append(assigned, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, `{}`)
+	})
+
+	s, err := snapshot.Export(context.Background(), iamClient, orgID, &snapshot.ExportOptions{
+		Secrets: snapshot.TemplateSecrets,
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	importer, err := snapshot.NewImporter(iamClient, nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+	plan, err := importer.Plan(context.Background(), s, orgID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.False(t, plan.HasChanges(), plan.String())
+
+	s.Roles[0].Permissions = append(s.Roles[0].Permissions, "DEVICE.READ")
+	s.Groups = append(s.Groups, snapshot.Group{Name: "READERS", Roles: []string{"ADMIN"}})
+	plan, err = importer.Plan(context.Background(), s, orgID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, plan.HasChanges())
+	assert.Contains(t, plan.String(), `~ update role "ADMIN" (add permission DEVICE.READ)`)
+	assert.Contains(t, plan.String(), `+ create group "READERS" (add role ADMIN)`)
+
+	report, err := plan.Apply(context.Background())
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, report.Applied, 2)
+	assert.Len(t, permissionsAdded, 1)
+	assert.True(t, strings.Contains(permissionsAdded[0], "DEVICE.READ"))
+	if assert.Len(t, assigned, 1) {
+		assert.True(t, strings.Contains(assigned[0], roleID))
+	}
+}
+
+func TestPlanUnresolvedSecret(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	s := &snapshot.Snapshot{
+		Version: snapshot.CurrentVersion,
+		Propositions: []snapshot.Proposition{{
+			Name: "NEWPROP",
+			Applications: []snapshot.Application{{
+				Name: "NEWAPP",
+				Clients: []snapshot.Client{{
+					ClientID: "newclient",
+					Name:     "New Client",
+					Password: "${CLIENT_NEWCLIENT_PASSWORD}",
+				}},
+			}},
+		}},
+	}
+	importer, _ := snapshot.NewImporter(iamClient, &snapshot.ImportOptions{
+		Secrets: func(name string) (string, bool) { return "", false },
+	})
+	_, err := importer.Plan(context.Background(), s, orgID)
+	assert.True(t, errors.Is(err, snapshot.ErrUnresolvedSecret))
+
+	importer, _ = snapshot.NewImporter(iamClient, &snapshot.ImportOptions{
+		Secrets: func(name string) (string, bool) {
+			return "Secret123!", name == "CLIENT_NEWCLIENT_PASSWORD"
+		},
+	})
+	plan, err := importer.Plan(context.Background(), s, orgID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, `+ create proposition "NEWPROP"
++ create application "NEWPROP/NEWAPP"
++ create client "NEWPROP/NEWAPP/New Client"
+`, plan.String())
+}
+
+func TestPlanRemovesStaleSharingPolicies(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	var removed []string
+	muxIDM.HandleFunc("/authorize/identity/Role/"+roleID+"/$remove-sharing-policy", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		removed = append(removed, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, `{}`)
+	})
+
+	s, err := snapshot.Export(context.Background(), iamClient, orgID, nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+	sharingPolicies = `{"total": 1, "entry": [{"sharingPolicy": "Restricted", "purpose": "support", "targetOrganizationId": "other-org"}]}`
+
+	importer, _ := snapshot.NewImporter(iamClient, nil)
+	plan, err := importer.Plan(context.Background(), s, orgID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Contains(t, plan.String(), `~ update role "ADMIN" (stop sharing with other-org)`)
+
+	_, err = plan.Apply(context.Background())
+	if !assert.Nil(t, err) || !assert.Len(t, removed, 1) {
+		return
+	}
+	assert.Contains(t, removed[0], `"targetOrganizationId":"other-org"`)
+}
+
+func TestPlanInvalidPrivateKey(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	s := &snapshot.Snapshot{
+		Version: snapshot.CurrentVersion,
+		Propositions: []snapshot.Proposition{{
+			Name: "PROP",
+			Applications: []snapshot.Application{{
+				Name: "APP",
+				Services: []snapshot.Service{{
+					Name:       "newsvc",
+					PrivateKey: "${SERVICE_NEWSVC_PRIVATE_KEY}",
+				}},
+			}},
+		}},
+	}
+	importer, _ := snapshot.NewImporter(iamClient, &snapshot.ImportOptions{
+		Secrets: func(name string) (string, bool) { return "not a key", true },
+	})
+	_, err := importer.Plan(context.Background(), s, orgID)
+	assert.True(t, errors.Is(err, snapshot.ErrInvalidPrivateKey))
+}
+
+func TestPlanMapsSharingPolicyTargets(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	s := &snapshot.Snapshot{
+		Version:        snapshot.CurrentVersion,
+		OrganizationID: "source-org",
+		Roles: []snapshot.Role{{
+			Name:        "ADMIN",
+			Description: "Admin role",
+			Permissions: []string{"GROUP.WRITE", "USER.READ"},
+			SharingPolicies: []snapshot.SharingPolicy{
+				{TargetOrganizationID: "source-child", SharingPolicy: "Restricted"},
+				{TargetOrganizationID: "source-org", SharingPolicy: "AllowChildren"},
+			},
+		}},
+	}
+	importer, _ := snapshot.NewImporter(iamClient, nil)
+	_, err := importer.Plan(context.Background(), s, orgID)
+	assert.True(t, errors.Is(err, snapshot.ErrUnmappedOrg))
+
+	importer, _ = snapshot.NewImporter(iamClient, &snapshot.ImportOptions{
+		Organizations: map[string]string{"source-child": "target-child"},
+	})
+	plan, err := importer.Plan(context.Background(), s, orgID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Contains(t, plan.String(), `~ update role "ADMIN" (share with target-child, share with `+orgID+`)`)
+}
+
+func TestPlanServiceValidity(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	s := &snapshot.Snapshot{
+		Version: snapshot.CurrentVersion,
+		Propositions: []snapshot.Proposition{{
+			Name:              "PROP",
+			Description:       "Proposition",
+			GlobalReferenceID: "prop-ref",
+			Applications: []snapshot.Application{{
+				Name:              "APP",
+				GlobalReferenceID: "app-ref",
+				Services: []snapshot.Service{{
+					Name:          "svc",
+					Validity:      24,
+					Scopes:        []string{"cn", "openid"},
+					DefaultScopes: []string{"cn"},
+					PrivateKey:    "${SERVICE_SVC_PRIVATE_KEY}",
+				}},
+			}},
+		}},
+	}
+	importer, _ := snapshot.NewImporter(iamClient, &snapshot.ImportOptions{
+		Secrets: func(name string) (string, bool) { return "", false },
+	})
+	_, err := importer.Plan(context.Background(), s, orgID)
+	assert.True(t, errors.Is(err, snapshot.ErrUnresolvedSecret))
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.Nil(t, err) {
+		return
+	}
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	importer, _ = snapshot.NewImporter(iamClient, &snapshot.ImportOptions{
+		Secrets: func(name string) (string, bool) { return pemKey, name == "SERVICE_SVC_PRIVATE_KEY" },
+	})
+	plan, err := importer.Plan(context.Background(), s, orgID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Contains(t, plan.String(), `~ update service "PROP/APP/svc" (validity 0 -> 24 months)`)
+}
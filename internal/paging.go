@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// FollowNext calls fetch for the first page with a nil next URL and then once for the next
+// link of each page, until a page has no next link. fetch returns the links of the page it read
+func FollowNext(fetch func(next *url.URL) (BundleLinks, error)) error {
+	var next *url.URL
+	for {
+		links, err := fetch(next)
+		if err != nil {
+			return err
+		}
+		link := links.Next()
+		if link == nil { // No next page
+			return nil
+		}
+		if next, err = url.Parse(link.URL); err != nil {
+			return fmt.Errorf("next link: %w", err)
+		}
+	}
+}
+
+// Resources decodes the resources of the bundle entries. Entries which do not decode as T are skipped
+func Resources[T any](b *Bundle) []T {
+	var resources []T
+	for _, e := range b.Entry {
+		var resource T
+		if err := json.Unmarshal(e.Resource, &resource); err == nil {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
@@ -0,0 +1,47 @@
+package internal_test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollowNext(t *testing.T) {
+	var queries []string
+	err := internal.FollowNext(func(next *url.URL) (internal.BundleLinks, error) {
+		if next == nil {
+			queries = append(queries, "")
+			return internal.BundleLinks{{URL: "https://example.com/Blob?_page=2", Relation: "next"}}, nil
+		}
+		queries = append(queries, next.RawQuery)
+		return internal.BundleLinks{{URL: "https://example.com/Blob?_page=2", Relation: "self"}}, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"", "_page=2"}, queries)
+
+	fetchErr := errors.New("fetch failed")
+	err = internal.FollowNext(func(next *url.URL) (internal.BundleLinks, error) {
+		return nil, fetchErr
+	})
+	assert.ErrorIs(t, err, fetchErr)
+
+	err = internal.FollowNext(func(next *url.URL) (internal.BundleLinks, error) {
+		return internal.BundleLinks{{URL: "://bad", Relation: "next"}}, nil
+	})
+	assert.NotNil(t, err)
+}
+
+func TestResources(t *testing.T) {
+	b := &internal.Bundle{Entry: []internal.BundleEntry{
+		{Resource: []byte(`{"id": "a"}`)},
+		{Resource: []byte(`"not an object"`)},
+		{Resource: []byte(`{"id": "b"}`)},
+	}}
+	type resource struct {
+		ID string `json:"id"`
+	}
+	assert.Equal(t, []resource{{ID: "a"}, {ID: "b"}}, internal.Resources[resource](b))
+}
@@ -3,9 +3,14 @@ package stl
 import (
 	"context"
 	"fmt"
+	"path"
+	"strings"
+
 	"github.com/hasura/go-graphql-client"
 )
 
+const devicesPageSize = 100
+
 // Device represents a STL device
 type Device struct {
 	ID               int64
@@ -74,3 +79,68 @@ func (d *DevicesService) GetDeviceByID(ctx context.Context, id int64) (*Device,
 	}
 	return &query.Device, nil
 }
+
+// DeviceSearchOptions filters the result of GetDevices. Empty fields match any device.
+// Name is a path.Match pattern, e.g. "SME100-*"
+type DeviceSearchOptions struct {
+	State  string
+	Region string
+	Name   string
+}
+
+// Match returns true if device satisfies the options
+func (o *DeviceSearchOptions) Match(device Device) (bool, error) {
+	if o == nil {
+		return true, nil
+	}
+	if o.State != "" && !strings.EqualFold(o.State, device.State) {
+		return false, nil
+	}
+	if o.Region != "" && !strings.EqualFold(o.Region, device.Region) {
+		return false, nil
+	}
+	if o.Name != "" {
+		return path.Match(o.Name, device.Name)
+	}
+	return true, nil
+}
+
+// GetDevices retrieves all devices matching opt, following all result pages. A nil opt returns all devices
+func (d *DevicesService) GetDevices(ctx context.Context, opt *DeviceSearchOptions) (*[]Device, error) {
+	devices := make([]Device, 0)
+	var after *graphql.String
+	for {
+		var query struct {
+			Devices struct {
+				Edges []struct {
+					Node Device
+				}
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   string
+				}
+			} `graphql:"devices(first: $first, after: $after)"`
+		}
+		err := d.client.gql.Query(ctx, &query, map[string]interface{}{
+			"first": graphql.Int(devicesPageSize),
+			"after": after,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range query.Devices.Edges {
+			ok, err := opt.Match(e.Node)
+			if err != nil {
+				return nil, fmt.Errorf("name pattern %q: %w", opt.Name, err)
+			}
+			if ok {
+				devices = append(devices, e.Node)
+			}
+		}
+		if !query.Devices.PageInfo.HasNextPage {
+			return &devices, nil
+		}
+		cursor := graphql.String(query.Devices.PageInfo.EndCursor)
+		after = &cursor
+	}
+}
@@ -2,10 +2,13 @@ package stl_test
 
 import (
 	"context"
-	"github.com/stretchr/testify/assert"
+	"encoding/json"
 	"io"
 	"net/http"
 	"testing"
+
+	"github.com/dip-software/go-dip-api/stl"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGetDevices(t *testing.T) {
@@ -117,3 +120,72 @@ func TestDeviceFailures(t *testing.T) {
 	err = client.Devices.SyncDeviceConfig(ctx, serial)
 	assert.NotNil(t, err)
 }
+
+func TestSearchDevices(t *testing.T) {
+	teardown, err := setup(t)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer teardown()
+
+	muxSTL.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case "POST":
+			var body struct {
+				Query     string                 `json:"query"`
+				Variables map[string]interface{} `json:"variables"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			assert.Contains(t, body.Query, "devices(first: $first, after: $after)")
+			assert.Equal(t, float64(100), body.Variables["first"])
+			w.WriteHeader(http.StatusOK)
+			if body.Variables["after"] == "cursor-2" {
+				_, _ = io.WriteString(w, `{
+  "data": {
+    "devices": {
+      "edges": [
+        {"node": {"id": 3, "name": "SME100-3", "state": "authorized", "region": "eu1", "serialNumber": "A3"}},
+        {"node": {"id": 4, "name": "Lab gateway", "state": "authorized", "region": "na1", "serialNumber": "A4"}}
+      ],
+      "pageInfo": {"hasNextPage": false, "endCursor": "cursor-4"}
+    }
+  }
+}`)
+				return
+			}
+			assert.Nil(t, body.Variables["after"])
+			_, _ = io.WriteString(w, `{
+  "data": {
+    "devices": {
+      "edges": [
+        {"node": {"id": 1, "name": "SME100-1", "state": "authorized", "region": "na1", "serialNumber": "A1"}},
+        {"node": {"id": 2, "name": "SME100-2", "state": "unauthorized", "region": "na1", "serialNumber": "A2"}}
+      ],
+      "pageInfo": {"hasNextPage": true, "endCursor": "cursor-2"}
+    }
+  }
+}`)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	ctx := context.Background()
+
+	devices, err := client.Devices.GetDevices(ctx, nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, *devices, 4)
+
+	devices, err = client.Devices.GetDevices(ctx, &stl.DeviceSearchOptions{State: "Authorized", Region: "na1", Name: "SME100-*"})
+	if !assert.Nil(t, err) {
+		return
+	}
+	if assert.Len(t, *devices, 1) {
+		assert.Equal(t, "A1", (*devices)[0].SerialNumber)
+	}
+
+	_, err = client.Devices.GetDevices(ctx, &stl.DeviceSearchOptions{Name: "["})
+	assert.NotNil(t, err)
+}
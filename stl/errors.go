@@ -0,0 +1,9 @@
+package stl
+
+import "errors"
+
+// Exported Errors
+var (
+	ErrRedactedSecret  = errors.New("snapshot secret is redacted and not present on the device")
+	ErrMissingSnapshot = errors.New("missing snapshot")
+)
@@ -0,0 +1,13 @@
+package rollout
+
+import "errors"
+
+// Exported Errors
+var (
+	ErrMissingServices = errors.New("missing STL services")
+	ErrInvalidSpec     = errors.New("invalid rollout spec")
+	ErrInvalidTemplate = errors.New("invalid template")
+	ErrRenderFailed    = errors.New("rendering template failed")
+	ErrRolloutFailed   = errors.New("rollout failed")
+	ErrRollbackFailed  = errors.New("rollback failed")
+)
@@ -0,0 +1,620 @@
+// Package rollout applies STL device configuration to a fleet of devices in batches.
+// Configuration is rendered per device from Go templates, every device is synced
+// after its changes are applied and changes are rolled back when a device fails
+package rollout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/dip-software/go-dip-api/stl"
+)
+
+// Syncer pushes the configuration to a device, e.g. stl.Client.Devices
+type Syncer interface {
+	SyncDeviceConfig(ctx context.Context, serial string) error
+}
+
+// Apps manages application resources, e.g. stl.Client.Apps
+type Apps interface {
+	GetAppResourcesBySerial(ctx context.Context, serial string) (*[]stl.AppResource, error)
+	CreateAppResource(ctx context.Context, input stl.CreateApplicationResourceInput) (*stl.AppResource, error)
+	UpdateAppResource(ctx context.Context, input stl.UpdateApplicationResourceInput) (*stl.AppResource, error)
+	DeleteAppResource(ctx context.Context, input stl.DeleteApplicationResourceInput) (bool, error)
+}
+
+// Certs manages custom certificates, e.g. stl.Client.Certs
+type Certs interface {
+	GetCustomCertsBySerial(ctx context.Context, serial string) (*[]stl.CustomCert, error)
+	CreateCustomCert(ctx context.Context, input stl.CreateAppCustomCertInput) (*stl.CustomCert, error)
+	UpdateCustomCert(ctx context.Context, input stl.UpdateAppCustomCertInput) (*stl.CustomCert, error)
+	DeleteCustomCert(ctx context.Context, input stl.DeleteAppCustomCertInput) (bool, error)
+}
+
+// Config manages firewall exceptions and logging, e.g. stl.Client.Config
+type Config interface {
+	GetFirewallExceptionsBySerial(ctx context.Context, serial string) (*stl.AppFirewallException, error)
+	UpdateAppFirewallExceptions(ctx context.Context, input stl.UpdateAppFirewallExceptionInput) (*stl.AppFirewallException, error)
+	GetAppLoggingBySerial(ctx context.Context, serial string) (*stl.AppLogging, error)
+	UpdateAppLogging(ctx context.Context, input stl.UpdateAppLoggingInput) (*stl.AppLogging, error)
+}
+
+var (
+	_ Syncer = (*stl.DevicesService)(nil)
+	_ Apps   = (*stl.AppsService)(nil)
+	_ Certs  = (*stl.CertsService)(nil)
+	_ Config = (*stl.ConfigService)(nil)
+)
+
+// Services are the STL services used by an Engine
+type Services struct {
+	Devices Syncer
+	Apps    Apps
+	Certs   Certs
+	Config  Config
+}
+
+// FromClient returns the Services of an STL client
+func FromClient(client *stl.Client) Services {
+	return Services{Devices: client.Devices, Apps: client.Apps, Certs: client.Certs, Config: client.Config}
+}
+
+// AppResource is an application resource. Content is a template
+type AppResource struct {
+	Name    string
+	Content string
+	// IsLocked is set when the resource is created or its content changes. STL does not
+	// return the lock state of a resource, so a change of IsLocked alone is not detected
+	// and a rollback restores the previous content but keeps the new lock state
+	IsLocked bool
+}
+
+// CustomCert is a custom certificate. Key and Cert are templates
+type CustomCert struct {
+	Name string
+	Key  string
+	Cert string
+}
+
+// Logging is the logging configuration. All string fields are templates
+type Logging struct {
+	RawConfig        string
+	HSDPLogging      bool
+	HSDPIngestorHost string
+	HSDPSharedKey    string
+	HSDPSecretKey    string
+	HSDPProductKey   string
+	HSDPCustomField  *bool
+}
+
+// Spec is the configuration to roll out. Nil FirewallExceptions or Logging are left alone
+type Spec struct {
+	AppResources       []AppResource
+	CustomCerts        []CustomCert
+	FirewallExceptions *stl.AppFirewallException
+	Logging            *Logging
+}
+
+// TemplateData is passed to every template. Vars contains the defaults overlaid
+// with the variables of the device
+type TemplateData struct {
+	Device stl.Device
+	Vars   map[string]string
+}
+
+// Options control a rollout
+type Options struct {
+	// BatchSize is the number of devices per batch. Defaults to 10
+	BatchSize int
+	// Concurrency limits the devices processed in parallel within a batch. Defaults to 4
+	Concurrency int
+	// MaxFailures is the number of failed devices tolerated before no further batches
+	// are started. The default of zero halts after the first batch with a failure
+	MaxFailures int
+	// Defaults are template variables for all devices
+	Defaults map[string]string
+	// Vars are template variables per device serial number
+	Vars map[string]map[string]string
+	// SkipSync does not sync devices after applying changes
+	SkipSync bool
+}
+
+// Engine rolls out a Spec
+type Engine struct {
+	services  Services
+	spec      Spec
+	opts      Options
+	templates map[string]*template.Template
+}
+
+// NewEngine validates spec and returns an Engine
+func NewEngine(services Services, spec Spec, opts Options) (*Engine, error) {
+	if services.Apps == nil || services.Certs == nil || services.Config == nil || (services.Devices == nil && !opts.SkipSync) {
+		return nil, ErrMissingServices
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 10
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	e := &Engine{services: services, spec: spec, opts: opts, templates: make(map[string]*template.Template)}
+	parse := func(name, text string) error {
+		t, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+		}
+		e.templates[name] = t
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, app := range spec.AppResources {
+		if app.Name == "" || seen["app/"+app.Name] {
+			return nil, fmt.Errorf("%w: app resource %q", ErrInvalidSpec, app.Name)
+		}
+		seen["app/"+app.Name] = true
+		if err := parse("app/"+app.Name, app.Content); err != nil {
+			return nil, err
+		}
+	}
+	for _, cert := range spec.CustomCerts {
+		if cert.Name == "" || seen["cert/"+cert.Name] {
+			return nil, fmt.Errorf("%w: custom cert %q", ErrInvalidSpec, cert.Name)
+		}
+		seen["cert/"+cert.Name] = true
+		if err := parse("cert/"+cert.Name+"/key", cert.Key); err != nil {
+			return nil, err
+		}
+		if err := parse("cert/"+cert.Name+"/cert", cert.Cert); err != nil {
+			return nil, err
+		}
+	}
+	if l := spec.Logging; l != nil {
+		for name, text := range map[string]string{
+			"logging/rawConfig":        l.RawConfig,
+			"logging/hsdpIngestorHost": l.HSDPIngestorHost,
+			"logging/hsdpSharedKey":    l.HSDPSharedKey,
+			"logging/hsdpSecretKey":    l.HSDPSecretKey,
+			"logging/hsdpProductKey":   l.HSDPProductKey,
+		} {
+			if err := parse(name, text); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if spec.AppResources == nil && spec.CustomCerts == nil && spec.FirewallExceptions == nil && spec.Logging == nil {
+		return nil, fmt.Errorf("%w: nothing to roll out", ErrInvalidSpec)
+	}
+	return e, nil
+}
+
+// Kind is the kind of configuration a Change touches
+type Kind string
+
+// Kinds
+const (
+	KindAppResource       Kind = "appResource"
+	KindCustomCert        Kind = "customCert"
+	KindFirewallException Kind = "firewallException"
+	KindLogging           Kind = "logging"
+)
+
+// Action describes what a Change did
+type Action string
+
+// Actions
+const (
+	Created Action = "created"
+	Updated Action = "updated"
+)
+
+// Change is a change applied to a device
+type Change struct {
+	Kind   Kind   `json:"kind"`
+	Name   string `json:"name,omitempty"`
+	Action Action `json:"action"`
+
+	undo func(ctx context.Context) error
+}
+
+func (c Change) String() string {
+	if c.Name == "" {
+		return fmt.Sprintf("%s %s", c.Kind, c.Action)
+	}
+	return fmt.Sprintf("%s %s %s", c.Kind, c.Name, c.Action)
+}
+
+// Status is the outcome of a device
+type Status string
+
+// Statuses
+const (
+	Succeeded  Status = "succeeded"
+	Unchanged  Status = "unchanged"
+	Failed     Status = "failed"
+	RolledBack Status = "rolledBack"
+	Skipped    Status = "skipped"
+)
+
+// DeviceResult is the outcome of a rollout to a single device
+type DeviceResult struct {
+	Device  stl.Device `json:"device"`
+	Batch   int        `json:"batch"`
+	Status  Status     `json:"status"`
+	Changes []Change   `json:"changes,omitempty"`
+	// Error is the error which failed the device
+	Error error `json:"-"`
+	// RollbackError is set when undoing the applied changes failed too
+	RollbackError error `json:"-"`
+}
+
+// Report is the outcome of a rollout
+type Report struct {
+	Results []DeviceResult `json:"results"`
+	Halted  bool           `json:"halted"`
+}
+
+// Count returns the number of devices with status
+func (r *Report) Count(status Status) int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Failures returns the results of devices which failed
+func (r *Report) Failures() []DeviceResult {
+	var failed []DeviceResult
+	for _, res := range r.Results {
+		if res.Error != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Err returns ErrRolloutFailed if any device failed
+func (r *Report) Err() error {
+	failed := r.Failures()
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %d of %d devices, first: %s: %v", ErrRolloutFailed, len(failed), len(r.Results), failed[0].Device.SerialNumber, failed[0].Error)
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+	for _, res := range r.Results {
+		fmt.Fprintf(&b, "%s (%s): %s", res.Device.SerialNumber, res.Device.Name, res.Status)
+		if res.Error != nil {
+			fmt.Fprintf(&b, ": %v", res.Error)
+		}
+		b.WriteString("\n")
+	}
+	if r.Halted {
+		b.WriteString("halted: too many failures\n")
+	}
+	return b.String()
+}
+
+// Run rolls the spec out to devices
+func (e *Engine) Run(ctx context.Context, devices []stl.Device) *Report {
+	report := &Report{Results: make([]DeviceResult, len(devices))}
+	failures := 0
+	for start, batch := 0, 1; start < len(devices); start, batch = start+e.opts.BatchSize, batch+1 {
+		end := min(start+e.opts.BatchSize, len(devices))
+		if report.Halted || ctx.Err() != nil {
+			for i := start; i < end; i++ {
+				report.Results[i] = DeviceResult{Device: devices[i], Batch: batch, Status: Skipped}
+			}
+			continue
+		}
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, e.opts.Concurrency)
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				report.Results[i] = e.rollout(ctx, devices[i])
+				report.Results[i].Batch = batch
+			}(i)
+		}
+		wg.Wait()
+		for i := start; i < end; i++ {
+			if report.Results[i].Error != nil {
+				failures++
+			}
+		}
+		if failures > e.opts.MaxFailures {
+			report.Halted = true
+		}
+	}
+	return report
+}
+
+func (e *Engine) render(name string, data TemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := e.templates[name].Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrRenderFailed, err)
+	}
+	return buf.String(), nil
+}
+
+func (e *Engine) data(device stl.Device) TemplateData {
+	vars := make(map[string]string)
+	for k, v := range e.opts.Defaults {
+		vars[k] = v
+	}
+	for k, v := range e.opts.Vars[device.SerialNumber] {
+		vars[k] = v
+	}
+	return TemplateData{Device: device, Vars: vars}
+}
+
+// rendered is the spec rendered for a single device
+type rendered struct {
+	apps    []AppResource
+	certs   []CustomCert
+	logging *stl.AppLogging
+}
+
+func (e *Engine) renderAll(device stl.Device) (*rendered, error) {
+	data := e.data(device)
+	r := &rendered{}
+	var err error
+	for _, app := range e.spec.AppResources {
+		if app.Content, err = e.render("app/"+app.Name, data); err != nil {
+			return nil, fmt.Errorf("app resource %s: %w", app.Name, err)
+		}
+		r.apps = append(r.apps, app)
+	}
+	for _, cert := range e.spec.CustomCerts {
+		if cert.Key, err = e.render("cert/"+cert.Name+"/key", data); err != nil {
+			return nil, fmt.Errorf("custom cert %s: %w", cert.Name, err)
+		}
+		if cert.Cert, err = e.render("cert/"+cert.Name+"/cert", data); err != nil {
+			return nil, fmt.Errorf("custom cert %s: %w", cert.Name, err)
+		}
+		r.certs = append(r.certs, cert)
+	}
+	if l := e.spec.Logging; l != nil {
+		logging := &stl.AppLogging{HSDPLogging: l.HSDPLogging, HSDPCustomField: l.HSDPCustomField}
+		for name, field := range map[string]*string{
+			"logging/rawConfig":        &logging.RawConfig,
+			"logging/hsdpIngestorHost": &logging.HSDPIngestorHost,
+			"logging/hsdpSharedKey":    &logging.HSDPSharedKey,
+			"logging/hsdpSecretKey":    &logging.HSDPSecretKey,
+			"logging/hsdpProductKey":   &logging.HSDPProductKey,
+		} {
+			if *field, err = e.render(name, data); err != nil {
+				return nil, fmt.Errorf("logging: %w", err)
+			}
+		}
+		if ok, err := (stl.UpdateAppLoggingInput{AppLogging: *logging}).Validate(); !ok {
+			return nil, fmt.Errorf("logging: %w", err)
+		}
+		r.logging = logging
+	}
+	return r, nil
+}
+
+// rollout applies the spec to a single device. Templates are rendered before
+// anything is changed, so a render failure leaves the device untouched
+func (e *Engine) rollout(ctx context.Context, device stl.Device) DeviceResult {
+	result := DeviceResult{Device: device}
+	r, err := e.renderAll(device)
+	if err == nil {
+		err = e.apply(ctx, device, r, &result)
+	}
+	if err == nil && len(result.Changes) > 0 && !e.opts.SkipSync {
+		if err = e.services.Devices.SyncDeviceConfig(ctx, device.SerialNumber); err != nil {
+			err = fmt.Errorf("sync: %w", err)
+		}
+	}
+	switch {
+	case err == nil && len(result.Changes) == 0:
+		result.Status = Unchanged
+	case err == nil:
+		result.Status = Succeeded
+	default:
+		result.Error = err
+		result.Status = Failed
+		if len(result.Changes) > 0 {
+			result.RollbackError = rollback(context.WithoutCancel(ctx), result.Changes)
+			if result.RollbackError == nil {
+				result.Status = RolledBack
+			}
+		}
+	}
+	return result
+}
+
+// rollback undoes changes in reverse order
+func rollback(ctx context.Context, changes []Change) error {
+	var errs []string
+	for i := len(changes) - 1; i >= 0; i-- {
+		if err := changes[i].undo(ctx); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", changes[i], err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %s", ErrRollbackFailed, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (e *Engine) apply(ctx context.Context, device stl.Device, r *rendered, result *DeviceResult) error {
+	serial := device.SerialNumber
+	if len(r.apps) > 0 {
+		existing, err := e.services.Apps.GetAppResourcesBySerial(ctx, serial)
+		if err != nil {
+			return fmt.Errorf("get app resources: %w", err)
+		}
+		for _, app := range r.apps {
+			change, err := e.applyApp(ctx, device, *existing, app)
+			if err != nil {
+				return fmt.Errorf("app resource %s: %w", app.Name, err)
+			}
+			if change != nil {
+				result.Changes = append(result.Changes, *change)
+			}
+		}
+	}
+	if len(r.certs) > 0 {
+		existing, err := e.services.Certs.GetCustomCertsBySerial(ctx, serial)
+		if err != nil {
+			return fmt.Errorf("get custom certs: %w", err)
+		}
+		for _, cert := range r.certs {
+			change, err := e.applyCert(ctx, serial, *existing, cert)
+			if err != nil {
+				return fmt.Errorf("custom cert %s: %w", cert.Name, err)
+			}
+			if change != nil {
+				result.Changes = append(result.Changes, *change)
+			}
+		}
+	}
+	if want := e.spec.FirewallExceptions; want != nil {
+		current, err := e.services.Config.GetFirewallExceptionsBySerial(ctx, serial)
+		if err != nil {
+			return fmt.Errorf("get firewall exceptions: %w", err)
+		}
+		if !samePorts(current.TCP, want.TCP) || !samePorts(current.UDP, want.UDP) {
+			previous := stl.AppFirewallException{TCP: current.TCP, UDP: current.UDP}
+			if _, err := e.services.Config.UpdateAppFirewallExceptions(ctx, stl.UpdateAppFirewallExceptionInput{
+				AppFirewallException: stl.AppFirewallException{TCP: want.TCP, UDP: want.UDP},
+				SerialNumber:         serial,
+			}); err != nil {
+				return fmt.Errorf("update firewall exceptions: %w", err)
+			}
+			result.Changes = append(result.Changes, Change{Kind: KindFirewallException, Action: Updated, undo: func(ctx context.Context) error {
+				_, err := e.services.Config.UpdateAppFirewallExceptions(ctx, stl.UpdateAppFirewallExceptionInput{AppFirewallException: previous, SerialNumber: serial})
+				return err
+			}})
+		}
+	}
+	if want := r.logging; want != nil {
+		current, err := e.services.Config.GetAppLoggingBySerial(ctx, serial)
+		if err != nil {
+			return fmt.Errorf("get logging: %w", err)
+		}
+		previous := *current
+		previous.DeviceID = 0
+		if !sameLogging(previous, *want) {
+			if _, err := e.services.Config.UpdateAppLogging(ctx, stl.UpdateAppLoggingInput{AppLogging: *want, SerialNumber: serial}); err != nil {
+				return fmt.Errorf("update logging: %w", err)
+			}
+			result.Changes = append(result.Changes, Change{Kind: KindLogging, Action: Updated, undo: func(ctx context.Context) error {
+				_, err := e.services.Config.UpdateAppLogging(ctx, stl.UpdateAppLoggingInput{AppLogging: previous, SerialNumber: serial})
+				return err
+			}})
+		}
+	}
+	return nil
+}
+
+func (e *Engine) applyApp(ctx context.Context, device stl.Device, existing []stl.AppResource, app AppResource) (*Change, error) {
+	apps := e.services.Apps
+	serial := device.SerialNumber
+	for _, current := range existing {
+		if current.Name != app.Name {
+			continue
+		}
+		if current.Content == app.Content {
+			return nil, nil
+		}
+		update := stl.UpdateApplicationResourceInput{
+			ID:           current.ID,
+			DeviceID:     device.ID,
+			SerialNumber: serial,
+			Name:         app.Name,
+			Content:      app.Content,
+			IsLocked:     app.IsLocked,
+		}
+		if _, err := apps.UpdateAppResource(ctx, update); err != nil {
+			return nil, err
+		}
+		// The lock state of current is unknown, so the rollback only restores the content
+		previous := update
+		previous.Content = current.Content
+		return &Change{Kind: KindAppResource, Name: app.Name, Action: Updated, undo: func(ctx context.Context) error {
+			_, err := apps.UpdateAppResource(ctx, previous)
+			return err
+		}}, nil
+	}
+	created, err := apps.CreateAppResource(ctx, stl.CreateApplicationResourceInput{
+		SerialNumber: serial,
+		Name:         app.Name,
+		Content:      app.Content,
+		IsLocked:     app.IsLocked,
+	})
+	if err != nil {
+		return nil, err
+	}
+	id := created.ID
+	return &Change{Kind: KindAppResource, Name: app.Name, Action: Created, undo: func(ctx context.Context) error {
+		_, err := apps.DeleteAppResource(ctx, stl.DeleteApplicationResourceInput{ID: id, Name: app.Name, SerialNumber: serial, DeviceID: device.ID})
+		return err
+	}}, nil
+}
+
+func (e *Engine) applyCert(ctx context.Context, serial string, existing []stl.CustomCert, cert CustomCert) (*Change, error) {
+	certs := e.services.Certs
+	for _, current := range existing {
+		if current.Name != cert.Name {
+			continue
+		}
+		if current.Key == cert.Key && current.Cert == cert.Cert {
+			return nil, nil
+		}
+		if _, err := certs.UpdateCustomCert(ctx, stl.UpdateAppCustomCertInput{ID: current.ID, Name: cert.Name, Key: cert.Key, Cert: cert.Cert}); err != nil {
+			return nil, err
+		}
+		previous := stl.UpdateAppCustomCertInput{ID: current.ID, Name: current.Name, Key: current.Key, Cert: current.Cert}
+		return &Change{Kind: KindCustomCert, Name: cert.Name, Action: Updated, undo: func(ctx context.Context) error {
+			_, err := certs.UpdateCustomCert(ctx, previous)
+			return err
+		}}, nil
+	}
+	created, err := certs.CreateCustomCert(ctx, stl.CreateAppCustomCertInput{
+		CustomCert:   stl.CustomCert{Name: cert.Name, Key: cert.Key, Cert: cert.Cert},
+		SerialNumber: serial,
+	})
+	if err != nil {
+		return nil, err
+	}
+	id := created.ID
+	return &Change{Kind: KindCustomCert, Name: cert.Name, Action: Created, undo: func(ctx context.Context) error {
+		_, err := certs.DeleteCustomCert(ctx, stl.DeleteAppCustomCertInput{ID: id})
+		return err
+	}}, nil
+}
+
+func samePorts(a, b []int) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+func sameLogging(a, b stl.AppLogging) bool {
+	customA, customB := a.HSDPCustomField != nil && *a.HSDPCustomField, b.HSDPCustomField != nil && *b.HSDPCustomField
+	return a.RawConfig == b.RawConfig &&
+		a.HSDPLogging == b.HSDPLogging &&
+		a.HSDPIngestorHost == b.HSDPIngestorHost &&
+		a.HSDPSharedKey == b.HSDPSharedKey &&
+		a.HSDPSecretKey == b.HSDPSecretKey &&
+		a.HSDPProductKey == b.HSDPProductKey &&
+		customA == customB
+}
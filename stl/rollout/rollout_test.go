@@ -0,0 +1,294 @@
+package rollout_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/stl"
+	"github.com/dip-software/go-dip-api/stl/rollout"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type deviceState struct {
+	apps     []stl.AppResource
+	certs    []stl.CustomCert
+	firewall stl.AppFirewallException
+	logging  stl.AppLogging
+}
+
+type fakeSTL struct {
+	mu       sync.Mutex
+	devices  map[string]*deviceState
+	nextID   int64
+	synced   []string
+	failSync map[string]bool
+	failCert map[string]bool
+}
+
+func newFake(serials ...string) *fakeSTL {
+	f := &fakeSTL{devices: make(map[string]*deviceState), failSync: map[string]bool{}, failCert: map[string]bool{}}
+	for _, serial := range serials {
+		f.devices[serial] = &deviceState{}
+	}
+	return f
+}
+
+func (f *fakeSTL) services() rollout.Services {
+	return rollout.Services{Devices: f, Apps: f, Certs: f, Config: f}
+}
+
+func (f *fakeSTL) SyncDeviceConfig(_ context.Context, serial string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failSync[serial] {
+		return errors.New("device offline")
+	}
+	f.synced = append(f.synced, serial)
+	return nil
+}
+
+func (f *fakeSTL) GetAppResourcesBySerial(_ context.Context, serial string) (*[]stl.AppResource, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	apps := append([]stl.AppResource{}, f.devices[serial].apps...)
+	return &apps, nil
+}
+
+func (f *fakeSTL) CreateAppResource(_ context.Context, input stl.CreateApplicationResourceInput) (*stl.AppResource, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	app := stl.AppResource{ID: f.nextID, Name: input.Name, Content: input.Content}
+	f.devices[input.SerialNumber].apps = append(f.devices[input.SerialNumber].apps, app)
+	return &app, nil
+}
+
+func (f *fakeSTL) UpdateAppResource(_ context.Context, input stl.UpdateApplicationResourceInput) (*stl.AppResource, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, app := range f.devices[input.SerialNumber].apps {
+		if app.ID == input.ID {
+			f.devices[input.SerialNumber].apps[i].Content = input.Content
+			return &f.devices[input.SerialNumber].apps[i], nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (f *fakeSTL) DeleteAppResource(_ context.Context, input stl.DeleteApplicationResourceInput) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state := f.devices[input.SerialNumber]
+	for i, app := range state.apps {
+		if app.ID == input.ID {
+			state.apps = append(state.apps[:i], state.apps[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, errors.New("not found")
+}
+
+func (f *fakeSTL) GetCustomCertsBySerial(_ context.Context, serial string) (*[]stl.CustomCert, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	certs := append([]stl.CustomCert{}, f.devices[serial].certs...)
+	return &certs, nil
+}
+
+func (f *fakeSTL) CreateCustomCert(_ context.Context, input stl.CreateAppCustomCertInput) (*stl.CustomCert, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failCert[input.SerialNumber] {
+		return nil, errors.New("invalid certificate")
+	}
+	f.nextID++
+	cert := input.CustomCert
+	cert.ID = f.nextID
+	f.devices[input.SerialNumber].certs = append(f.devices[input.SerialNumber].certs, cert)
+	return &cert, nil
+}
+
+func (f *fakeSTL) UpdateCustomCert(_ context.Context, input stl.UpdateAppCustomCertInput) (*stl.CustomCert, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, state := range f.devices {
+		for i, cert := range state.certs {
+			if cert.ID == input.ID {
+				state.certs[i] = stl.CustomCert{ID: input.ID, Name: input.Name, Key: input.Key, Cert: input.Cert}
+				return &state.certs[i], nil
+			}
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (f *fakeSTL) DeleteCustomCert(_ context.Context, input stl.DeleteAppCustomCertInput) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, state := range f.devices {
+		for i, cert := range state.certs {
+			if cert.ID == input.ID {
+				state.certs = append(state.certs[:i], state.certs[i+1:]...)
+				return true, nil
+			}
+		}
+	}
+	return false, errors.New("not found")
+}
+
+func (f *fakeSTL) GetFirewallExceptionsBySerial(_ context.Context, serial string) (*stl.AppFirewallException, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fw := f.devices[serial].firewall
+	return &fw, nil
+}
+
+func (f *fakeSTL) UpdateAppFirewallExceptions(_ context.Context, input stl.UpdateAppFirewallExceptionInput) (*stl.AppFirewallException, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.devices[input.SerialNumber].firewall = input.AppFirewallException
+	return &input.AppFirewallException, nil
+}
+
+func (f *fakeSTL) GetAppLoggingBySerial(_ context.Context, serial string) (*stl.AppLogging, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	logging := f.devices[serial].logging
+	return &logging, nil
+}
+
+func (f *fakeSTL) UpdateAppLogging(_ context.Context, input stl.UpdateAppLoggingInput) (*stl.AppLogging, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.devices[input.SerialNumber].logging = input.AppLogging
+	return &input.AppLogging, nil
+}
+
+func devices(n int) []stl.Device {
+	var list []stl.Device
+	for i := 1; i <= n; i++ {
+		list = append(list, stl.Device{ID: int64(i), Name: fmt.Sprintf("SME100-%d", i), SerialNumber: fmt.Sprintf("S%d", i)})
+	}
+	return list
+}
+
+func serials(list []stl.Device) []string {
+	var s []string
+	for _, d := range list {
+		s = append(s, d.SerialNumber)
+	}
+	return s
+}
+
+var spec = rollout.Spec{
+	AppResources: []rollout.AppResource{
+		{Name: "app.yml", Content: "site: {{ .Vars.site }}\nname: {{ .Device.Name }}\n"},
+	},
+	CustomCerts: []rollout.CustomCert{
+		{Name: "tls", Key: "key-{{ .Device.SerialNumber }}", Cert: "cert-{{ .Device.SerialNumber }}"},
+	},
+	FirewallExceptions: &stl.AppFirewallException{TCP: []int{443, 8080}},
+	Logging:            &rollout.Logging{RawConfig: "[OUTPUT]\n    Tag {{ .Vars.site }}"},
+}
+
+func TestRun(t *testing.T) {
+	list := devices(5)
+	fake := newFake(serials(list)...)
+	fake.devices["S2"].apps = []stl.AppResource{{ID: 900, Name: "app.yml", Content: "old"}}
+	fake.devices["S2"].firewall = stl.AppFirewallException{TCP: []int{22}}
+
+	engine, err := rollout.NewEngine(fake.services(), spec, rollout.Options{
+		BatchSize:   2,
+		Concurrency: 2,
+		Defaults:    map[string]string{"site": "default"},
+		Vars:        map[string]map[string]string{"S1": {"site": "amsterdam"}},
+	})
+	require.NoError(t, err)
+
+	report := engine.Run(context.Background(), list)
+	require.NoError(t, report.Err())
+	assert.Equal(t, 5, report.Count(rollout.Succeeded))
+	assert.Equal(t, []int{1, 1, 2, 2, 3}, []int{report.Results[0].Batch, report.Results[1].Batch, report.Results[2].Batch, report.Results[3].Batch, report.Results[4].Batch})
+	assert.ElementsMatch(t, serials(list), fake.synced)
+
+	s1 := fake.devices["S1"]
+	assert.Equal(t, "site: amsterdam\nname: SME100-1\n", s1.apps[0].Content)
+	assert.Equal(t, "cert-S1", s1.certs[0].Cert)
+	assert.Equal(t, []int{443, 8080}, s1.firewall.TCP)
+	assert.Equal(t, "[OUTPUT]\n    Tag amsterdam", s1.logging.RawConfig)
+
+	s2 := report.Results[1]
+	assert.Equal(t, "appResource app.yml updated", s2.Changes[0].String())
+	assert.Equal(t, "site: default\nname: SME100-2\n", fake.devices["S2"].apps[0].Content)
+
+	// Running again changes nothing
+	fake.synced = nil
+	report = engine.Run(context.Background(), list)
+	require.NoError(t, report.Err())
+	assert.Equal(t, 5, report.Count(rollout.Unchanged))
+	assert.Empty(t, fake.synced)
+}
+
+func TestRollback(t *testing.T) {
+	list := devices(4)
+	fake := newFake(serials(list)...)
+	fake.devices["S1"].apps = []stl.AppResource{{ID: 900, Name: "app.yml", Content: "old"}}
+	fake.failSync["S1"] = true
+	fake.failCert["S2"] = true
+
+	engine, err := rollout.NewEngine(fake.services(), spec, rollout.Options{
+		BatchSize:   2,
+		MaxFailures: 1,
+		Defaults:    map[string]string{"site": "x"},
+	})
+	require.NoError(t, err)
+
+	report := engine.Run(context.Background(), list)
+	assert.ErrorIs(t, report.Err(), rollout.ErrRolloutFailed)
+	assert.True(t, report.Halted)
+	assert.Equal(t, 2, report.Count(rollout.RolledBack))
+	assert.Equal(t, 2, report.Count(rollout.Skipped))
+	assert.Contains(t, report.String(), "halted")
+
+	// Sync failed after all changes were applied, everything is undone
+	s1 := fake.devices["S1"]
+	assert.Equal(t, []stl.AppResource{{ID: 900, Name: "app.yml", Content: "old"}}, s1.apps)
+	assert.Empty(t, s1.certs)
+	assert.Empty(t, s1.firewall.TCP)
+	assert.Empty(t, s1.logging.RawConfig)
+	assert.Contains(t, report.Results[0].Error.Error(), "sync")
+
+	// Creating the cert failed, the created app resource is removed
+	assert.Empty(t, fake.devices["S2"].apps)
+	assert.Contains(t, report.Results[1].Error.Error(), "custom cert tls")
+	assert.Empty(t, fake.synced)
+}
+
+func TestRenderFailure(t *testing.T) {
+	list := devices(1)
+	fake := newFake(serials(list)...)
+	engine, err := rollout.NewEngine(fake.services(), spec, rollout.Options{})
+	require.NoError(t, err)
+
+	report := engine.Run(context.Background(), list)
+	require.Len(t, report.Failures(), 1)
+	assert.ErrorIs(t, report.Results[0].Error, rollout.ErrRenderFailed)
+	assert.Equal(t, rollout.Failed, report.Results[0].Status)
+	assert.Empty(t, fake.devices["S1"].apps)
+}
+
+func TestNewEngine(t *testing.T) {
+	fake := newFake()
+	_, err := rollout.NewEngine(rollout.Services{}, spec, rollout.Options{})
+	assert.ErrorIs(t, err, rollout.ErrMissingServices)
+	_, err = rollout.NewEngine(fake.services(), rollout.Spec{}, rollout.Options{})
+	assert.ErrorIs(t, err, rollout.ErrInvalidSpec)
+	_, err = rollout.NewEngine(fake.services(), rollout.Spec{AppResources: []rollout.AppResource{{Name: "a"}, {Name: "a"}}}, rollout.Options{})
+	assert.ErrorIs(t, err, rollout.ErrInvalidSpec)
+	_, err = rollout.NewEngine(fake.services(), rollout.Spec{AppResources: []rollout.AppResource{{Name: "a", Content: "{{"}}}, rollout.Options{})
+	assert.ErrorIs(t, err, rollout.ErrInvalidTemplate)
+}
@@ -0,0 +1,431 @@
+package stl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Redacted replaces secrets in a DeviceSnapshot
+const Redacted = "REDACTED"
+
+// SnapshotAppResource is an application resource in a DeviceSnapshot
+type SnapshotAppResource struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// SnapshotCustomCert is a custom certificate in a DeviceSnapshot. The key is redacted,
+// KeyFingerprint identifies it without revealing it
+type SnapshotCustomCert struct {
+	Name           string `json:"name"`
+	Cert           string `json:"cert"`
+	Key            string `json:"key"`
+	KeyFingerprint string `json:"keyFingerprint,omitempty"`
+}
+
+// SnapshotLogging is the logging configuration in a DeviceSnapshot with the shared and secret key redacted
+type SnapshotLogging struct {
+	AppLogging
+	SharedKeyFingerprint string `json:"sharedKeyFingerprint,omitempty"`
+	SecretKeyFingerprint string `json:"secretKeyFingerprint,omitempty"`
+}
+
+// DeviceSnapshot is the edge configuration of a device
+type DeviceSnapshot struct {
+	SerialNumber       string                `json:"serialNumber"`
+	TakenAt            time.Time             `json:"takenAt"`
+	AppResources       []SnapshotAppResource `json:"appResources"`
+	CustomCerts        []SnapshotCustomCert  `json:"customCerts"`
+	FirewallExceptions AppFirewallException  `json:"firewallExceptions"`
+	Logging            SnapshotLogging       `json:"logging"`
+}
+
+// Fingerprint returns the fingerprint of a secret as used in a DeviceSnapshot
+func Fingerprint(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func redact(secret string) string {
+	if secret == "" || secret == Redacted {
+		return secret
+	}
+	return Redacted
+}
+
+// deviceState is the unredacted configuration of a device
+type deviceState struct {
+	device   *Device
+	apps     []AppResource
+	certs    []CustomCert
+	firewall AppFirewallException
+	logging  AppLogging
+}
+
+func (c *Client) capture(ctx context.Context, serial string) (*deviceState, error) {
+	state := &deviceState{}
+	device, err := c.Devices.GetDeviceBySerial(ctx, serial)
+	if err != nil {
+		return nil, fmt.Errorf("get device: %w", err)
+	}
+	state.device = device
+	apps, err := c.Apps.GetAppResourcesBySerial(ctx, serial)
+	if err != nil {
+		return nil, fmt.Errorf("get app resources: %w", err)
+	}
+	state.apps = *apps
+	certs, err := c.Certs.GetCustomCertsBySerial(ctx, serial)
+	if err != nil {
+		return nil, fmt.Errorf("get custom certs: %w", err)
+	}
+	state.certs = *certs
+	firewall, err := c.Config.GetFirewallExceptionsBySerial(ctx, serial)
+	if err != nil {
+		return nil, fmt.Errorf("get firewall exceptions: %w", err)
+	}
+	state.firewall = AppFirewallException{TCP: sortedPorts(firewall.TCP), UDP: sortedPorts(firewall.UDP)}
+	logging, err := c.Config.GetAppLoggingBySerial(ctx, serial)
+	if err != nil {
+		return nil, fmt.Errorf("get logging: %w", err)
+	}
+	state.logging = *logging
+	state.logging.DeviceID = 0
+	return state, nil
+}
+
+func (s *deviceState) snapshot(includeSecrets bool) *DeviceSnapshot {
+	snap := &DeviceSnapshot{
+		SerialNumber:       s.device.SerialNumber,
+		TakenAt:            time.Now().UTC(),
+		AppResources:       make([]SnapshotAppResource, 0, len(s.apps)),
+		CustomCerts:        make([]SnapshotCustomCert, 0, len(s.certs)),
+		FirewallExceptions: s.firewall,
+		Logging: SnapshotLogging{
+			AppLogging:           s.logging,
+			SharedKeyFingerprint: Fingerprint(s.logging.HSDPSharedKey),
+			SecretKeyFingerprint: Fingerprint(s.logging.HSDPSecretKey),
+		},
+	}
+	if !includeSecrets {
+		snap.Logging.HSDPSharedKey = redact(s.logging.HSDPSharedKey)
+		snap.Logging.HSDPSecretKey = redact(s.logging.HSDPSecretKey)
+	}
+	for _, app := range s.apps {
+		snap.AppResources = append(snap.AppResources, SnapshotAppResource{Name: app.Name, Content: app.Content})
+	}
+	for _, cert := range s.certs {
+		key := cert.Key
+		if !includeSecrets {
+			key = redact(key)
+		}
+		snap.CustomCerts = append(snap.CustomCerts, SnapshotCustomCert{
+			Name:           cert.Name,
+			Cert:           cert.Cert,
+			Key:            key,
+			KeyFingerprint: Fingerprint(cert.Key),
+		})
+	}
+	sort.Slice(snap.AppResources, func(i, j int) bool { return snap.AppResources[i].Name < snap.AppResources[j].Name })
+	sort.Slice(snap.CustomCerts, func(i, j int) bool { return snap.CustomCerts[i].Name < snap.CustomCerts[j].Name })
+	return snap
+}
+
+// SnapshotOptions controls the behaviour of Snapshot
+type SnapshotOptions struct {
+	// IncludeSecrets keeps certificate keys and HSDP logging keys in the snapshot instead of redacting them
+	IncludeSecrets bool
+}
+
+// Snapshot captures the app resources, custom certs, firewall exceptions and logging
+// configuration of a device. Certificate keys and HSDP logging keys are redacted unless
+// opts includes secrets
+func (c *Client) Snapshot(ctx context.Context, serial string, opts *SnapshotOptions) (*DeviceSnapshot, error) {
+	state, err := c.capture(ctx, serial)
+	if err != nil {
+		return nil, err
+	}
+	return state.snapshot(opts != nil && opts.IncludeSecrets), nil
+}
+
+// Difference is a single difference between two snapshots
+type Difference struct {
+	Path string `json:"path"`
+	A    string `json:"a"`
+	B    string `json:"b"`
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: %q -> %q", d.Path, d.A, d.B)
+}
+
+// Diff returns the differences between a and b. Secrets are compared by fingerprint
+func Diff(a, b *DeviceSnapshot) []Difference {
+	var diffs []Difference
+	add := func(path, va, vb string) {
+		if va != vb {
+			diffs = append(diffs, Difference{Path: path, A: va, B: vb})
+		}
+	}
+
+	appsA, appsB := make(map[string]string), make(map[string]string)
+	for _, app := range a.AppResources {
+		appsA[app.Name] = app.Content
+	}
+	for _, app := range b.AppResources {
+		appsB[app.Name] = app.Content
+	}
+	for _, name := range unionKeys(appsA, appsB) {
+		add("appResources/"+name+"/content", appsA[name], appsB[name])
+	}
+
+	certsA, certsB := make(map[string]SnapshotCustomCert), make(map[string]SnapshotCustomCert)
+	for _, cert := range a.CustomCerts {
+		certsA[cert.Name] = cert
+	}
+	for _, cert := range b.CustomCerts {
+		certsB[cert.Name] = cert
+	}
+	for _, name := range unionKeys(certsA, certsB) {
+		add("customCerts/"+name+"/cert", certsA[name].Cert, certsB[name].Cert)
+		add("customCerts/"+name+"/key", certsA[name].keyFingerprint(), certsB[name].keyFingerprint())
+	}
+
+	add("firewallExceptions/tcp", formatPorts(a.FirewallExceptions.TCP), formatPorts(b.FirewallExceptions.TCP))
+	add("firewallExceptions/udp", formatPorts(a.FirewallExceptions.UDP), formatPorts(b.FirewallExceptions.UDP))
+
+	la, lb := a.Logging, b.Logging
+	add("logging/rawConfig", la.RawConfig, lb.RawConfig)
+	add("logging/hsdpLogging", fmt.Sprint(la.HSDPLogging), fmt.Sprint(lb.HSDPLogging))
+	add("logging/hsdpIngestorHost", la.HSDPIngestorHost, lb.HSDPIngestorHost)
+	add("logging/hsdpProductKey", la.HSDPProductKey, lb.HSDPProductKey)
+	add("logging/hsdpSharedKey", la.sharedKeyFingerprint(), lb.sharedKeyFingerprint())
+	add("logging/hsdpSecretKey", la.secretKeyFingerprint(), lb.secretKeyFingerprint())
+	add("logging/hsdpCustomField", formatBool(la.HSDPCustomField), formatBool(lb.HSDPCustomField))
+	return diffs
+}
+
+func (c SnapshotCustomCert) keyFingerprint() string {
+	if c.Key != Redacted {
+		return Fingerprint(c.Key)
+	}
+	return c.KeyFingerprint
+}
+
+func (l SnapshotLogging) sharedKeyFingerprint() string {
+	if l.HSDPSharedKey != Redacted {
+		return Fingerprint(l.HSDPSharedKey)
+	}
+	return l.SharedKeyFingerprint
+}
+
+func (l SnapshotLogging) secretKeyFingerprint() string {
+	if l.HSDPSecretKey != Redacted {
+		return Fingerprint(l.HSDPSecretKey)
+	}
+	return l.SecretKeyFingerprint
+}
+
+// resolve returns the secret at path for a possibly redacted value. A redacted secret is taken
+// from secrets, or from current if its fingerprint matches
+func resolve(path, value, fingerprint, current string, secrets func(path string) (string, bool)) (string, bool) {
+	if value != Redacted {
+		return value, true
+	}
+	if secrets != nil {
+		if secret, ok := secrets(path); ok {
+			return secret, true
+		}
+	}
+	if current != "" && Fingerprint(current) == fingerprint {
+		return current, true
+	}
+	return "", false
+}
+
+// ApplyOptions controls the behaviour of Apply
+type ApplyOptions struct {
+	// Secrets resolves redacted secrets by their Difference path, e.g. customCerts/tls/key
+	// or logging/hsdpSecretKey
+	Secrets func(path string) (string, bool)
+}
+
+// Apply makes the configuration of the device with serial match snapshot, e.g. to clone
+// a device onto replacement hardware. App resources and custom certs which are not in the
+// snapshot are removed. Redacted secrets must be filled in the snapshot, resolved by
+// opts.Secrets or already be present on the device, otherwise ErrRedactedSecret is returned
+// before anything changes. The device is synced when changes were made. The applied
+// differences are returned
+func (c *Client) Apply(ctx context.Context, serial string, snapshot *DeviceSnapshot, opts *ApplyOptions) ([]Difference, error) {
+	if snapshot == nil {
+		return nil, ErrMissingSnapshot
+	}
+	var secrets func(path string) (string, bool)
+	if opts != nil {
+		secrets = opts.Secrets
+	}
+	state, err := c.capture(ctx, serial)
+	if err != nil {
+		return nil, err
+	}
+	device := state.device
+
+	// Resolve all secrets up front
+	var missing []string
+	certKeys := make(map[string]string)
+	currentCerts := make(map[string]CustomCert)
+	for _, cert := range state.certs {
+		currentCerts[cert.Name] = cert
+	}
+	want := *snapshot
+	want.CustomCerts = slices.Clone(snapshot.CustomCerts)
+	for i, cert := range want.CustomCerts {
+		path := "customCerts/" + cert.Name + "/key"
+		key, ok := resolve(path, cert.Key, cert.KeyFingerprint, currentCerts[cert.Name].Key, secrets)
+		if !ok {
+			missing = append(missing, path)
+		}
+		certKeys[cert.Name] = key
+		want.CustomCerts[i].Key = key
+	}
+	logging := snapshot.Logging.AppLogging
+	logging.DeviceID = 0
+	var ok bool
+	if logging.HSDPSharedKey, ok = resolve("logging/hsdpSharedKey", logging.HSDPSharedKey, snapshot.Logging.SharedKeyFingerprint, state.logging.HSDPSharedKey, secrets); !ok {
+		missing = append(missing, "logging/hsdpSharedKey")
+	}
+	if logging.HSDPSecretKey, ok = resolve("logging/hsdpSecretKey", logging.HSDPSecretKey, snapshot.Logging.SecretKeyFingerprint, state.logging.HSDPSecretKey, secrets); !ok {
+		missing = append(missing, "logging/hsdpSecretKey")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrRedactedSecret, strings.Join(missing, ", "))
+	}
+	if valid, err := (UpdateAppLoggingInput{AppLogging: logging}).Validate(); !valid {
+		return nil, err
+	}
+
+	want.Logging.AppLogging = logging
+	diffs := Diff(state.snapshot(false), &want)
+	if len(diffs) == 0 {
+		return nil, nil
+	}
+
+	// App resources
+	wantApps := make(map[string]string)
+	for _, app := range snapshot.AppResources {
+		wantApps[app.Name] = app.Content
+	}
+	for _, app := range state.apps {
+		content, ok := wantApps[app.Name]
+		switch {
+		case !ok:
+			if _, err := c.Apps.DeleteAppResource(ctx, DeleteApplicationResourceInput{ID: app.ID, Name: app.Name, SerialNumber: serial, DeviceID: device.ID}); err != nil {
+				return nil, fmt.Errorf("delete app resource %s: %w", app.Name, err)
+			}
+		case content != app.Content:
+			if _, err := c.Apps.UpdateAppResource(ctx, UpdateApplicationResourceInput{ID: app.ID, DeviceID: device.ID, SerialNumber: serial, Name: app.Name, Content: content}); err != nil {
+				return nil, fmt.Errorf("update app resource %s: %w", app.Name, err)
+			}
+		}
+		delete(wantApps, app.Name)
+	}
+	for _, app := range snapshot.AppResources {
+		if _, ok := wantApps[app.Name]; !ok {
+			continue
+		}
+		if _, err := c.Apps.CreateAppResource(ctx, CreateApplicationResourceInput{SerialNumber: serial, Name: app.Name, Content: app.Content}); err != nil {
+			return nil, fmt.Errorf("create app resource %s: %w", app.Name, err)
+		}
+	}
+
+	// Custom certs
+	wantCerts := make(map[string]bool)
+	for _, cert := range snapshot.CustomCerts {
+		wantCerts[cert.Name] = true
+		current, exists := currentCerts[cert.Name]
+		key := certKeys[cert.Name]
+		switch {
+		case !exists:
+			if _, err := c.Certs.CreateCustomCert(ctx, CreateAppCustomCertInput{CustomCert: CustomCert{Name: cert.Name, Key: key, Cert: cert.Cert}, SerialNumber: serial}); err != nil {
+				return nil, fmt.Errorf("create custom cert %s: %w", cert.Name, err)
+			}
+		case current.Cert != cert.Cert || current.Key != key:
+			if _, err := c.Certs.UpdateCustomCert(ctx, UpdateAppCustomCertInput{ID: current.ID, Name: cert.Name, Key: key, Cert: cert.Cert}); err != nil {
+				return nil, fmt.Errorf("update custom cert %s: %w", cert.Name, err)
+			}
+		}
+	}
+	for _, cert := range state.certs {
+		if !wantCerts[cert.Name] {
+			if _, err := c.Certs.DeleteCustomCert(ctx, DeleteAppCustomCertInput{ID: cert.ID}); err != nil {
+				return nil, fmt.Errorf("delete custom cert %s: %w", cert.Name, err)
+			}
+		}
+	}
+
+	// Firewall exceptions and logging
+	if !slices.Equal(state.firewall.TCP, sortedPorts(snapshot.FirewallExceptions.TCP)) || !slices.Equal(state.firewall.UDP, sortedPorts(snapshot.FirewallExceptions.UDP)) {
+		if _, err := c.Config.UpdateAppFirewallExceptions(ctx, UpdateAppFirewallExceptionInput{
+			AppFirewallException: AppFirewallException{TCP: snapshot.FirewallExceptions.TCP, UDP: snapshot.FirewallExceptions.UDP},
+			SerialNumber:         serial,
+		}); err != nil {
+			return nil, fmt.Errorf("update firewall exceptions: %w", err)
+		}
+	}
+	for _, d := range diffs {
+		if strings.HasPrefix(d.Path, "logging/") {
+			if _, err := c.Config.UpdateAppLogging(ctx, UpdateAppLoggingInput{AppLogging: logging, SerialNumber: serial}); err != nil {
+				return nil, fmt.Errorf("update logging: %w", err)
+			}
+			break
+		}
+	}
+
+	if err := c.Devices.SyncDeviceConfig(ctx, serial); err != nil {
+		return diffs, fmt.Errorf("sync: %w", err)
+	}
+	return diffs, nil
+}
+
+func sortedPorts(ports []int) []int {
+	sorted := slices.Clone(ports)
+	slices.Sort(sorted)
+	return sorted
+}
+
+func formatPorts(ports []int) string {
+	s := make([]string, 0, len(ports))
+	for _, p := range sortedPorts(ports) {
+		s = append(s, fmt.Sprint(p))
+	}
+	return strings.Join(s, ",")
+}
+
+func formatBool(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return fmt.Sprint(*b)
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
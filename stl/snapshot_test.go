@@ -0,0 +1,302 @@
+package stl_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dip-software/go-dip-api/stl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDevice struct {
+	id       int64
+	apps     []stl.AppResource
+	certs    []stl.CustomCert
+	firewall stl.AppFirewallException
+	logging  stl.AppLogging
+}
+
+type fakeEdge struct {
+	mu        sync.Mutex
+	devices   map[string]*fakeDevice
+	nextID    int64
+	mutations []string
+}
+
+func (f *fakeEdge) device(serial string) *fakeDevice {
+	if d, ok := f.devices[serial]; ok {
+		return d
+	}
+	return &fakeDevice{}
+}
+
+func (f *fakeEdge) serve(t *testing.T) {
+	muxSTL.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var body struct {
+			Query     string                     `json:"query"`
+			Variables map[string]json.RawMessage `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		var serial string
+		for _, name := range []string{"serial", "serialNumber"} {
+			if v, ok := body.Variables[name]; ok {
+				_ = json.Unmarshal(v, &serial)
+			}
+		}
+		var input struct {
+			ID           int64  `json:"id"`
+			SerialNumber string `json:"serialNumber"`
+			Name         string `json:"name"`
+			Content      string `json:"content"`
+			Key          string `json:"key"`
+			Cert         string `json:"cert"`
+			TCP          []int  `json:"tcp"`
+			UDP          []int  `json:"udp"`
+		}
+		if v, ok := body.Variables["input"]; ok {
+			_ = json.Unmarshal(v, &input)
+		}
+		status := map[string]interface{}{"success": true, "statusCode": 200}
+		data := map[string]interface{}{}
+		d := f.device(serial)
+		q := body.Query
+		if strings.HasPrefix(q, "mutation") {
+			name := q[strings.Index(q, "{")+1 : strings.Index(q, "(input")]
+			f.mutations = append(f.mutations, name)
+			d = f.device(input.SerialNumber)
+			switch name {
+			case "createApplicationResource":
+				f.nextID++
+				d.apps = append(d.apps, stl.AppResource{ID: f.nextID, Name: input.Name, Content: input.Content})
+			case "updateApplicationResource":
+				for i := range d.apps {
+					if d.apps[i].ID == input.ID {
+						d.apps[i].Content = input.Content
+					}
+				}
+			case "deleteApplicationResource":
+				d.apps = removeApp(d.apps, input.ID)
+			case "createAppCustomCert":
+				f.nextID++
+				d.certs = append(d.certs, stl.CustomCert{ID: f.nextID, Name: input.Name, Key: input.Key, Cert: input.Cert})
+			case "updateAppCustomCert":
+				for _, dev := range f.devices {
+					for i := range dev.certs {
+						if dev.certs[i].ID == input.ID {
+							dev.certs[i] = stl.CustomCert{ID: input.ID, Name: input.Name, Key: input.Key, Cert: input.Cert}
+						}
+					}
+				}
+			case "deleteAppCustomCert":
+				for _, dev := range f.devices {
+					dev.certs = removeCert(dev.certs, input.ID)
+				}
+			case "updateAppFirewallException":
+				d.firewall = stl.AppFirewallException{TCP: input.TCP, UDP: input.UDP}
+			case "updateAppLogging":
+				var logging stl.UpdateAppLoggingInput
+				_ = json.Unmarshal(body.Variables["input"], &logging)
+				d.logging = logging.AppLogging
+			}
+			data[name] = status
+		} else {
+			switch {
+			case strings.Contains(q, "device(serialNumber"):
+				data["device"] = map[string]interface{}{"id": d.id, "name": serial, "state": "authorized", "region": "na1", "serialNumber": serial}
+			case strings.Contains(q, "applicationResources("):
+				data["applicationResources"] = edges(d.apps)
+			case strings.Contains(q, "appCustomCerts("):
+				data["appCustomCerts"] = edges(d.certs)
+			case strings.Contains(q, "appFirewallException("):
+				data["appFirewallException"] = d.firewall
+			case strings.Contains(q, "appLogging("):
+				data["appLogging"] = d.logging
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	})
+}
+
+func edges[T any](nodes []T) map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(nodes))
+	for _, n := range nodes {
+		list = append(list, map[string]interface{}{"node": n})
+	}
+	return map[string]interface{}{"edges": list}
+}
+
+func removeApp(apps []stl.AppResource, id int64) []stl.AppResource {
+	var kept []stl.AppResource
+	for _, a := range apps {
+		if a.ID != id {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
+
+func removeCert(certs []stl.CustomCert, id int64) []stl.CustomCert {
+	var kept []stl.CustomCert
+	for _, c := range certs {
+		if c.ID != id {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+func TestSnapshotDiffApply(t *testing.T) {
+	teardown, err := setup(t)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer teardown()
+
+	fake := &fakeEdge{nextID: 100, devices: map[string]*fakeDevice{
+		"SRC": {
+			id:       1,
+			apps:     []stl.AppResource{{ID: 1, Name: "b.yml", Content: "b"}, {ID: 2, Name: "a.yml", Content: "a"}},
+			certs:    []stl.CustomCert{{ID: 3, Name: "tls", Key: "private", Cert: "public"}},
+			firewall: stl.AppFirewallException{TCP: []int{8080, 443}},
+			logging:  stl.AppLogging{HSDPLogging: true, HSDPIngestorHost: "https://logs", HSDPSharedKey: "shared", HSDPSecretKey: "secret", HSDPProductKey: "product"},
+		},
+		"DST": {
+			id:   2,
+			apps: []stl.AppResource{{ID: 4, Name: "a.yml", Content: "old"}, {ID: 5, Name: "stale.yml", Content: "x"}},
+		},
+	}}
+	fake.serve(t)
+	ctx := context.Background()
+
+	snapshot, err := client.Snapshot(ctx, "SRC", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "SRC", snapshot.SerialNumber)
+	assert.Equal(t, []stl.SnapshotAppResource{{Name: "a.yml", Content: "a"}, {Name: "b.yml", Content: "b"}}, snapshot.AppResources)
+	assert.Equal(t, stl.Redacted, snapshot.CustomCerts[0].Key)
+	assert.Equal(t, stl.Fingerprint("private"), snapshot.CustomCerts[0].KeyFingerprint)
+	assert.Equal(t, []int{443, 8080}, snapshot.FirewallExceptions.TCP)
+	assert.Equal(t, stl.Redacted, snapshot.Logging.HSDPSecretKey)
+
+	// Secrets never end up in the serialized document
+	data, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "private")
+	assert.NotContains(t, string(data), `"secret"`)
+
+	target, err := client.Snapshot(ctx, "DST", nil)
+	require.NoError(t, err)
+	diffs := stl.Diff(target, snapshot)
+	paths := make([]string, 0, len(diffs))
+	for _, d := range diffs {
+		paths = append(paths, d.Path)
+	}
+	assert.Contains(t, paths, "appResources/a.yml/content")
+	assert.Contains(t, paths, "appResources/stale.yml/content")
+	assert.Contains(t, paths, "customCerts/tls/key")
+	assert.Contains(t, paths, "firewallExceptions/tcp")
+	assert.Contains(t, paths, "logging/hsdpSecretKey")
+	assert.Empty(t, stl.Diff(snapshot, snapshot))
+
+	// The replacement device has none of the secrets
+	_, err = client.Apply(ctx, "DST", snapshot, nil)
+	assert.ErrorIs(t, err, stl.ErrRedactedSecret)
+	assert.Empty(t, fake.mutations)
+
+	snapshot.CustomCerts[0].Key = "private"
+	snapshot.Logging.HSDPSharedKey = "shared"
+	snapshot.Logging.HSDPSecretKey = "secret"
+	applied, err := client.Apply(ctx, "DST", snapshot, nil)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(diffs))
+	assert.Equal(t, "syncDeviceConfigs", fake.mutations[len(fake.mutations)-1])
+
+	dst := fake.devices["DST"]
+	assert.Equal(t, "private", dst.certs[0].Key)
+	assert.Equal(t, "secret", dst.logging.HSDPSecretKey)
+	assert.Equal(t, []int{443, 8080}, dst.firewall.TCP)
+
+	// Now the device has the secrets, so the redacted snapshot applies without changes
+	redacted, err := client.Snapshot(ctx, "SRC", nil)
+	require.NoError(t, err)
+	fake.mutations = nil
+	applied, err = client.Apply(ctx, "DST", redacted, nil)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	assert.Empty(t, fake.mutations)
+
+	clone, err := client.Snapshot(ctx, "DST", nil)
+	require.NoError(t, err)
+	assert.Empty(t, stl.Diff(clone, redacted))
+
+	_, err = client.Apply(ctx, "DST", nil, nil)
+	assert.ErrorIs(t, err, stl.ErrMissingSnapshot)
+}
+
+func TestSnapshotSecrets(t *testing.T) {
+	teardown, err := setup(t)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer teardown()
+
+	fake := &fakeEdge{nextID: 100, devices: map[string]*fakeDevice{
+		"SRC": {
+			id:      1,
+			certs:   []stl.CustomCert{{ID: 3, Name: "tls", Key: "private", Cert: "public"}},
+			logging: stl.AppLogging{HSDPLogging: true, HSDPIngestorHost: "https://logs", HSDPSharedKey: "shared", HSDPSecretKey: "secret", HSDPProductKey: "product"},
+		},
+		"DST": {id: 2},
+	}}
+	fake.serve(t)
+	ctx := context.Background()
+
+	full, err := client.Snapshot(ctx, "SRC", &stl.SnapshotOptions{IncludeSecrets: true})
+	require.NoError(t, err)
+	assert.Equal(t, "private", full.CustomCerts[0].Key)
+	assert.Equal(t, stl.Fingerprint("private"), full.CustomCerts[0].KeyFingerprint)
+	assert.Equal(t, "secret", full.Logging.HSDPSecretKey)
+
+	redacted, err := client.Snapshot(ctx, "SRC", nil)
+	require.NoError(t, err)
+	assert.Empty(t, stl.Diff(full, redacted))
+
+	var resolved []string
+	_, err = client.Apply(ctx, "DST", redacted, &stl.ApplyOptions{
+		Secrets: func(path string) (string, bool) {
+			resolved = append(resolved, path)
+			return "", false
+		},
+	})
+	assert.ErrorIs(t, err, stl.ErrRedactedSecret)
+	assert.Equal(t, []string{"customCerts/tls/key", "logging/hsdpSharedKey", "logging/hsdpSecretKey"}, resolved)
+
+	secrets := map[string]string{
+		"customCerts/tls/key":   "private",
+		"logging/hsdpSharedKey": "shared",
+		"logging/hsdpSecretKey": "secret",
+	}
+	applied, err := client.Apply(ctx, "DST", redacted, &stl.ApplyOptions{
+		Secrets: func(path string) (string, bool) {
+			secret, ok := secrets[path]
+			return secret, ok
+		},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, applied)
+	dst := fake.devices["DST"]
+	assert.Equal(t, "private", dst.certs[0].Key)
+	assert.Equal(t, "shared", dst.logging.HSDPSharedKey)
+	assert.Equal(t, "secret", dst.logging.HSDPSecretKey)
+
+	clone, err := client.Snapshot(ctx, "DST", &stl.SnapshotOptions{IncludeSecrets: true})
+	require.NoError(t, err)
+	assert.Empty(t, stl.Diff(clone, full))
+}